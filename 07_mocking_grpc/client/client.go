@@ -3,16 +3,52 @@ package main
 import (
 	"context"
 	"log"
+	"os"
 	"time"
 
 	pb "grpc-example/hellopb"
+	"grpc-example/interceptors"
+	"grpc-example/tlsconfig"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
+// transportCredentials returns TLS credentials when GREETER_TLS is true or
+// GREETER_TLS_CA is set, plaintext otherwise. GREETER_TLS_CERT and
+// GREETER_TLS_KEY present a client certificate for mutual TLS.
+func transportCredentials() (credentials.TransportCredentials, error) {
+	files := tlsconfig.Files{
+		CertFile: os.Getenv("GREETER_TLS_CERT"),
+		KeyFile:  os.Getenv("GREETER_TLS_KEY"),
+		CAFile:   os.Getenv("GREETER_TLS_CA"),
+	}
+	if os.Getenv("GREETER_TLS") != "true" && files.CAFile == "" {
+		return insecure.NewCredentials(), nil
+	}
+	config, err := tlsconfig.Client(files, os.Getenv("GREETER_TLS_SERVER_NAME"))
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(config), nil
+}
+
 func main() {
-	conn, err := grpc.NewClient("localhost:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// A server started with -insecure-dev accepts the development token
+	token := os.Getenv("GREETER_TOKEN")
+	if token == "" {
+		token = "dev-token"
+	}
+
+	creds, err := transportCredentials()
+	if err != nil {
+		log.Fatalf("failed to load TLS certificates: %v", err)
+	}
+
+	conn, err := grpc.NewClient("localhost:50051",
+		grpc.WithTransportCredentials(creds),
+		grpc.WithPerRPCCredentials(interceptors.TokenCredentials(token)))
 	if err != nil {
 		log.Fatalf("did not connect: %v", err)
 	}
@@ -2,21 +2,31 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"grpc-example/grpcmock"
+	"grpc-example/grpcrecord"
 	"grpc-example/hellopb"
 	pb "grpc-example/hellopb"
+	"grpc-example/interceptors"
 	"grpc-example/mocks"
 	"grpc-example/testhelpers"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
+const testToken = "test-token"
+
 func Test_SayHello(t *testing.T) {
+	// The mock is served behind the same interceptors as the real server
 	address, conn, cleanup := testhelpers.StartTestGRPCServer(t, func(s *grpc.Server) {
 		hellopb.RegisterGreeterServer(s, &mocks.ServerMock{})
-	})
+	}, testhelpers.WithServerOptions(interceptors.ServerOptions(interceptors.DefaultConfig(testToken))...))
 	defer cleanup()
 
 	client := pb.NewGreeterClient(conn)
@@ -26,10 +36,191 @@ func Test_SayHello(t *testing.T) {
 	}
 
 	// Real test without making call
-	response, err := client.SayHello(context.Background(), in)
+	response, err := client.SayHello(context.Background(), in, grpc.PerRPCCredentials(interceptors.TokenCredentials(testToken)))
 	require.NoError(t, err)
 	require.Equal(t, response.Message, "Salut Gabriel")
 
 	fmt.Println("The server run in the following address:", address)
 
 }
+
+// startMock serves the mock in memory behind the production interceptors
+func startMock(t *testing.T) pb.GreeterClient {
+	conn := testhelpers.StartBufconnGRPCServer(t, func(s *grpc.Server) {
+		pb.RegisterGreeterServer(s, &mocks.ServerMock{})
+	}, testhelpers.WithServerOptions(interceptors.ServerOptions(interceptors.DefaultConfig(testToken))...))
+	return pb.NewGreeterClient(conn)
+}
+
+func authorized() grpc.CallOption {
+	return grpc.PerRPCCredentials(interceptors.TokenCredentials(testToken))
+}
+
+func Test_SayHelloStream(t *testing.T) {
+	client := startMock(t)
+
+	stream, err := client.SayHelloStream(context.Background(), &pb.HelloStreamRequest{Name: "Gabriel", Count: 3}, authorized())
+	require.NoError(t, err)
+
+	testhelpers.ExpectMessages(t, stream,
+		&pb.HelloReply{Message: "Salut Gabriel (1/3)"},
+		&pb.HelloReply{Message: "Salut Gabriel (2/3)"},
+		&pb.HelloReply{Message: "Salut Gabriel (3/3)"},
+	)
+}
+
+func Test_SayHelloStreamValidatesTheCount(t *testing.T) {
+	client := startMock(t)
+
+	stream, err := client.SayHelloStream(context.Background(), &pb.HelloStreamRequest{Name: "Gabriel"}, authorized())
+	require.NoError(t, err)
+
+	// Errors of a server stream arrive on the first Recv
+	_, err = stream.Recv()
+	testhelpers.ExpectStatus(t, err, codes.InvalidArgument)
+}
+
+func Test_CollectNames(t *testing.T) {
+	client := startMock(t)
+
+	stream, err := client.CollectNames(context.Background(), authorized())
+	require.NoError(t, err)
+	for _, name := range []string{"Ann", "Bob", "Cleo"} {
+		require.NoError(t, stream.Send(&pb.HelloRequest{Name: name}))
+	}
+
+	summary, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	require.Equal(t, "Salut Ann, Bob, Cleo", summary.GetMessage())
+	require.Equal(t, []string{"Ann", "Bob", "Cleo"}, summary.GetNames())
+}
+
+func Test_Chat(t *testing.T) {
+	client := startMock(t)
+
+	stream, err := client.Chat(context.Background(), authorized())
+	require.NoError(t, err)
+
+	testhelpers.NewStreamScript(t, stream).
+		Send(&pb.HelloRequest{Name: "Ann"}).
+		Expect(&pb.HelloReply{Message: "Salut Ann"}).
+		Send(&pb.HelloRequest{Name: "Bob"}, &pb.HelloRequest{Name: "Cleo"}).
+		Expect(&pb.HelloReply{Message: "Salut Bob"}, &pb.HelloReply{Message: "Salut Cleo"}).
+		CloseSend().
+		ExpectEnd()
+}
+
+func Test_ChatRejectsAnInvalidMessage(t *testing.T) {
+	client := startMock(t)
+
+	stream, err := client.Chat(context.Background(), authorized())
+	require.NoError(t, err)
+
+	// The validation interceptor checks every message of the stream
+	testhelpers.NewStreamScript(t, stream).
+		Send(&pb.HelloRequest{Name: "Ann"}).
+		Expect(&pb.HelloReply{Message: "Salut Ann"}).
+		Send(&pb.HelloRequest{}).
+		ExpectStatus(codes.InvalidArgument)
+}
+
+func Test_SayHelloWithProgrammableMock(t *testing.T) {
+	// Each scenario declares its answers instead of needing a new mock struct
+	mock := grpcmock.New(&pb.Greeter_ServiceDesc)
+	mock.On("SayHello", &pb.HelloRequest{Name: "Gabriel"}).Return(&pb.HelloReply{Message: "Hola Gabriel"}).Once()
+	mock.On("SayHello", &pb.HelloRequest{Name: "Gabriel"}).ReturnError(codes.ResourceExhausted, "slow down")
+
+	_, conn, cleanup := testhelpers.StartTestGRPCServer(t, mock.Register,
+		testhelpers.WithServerOptions(interceptors.ServerOptions(interceptors.DefaultConfig(testToken))...))
+	defer cleanup()
+	client := pb.NewGreeterClient(conn)
+
+	response, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "Gabriel"}, authorized())
+	require.NoError(t, err)
+	require.Equal(t, "Hola Gabriel", response.GetMessage())
+
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{Name: "Gabriel"}, authorized())
+	testhelpers.ExpectStatus(t, err, codes.ResourceExhausted)
+
+	// Rejected by the validation interceptor, the mock never sees it
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{}, authorized())
+	testhelpers.ExpectStatus(t, err, codes.InvalidArgument)
+
+	mock.AssertExpectations(t)
+	mock.AssertNumberOfCalls(t, "SayHello", 2)
+}
+
+func Test_SayHelloOverTLS(t *testing.T) {
+	certs := testhelpers.NewCertificates(t)
+	register := func(s *grpc.Server) {
+		pb.RegisterGreeterServer(s, &mocks.ServerMock{})
+	}
+
+	tests := []struct {
+		name string
+		opts []testhelpers.Option
+		code codes.Code
+	}{
+		{"TLS", []testhelpers.Option{testhelpers.WithTLS(certs)}, codes.OK},
+		{"mutual TLS", []testhelpers.Option{testhelpers.WithMutualTLS(certs)}, codes.OK},
+		{"wrong CA", []testhelpers.Option{
+			testhelpers.WithTLS(certs),
+			testhelpers.WithClientCredentials(credentials.NewTLS(testhelpers.NewCertificates(t).ClientTLSConfig())),
+		}, codes.Unavailable},
+		{"plaintext client", []testhelpers.Option{
+			testhelpers.WithTLS(certs),
+			testhelpers.WithClientCredentials(insecure.NewCredentials()),
+		}, codes.Unavailable},
+		{"missing client certificate", []testhelpers.Option{
+			testhelpers.WithMutualTLS(certs),
+			testhelpers.WithClientCredentials(credentials.NewTLS(&tls.Config{RootCAs: certs.CertPool()})),
+		}, codes.Unavailable},
+		{"client certificate of another CA", []testhelpers.Option{
+			testhelpers.WithMutualTLS(certs),
+			testhelpers.WithClientCredentials(credentials.NewTLS(&tls.Config{
+				RootCAs:      certs.CertPool(),
+				Certificates: []tls.Certificate{testhelpers.NewCertificates(t).Client},
+			})),
+		}, codes.Unavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The handshake runs over bufconn like over TCP
+			conn := testhelpers.StartBufconnGRPCServer(t, register, tt.opts...)
+
+			_, err := pb.NewGreeterClient(conn).SayHello(context.Background(), &pb.HelloRequest{Name: "Gabriel"})
+			testhelpers.ExpectStatus(t, err, tt.code)
+		})
+	}
+}
+
+// Test_SayHelloReplayed runs against the real server recorded in
+// testdata/greeter.json. Start the server with go run server/server.go and
+// run the test with GRPC_RECORD=1 to record it again.
+func Test_SayHelloReplayed(t *testing.T) {
+	conn := grpcrecord.Start(t, "testdata/greeter.json", func() (*grpc.ClientConn, error) {
+		return grpc.NewClient("localhost:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	})
+	client := pb.NewGreeterClient(conn)
+	// The token the server accepts by default, the recording doesn't keep it
+	devToken := grpc.PerRPCCredentials(interceptors.TokenCredentials("dev-token"))
+
+	response, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "Gabriel"}, devToken)
+	require.NoError(t, err)
+	require.Equal(t, "Hello Gabriel", response.GetMessage())
+
+	stream, err := client.SayHelloStream(context.Background(), &pb.HelloStreamRequest{Name: "Gabriel", Count: 2}, devToken)
+	require.NoError(t, err)
+	testhelpers.ExpectMessages(t, stream,
+		&pb.HelloReply{Message: "Hello Gabriel (1/2)"},
+		&pb.HelloReply{Message: "Hello Gabriel (2/2)"},
+	)
+
+	chat, err := client.Chat(context.Background(), devToken)
+	require.NoError(t, err)
+	testhelpers.NewStreamScript(t, chat).
+		Send(&pb.HelloRequest{Name: "Ann"}).
+		Expect(&pb.HelloReply{Message: "Hello Ann"}).
+		Send(&pb.HelloRequest{}).
+		ExpectStatus(codes.InvalidArgument)
+}
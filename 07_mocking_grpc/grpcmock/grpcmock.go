@@ -0,0 +1,409 @@
+// Package grpcmock is a programmable mock for any gRPC service. Instead of
+// writing a struct per scenario, tests declare what each method expects and
+// answers, much like testify's mock.On(...).Return(...):
+//
+//	mock := grpcmock.New(&pb.Greeter_ServiceDesc)
+//	mock.On("SayHello", &pb.HelloRequest{Name: "Ann"}).Return(&pb.HelloReply{Message: "Hi Ann"}).Once()
+//	mock.On("SayHello").ReturnError(codes.Unavailable, "try later")
+//	_, conn, cleanup := testhelpers.StartTestGRPCServer(t, mock.Register)
+//	...
+//	mock.AssertExpectations(t)
+//
+// Calls no expectation matches fail with Unimplemented and are reported by
+// AssertExpectations.
+package grpcmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Server mocks the service of a grpc.ServiceDesc
+type Server struct {
+	desc *grpc.ServiceDesc
+	// requestTypes are the request message types by method name
+	requestTypes map[string]protoreflect.MessageType
+
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []Call
+	unexpected   []Call
+}
+
+// Call is a call the mock received, the requests are the messages the
+// client sent, one for unary and server streaming methods
+type Call struct {
+	Method   string
+	Requests []proto.Message
+}
+
+// New returns a mock of the service described by desc, e.g.
+// &pb.Greeter_ServiceDesc. It panics when the messages of the service are
+// not in the protobuf registry, which only happens if its generated package
+// is not linked in.
+func New(desc *grpc.ServiceDesc) *Server {
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(desc.ServiceName))
+	if err != nil {
+		panic(fmt.Sprintf("grpcmock: service %s: %v", desc.ServiceName, err))
+	}
+	service, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		panic(fmt.Sprintf("grpcmock: %s is not a service", desc.ServiceName))
+	}
+
+	requestTypes := make(map[string]protoreflect.MessageType)
+	methods := service.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+		mt, err := protoregistry.GlobalTypes.FindMessageByName(method.Input().FullName())
+		if err != nil {
+			panic(fmt.Sprintf("grpcmock: request of %s: %v", method.FullName(), err))
+		}
+		requestTypes[string(method.Name())] = mt
+	}
+	return &Server{desc: desc, requestTypes: requestTypes}
+}
+
+// Register registers the mock on s. Its signature fits the register
+// function of testhelpers.StartTestGRPCServer.
+func (m *Server) Register(s *grpc.Server) {
+	desc := grpc.ServiceDesc{
+		ServiceName: m.desc.ServiceName,
+		// Any handler implements the empty interface
+		HandlerType: (*any)(nil),
+		Metadata:    m.desc.Metadata,
+	}
+	for _, method := range m.desc.Methods {
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: method.MethodName,
+			Handler:    m.unaryHandler(method.MethodName),
+		})
+	}
+	for _, stream := range m.desc.Streams {
+		desc.Streams = append(desc.Streams, grpc.StreamDesc{
+			StreamName:    stream.StreamName,
+			Handler:       m.streamHandler(stream),
+			ServerStreams: stream.ServerStreams,
+			ClientStreams: stream.ClientStreams,
+		})
+	}
+	s.RegisterService(&desc, m)
+}
+
+// On adds an expectation for the method, e.g. "SayHello". The requests
+// match the messages the client sends in order, each either a proto.Message
+// compared with proto.Equal or a Matcher. Without requests any call of the
+// method matches.
+//
+// When several expectations match a call, the first one declared that is
+// not used up by Times wins.
+func (m *Server) On(method string, requests ...any) *Expectation {
+	if _, ok := m.requestTypes[method]; !ok {
+		panic(fmt.Sprintf("grpcmock: %s has no method %s", m.desc.ServiceName, method))
+	}
+
+	matchers := make([]Matcher, len(requests))
+	for i, request := range requests {
+		switch r := request.(type) {
+		case Matcher:
+			matchers[i] = r
+		case proto.Message:
+			matchers[i] = Equal(r)
+		default:
+			panic(fmt.Sprintf("grpcmock: request %d of %s is a %T, not a proto.Message or Matcher", i, method, request))
+		}
+	}
+
+	e := &Expectation{method: method, matchers: matchers}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// Calls returns the calls of the method received so far, matched or not
+func (m *Server) Calls(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var calls []Call
+	for _, call := range m.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// AssertExpectations fails the test when a call matched no expectation, or
+// an expectation was called fewer times than it should have been
+func (m *Server) AssertExpectations(t testing.TB) bool {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := true
+	for _, call := range m.unexpected {
+		t.Errorf("grpcmock: unexpected call %s(%s)", call.Method, formatRequests(call.Requests))
+		ok = false
+	}
+	for _, e := range m.expectations {
+		if e.unmet() {
+			t.Errorf("grpcmock: %s", e.describeUnmet())
+			ok = false
+		}
+	}
+	return ok
+}
+
+// AssertNumberOfCalls fails the test unless the method was called n times
+func (m *Server) AssertNumberOfCalls(t testing.TB, method string, n int) bool {
+	t.Helper()
+	if got := len(m.Calls(method)); got != n {
+		t.Errorf("grpcmock: %s was called %d times, want %d", method, got, n)
+		return false
+	}
+	return true
+}
+
+// match records the call and returns the expectation answering it, nil
+// when none matches
+func (m *Server) match(method string, requests []proto.Message) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	call := Call{Method: method, Requests: requests}
+	m.calls = append(m.calls, call)
+	for _, e := range m.expectations {
+		if e.matches(method, requests) {
+			e.calls++
+			return e
+		}
+	}
+	m.unexpected = append(m.unexpected, call)
+	return nil
+}
+
+func (m *Server) unaryHandler(method string) func(any, context.Context, func(any) error, grpc.UnaryServerInterceptor) (any, error) {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		req := m.requestTypes[method].New().Interface()
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+
+		handler := func(ctx context.Context, req any) (any, error) {
+			e := m.match(method, []proto.Message{req.(proto.Message)})
+			if e == nil {
+				return nil, unexpected(method)
+			}
+			if err := e.wait(ctx); err != nil {
+				return nil, err
+			}
+			if len(e.responses) == 0 || e.err != nil {
+				return nil, e.result()
+			}
+			return e.responses[0], nil
+		}
+		if interceptor == nil {
+			return handler(ctx, req)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod(m.desc.ServiceName, method)}
+		return interceptor(ctx, req, info, handler)
+	}
+}
+
+// streamHandler answers a streaming method once the client is done
+// sending: with the one request of a server stream right away, and after
+// the client closes its side for client and bidirectional streams
+func (m *Server) streamHandler(desc grpc.StreamDesc) grpc.StreamHandler {
+	method := desc.StreamName
+	return func(srv any, stream grpc.ServerStream) error {
+		var requests []proto.Message
+		for {
+			req := m.requestTypes[method].New().Interface()
+			err := stream.RecvMsg(req)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			requests = append(requests, req)
+			if !desc.ClientStreams {
+				break
+			}
+		}
+
+		e := m.match(method, requests)
+		if e == nil {
+			return unexpected(method)
+		}
+		if err := e.wait(stream.Context()); err != nil {
+			return err
+		}
+		for _, response := range e.responses {
+			if err := stream.SendMsg(response); err != nil {
+				return err
+			}
+		}
+		// A client stream must answer with exactly one message
+		if desc.ClientStreams && !desc.ServerStreams && len(e.responses) == 0 && e.err == nil {
+			return status.Errorf(codes.Internal, "grpcmock: %s expectation has no response", method)
+		}
+		return e.err
+	}
+}
+
+func unexpected(method string) error {
+	return status.Errorf(codes.Unimplemented, "grpcmock: no expectation matches the call of %s", method)
+}
+
+func fullMethod(service, method string) string {
+	return "/" + service + "/" + method
+}
+
+func formatRequests(requests []proto.Message) string {
+	formatted := make([]string, len(requests))
+	for i, req := range requests {
+		formatted[i] = fmt.Sprintf("%v", req)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// Expectation is what a method expects and answers, configured with its
+// chainable methods
+type Expectation struct {
+	method    string
+	matchers  []Matcher
+	responses []proto.Message
+	err       error
+	delay     time.Duration
+	// times is the number of calls it answers, 0 for any number
+	times    int
+	optional bool
+
+	// calls is guarded by the Server's mutex
+	calls int
+}
+
+// Return sets the response, or for server and bidirectional streams the
+// responses sent in order
+func (e *Expectation) Return(responses ...proto.Message) *Expectation {
+	e.responses = responses
+	return e
+}
+
+// ReturnError answers with a status error. With Return too, streams send
+// the responses before failing.
+func (e *Expectation) ReturnError(code codes.Code, message string) *Expectation {
+	return e.ReturnStatus(status.New(code, message))
+}
+
+// ReturnStatus answers with st, e.g. a status carrying details
+func (e *Expectation) ReturnStatus(st *status.Status) *Expectation {
+	e.err = st.Err()
+	return e
+}
+
+// After delays the answer by d. A call whose deadline passes or that is
+// canceled first fails with DeadlineExceeded or Canceled.
+func (e *Expectation) After(d time.Duration) *Expectation {
+	e.delay = d
+	return e
+}
+
+// Times limits the expectation to n calls, which AssertExpectations checks
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// Once is Times(1)
+func (e *Expectation) Once() *Expectation {
+	return e.Times(1)
+}
+
+// Twice is Times(2)
+func (e *Expectation) Twice() *Expectation {
+	return e.Times(2)
+}
+
+// Maybe makes the expectation optional, AssertExpectations doesn't fail
+// when it is never called
+func (e *Expectation) Maybe() *Expectation {
+	e.optional = true
+	return e
+}
+
+func (e *Expectation) matches(method string, requests []proto.Message) bool {
+	if e.method != method || (e.times > 0 && e.calls >= e.times) {
+		return false
+	}
+	if len(e.matchers) == 0 {
+		return true
+	}
+	if len(e.matchers) != len(requests) {
+		return false
+	}
+	for i, matcher := range e.matchers {
+		if !matcher.Match(requests[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Expectation) unmet() bool {
+	switch {
+	case e.times > 0:
+		return e.calls < e.times && !(e.optional && e.calls == 0)
+	default:
+		return e.calls == 0 && !e.optional
+	}
+}
+
+func (e *Expectation) describeUnmet() string {
+	matchers := make([]string, len(e.matchers))
+	for i, matcher := range e.matchers {
+		matchers[i] = matcher.String()
+	}
+	want := "at least once"
+	if e.times > 0 {
+		want = fmt.Sprintf("%d times", e.times)
+	}
+	return fmt.Sprintf("%s(%s) was called %d times, want %s", e.method, strings.Join(matchers, ", "), e.calls, want)
+}
+
+func (e *Expectation) wait(ctx context.Context) error {
+	if e.delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(e.delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func (e *Expectation) result() error {
+	if e.err != nil {
+		return e.err
+	}
+	return status.Errorf(codes.Internal, "grpcmock: %s expectation has no response", e.method)
+}
@@ -0,0 +1,163 @@
+package grpcmock_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"grpc-example/grpcmock"
+	pb "grpc-example/hellopb"
+	"grpc-example/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// startMock serves a Greeter mock and returns its client
+func startMock(t *testing.T) (*grpcmock.Server, pb.GreeterClient) {
+	mock := grpcmock.New(&pb.Greeter_ServiceDesc)
+	_, conn, cleanup := testhelpers.StartTestGRPCServer(t, mock.Register)
+	t.Cleanup(cleanup)
+	return mock, pb.NewGreeterClient(conn)
+}
+
+// recorder captures the failures of the assertions
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestUnaryExpectationsMatchTheRequest(t *testing.T) {
+	mock, client := startMock(t)
+	mock.On("SayHello", &pb.HelloRequest{Name: "Ann"}).Return(&pb.HelloReply{Message: "Hi Ann"})
+	mock.On("SayHello", grpcmock.MatchedBy(func(req *pb.HelloRequest) bool {
+		return strings.HasPrefix(req.GetName(), "B")
+	})).Return(&pb.HelloReply{Message: "Hi B"})
+	mock.On("SayHello", grpcmock.Any()).ReturnError(codes.NotFound, "who?")
+
+	reply, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "Ann"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi Ann", reply.GetMessage())
+
+	reply, err = client.SayHello(context.Background(), &pb.HelloRequest{Name: "Bob"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi B", reply.GetMessage())
+
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{Name: "Zoe"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "who?", status.Convert(err).Message())
+
+	mock.AssertExpectations(t)
+	mock.AssertNumberOfCalls(t, "SayHello", 3)
+}
+
+func TestTimesUsesUpAnExpectation(t *testing.T) {
+	mock, client := startMock(t)
+	mock.On("SayHello").Return(&pb.HelloReply{Message: "first"}).Once()
+	mock.On("SayHello").Return(&pb.HelloReply{Message: "then"})
+
+	var messages []string
+	for range 3 {
+		reply, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "Ann"})
+		require.NoError(t, err)
+		messages = append(messages, reply.GetMessage())
+	}
+	assert.Equal(t, []string{"first", "then", "then"}, messages)
+	mock.AssertExpectations(t)
+}
+
+func TestAfterDelaysTheAnswerUntilTheDeadline(t *testing.T) {
+	mock, client := startMock(t)
+	mock.On("SayHello").Return(&pb.HelloReply{Message: "late"}).After(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.SayHello(ctx, &pb.HelloRequest{Name: "Ann"})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestAssertExpectationsReportsUnmetAndUnexpectedCalls(t *testing.T) {
+	mock, client := startMock(t)
+	mock.On("SayHello", &pb.HelloRequest{Name: "Ann"}).Return(&pb.HelloReply{}).Twice()
+	mock.On("Chat").Maybe()
+
+	client.SayHello(context.Background(), &pb.HelloRequest{Name: "Ann"})
+	_, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "Bob"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	r := &recorder{TB: t}
+	assert.False(t, mock.AssertExpectations(r))
+	require.Len(t, r.errors, 2)
+	assert.Contains(t, r.errors[0], `unexpected call SayHello(name:"Bob")`)
+	assert.Contains(t, r.errors[1], "was called 1 times, want 2 times")
+}
+
+func TestServerStreamSendsTheResponses(t *testing.T) {
+	mock, client := startMock(t)
+	mock.On("SayHelloStream", &pb.HelloStreamRequest{Name: "Ann", Count: 2}).
+		Return(&pb.HelloReply{Message: "one"}, &pb.HelloReply{Message: "two"}).
+		ReturnError(codes.Aborted, "cut short")
+
+	stream, err := client.SayHelloStream(context.Background(), &pb.HelloStreamRequest{Name: "Ann", Count: 2})
+	require.NoError(t, err)
+
+	for _, want := range []string{"one", "two"} {
+		reply, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, want, reply.GetMessage())
+	}
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Aborted, status.Code(err))
+}
+
+func TestClientStreamMatchesEveryMessage(t *testing.T) {
+	mock, client := startMock(t)
+	mock.On("CollectNames", &pb.HelloRequest{Name: "Ann"}, &pb.HelloRequest{Name: "Bob"}).
+		Return(&pb.HelloSummary{Message: "Hi both"})
+
+	stream, err := client.CollectNames(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.HelloRequest{Name: "Ann"}))
+	require.NoError(t, stream.Send(&pb.HelloRequest{Name: "Bob"}))
+
+	summary, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, "Hi both", summary.GetMessage())
+
+	calls := mock.Calls("CollectNames")
+	require.Len(t, calls, 1)
+	assert.Len(t, calls[0].Requests, 2)
+}
+
+func TestBidiStreamAnswersOnceTheClientIsDone(t *testing.T) {
+	mock, client := startMock(t)
+	mock.On("Chat", &pb.HelloRequest{Name: "Ann"}).Return(&pb.HelloReply{Message: "Hi Ann"})
+
+	stream, err := client.Chat(context.Background())
+	require.NoError(t, err)
+
+	testhelpers.NewStreamScript(t, stream).
+		Send(&pb.HelloRequest{Name: "Ann"}).
+		CloseSend().
+		Expect(&pb.HelloReply{Message: "Hi Ann"}).
+		ExpectEnd()
+	mock.AssertExpectations(t)
+}
+
+func TestOnPanicsForAnUnknownMethod(t *testing.T) {
+	mock := grpcmock.New(&pb.Greeter_ServiceDesc)
+
+	assert.Panics(t, func() { mock.On("SayGoodbye") })
+	assert.Panics(t, func() { mock.On("SayHello", "Ann") })
+}
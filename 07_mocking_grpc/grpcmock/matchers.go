@@ -0,0 +1,46 @@
+package grpcmock
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Matcher decides whether a request matches an expectation
+type Matcher interface {
+	Match(req proto.Message) bool
+	String() string
+}
+
+type matcher struct {
+	match       func(proto.Message) bool
+	description string
+}
+
+func (m matcher) Match(req proto.Message) bool { return m.match(req) }
+func (m matcher) String() string               { return m.description }
+
+// Any matches every request
+func Any() Matcher {
+	return matcher{func(proto.Message) bool { return true }, "any"}
+}
+
+// Equal matches requests equal to want according to proto.Equal. On uses
+// it for the messages it is given.
+func Equal(want proto.Message) Matcher {
+	return matcher{func(req proto.Message) bool { return proto.Equal(req, want) }, fmt.Sprintf("%v", want)}
+}
+
+// MatchedBy matches the requests of type T fn accepts, e.g.
+//
+//	grpcmock.MatchedBy(func(req *pb.HelloRequest) bool { return strings.HasPrefix(req.GetName(), "A") })
+func MatchedBy[T proto.Message](fn func(T) bool) Matcher {
+	var zero T
+	return matcher{
+		func(req proto.Message) bool {
+			typed, ok := req.(T)
+			return ok && fn(typed)
+		},
+		fmt.Sprintf("MatchedBy(func(%T) bool)", zero),
+	}
+}
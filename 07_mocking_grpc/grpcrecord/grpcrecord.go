@@ -0,0 +1,203 @@
+// Package grpcrecord records the calls a client makes to a real gRPC server
+// and replays them later as a mock server, so client tests run without the
+// server and without hand-written mocks:
+//
+//	conn := grpcrecord.Start(t, "testdata/greeter.json", func() (*grpc.ClientConn, error) {
+//		return grpc.NewClient("localhost:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	})
+//	client := pb.NewGreeterClient(conn)
+//
+// Start replays the file. With GRPC_RECORD=1 in the environment it proxies
+// the calls to the server instead and writes them to the file when the test
+// ends. Unary and streaming calls are recorded alike: the messages in the
+// order the proxy saw them and the status the call ended with. Metadata is
+// forwarded but not recorded, so tokens stay out of the files.
+package grpcrecord
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"grpc-example/testhelpers"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// RecordEnv is the environment variable that turns on recording in Start
+const RecordEnv = "GRPC_RECORD"
+
+// Start replays the calls recorded in path, or records them from the server
+// dial connects to when RecordEnv is 1. The options configure the server
+// the returned connection talks to, e.g. with the interceptors of the real
+// server.
+func Start(t *testing.T, path string, dial func() (*grpc.ClientConn, error), opts ...testhelpers.Option) *grpc.ClientConn {
+	t.Helper()
+	if os.Getenv(RecordEnv) != "1" {
+		return Replay(t, path, opts...)
+	}
+
+	upstream, err := dial()
+	if err != nil {
+		t.Fatalf("grpcrecord: dial the server to record: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := upstream.Close(); err != nil {
+			t.Logf("grpcrecord: close the recorded server: %v", err)
+		}
+	})
+	return Record(t, path, upstream, opts...)
+}
+
+// call is a recorded call: the requests and responses in the order they
+// passed and the status it ended with
+type call struct {
+	method string
+	events []event
+	status *status.Status
+}
+
+// event is a request or a response of a call
+type event struct {
+	request bool
+	message proto.Message
+}
+
+// fileCall is a call as the file stores it, the messages in the protobuf
+// JSON mapping and the code by name, e.g. InvalidArgument
+type fileCall struct {
+	Method   string        `json:"method"`
+	Messages []fileMessage `json:"messages"`
+	Code     string        `json:"code"`
+	Message  string        `json:"message,omitempty"`
+}
+
+// fileMessage holds either a request or a response
+type fileMessage struct {
+	Request  json.RawMessage `json:"request,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+type file struct {
+	Calls []fileCall `json:"calls"`
+}
+
+// writeFile saves the calls to path as indented JSON, creating its
+// directory
+func writeFile(path string, calls []*call) error {
+	var f file
+	for _, c := range calls {
+		fc := fileCall{Method: c.method, Code: c.status.Code().String(), Message: c.status.Message()}
+		for _, e := range c.events {
+			data, err := protojson.Marshal(e.message)
+			if err != nil {
+				return fmt.Errorf("message of %s: %w", c.method, err)
+			}
+			if e.request {
+				fc.Messages = append(fc.Messages, fileMessage{Request: data})
+			} else {
+				fc.Messages = append(fc.Messages, fileMessage{Response: data})
+			}
+		}
+		f.Calls = append(f.Calls, fc)
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// readFile loads the calls saved in path. The messages of every method
+// must be in the protobuf registry.
+func readFile(path string) ([]*call, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	calls := make([]*call, len(f.Calls))
+	for i, fc := range f.Calls {
+		types, err := lookupMethod(fc.Method)
+		if err != nil {
+			return nil, err
+		}
+		code, err := parseCode(fc.Code)
+		if err != nil {
+			return nil, fmt.Errorf("call %d of %s: %w", i, fc.Method, err)
+		}
+
+		c := &call{method: fc.Method, status: status.New(code, fc.Message)}
+		for j, fm := range fc.Messages {
+			e := event{request: fm.Request != nil}
+			mt, data := types.response, fm.Response
+			if e.request {
+				mt, data = types.request, fm.Request
+			}
+			e.message = mt.New().Interface()
+			if err := protojson.Unmarshal(data, e.message); err != nil {
+				return nil, fmt.Errorf("message %d of call %d of %s: %w", j, i, fc.Method, err)
+			}
+			c.events = append(c.events, e)
+		}
+		calls[i] = c
+	}
+	return calls, nil
+}
+
+// methodTypes are the request and response types of a method
+type methodTypes struct {
+	request  protoreflect.MessageType
+	response protoreflect.MessageType
+}
+
+// lookupMethod finds the types of a full method, "/package.Service/Method",
+// in the protobuf registry, where the generated package of the service
+// puts them
+func lookupMethod(fullMethod string) (methodTypes, error) {
+	name := protoreflect.FullName(strings.Replace(strings.TrimPrefix(fullMethod, "/"), "/", ".", 1))
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+	if err != nil {
+		return methodTypes{}, fmt.Errorf("method %s: %w", fullMethod, err)
+	}
+	method, ok := d.(protoreflect.MethodDescriptor)
+	if !ok {
+		return methodTypes{}, fmt.Errorf("%s is not a method", fullMethod)
+	}
+
+	var types methodTypes
+	if types.request, err = protoregistry.GlobalTypes.FindMessageByName(method.Input().FullName()); err != nil {
+		return methodTypes{}, fmt.Errorf("request of %s: %w", fullMethod, err)
+	}
+	if types.response, err = protoregistry.GlobalTypes.FindMessageByName(method.Output().FullName()); err != nil {
+		return methodTypes{}, fmt.Errorf("response of %s: %w", fullMethod, err)
+	}
+	return types, nil
+}
+
+// parseCode returns the code named by its String form, e.g. InvalidArgument
+func parseCode(name string) (codes.Code, error) {
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	return codes.Unknown, fmt.Errorf("unknown status code %q", name)
+}
@@ -0,0 +1,145 @@
+package grpcrecord_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"grpc-example/grpcrecord"
+	pb "grpc-example/hellopb"
+	"grpc-example/interceptors"
+	"grpc-example/mocks"
+	"grpc-example/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const token = "secret"
+
+// startUpstream serves the mock behind the interceptors, standing in for
+// the real server being recorded
+func startUpstream(t *testing.T) *grpc.ClientConn {
+	return testhelpers.StartBufconnGRPCServer(t, func(s *grpc.Server) {
+		pb.RegisterGreeterServer(s, &mocks.ServerMock{})
+	}, testhelpers.WithServerOptions(interceptors.ServerOptions(interceptors.DefaultConfig(token))...))
+}
+
+func authorized() grpc.CallOption {
+	return grpc.PerRPCCredentials(interceptors.TokenCredentials(token))
+}
+
+// exercise makes one call of every kind plus one the server rejects, and
+// checks the answers
+func exercise(t *testing.T, client pb.GreeterClient) {
+	ctx := context.Background()
+
+	reply, err := client.SayHello(ctx, &pb.HelloRequest{Name: "Ann"}, authorized())
+	require.NoError(t, err)
+	assert.Equal(t, "Salut Ann", reply.GetMessage())
+
+	_, err = client.SayHello(ctx, &pb.HelloRequest{}, authorized())
+	testhelpers.ExpectStatus(t, err, codes.InvalidArgument)
+
+	stream, err := client.SayHelloStream(ctx, &pb.HelloStreamRequest{Name: "Bob", Count: 2}, authorized())
+	require.NoError(t, err)
+	testhelpers.ExpectMessages(t, stream,
+		&pb.HelloReply{Message: "Salut Bob (1/2)"},
+		&pb.HelloReply{Message: "Salut Bob (2/2)"},
+	)
+
+	collect, err := client.CollectNames(ctx, authorized())
+	require.NoError(t, err)
+	require.NoError(t, collect.Send(&pb.HelloRequest{Name: "Ann"}))
+	require.NoError(t, collect.Send(&pb.HelloRequest{Name: "Bob"}))
+	summary, err := collect.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, "Salut Ann, Bob", summary.GetMessage())
+
+	chat, err := client.Chat(ctx, authorized())
+	require.NoError(t, err)
+	testhelpers.NewStreamScript(t, chat).
+		Send(&pb.HelloRequest{Name: "Cleo"}).
+		Expect(&pb.HelloReply{Message: "Salut Cleo"}).
+		Send(&pb.HelloRequest{Name: "Dan"}).
+		Expect(&pb.HelloReply{Message: "Salut Dan"}).
+		CloseSend().
+		ExpectEnd()
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "greeter.json")
+
+	t.Run("record", func(t *testing.T) {
+		conn := grpcrecord.Record(t, path, startUpstream(t))
+		exercise(t, pb.NewGreeterClient(conn))
+	})
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"method": "/hellopb.Greeter/Chat"`)
+	assert.Contains(t, string(data), `"code": "InvalidArgument"`)
+	assert.NotContains(t, string(data), token, "metadata is not recorded")
+
+	t.Run("replay", func(t *testing.T) {
+		// No upstream and no token needed, the answers come from the file
+		conn := grpcrecord.Replay(t, path)
+		exercise(t, pb.NewGreeterClient(conn))
+	})
+}
+
+func TestReplayRejectsCallsNotRecorded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "greeter.json")
+	t.Run("record", func(t *testing.T) {
+		conn := grpcrecord.Record(t, path, startUpstream(t))
+		_, err := pb.NewGreeterClient(conn).SayHello(context.Background(), &pb.HelloRequest{Name: "Ann"}, authorized())
+		require.NoError(t, err)
+		chat, err := pb.NewGreeterClient(conn).Chat(context.Background(), authorized())
+		require.NoError(t, err)
+		testhelpers.NewStreamScript(t, chat).
+			Send(&pb.HelloRequest{Name: "Ann"}).
+			Expect(&pb.HelloReply{Message: "Salut Ann"}).
+			Send(&pb.HelloRequest{Name: "Bob"}).
+			Expect(&pb.HelloReply{Message: "Salut Bob"}).
+			CloseSend().
+			ExpectEnd()
+	})
+	client := pb.NewGreeterClient(grpcrecord.Replay(t, path))
+
+	_, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "Zoe"})
+	testhelpers.ExpectStatus(t, err, codes.Unimplemented)
+
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{Name: "Ann"})
+	require.NoError(t, err)
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{Name: "Ann"})
+	testhelpers.ExpectStatus(t, err, codes.Unimplemented)
+
+	chat, err := client.Chat(context.Background())
+	require.NoError(t, err)
+	testhelpers.NewStreamScript(t, chat).
+		Send(&pb.HelloRequest{Name: "Ann"}).
+		Expect(&pb.HelloReply{Message: "Salut Ann"}).
+		Send(&pb.HelloRequest{Name: "Zoe"}).
+		ExpectStatus(codes.Unimplemented)
+}
+
+func TestStartReplaysUnlessRecording(t *testing.T) {
+	t.Setenv(grpcrecord.RecordEnv, "")
+	dial := func() (*grpc.ClientConn, error) {
+		t.Fatal("replaying must not dial the server")
+		return nil, nil
+	}
+	path := filepath.Join(t.TempDir(), "greeter.json")
+	t.Run("record", func(t *testing.T) {
+		client := pb.NewGreeterClient(grpcrecord.Record(t, path, startUpstream(t)))
+		_, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "Ann"}, authorized())
+		require.NoError(t, err)
+	})
+
+	reply, err := pb.NewGreeterClient(grpcrecord.Start(t, path, dial)).SayHello(context.Background(), &pb.HelloRequest{Name: "Ann"})
+	require.NoError(t, err)
+	assert.Equal(t, "Salut Ann", reply.GetMessage())
+}
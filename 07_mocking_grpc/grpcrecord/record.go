@@ -0,0 +1,166 @@
+package grpcrecord
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"grpc-example/testhelpers"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// recorder proxies every call to upstream and keeps what went through
+type recorder struct {
+	upstream *grpc.ClientConn
+
+	mu    sync.Mutex
+	calls []*call
+}
+
+// Record starts an in-memory server proxying every call to upstream and
+// returns a connection to it. The calls are written to path, replacing it,
+// in the order they ended when the test ends.
+func Record(t *testing.T, path string, upstream *grpc.ClientConn, opts ...testhelpers.Option) *grpc.ClientConn {
+	t.Helper()
+	r := &recorder{upstream: upstream}
+
+	// The proxy registers no service, every call goes to the unknown service handler
+	opts = append(opts, testhelpers.WithServerOptions(grpc.UnknownServiceHandler(r.proxy)))
+	conn := testhelpers.StartBufconnGRPCServer(t, func(*grpc.Server) {}, opts...)
+
+	t.Cleanup(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if err := writeFile(path, r.calls); err != nil {
+			t.Errorf("grpcrecord: write %s: %v", path, err)
+		}
+	})
+	return conn
+}
+
+// proxy forwards a call to upstream, the requests from a goroutine and the
+// responses as they arrive, and records both in the order they pass
+func (r *recorder) proxy(_ any, serverStream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(serverStream)
+	types, err := lookupMethod(method)
+	if err != nil {
+		return status.Errorf(codes.Unimplemented, "grpcrecord: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(serverStream.Context())
+	defer cancel()
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = metadata.NewOutgoingContext(ctx, forwarded(md))
+
+	rec := &recording{method: method}
+	err = r.forward(ctx, cancel, serverStream, rec, types)
+	r.add(rec.done(err))
+	return err
+}
+
+// forward runs the call on upstream and returns its status
+func (r *recorder) forward(ctx context.Context, cancel context.CancelFunc, serverStream grpc.ServerStream, rec *recording, types methodTypes) error {
+	// Every call is a bidirectional stream on the wire, whatever its kind
+	clientStream, err := r.upstream.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}, rec.method)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			req := types.request.New().Interface()
+			err := serverStream.RecvMsg(req)
+			if errors.Is(err, io.EOF) {
+				_ = clientStream.CloseSend()
+				return
+			}
+			if err != nil {
+				// The client is gone, canceling ends the upstream call too
+				cancel()
+				return
+			}
+			rec.add(event{request: true, message: req})
+			// When upstream ends the call, its status comes from RecvMsg
+			if err := clientStream.SendMsg(req); err != nil {
+				return
+			}
+		}
+	}()
+
+	// The trailer and the header, unless a response already sent it, go
+	// back to the client however the call ends
+	defer func() { serverStream.SetTrailer(clientStream.Trailer()) }()
+	headerSent := false
+	for {
+		res := types.response.New().Interface()
+		err := clientStream.RecvMsg(res)
+		if !headerSent {
+			if header, headerErr := clientStream.Header(); headerErr == nil && len(header) > 0 {
+				_ = serverStream.SendHeader(header)
+			}
+			headerSent = true
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rec.add(event{message: res})
+		if err := serverStream.SendMsg(res); err != nil {
+			return err
+		}
+	}
+}
+
+// forwarded returns the metadata of an incoming call without the headers
+// the transport sets itself
+func forwarded(md metadata.MD) metadata.MD {
+	out := metadata.MD{}
+	for key, values := range md {
+		if strings.HasPrefix(key, ":") || key == "content-type" || key == "user-agent" {
+			continue
+		}
+		out[key] = values
+	}
+	return out
+}
+
+func (r *recorder) add(c *call) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, c)
+}
+
+// recording is a call in flight, its requests are added from another
+// goroutine than its responses
+type recording struct {
+	method string
+
+	mu     sync.Mutex
+	events []event
+}
+
+func (rec *recording) add(e event) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.events = append(rec.events, e)
+}
+
+// done returns the call as it ended with err
+func (rec *recording) done(err error) *call {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return &call{
+		method: rec.method,
+		events: append([]event(nil), rec.events...),
+		status: status.Convert(err),
+	}
+}
@@ -0,0 +1,112 @@
+package grpcrecord
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"grpc-example/testhelpers"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// player answers calls with the recorded ones, each used once
+type player struct {
+	mu    sync.Mutex
+	calls []*call
+	used  []bool
+}
+
+// Replay starts an in-memory mock server answering with the calls recorded
+// in path and returns a connection to it. A call is answered by the first
+// unused recording of its method whose first request is equal; the
+// requests that follow must be equal too. Anything else fails with
+// Unimplemented.
+func Replay(t *testing.T, path string, opts ...testhelpers.Option) *grpc.ClientConn {
+	t.Helper()
+	calls, err := readFile(path)
+	if err != nil {
+		t.Fatalf("grpcrecord: read %s: %v (record it with %s=1)", path, err, RecordEnv)
+	}
+	p := &player{calls: calls, used: make([]bool, len(calls))}
+
+	opts = append(opts, testhelpers.WithServerOptions(grpc.UnknownServiceHandler(p.replay)))
+	return testhelpers.StartBufconnGRPCServer(t, func(*grpc.Server) {}, opts...)
+}
+
+// replay plays back a recorded call: it receives the requests, comparing
+// them with the recording, and sends the responses in their recorded order
+func (p *player) replay(_ any, stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+	types, err := lookupMethod(method)
+	if err != nil {
+		return status.Errorf(codes.Unimplemented, "grpcrecord: %v", err)
+	}
+
+	recv := func() (proto.Message, error) {
+		req := types.request.New().Interface()
+		err := stream.RecvMsg(req)
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return req, err
+	}
+
+	// Calls are told apart by their first request, nil when the client
+	// sent none
+	first, err := recv()
+	if err != nil {
+		return err
+	}
+	c := p.take(method, first)
+	if c == nil {
+		return status.Errorf(codes.Unimplemented, "grpcrecord: no recorded call of %s starts with %v", method, first)
+	}
+
+	events := c.events
+	if first != nil {
+		events = events[1:]
+	}
+	for _, e := range events {
+		if !e.request {
+			if err := stream.SendMsg(e.message); err != nil {
+				return err
+			}
+			continue
+		}
+		req, err := recv()
+		if err != nil {
+			return err
+		}
+		if req == nil || !proto.Equal(req, e.message) {
+			return status.Errorf(codes.Unimplemented, "grpcrecord: %s got request %v, the recording has %v", method, req, e.message)
+		}
+	}
+	return c.status.Err()
+}
+
+// take returns the first unused call of method whose first request is
+// equal to first, marking it used
+func (p *player) take(method string, first proto.Message) *call {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, c := range p.calls {
+		if p.used[i] || c.method != method {
+			continue
+		}
+		var recorded proto.Message
+		if len(c.events) > 0 && c.events[0].request {
+			recorded = c.events[0].message
+		}
+		if (first == nil && recorded == nil) || (first != nil && recorded != nil && proto.Equal(first, recorded)) {
+			p.used[i] = true
+			return c
+		}
+	}
+	return nil
+}
@@ -7,12 +7,11 @@
 package hellopb
 
 import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
-
-	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
-	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 )
 
 const (
@@ -110,18 +109,132 @@ func (x *HelloReply) GetMessage() string {
 	return ""
 }
 
+type HelloStreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// count is the number of greetings, 1 to 100
+	Count         int32 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HelloStreamRequest) Reset() {
+	*x = HelloStreamRequest{}
+	mi := &file_hello_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloStreamRequest) ProtoMessage() {}
+
+func (x *HelloStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hello_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloStreamRequest.ProtoReflect.Descriptor instead.
+func (*HelloStreamRequest) Descriptor() ([]byte, []int) {
+	return file_hello_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *HelloStreamRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *HelloStreamRequest) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type HelloSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Names         []string               `protobuf:"bytes,2,rep,name=names,proto3" json:"names,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HelloSummary) Reset() {
+	*x = HelloSummary{}
+	mi := &file_hello_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloSummary) ProtoMessage() {}
+
+func (x *HelloSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_hello_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloSummary.ProtoReflect.Descriptor instead.
+func (*HelloSummary) Descriptor() ([]byte, []int) {
+	return file_hello_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *HelloSummary) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *HelloSummary) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
 var File_hello_proto protoreflect.FileDescriptor
 
 const file_hello_proto_rawDesc = "" +
 	"\n" +
-	"\vhello.proto\x12\x05hello\"\"\n" +
+	"\vhello.proto\x12\ahellopb\"\"\n" +
 	"\fHelloRequest\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\"&\n" +
 	"\n" +
 	"HelloReply\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage2?\n" +
-	"\aGreeter\x124\n" +
-	"\bSayHello\x12\x13.hello.HelloRequest\x1a\x11.hello.HelloReply\"\x00B\x14Z\x12grpc-example/hellob\x06proto3"
+	"\amessage\x18\x01 \x01(\tR\amessage\">\n" +
+	"\x12HelloStreamRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\">\n" +
+	"\fHelloSummary\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x14\n" +
+	"\x05names\x18\x02 \x03(\tR\x05names2\x87\x02\n" +
+	"\aGreeter\x128\n" +
+	"\bSayHello\x12\x15.hellopb.HelloRequest\x1a\x13.hellopb.HelloReply\"\x00\x12F\n" +
+	"\x0eSayHelloStream\x12\x1b.hellopb.HelloStreamRequest\x1a\x13.hellopb.HelloReply\"\x000\x01\x12@\n" +
+	"\fCollectNames\x12\x15.hellopb.HelloRequest\x1a\x15.hellopb.HelloSummary\"\x00(\x01\x128\n" +
+	"\x04Chat\x12\x15.hellopb.HelloRequest\x1a\x13.hellopb.HelloReply\"\x00(\x010\x01B\x16Z\x14grpc-example/hellopbb\x06proto3"
 
 var (
 	file_hello_proto_rawDescOnce sync.Once
@@ -135,16 +248,24 @@ func file_hello_proto_rawDescGZIP() []byte {
 	return file_hello_proto_rawDescData
 }
 
-var file_hello_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_hello_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_hello_proto_goTypes = []any{
-	(*HelloRequest)(nil), // 0: hello.HelloRequest
-	(*HelloReply)(nil),   // 1: hello.HelloReply
+	(*HelloRequest)(nil),       // 0: hellopb.HelloRequest
+	(*HelloReply)(nil),         // 1: hellopb.HelloReply
+	(*HelloStreamRequest)(nil), // 2: hellopb.HelloStreamRequest
+	(*HelloSummary)(nil),       // 3: hellopb.HelloSummary
 }
 var file_hello_proto_depIdxs = []int32{
-	0, // 0: hello.Greeter.SayHello:input_type -> hello.HelloRequest
-	1, // 1: hello.Greeter.SayHello:output_type -> hello.HelloReply
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
+	0, // 0: hellopb.Greeter.SayHello:input_type -> hellopb.HelloRequest
+	2, // 1: hellopb.Greeter.SayHelloStream:input_type -> hellopb.HelloStreamRequest
+	0, // 2: hellopb.Greeter.CollectNames:input_type -> hellopb.HelloRequest
+	0, // 3: hellopb.Greeter.Chat:input_type -> hellopb.HelloRequest
+	1, // 4: hellopb.Greeter.SayHello:output_type -> hellopb.HelloReply
+	1, // 5: hellopb.Greeter.SayHelloStream:output_type -> hellopb.HelloReply
+	3, // 6: hellopb.Greeter.CollectNames:output_type -> hellopb.HelloSummary
+	1, // 7: hellopb.Greeter.Chat:output_type -> hellopb.HelloReply
+	4, // [4:8] is the sub-list for method output_type
+	0, // [0:4] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -161,7 +282,7 @@ func file_hello_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_hello_proto_rawDesc), len(file_hello_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
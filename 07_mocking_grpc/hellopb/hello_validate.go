@@ -0,0 +1,42 @@
+package hellopb
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+const (
+	// maxNameLength caps the name of a request
+	maxNameLength = 100
+	// maxCount caps the greetings of a HelloStreamRequest
+	maxCount = 100
+)
+
+// Validate checks the fields of the request, it is called by the
+// validation interceptor before SayHello and for every message of
+// CollectNames and Chat. Written by hand, unlike the rest of the package.
+func (r *HelloRequest) Validate() error {
+	return validateName(r.GetName())
+}
+
+// Validate checks the fields of the request, it is called by the
+// validation interceptor before SayHelloStream
+func (r *HelloStreamRequest) Validate() error {
+	if err := validateName(r.GetName()); err != nil {
+		return err
+	}
+	if r.GetCount() < 1 || r.GetCount() > maxCount {
+		return errors.New("count must be between 1 and 100")
+	}
+	return nil
+}
+
+func validateName(name string) error {
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return errors.New("name must be at most 100 characters")
+	}
+	return nil
+}
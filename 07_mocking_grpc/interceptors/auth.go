@@ -0,0 +1,71 @@
+package interceptors
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authorizationKey is the metadata key carrying "Bearer <token>"
+const authorizationKey = "authorization"
+
+// UnaryAuth rejects calls without one of the tokens with Unauthenticated
+func UnaryAuth(tokens ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authenticate(ctx, tokens); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuth rejects streams without one of the tokens with Unauthenticated
+func StreamAuth(tokens ...string) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authenticate(stream.Context(), tokens); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+func authenticate(ctx context.Context, tokens []string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationKey)
+	if len(values) == 0 {
+		return status.Error(codes.Unauthenticated, "missing authorization metadata")
+	}
+
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		return status.Error(codes.Unauthenticated, "authorization must be a bearer token")
+	}
+	for _, valid := range tokens {
+		// Constant time, so the comparison doesn't leak how much of a token matched
+		if subtle.ConstantTimeCompare([]byte(token), []byte(valid)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid token")
+}
+
+// TokenCredentials sends token as the bearer token of every call, for
+// clients dialing with grpc.WithPerRPCCredentials
+type TokenCredentials string
+
+func (t TokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{authorizationKey: "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity is false so the examples can dial without TLS
+func (t TokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+var _ credentials.PerRPCCredentials = TokenCredentials("")
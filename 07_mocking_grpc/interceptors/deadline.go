@@ -0,0 +1,67 @@
+package interceptors
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Deadlines bounds how long a call may run
+type Deadlines struct {
+	// Default is the timeout of calls without a deadline, zero for none
+	Default time.Duration
+	// Max caps the timeout of every call, zero for no cap
+	Max time.Duration
+}
+
+// UnaryDeadline applies the deadlines to the context of a call and rejects
+// calls whose deadline already passed with DeadlineExceeded
+func UnaryDeadline(deadlines Deadlines) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, cancel, err := deadlines.apply(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// StreamDeadline applies the deadlines to the context of a stream
+func StreamDeadline(deadlines Deadlines) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel, err := deadlines.apply(stream.Context())
+		if err != nil {
+			return err
+		}
+		defer cancel()
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func (d Deadlines) apply(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	deadline, ok := ctx.Deadline()
+	if ok && time.Until(deadline) <= 0 {
+		return nil, nil, status.Error(codes.DeadlineExceeded, "deadline exceeded before the call started")
+	}
+
+	var timeout time.Duration
+	switch {
+	case !ok:
+		timeout = d.Default
+		if d.Max > 0 && (timeout == 0 || timeout > d.Max) {
+			timeout = d.Max
+		}
+	case d.Max > 0 && time.Until(deadline) > d.Max:
+		timeout = d.Max
+	}
+
+	if timeout == 0 {
+		return ctx, func() {}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, nil
+}
@@ -0,0 +1,124 @@
+// Package interceptors holds the unary and stream interceptors of the
+// Greeter server: structured logging, panic recovery, token
+// authentication, deadline enforcement and request validation.
+//
+// The server and the test helpers both build their stack with
+// ServerOptions, so tests exercise the same interceptors as production.
+package interceptors
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Config configures the interceptor stack
+type Config struct {
+	// Logger receives one record per call, slog.Default() when nil
+	Logger *slog.Logger
+	// Tokens are the bearer tokens accepted in the authorization metadata.
+	// Without tokens every call is rejected.
+	Tokens []string
+	// PublicServices are the services called without a token, e.g.
+	// grpc.health.v1.Health for the deployment tooling
+	PublicServices []string
+	// DefaultTimeout is applied to calls that arrive without a deadline,
+	// zero leaves them without one
+	DefaultTimeout time.Duration
+	// MaxTimeout caps the deadline clients ask for, zero doesn't cap it
+	MaxTimeout time.Duration
+}
+
+// InfrastructureServices are the health checking and reflection services,
+// which the tools probing the server call without a token
+var InfrastructureServices = []string{
+	"grpc.health.v1.Health",
+	"grpc.reflection.v1.ServerReflection",
+	"grpc.reflection.v1alpha.ServerReflection",
+}
+
+// DefaultConfig is the configuration of the Greeter server: calls without a
+// deadline get 5 seconds, none may run longer than 30 and the
+// InfrastructureServices need no token
+func DefaultConfig(tokens ...string) Config {
+	return Config{
+		Tokens:         tokens,
+		PublicServices: InfrastructureServices,
+		DefaultTimeout: 5 * time.Second,
+		MaxTimeout:     30 * time.Second,
+	}
+}
+
+// ServerOptions returns the interceptor chains as server options. Logging
+// runs first so it records the code of every call, including the rejected
+// and recovered ones.
+func ServerOptions(config Config) []grpc.ServerOption {
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	deadlines := Deadlines{Default: config.DefaultTimeout, Max: config.MaxTimeout}
+
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			UnaryLogging(logger),
+			UnaryRecovery(logger),
+			UnaryExcept(config.PublicServices, UnaryAuth(config.Tokens...)),
+			UnaryDeadline(deadlines),
+			UnaryValidation(),
+		),
+		grpc.ChainStreamInterceptor(
+			StreamLogging(logger),
+			StreamRecovery(logger),
+			StreamExcept(config.PublicServices, StreamAuth(config.Tokens...)),
+			StreamDeadline(deadlines),
+			StreamValidation(),
+		),
+	}
+}
+
+// UnaryExcept runs interceptor for the calls of every service but the
+// ones listed, which go straight to the next handler
+func UnaryExcept(services []string, interceptor grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if inServices(info.FullMethod, services) {
+			return handler(ctx, req)
+		}
+		return interceptor(ctx, req, info, handler)
+	}
+}
+
+// StreamExcept runs interceptor for the streams of every service but the
+// ones listed
+func StreamExcept(services []string, interceptor grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if inServices(info.FullMethod, services) {
+			return handler(srv, stream)
+		}
+		return interceptor(srv, stream, info, handler)
+	}
+}
+
+// inServices reports whether the full method, "/package.Service/Method",
+// belongs to one of the services
+func inServices(fullMethod string, services []string) bool {
+	for _, service := range services {
+		if strings.HasPrefix(fullMethod, "/"+service+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// contextStream replaces the context of a stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,198 @@
+package interceptors_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	pb "grpc-example/hellopb"
+	"grpc-example/interceptors"
+	"grpc-example/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const token = "secret"
+
+// greeter answers with the time left before its deadline, or panics for
+// the name "panic"
+type greeter struct {
+	pb.UnimplementedGreeterServer
+}
+
+func (g *greeter) SayHello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+	if in.GetName() == "panic" {
+		panic("boom")
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return &pb.HelloReply{Message: "no deadline"}, nil
+	}
+	return &pb.HelloReply{Message: time.Until(deadline).Round(time.Second).String()}, nil
+}
+
+// startGreeter serves greeter behind the interceptor stack and returns a
+// client and the log output
+func startGreeter(t *testing.T) (pb.GreeterClient, *bytes.Buffer) {
+	var logs bytes.Buffer
+	config := interceptors.DefaultConfig(token)
+	config.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+
+	_, conn, cleanup := testhelpers.StartTestGRPCServer(t, func(s *grpc.Server) {
+		pb.RegisterGreeterServer(s, &greeter{})
+	}, testhelpers.WithServerOptions(interceptors.ServerOptions(config)...))
+	t.Cleanup(cleanup)
+	return pb.NewGreeterClient(conn), &logs
+}
+
+func authorized() grpc.CallOption {
+	return grpc.PerRPCCredentials(interceptors.TokenCredentials(token))
+}
+
+func TestAuthRejectsCallsWithoutAValidToken(t *testing.T) {
+	client, _ := startGreeter(t)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"no metadata", context.Background()},
+		{"not a bearer token", metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic "+token)},
+		{"wrong token", metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer nope")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.SayHello(tt.ctx, &pb.HelloRequest{Name: "Gabriel"})
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		})
+	}
+}
+
+func TestPublicServicesNeedNoToken(t *testing.T) {
+	_, conn, cleanup := testhelpers.StartTestGRPCServer(t, func(s *grpc.Server) {
+		pb.RegisterGreeterServer(s, &greeter{})
+		healthpb.RegisterHealthServer(s, health.NewServer())
+	}, testhelpers.WithServerOptions(interceptors.ServerOptions(interceptors.DefaultConfig(token))...))
+	t.Cleanup(cleanup)
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	_, err = pb.NewGreeterClient(conn).SayHello(context.Background(), &pb.HelloRequest{Name: "Gabriel"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "the Greeter still needs a token")
+}
+
+func TestValidationRejectsInvalidRequests(t *testing.T) {
+	client, _ := startGreeter(t)
+
+	_, err := client.SayHello(context.Background(), &pb.HelloRequest{}, authorized())
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{Name: strings.Repeat("a", 101)}, authorized())
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRecoveryTurnsPanicsIntoInternal(t *testing.T) {
+	client, logs := startGreeter(t)
+
+	_, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "panic"}, authorized())
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "internal error", status.Convert(err).Message(), "the panic is not leaked")
+	assert.Contains(t, logs.String(), `"panic":"boom"`)
+
+	// The server keeps serving
+	_, err = client.SayHello(context.Background(), &pb.HelloRequest{Name: "Gabriel"}, authorized())
+	assert.NoError(t, err)
+}
+
+func TestDeadlinesAreDefaultedAndCapped(t *testing.T) {
+	client, _ := startGreeter(t)
+
+	reply, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "Gabriel"}, authorized())
+	require.NoError(t, err)
+	assert.Equal(t, "5s", reply.GetMessage(), "calls without a deadline get the default")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	reply, err = client.SayHello(ctx, &pb.HelloRequest{Name: "Gabriel"}, authorized())
+	require.NoError(t, err)
+	assert.Equal(t, "30s", reply.GetMessage(), "long deadlines are capped")
+}
+
+func TestLoggingRecordsEveryCall(t *testing.T) {
+	client, logs := startGreeter(t)
+
+	client.SayHello(context.Background(), &pb.HelloRequest{Name: "Gabriel"}, authorized())
+	client.SayHello(context.Background(), &pb.HelloRequest{Name: "Gabriel"})
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"method":"`+pb.Greeter_SayHello_FullMethodName+`"`)
+	assert.Contains(t, lines[0], `"code":"OK"`)
+	assert.Contains(t, lines[1], `"code":"Unauthenticated"`)
+}
+
+// fakeStream is a server stream receiving HelloRequests
+type fakeStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	recv []*pb.HelloRequest
+}
+
+func (s *fakeStream) Context() context.Context { return s.ctx }
+
+func (s *fakeStream) RecvMsg(m any) error {
+	*m.(*pb.HelloRequest) = pb.HelloRequest{Name: s.recv[0].GetName()}
+	s.recv = s.recv[1:]
+	return nil
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	info := &grpc.StreamServerInfo{FullMethod: pb.Greeter_Chat_FullMethodName}
+	authorizedCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+
+	t.Run("auth", func(t *testing.T) {
+		err := interceptors.StreamAuth(token)(nil, &fakeStream{ctx: context.Background()}, info, func(any, grpc.ServerStream) error {
+			t.Fatal("the handler must not run")
+			return nil
+		})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("recovery", func(t *testing.T) {
+		err := interceptors.StreamRecovery(slog.New(slog.DiscardHandler))(nil, &fakeStream{ctx: authorizedCtx}, info, func(any, grpc.ServerStream) error {
+			panic("boom")
+		})
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		err := interceptors.StreamDeadline(interceptors.Deadlines{Default: time.Minute})(nil, &fakeStream{ctx: authorizedCtx}, info, func(_ any, stream grpc.ServerStream) error {
+			_, ok := stream.Context().Deadline()
+			assert.True(t, ok)
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		stream := &fakeStream{ctx: authorizedCtx, recv: []*pb.HelloRequest{{Name: "Gabriel"}, {}}}
+		err := interceptors.StreamValidation()(nil, stream, info, func(_ any, stream grpc.ServerStream) error {
+			var req pb.HelloRequest
+			require.NoError(t, stream.RecvMsg(&req))
+			return stream.RecvMsg(&req)
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
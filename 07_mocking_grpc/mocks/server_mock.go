@@ -2,7 +2,11 @@ package mocks
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	pb "grpc-example/hellopb"
+	"io"
+	"strings"
 )
 
 type ServerMock struct {
@@ -12,3 +16,41 @@ type ServerMock struct {
 func (s *ServerMock) SayHello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
 	return &pb.HelloReply{Message: "Salut " + in.GetName()}, nil
 }
+
+func (s *ServerMock) SayHelloStream(in *pb.HelloStreamRequest, stream pb.Greeter_SayHelloStreamServer) error {
+	for i := 1; i <= int(in.GetCount()); i++ {
+		if err := stream.Send(&pb.HelloReply{Message: fmt.Sprintf("Salut %s (%d/%d)", in.GetName(), i, in.GetCount())}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ServerMock) CollectNames(stream pb.Greeter_CollectNamesServer) error {
+	var names []string
+	for {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(&pb.HelloSummary{Message: "Salut " + strings.Join(names, ", "), Names: names})
+		}
+		if err != nil {
+			return err
+		}
+		names = append(names, in.GetName())
+	}
+}
+
+func (s *ServerMock) Chat(stream pb.Greeter_ChatServer) error {
+	for {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&pb.HelloReply{Message: "Salut " + in.GetName()}); err != nil {
+			return err
+		}
+	}
+}
@@ -2,14 +2,40 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	pb "grpc-example/hellopb"
+	"grpc-example/interceptors"
+	"grpc-example/tlsconfig"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
+// devToken is only accepted with -insecure-dev, it is the token the client
+// sends by default
+const devToken = "dev-token"
+
+// errNoTokens is returned when no token is configured and the development
+// token is not allowed either
+var errNoTokens = errors.New("no tokens configured: set GREETER_TOKENS or -tokens, or pass -insecure-dev")
+
+// shutdownGrace is how long the calls in flight may run after SIGTERM
+const shutdownGrace = 10 * time.Second
+
 type server struct {
 	pb.UnimplementedGreeterServer
 }
@@ -18,15 +44,172 @@ func (s *server) SayHello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloRe
 	return &pb.HelloReply{Message: "Hello " + in.GetName()}, nil
 }
 
+func (s *server) SayHelloStream(in *pb.HelloStreamRequest, stream pb.Greeter_SayHelloStreamServer) error {
+	for i := 1; i <= int(in.GetCount()); i++ {
+		// Stop early when the client cancels or the deadline passes
+		if err := stream.Context().Err(); err != nil {
+			return err
+		}
+		reply := &pb.HelloReply{Message: fmt.Sprintf("Hello %s (%d/%d)", in.GetName(), i, in.GetCount())}
+		if err := stream.Send(reply); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *server) CollectNames(stream pb.Greeter_CollectNamesServer) error {
+	var names []string
+	for {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(&pb.HelloSummary{
+				Message: "Hello " + strings.Join(names, ", "),
+				Names:   names,
+			})
+		}
+		if err != nil {
+			return err
+		}
+		names = append(names, in.GetName())
+	}
+}
+
+func (s *server) Chat(stream pb.Greeter_ChatServer) error {
+	for {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&pb.HelloReply{Message: "Hello " + in.GetName()}); err != nil {
+			return err
+		}
+	}
+}
+
+// serverTokens returns the comma separated tokens of the -tokens flag, or of
+// GREETER_TOKENS when the flag is empty. Without any the server doesn't
+// start, unless insecureDev accepts the development token.
+func serverTokens(flagValue string, insecureDev bool) ([]string, error) {
+	value := flagValue
+	if value == "" {
+		value = os.Getenv("GREETER_TOKENS")
+	}
+
+	var tokens []string
+	for _, token := range strings.Split(value, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	if len(tokens) > 0 {
+		return tokens, nil
+	}
+	if !insecureDev {
+		return nil, errNoTokens
+	}
+	log.Printf("-insecure-dev: accepting the development token %q", devToken)
+	return []string{devToken}, nil
+}
+
+// tlsOption returns the server option serving TLS when GREETER_TLS_CERT is
+// set, nil for plaintext. GREETER_TLS_CLIENT_CA turns on mutual TLS.
+func tlsOption() (grpc.ServerOption, error) {
+	files := tlsconfig.Files{
+		CertFile: os.Getenv("GREETER_TLS_CERT"),
+		KeyFile:  os.Getenv("GREETER_TLS_KEY"),
+		CAFile:   os.Getenv("GREETER_TLS_CLIENT_CA"),
+	}
+	if files.CertFile == "" {
+		return nil, nil
+	}
+	config, err := tlsconfig.Server(files)
+	if err != nil {
+		return nil, err
+	}
+	return grpc.Creds(credentials.NewTLS(config)), nil
+}
+
+// register registers the Greeter with the health and reflection services.
+// Every service is NOT_SERVING until setServing says otherwise.
+func register(s *grpc.Server) *health.Server {
+	pb.RegisterGreeterServer(s, &server{})
+
+	healthServer := health.NewServer()
+	setServing(healthServer, healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+
+	reflection.Register(s)
+	return healthServer
+}
+
+// setServing sets the status of the server as a whole, the empty service
+// name, and of the Greeter
+func setServing(healthServer *health.Server, status healthpb.HealthCheckResponse_ServingStatus) {
+	healthServer.SetServingStatus("", status)
+	healthServer.SetServingStatus(pb.Greeter_ServiceDesc.ServiceName, status)
+}
+
+// stopOnSignal stops the server gracefully on SIGINT or SIGTERM. Health
+// checks report NOT_SERVING first so load balancers stop sending calls,
+// then the calls in flight get shutdownGrace to finish.
+func stopOnSignal(s *grpc.Server, healthServer *health.Server) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+	log.Printf("received %v, shutting down", sig)
+
+	// Shutdown marks every service NOT_SERVING and keeps them so
+	healthServer.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(shutdownGrace):
+		log.Printf("calls still running after %v, stopping", shutdownGrace)
+		s.Stop()
+	}
+}
+
 func main() {
+	tokensFlag := flag.String("tokens", "", "comma separated bearer tokens to accept, overrides GREETER_TOKENS")
+	insecureDev := flag.Bool("insecure-dev", false, "accept the development token when no tokens are configured")
+	flag.Parse()
+
+	tokens, err := serverTokens(*tokensFlag, *insecureDev)
+	if err != nil {
+		log.Fatalf("refusing to start: %v", err)
+	}
+
 	lis, err := net.Listen("tcp", ":50051")
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
-	s := grpc.NewServer()
-	pb.RegisterGreeterServer(s, &server{})
+	opts := interceptors.ServerOptions(interceptors.DefaultConfig(tokens...))
+	tlsOpt, err := tlsOption()
+	if err != nil {
+		log.Fatalf("failed to load TLS certificates: %v", err)
+	}
+	if tlsOpt != nil {
+		opts = append(opts, tlsOpt)
+		log.Printf("serving TLS, client certificates required: %v", os.Getenv("GREETER_TLS_CLIENT_CA") != "")
+	}
+
+	s := grpc.NewServer(opts...)
+	healthServer := register(s)
+	go stopOnSignal(s, healthServer)
+
+	setServing(healthServer, healthpb.HealthCheckResponse_SERVING)
 	log.Printf("server listening at %v", lis.Addr())
 	if err := s.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
+	log.Printf("server stopped")
 }
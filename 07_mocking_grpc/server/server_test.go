@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "grpc-example/hellopb"
+	"grpc-example/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+)
+
+// The streaming handlers are called directly with in-memory streams, no
+// server or connection involved
+
+func TestSayHelloStream(t *testing.T) {
+	stream := &testhelpers.FakeServerStream[pb.HelloStreamRequest, pb.HelloReply]{}
+
+	err := (&server{}).SayHelloStream(&pb.HelloStreamRequest{Name: "Ann", Count: 2}, stream)
+	require.NoError(t, err)
+
+	require.Len(t, stream.Sent, 2)
+	assert.Equal(t, "Hello Ann (1/2)", stream.Sent[0].GetMessage())
+	assert.Equal(t, "Hello Ann (2/2)", stream.Sent[1].GetMessage())
+}
+
+func TestSayHelloStreamStopsWhenTheClientIsGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stream := &testhelpers.FakeServerStream[pb.HelloStreamRequest, pb.HelloReply]{Ctx: ctx}
+
+	err := (&server{}).SayHelloStream(&pb.HelloStreamRequest{Name: "Ann", Count: 100}, stream)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, stream.Sent)
+}
+
+func TestCollectNames(t *testing.T) {
+	stream := &testhelpers.FakeServerStream[pb.HelloRequest, pb.HelloSummary]{
+		In: []*pb.HelloRequest{{Name: "Ann"}, {Name: "Bob"}},
+	}
+
+	require.NoError(t, (&server{}).CollectNames(stream))
+
+	require.Len(t, stream.Sent, 1)
+	assert.Equal(t, "Hello Ann, Bob", stream.Sent[0].GetMessage())
+	assert.Equal(t, []string{"Ann", "Bob"}, stream.Sent[0].GetNames())
+}
+
+func TestChat(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []*pb.HelloRequest
+		recvErr error
+		want    []string
+		wantErr error
+	}{
+		{"answers every message", []*pb.HelloRequest{{Name: "Ann"}, {Name: "Bob"}}, nil, []string{"Hello Ann", "Hello Bob"}, nil},
+		{"no messages", nil, nil, nil, nil},
+		{"broken stream", []*pb.HelloRequest{{Name: "Ann"}}, errors.New("connection reset"), []string{"Hello Ann"}, errors.New("connection reset")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &testhelpers.FakeServerStream[pb.HelloRequest, pb.HelloReply]{In: tt.in, RecvErr: tt.recvErr}
+
+			err := (&server{}).Chat(stream)
+
+			assert.Equal(t, tt.wantErr, err)
+			var got []string
+			for _, reply := range stream.Sent {
+				got = append(got, reply.GetMessage())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// The health and reflection services are queried over a bufconn server
+// registered like main registers it
+
+func TestHealthFollowsTheServingStatus(t *testing.T) {
+	var healthServer *health.Server
+	conn := testhelpers.StartBufconnGRPCServer(t, func(s *grpc.Server) {
+		healthServer = register(s)
+	})
+	service := pb.Greeter_ServiceDesc.ServiceName
+
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, testhelpers.HealthStatus(t, conn, service), "not serving before startup")
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVICE_UNKNOWN, testhelpers.HealthStatus(t, conn, "unknown.Service"))
+
+	go setServing(healthServer, healthpb.HealthCheckResponse_SERVING)
+	testhelpers.WaitForServing(t, conn, service)
+	testhelpers.WaitForServing(t, conn, "")
+
+	healthServer.Shutdown()
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, testhelpers.HealthStatus(t, conn, service), "not serving once shutting down")
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, testhelpers.HealthStatus(t, conn, ""))
+}
+
+func TestReflectionListsTheServices(t *testing.T) {
+	conn := testhelpers.StartBufconnGRPCServer(t, func(s *grpc.Server) {
+		register(s)
+	})
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.NoError(t, stream.CloseSend())
+
+	var services []string
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		services = append(services, service.GetName())
+	}
+	assert.Contains(t, services, "hellopb.Greeter")
+	assert.Contains(t, services, "grpc.health.v1.Health")
+}
+
+func TestServerTokens(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         string
+		flagValue   string
+		insecureDev bool
+		want        []string
+		wantErr     error
+	}{
+		{name: "tokens from the environment", env: "a, b", want: []string{"a", "b"}},
+		{name: "the flag overrides the environment", env: "a", flagValue: "c", want: []string{"c"}},
+		{name: "no token refuses to start", wantErr: errNoTokens},
+		{name: "blank tokens refuse to start", env: " , ", wantErr: errNoTokens},
+		{name: "insecure dev accepts the development token", insecureDev: true, want: []string{devToken}},
+		{name: "insecure dev keeps configured tokens", env: "a", insecureDev: true, want: []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GREETER_TOKENS", tt.env)
+
+			tokens, err := serverTokens(tt.flagValue, tt.insecureDev)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, tokens)
+		})
+	}
+}
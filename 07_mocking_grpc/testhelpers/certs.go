@@ -0,0 +1,187 @@
+package testhelpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Certificates is an ephemeral PKI generated in memory: a CA and the server
+// and client certificates it signed. The server certificate is valid for
+// localhost, 127.0.0.1, ::1 and bufnet, the address of
+// StartBufconnGRPCServer.
+type Certificates struct {
+	CA     *x509.Certificate
+	Server tls.Certificate
+	Client tls.Certificate
+
+	// The PEM encodings, as written by WriteFiles
+	CAPEM         []byte
+	ServerCertPEM []byte
+	ServerKeyPEM  []byte
+	ClientCertPEM []byte
+	ClientKeyPEM  []byte
+}
+
+// CertFiles are the paths WriteFiles wrote the certificates to
+type CertFiles struct {
+	CA         string
+	ServerCert string
+	ServerKey  string
+	ClientCert string
+	ClientKey  string
+}
+
+// NewCertificates generates a CA and a server and client certificate
+// valid for an hour. Call it once per test that needs a different CA.
+func NewCertificates(t testing.TB) *Certificates {
+	t.Helper()
+
+	caKey, caCert, caPEM := newCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "greeter test CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+
+	serverKey, _, serverPEM := newCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "greeter server"},
+		DNSNames:    []string{"localhost", "bufnet"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, caCert, caKey)
+
+	clientKey, _, clientPEM := newCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "greeter client"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caCert, caKey)
+
+	c := &Certificates{
+		CA:            caCert,
+		CAPEM:         caPEM,
+		ServerCertPEM: serverPEM,
+		ServerKeyPEM:  encodeKey(t, serverKey),
+		ClientCertPEM: clientPEM,
+		ClientKeyPEM:  encodeKey(t, clientKey),
+	}
+	var err error
+	if c.Server, err = tls.X509KeyPair(c.ServerCertPEM, c.ServerKeyPEM); err != nil {
+		t.Fatalf("server key pair: %v", err)
+	}
+	if c.Client, err = tls.X509KeyPair(c.ClientCertPEM, c.ClientKeyPEM); err != nil {
+		t.Fatalf("client key pair: %v", err)
+	}
+	return c
+}
+
+// CertPool returns a pool trusting only the CA
+func (c *Certificates) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(c.CA)
+	return pool
+}
+
+// ServerTLSConfig serves the server certificate without asking clients
+// for theirs
+func (c *Certificates) ServerTLSConfig() *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{c.Server},
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// MutualServerTLSConfig serves the server certificate and requires a client
+// certificate signed by the CA
+func (c *Certificates) MutualServerTLSConfig() *tls.Config {
+	config := c.ServerTLSConfig()
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	config.ClientCAs = c.CertPool()
+	return config
+}
+
+// ClientTLSConfig trusts the CA and presents the client certificate when
+// the server asks for one
+func (c *Certificates) ClientTLSConfig() *tls.Config {
+	return &tls.Config{
+		RootCAs:      c.CertPool(),
+		Certificates: []tls.Certificate{c.Client},
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// WriteFiles writes the certificates and keys as PEM files in dir, for
+// code that loads them from disk
+func (c *Certificates) WriteFiles(t testing.TB, dir string) CertFiles {
+	t.Helper()
+	files := CertFiles{
+		CA:         filepath.Join(dir, "ca.pem"),
+		ServerCert: filepath.Join(dir, "server.pem"),
+		ServerKey:  filepath.Join(dir, "server-key.pem"),
+		ClientCert: filepath.Join(dir, "client.pem"),
+		ClientKey:  filepath.Join(dir, "client-key.pem"),
+	}
+	for path, data := range map[string][]byte{
+		files.CA:         c.CAPEM,
+		files.ServerCert: c.ServerCertPEM,
+		files.ServerKey:  c.ServerKeyPEM,
+		files.ClientCert: c.ClientCertPEM,
+		files.ClientKey:  c.ClientKeyPEM,
+	} {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	return files
+}
+
+// newCertificate creates a certificate from template signed by parent, or
+// self-signed when parent is nil
+func newCertificate(t testing.TB, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*ecdsa.PrivateKey, *x509.Certificate, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		t.Fatalf("serial number: %v", err)
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("create certificate %s: %v", template.Subject.CommonName, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return key, cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func encodeKey(t testing.TB, key *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
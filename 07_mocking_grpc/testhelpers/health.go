@@ -0,0 +1,55 @@
+package testhelpers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// readyTimeout is how long WaitForServing waits
+	readyTimeout = 5 * time.Second
+	// readyPoll is how often WaitForServing checks
+	readyPoll = 10 * time.Millisecond
+)
+
+// HealthStatus returns the status the health service of conn reports for
+// service, "" for the server as a whole. A service the health server
+// doesn't know is SERVICE_UNKNOWN.
+func HealthStatus(t testing.TB, conn *grpc.ClientConn, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), readyTimeout)
+	defer cancel()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service}, grpc.WaitForReady(true))
+	if status.Code(err) == codes.NotFound {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+	if err != nil {
+		t.Fatalf("health check of %q: %v", service, err)
+	}
+	return resp.GetStatus()
+}
+
+// WaitForServing waits until the health service of conn reports service
+// SERVING, so tests don't assume a server serves as soon as it listens.
+// It fails the test after 5 seconds.
+func WaitForServing(t testing.TB, conn *grpc.ClientConn, service string) {
+	t.Helper()
+	deadline := time.Now().Add(readyTimeout)
+	for {
+		current := HealthStatus(t, conn, service)
+		if current == healthpb.HealthCheckResponse_SERVING {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("service %q is %v after %v, want SERVING", service, current, readyTimeout)
+		}
+		time.Sleep(readyPoll)
+	}
+}
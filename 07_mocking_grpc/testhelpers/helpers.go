@@ -1,22 +1,92 @@
 package testhelpers
 
 import (
+	"context"
 	"net"
 	"testing"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
 )
 
+// bufSize is the buffer of the in-memory connections of StartBufconnGRPCServer
+const bufSize = 1024 * 1024
+
+// Option configures the servers and connections of StartTestGRPCServer
+// and StartBufconnGRPCServer
+type Option func(*config)
+
+type config struct {
+	serverOptions []grpc.ServerOption
+	serverCreds   credentials.TransportCredentials
+	clientCreds   credentials.TransportCredentials
+	// clientOverride is set by WithClientCredentials and wins over clientCreds
+	clientOverride credentials.TransportCredentials
+}
+
+// WithServerOptions passes the options, e.g. interceptors.ServerOptions,
+// to grpc.NewServer
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(c *config) {
+		c.serverOptions = append(c.serverOptions, opts...)
+	}
+}
+
+// WithTLS serves TLS with the server certificate of certs, and the client
+// trusts their CA
+func WithTLS(certs *Certificates) Option {
+	return func(c *config) {
+		c.serverCreds = credentials.NewTLS(certs.ServerTLSConfig())
+		c.clientCreds = credentials.NewTLS(certs.ClientTLSConfig())
+	}
+}
+
+// WithMutualTLS is WithTLS where the server also requires a client
+// certificate signed by the CA of certs, which the client presents
+func WithMutualTLS(certs *Certificates) Option {
+	return func(c *config) {
+		c.serverCreds = credentials.NewTLS(certs.MutualServerTLSConfig())
+		c.clientCreds = credentials.NewTLS(certs.ClientTLSConfig())
+	}
+}
+
+// WithClientCredentials replaces the credentials of the client connection,
+// e.g. to connect with the wrong CA or without a client certificate.
+// It applies whatever the order of the options.
+func WithClientCredentials(creds credentials.TransportCredentials) Option {
+	return func(c *config) {
+		c.clientOverride = creds
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{clientCreds: insecure.NewCredentials()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.serverCreds != nil {
+		c.serverOptions = append(c.serverOptions, grpc.Creds(c.serverCreds))
+	}
+	if c.clientOverride != nil {
+		c.clientCreds = c.clientOverride
+	}
+	return c
+}
+
 // StartTestGRPCServer is a generic Test Server
-// similar to the httptest.NewServer implementation
-func StartTestGRPCServer(t *testing.T, register func(*grpc.Server)) (string, *grpc.ClientConn, func()) {
+// similar to the httptest.NewServer implementation.
+// Without options it serves plaintext with a default grpc.Server
+func StartTestGRPCServer(t *testing.T, register func(*grpc.Server), opts ...Option) (string, *grpc.ClientConn, func()) {
+	c := newConfig(opts)
+
 	lis, err := net.Listen("tcp", "localhost:0") // Listen on random port
 	if err != nil {
 		t.Fatalf("failed to listen: %v", err)
 	}
 
-	server := grpc.NewServer()
+	server := grpc.NewServer(c.serverOptions...)
 	register(server) // Let caller register their services
 
 	go func() {
@@ -25,7 +95,7 @@ func StartTestGRPCServer(t *testing.T, register func(*grpc.Server)) (string, *gr
 		}
 	}()
 
-	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(c.clientCreds))
 	if err != nil {
 		t.Fatalf("did not connect: %v", err)
 	}
@@ -42,3 +112,38 @@ func StartTestGRPCServer(t *testing.T, register func(*grpc.Server)) (string, *gr
 
 	return lis.Addr().String(), conn, cleanup
 }
+
+// StartBufconnGRPCServer is StartTestGRPCServer over an in-memory bufconn
+// listener instead of a TCP port, so tests need no network at all.
+// The server and connection are closed when the test ends.
+func StartBufconnGRPCServer(t *testing.T, register func(*grpc.Server), opts ...Option) *grpc.ClientConn {
+	c := newConfig(opts)
+	lis := bufconn.Listen(bufSize)
+
+	server := grpc.NewServer(c.serverOptions...)
+	register(server)
+
+	go func() {
+		if err := server.Serve(lis); err != nil {
+			t.Logf("server closed: %v", err)
+		}
+	}()
+
+	// The passthrough scheme hands the address to the dialer unresolved
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(c.clientCreds))
+	if err != nil {
+		t.Fatalf("did not connect: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := conn.Close(); err != nil {
+			t.Logf("connection closed: %v", err)
+		}
+		server.Stop()
+	})
+	return conn
+}
@@ -0,0 +1,207 @@
+package testhelpers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// RecvStream is the receiving side of a client stream
+type RecvStream[Res any] interface {
+	Recv() (Res, error)
+}
+
+// BidiStream is a client stream sending Req and receiving Res, as returned
+// for bidirectional RPCs
+type BidiStream[Req, Res any] interface {
+	Send(Req) error
+	Recv() (Res, error)
+	CloseSend() error
+}
+
+// RecvAll receives the messages of a stream until it ends and fails the
+// test when it ends with an error
+func RecvAll[Res any](t *testing.T, stream RecvStream[Res]) []Res {
+	t.Helper()
+	var messages []Res
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return messages
+		}
+		if err != nil {
+			t.Fatalf("stream failed after %d messages: %v", len(messages), err)
+		}
+		messages = append(messages, msg)
+	}
+}
+
+// ExpectMessages fails the test unless the stream sends exactly want and
+// then ends
+func ExpectMessages[Res proto.Message](t *testing.T, stream RecvStream[Res], want ...Res) {
+	t.Helper()
+	got := RecvAll(t, stream)
+	if len(got) != len(want) {
+		t.Fatalf("received %d messages, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !proto.Equal(got[i], want[i]) {
+			t.Errorf("message %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+// ExpectStatus fails the test unless err is a status with code
+func ExpectStatus(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != code {
+		t.Errorf("status = %v (%v), want %v", got, err, code)
+	}
+}
+
+// StreamScript plays a conversation on a bidirectional stream step by step:
+//
+//	testhelpers.NewStreamScript(t, stream).
+//		Send(&pb.HelloRequest{Name: "Ann"}).
+//		Expect(&pb.HelloReply{Message: "Hello Ann"}).
+//		CloseSend().
+//		ExpectEnd()
+//
+// Every step fails the test at the first unexpected message or error.
+type StreamScript[Req, Res proto.Message] struct {
+	t      *testing.T
+	stream BidiStream[Req, Res]
+}
+
+// NewStreamScript starts a script on stream
+func NewStreamScript[Req, Res proto.Message](t *testing.T, stream BidiStream[Req, Res]) *StreamScript[Req, Res] {
+	return &StreamScript[Req, Res]{t: t, stream: stream}
+}
+
+// Send sends the messages in order
+func (s *StreamScript[Req, Res]) Send(messages ...Req) *StreamScript[Req, Res] {
+	s.t.Helper()
+	for _, msg := range messages {
+		if err := s.stream.Send(msg); err != nil {
+			s.t.Fatalf("send %v: %v", msg, err)
+		}
+	}
+	return s
+}
+
+// Expect receives one message per want and compares them in order
+func (s *StreamScript[Req, Res]) Expect(want ...Res) *StreamScript[Req, Res] {
+	s.t.Helper()
+	for _, w := range want {
+		got, err := s.stream.Recv()
+		if err != nil {
+			s.t.Fatalf("receive, want %v: %v", w, err)
+		}
+		if !proto.Equal(got, w) {
+			s.t.Fatalf("received %v, want %v", got, w)
+		}
+	}
+	return s
+}
+
+// CloseSend tells the server the client is done sending
+func (s *StreamScript[Req, Res]) CloseSend() *StreamScript[Req, Res] {
+	s.t.Helper()
+	if err := s.stream.CloseSend(); err != nil {
+		s.t.Fatalf("close send: %v", err)
+	}
+	return s
+}
+
+// ExpectEnd fails the test unless the server ends the stream successfully
+// without sending anything more
+func (s *StreamScript[Req, Res]) ExpectEnd() {
+	s.t.Helper()
+	if msg, err := s.stream.Recv(); !errors.Is(err, io.EOF) {
+		s.t.Fatalf("stream did not end: received %v, %v", msg, err)
+	}
+}
+
+// ExpectStatus fails the test unless the server ends the stream with code
+// before sending anything more
+func (s *StreamScript[Req, Res]) ExpectStatus(code codes.Code) {
+	s.t.Helper()
+	msg, err := s.stream.Recv()
+	if err == nil {
+		s.t.Fatalf("received %v, want status %v", msg, code)
+	}
+	ExpectStatus(s.t, err, code)
+}
+
+// FakeServerStream is an in-memory server stream for calling streaming
+// handlers directly, without a server. It implements the server side of
+// server streaming, client streaming and bidirectional RPCs.
+type FakeServerStream[Req, Res any] struct {
+	grpc.ServerStream
+
+	// Ctx is the context of the call, context.Background() when nil
+	Ctx context.Context
+	// In are the messages the client sends, Recv returns io.EOF after them
+	In []*Req
+	// RecvErr is returned by Recv after In instead of io.EOF
+	RecvErr error
+	// SendErr is returned by Send and SendAndClose when set
+	SendErr error
+
+	// Sent are the messages the handler sent, in order
+	Sent []*Res
+	// Header and Trailer are the metadata the handler set
+	Header  metadata.MD
+	Trailer metadata.MD
+}
+
+func (s *FakeServerStream[Req, Res]) Context() context.Context {
+	if s.Ctx == nil {
+		return context.Background()
+	}
+	return s.Ctx
+}
+
+func (s *FakeServerStream[Req, Res]) Recv() (*Req, error) {
+	if len(s.In) == 0 {
+		if s.RecvErr != nil {
+			return nil, s.RecvErr
+		}
+		return nil, io.EOF
+	}
+	msg := s.In[0]
+	s.In = s.In[1:]
+	return msg, nil
+}
+
+func (s *FakeServerStream[Req, Res]) Send(msg *Res) error {
+	if s.SendErr != nil {
+		return s.SendErr
+	}
+	s.Sent = append(s.Sent, msg)
+	return nil
+}
+
+func (s *FakeServerStream[Req, Res]) SendAndClose(msg *Res) error {
+	return s.Send(msg)
+}
+
+func (s *FakeServerStream[Req, Res]) SetHeader(md metadata.MD) error {
+	s.Header = metadata.Join(s.Header, md)
+	return nil
+}
+
+func (s *FakeServerStream[Req, Res]) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *FakeServerStream[Req, Res]) SetTrailer(md metadata.MD) {
+	s.Trailer = metadata.Join(s.Trailer, md)
+}
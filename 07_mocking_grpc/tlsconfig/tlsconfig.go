@@ -0,0 +1,91 @@
+// Package tlsconfig loads the TLS configuration of the Greeter server and
+// client from PEM files
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Files are the PEM files of one side of a connection
+type Files struct {
+	// CertFile and KeyFile are the certificate presented to the other side.
+	// They are required for the server; a client without them connects
+	// without a client certificate.
+	CertFile string
+	KeyFile  string
+	// CAFile is the CA the other side's certificate must be signed by. For
+	// the server it turns on mutual TLS; for the client it replaces the
+	// system roots.
+	CAFile string
+}
+
+// Server returns the TLS configuration of the server. With a CAFile clients
+// must present a certificate it signed.
+func Server(files Files) (*tls.Config, error) {
+	if files.CertFile == "" || files.KeyFile == "" {
+		return nil, errors.New("the server needs a certificate and key")
+	}
+	cert, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load server certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if files.CAFile != "" {
+		pool, err := loadPool(files.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = pool
+	}
+	return config, nil
+}
+
+// Client returns the TLS configuration of a client. serverName overrides
+// the name the server certificate is checked against, the host dialed when
+// empty.
+func Client(files Files, serverName string) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if files.CAFile != "" {
+		pool, err := loadPool(files.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+
+	switch {
+	case files.CertFile != "" && files.KeyFile != "":
+		cert, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	case files.CertFile != "" || files.KeyFile != "":
+		return nil, errors.New("the client certificate needs both a certificate and key")
+	}
+	return config, nil
+}
+
+func loadPool(caFile string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("read CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates in %s", caFile)
+	}
+	return pool, nil
+}
@@ -0,0 +1,87 @@
+package tlsconfig_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	pb "grpc-example/hellopb"
+	"grpc-example/mocks"
+	"grpc-example/testhelpers"
+	"grpc-example/tlsconfig"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// sayHello serves the mock with the server configuration and calls it with
+// the client one, over TCP like the real client and server
+func sayHello(t *testing.T, server tlsconfig.Files, client tlsconfig.Files) error {
+	serverConfig, err := tlsconfig.Server(server)
+	require.NoError(t, err)
+	clientConfig, err := tlsconfig.Client(client, "")
+	require.NoError(t, err)
+
+	_, conn, cleanup := testhelpers.StartTestGRPCServer(t, func(s *grpc.Server) {
+		pb.RegisterGreeterServer(s, &mocks.ServerMock{})
+	},
+		testhelpers.WithServerOptions(grpc.Creds(credentials.NewTLS(serverConfig))),
+		testhelpers.WithClientCredentials(credentials.NewTLS(clientConfig)))
+	defer cleanup()
+
+	_, err = pb.NewGreeterClient(conn).SayHello(context.Background(), &pb.HelloRequest{Name: "Gabriel"})
+	return err
+}
+
+func TestCertificatesLoadedFromFiles(t *testing.T) {
+	files := testhelpers.NewCertificates(t).WriteFiles(t, t.TempDir())
+	other := testhelpers.NewCertificates(t).WriteFiles(t, t.TempDir())
+
+	server := tlsconfig.Files{CertFile: files.ServerCert, KeyFile: files.ServerKey}
+	mutualServer := tlsconfig.Files{CertFile: files.ServerCert, KeyFile: files.ServerKey, CAFile: files.CA}
+	client := tlsconfig.Files{CertFile: files.ClientCert, KeyFile: files.ClientKey, CAFile: files.CA}
+
+	tests := []struct {
+		name   string
+		server tlsconfig.Files
+		client tlsconfig.Files
+		code   codes.Code
+	}{
+		{"TLS", server, tlsconfig.Files{CAFile: files.CA}, codes.OK},
+		{"mutual TLS", mutualServer, client, codes.OK},
+		{"wrong CA", server, tlsconfig.Files{CAFile: other.CA}, codes.Unavailable},
+		{"missing client certificate", mutualServer, tlsconfig.Files{CAFile: files.CA}, codes.Unavailable},
+		{"client certificate of another CA", mutualServer,
+			tlsconfig.Files{CertFile: other.ClientCert, KeyFile: other.ClientKey, CAFile: files.CA}, codes.Unavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := sayHello(t, tt.server, tt.client)
+			assert.Equal(t, tt.code, status.Code(err), "%v", err)
+		})
+	}
+}
+
+func TestInvalidFilesAreRejected(t *testing.T) {
+	files := testhelpers.NewCertificates(t).WriteFiles(t, t.TempDir())
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+
+	_, err := tlsconfig.Server(tlsconfig.Files{})
+	assert.Error(t, err, "the server needs a certificate")
+
+	_, err = tlsconfig.Server(tlsconfig.Files{CertFile: files.ServerCert, KeyFile: missing})
+	assert.Error(t, err)
+
+	_, err = tlsconfig.Server(tlsconfig.Files{CertFile: files.ServerCert, KeyFile: files.ServerKey, CAFile: files.ServerKey})
+	assert.Error(t, err, "a key is not a CA")
+
+	_, err = tlsconfig.Client(tlsconfig.Files{CertFile: files.ClientCert}, "")
+	assert.Error(t, err, "a client certificate needs its key")
+
+	_, err = tlsconfig.Client(tlsconfig.Files{CAFile: missing}, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,289 @@
+// Package camouflage is a file-based HTTP mock server in the spirit of
+// Camouflage: the mocks live in a directory tree mirroring the URL paths,
+// one METHOD.mock file per method, e.g. GET /v4/latest/USD is answered by
+//
+//	mocks/v4/latest/USD/GET.mock
+//
+// A directory named __ matches any path segment, the exact name wins. A
+// mock file is a raw HTTP response, a Go template rendered per request:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//	Response-Delay: 250
+//
+//	{"base": "{{ wildcard 0 }}", "date": "{{ now "2006-01-02" }}"}
+//
+// Several responses separated by a ==== line are served in order, one per
+// request, the last one repeating. The Response-Delay header, in
+// milliseconds, delays the response and is not sent.
+package camouflage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const (
+	// Wildcard is the directory name matching any path segment
+	Wildcard = "__"
+	// Separator is the line between the responses of a mock file
+	Separator = "===="
+	// DelayHeader delays the response by its value in milliseconds
+	DelayHeader = "Response-Delay"
+	// mockExt is the extension of mock files, named after their method
+	mockExt = ".mock"
+)
+
+// Handler serves the mocks of a directory. The files are read on every
+// request, so they can be edited while the server runs.
+type Handler struct {
+	dir string
+
+	mu  sync.Mutex
+	now func() time.Time
+	rnd *rand.Rand
+	// served counts the requests each mock file answered, which picks
+	// the response of the sequence
+	served map[string]int
+}
+
+// NewHandler creates a handler serving the mocks under dir
+func NewHandler(dir string) *Handler {
+	return &Handler{
+		dir:    dir,
+		now:    time.Now,
+		rnd:    rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		served: make(map[string]int),
+	}
+}
+
+// SetClock replaces the clock of the now and unix helpers, e.g. with a
+// fixed time in tests
+func (h *Handler) SetClock(now func() time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.now = now
+}
+
+// SetSeed makes the random helpers return the same values on every run
+func (h *Handler) SetSeed(seed uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rnd = rand.New(rand.NewPCG(seed, seed))
+}
+
+// Reset starts every response sequence over
+func (h *Handler) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.served = make(map[string]int)
+}
+
+// ServeHTTP answers with the mock of the method and path of r, or 404 when
+// there is none
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	file, wildcards, ok := h.find(r.Method, r.URL.Path)
+	if !ok {
+		http.Error(w, fmt.Sprintf("camouflage: no mock for %s %s", r.Method, r.URL.Path), http.StatusNotFound)
+		return
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		http.Error(w, "camouflage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "camouflage: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	section := h.next(file, splitResponses(string(content)))
+	rendered, err := h.render(file, section, &request{r: r, body: string(body), wildcards: wildcards})
+	if err != nil {
+		http.Error(w, "camouflage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp, err := parseResponse(rendered)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("camouflage: %s: %v", file, err), http.StatusInternalServerError)
+		return
+	}
+
+	if err := sleep(r.Context(), resp.delay); err != nil {
+		return
+	}
+	for key, values := range resp.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(resp.status)
+	io.WriteString(w, resp.body)
+}
+
+// find walks the directory tree along the segments of urlPath, preferring
+// exact names to wildcards, and returns the mock file of method and the
+// segments the wildcards matched
+func (h *Handler) find(method, urlPath string) (string, []string, bool) {
+	var segments []string
+	for _, segment := range strings.Split(path.Clean("/"+urlPath), "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return h.walk(h.dir, segments, strings.ToUpper(method)+mockExt, nil)
+}
+
+func (h *Handler) walk(dir string, segments []string, name string, wildcards []string) (string, []string, bool) {
+	if len(segments) == 0 {
+		file := filepath.Join(dir, name)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file, wildcards, true
+		}
+		return "", nil, false
+	}
+	segment, rest := segments[0], segments[1:]
+	if segment != Wildcard && !strings.ContainsAny(segment, `/\`) && isDir(filepath.Join(dir, segment)) {
+		if file, matched, ok := h.walk(filepath.Join(dir, segment), rest, name, wildcards); ok {
+			return file, matched, true
+		}
+	}
+	if isDir(filepath.Join(dir, Wildcard)) {
+		return h.walk(filepath.Join(dir, Wildcard), rest, name, append(wildcards[:len(wildcards):len(wildcards)], segment))
+	}
+	return "", nil, false
+}
+
+func isDir(name string) bool {
+	info, err := os.Stat(name)
+	return err == nil && info.IsDir()
+}
+
+// next returns the response of the sequence due for file
+func (h *Handler) next(file string, responses []string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	i := h.served[file]
+	h.served[file]++
+	if i >= len(responses) {
+		i = len(responses) - 1
+	}
+	return responses[i]
+}
+
+// splitResponses splits a mock file on its separator lines
+func splitResponses(content string) []string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	var responses []string
+	var current []string
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == Separator {
+			responses = append(responses, strings.Join(current, "\n"))
+			current = nil
+			continue
+		}
+		current = append(current, line)
+	}
+	return append(responses, strings.Join(current, "\n"))
+}
+
+// response is a rendered mock response
+type response struct {
+	status int
+	header http.Header
+	body   string
+	delay  time.Duration
+}
+
+// parseResponse reads the status line, the headers and the body of a
+// rendered response
+func parseResponse(text string) (*response, error) {
+	text = strings.TrimLeft(text, "\n")
+	head, body, _ := strings.Cut(text, "\n\n")
+	lines := strings.Split(head, "\n")
+
+	// HTTP/1.1 200 OK, the reason phrase is optional
+	fields := strings.Fields(lines[0])
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "HTTP/") {
+		return nil, fmt.Errorf("status line %q is not like HTTP/1.1 200 OK", lines[0])
+	}
+	status, err := strconv.Atoi(fields[1])
+	if err != nil || status < 100 || status > 999 {
+		return nil, fmt.Errorf("invalid status code %q", fields[1])
+	}
+
+	resp := &response{status: status, header: http.Header{}, body: strings.TrimRight(body, "\n")}
+	for _, line := range lines[1:] {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("header %q has no colon", line)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if http.CanonicalHeaderKey(key) == DelayHeader {
+			ms, err := strconv.Atoi(value)
+			if err != nil || ms < 0 {
+				return nil, fmt.Errorf("invalid %s %q", DelayHeader, value)
+			}
+			resp.delay = time.Duration(ms) * time.Millisecond
+			continue
+		}
+		resp.header.Add(key, value)
+	}
+	return resp, nil
+}
+
+// sleep waits d, returning early with the error of ctx when the client
+// gives up
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// render executes a response of file as a template with the helpers bound
+// to req
+func (h *Handler) render(file, text string, req *request) (string, error) {
+	tmpl, err := template.New(filepath.Base(file)).Option("missingkey=zero").Funcs(h.funcs(req)).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, req); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// Server is an httptest.Server serving the mocks of a directory, with the
+// Handler to control them
+type Server struct {
+	*httptest.Server
+	*Handler
+}
+
+// NewServer starts a server serving the mocks under dir, like
+// httptest.NewServer. The caller should call Close when finished.
+func NewServer(dir string) *Server {
+	h := NewHandler(dir)
+	return &Server{Server: httptest.NewServer(h), Handler: h}
+}
@@ -0,0 +1,141 @@
+package camouflage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fixedNow = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func newTestServer(t *testing.T) *Server {
+	s := NewServer("testdata")
+	t.Cleanup(s.Close)
+	s.SetClock(func() time.Time { return fixedNow })
+	s.SetSeed(1)
+	return s
+}
+
+// do sends a request and returns the response with its body read
+func do(t *testing.T, s *Server, method, path, body string, header http.Header) (*http.Response, string) {
+	req, err := http.NewRequest(method, s.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := s.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(data)
+}
+
+func TestServesTheMockOfThePath(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		want   string
+	}{
+		{"wildcard", "GET", "/users/42?page=3", http.StatusOK, `{"id": "42", "page": "3", "path": "/users/42"}`},
+		{"exact name wins", "GET", "/users/me", http.StatusOK, `{"id": "me"}`},
+		{"trailing slash", "GET", "/users/me/", http.StatusOK, `{"id": "me"}`},
+		{"no mock for the method", "DELETE", "/users/42", http.StatusNotFound, "camouflage: no mock for DELETE /users/42\n"},
+		{"no mock for the path", "GET", "/users/42/friends", http.StatusNotFound, "camouflage: no mock for GET /users/42/friends\n"},
+		{"no escaping the directory", "GET", "/../camouflage_test.go", http.StatusNotFound, ""},
+		{"invalid mock", "GET", "/broken", http.StatusInternalServerError, "is not like HTTP/1.1 200 OK"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := do(t, s, tt.method, tt.path, "", nil)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Contains(t, body, tt.want)
+		})
+	}
+}
+
+func TestTemplateHelpers(t *testing.T) {
+	s := newTestServer(t)
+
+	resp, body := do(t, s, "GET", "/users/42", "", http.Header{"X-Request-Id": {"req-1"}})
+	assert.Equal(t, "req-1", resp.Header.Get("X-Request-Id"))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Contains(t, body, `"id": "42"`)
+
+	resp, body = do(t, s, "POST", "/users/42/orders", `{"item": "book"}`, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, body)
+	var order struct {
+		User    string         `json:"user"`
+		Order   map[string]any `json:"order"`
+		ID      string         `json:"id"`
+		Code    string         `json:"code"`
+		Total   int            `json:"total"`
+		Created int64          `json:"created"`
+		Date    string         `json:"date"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &order))
+	assert.Equal(t, "42", order.User)
+	assert.Equal(t, "book", order.Order["item"])
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, order.ID)
+	assert.Regexp(t, `^[a-zA-Z0-9]{6}$`, order.Code)
+	assert.GreaterOrEqual(t, order.Total, 1)
+	assert.LessOrEqual(t, order.Total, 9)
+	assert.Equal(t, fixedNow.Unix(), order.Created)
+	assert.Equal(t, "2025-03-10", order.Date)
+}
+
+func TestTheSeedMakesRandomDataRepeatable(t *testing.T) {
+	first, second := newTestServer(t), newTestServer(t)
+
+	_, a := do(t, first, "POST", "/users/1/orders", `{}`, nil)
+	_, b := do(t, second, "POST", "/users/1/orders", `{}`, nil)
+	assert.Equal(t, a, b)
+}
+
+func TestResponsesAreServedInSequence(t *testing.T) {
+	s := newTestServer(t)
+
+	resp, body := do(t, s, "GET", "/echo", "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "first", body)
+
+	start := time.Now()
+	resp, body = do(t, s, "GET", "/echo", "", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "second", body)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "the second response is delayed")
+	assert.Empty(t, resp.Header.Get(DelayHeader), "the delay is not sent")
+
+	for i := 0; i < 2; i++ {
+		_, body = do(t, s, "GET", "/echo", "", nil)
+		assert.Equal(t, "third", body, "the last response repeats")
+	}
+
+	s.Reset()
+	_, body = do(t, s, "GET", "/echo", "", nil)
+	assert.Equal(t, "first", body)
+}
+
+func TestDelayStopsWhenTheClientGivesUp(t *testing.T) {
+	s := newTestServer(t)
+	do(t, s, "GET", "/echo", "", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", s.URL+"/echo", nil)
+	require.NoError(t, err)
+	_, err = s.Client().Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
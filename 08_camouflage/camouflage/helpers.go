@@ -0,0 +1,104 @@
+package camouflage
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// request is the data of a mock template and what its helpers read
+type request struct {
+	r         *http.Request
+	body      string
+	wildcards []string
+}
+
+// Method is the method of the request, {{ .Method }} in a template
+func (req *request) Method() string { return req.r.Method }
+
+// Path is the path of the request, {{ .Path }} in a template
+func (req *request) Path() string { return req.r.URL.Path }
+
+// letters are the characters of randomString
+const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// funcs are the template helpers:
+//
+//	query "name"           the query parameter
+//	header "Name"          the request header
+//	segment 2              the path segment at the index, from 0
+//	wildcard 0             the segment the wildcard directory at the index matched
+//	body                   the request body
+//	now "2006-01-02"       the current time in the Go layout
+//	unix                   the current time in seconds
+//	randomInt 1 10         an integer in [min, max]
+//	randomFloat 0.5 1.5    a float in [min, max)
+//	randomString 8         letters and digits
+//	uuid                   a random version 4 UUID
+func (h *Handler) funcs(req *request) template.FuncMap {
+	return template.FuncMap{
+		"query":  func(name string) string { return req.r.URL.Query().Get(name) },
+		"header": func(name string) string { return req.r.Header.Get(name) },
+		"segment": func(i int) string {
+			segments := strings.Split(strings.Trim(req.r.URL.Path, "/"), "/")
+			if i < 0 || i >= len(segments) {
+				return ""
+			}
+			return segments[i]
+		},
+		"wildcard": func(i int) string {
+			if i < 0 || i >= len(req.wildcards) {
+				return ""
+			}
+			return req.wildcards[i]
+		},
+		"body": func() string { return req.body },
+		"now":  func(layout string) string { return h.clock().Format(layout) },
+		"unix": func() int64 { return h.clock().Unix() },
+		"randomInt": func(lo, hi int) (int, error) {
+			if hi < lo {
+				return 0, fmt.Errorf("randomInt: max %d is below min %d", hi, lo)
+			}
+			return lo + h.intN(hi-lo+1), nil
+		},
+		"randomFloat": func(lo, hi float64) float64 {
+			return lo + h.float()*(hi-lo)
+		},
+		"randomString": func(n int) string {
+			var b strings.Builder
+			for i := 0; i < n; i++ {
+				b.WriteByte(letters[h.intN(len(letters))])
+			}
+			return b.String()
+		},
+		"uuid": func() string {
+			var b [16]byte
+			for i := range b {
+				b[i] = byte(h.intN(256))
+			}
+			b[6] = b[6]&0x0f | 0x40
+			b[8] = b[8]&0x3f | 0x80
+			return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+		},
+	}
+}
+
+func (h *Handler) clock() time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.now()
+}
+
+func (h *Handler) intN(n int) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.rnd.IntN(n)
+}
+
+func (h *Handler) float() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.rnd.Float64()
+}
@@ -0,0 +1,63 @@
+package camouflage
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The exchange-rate mock set answers like the v4 API of exchangerate-api.com
+
+func TestExchangeRateMocks(t *testing.T) {
+	s := NewServer("../mocks/exchangerate-api")
+	defer s.Close()
+
+	tests := []struct {
+		path   string
+		status int
+		base   string
+		rate   string
+	}{
+		{"/v4/latest/USD", http.StatusOK, "USD", "EUR"},
+		{"/v4/latest/EUR", http.StatusOK, "EUR", "USD"},
+		{"/v4/latest/XXX", http.StatusNotFound, "XXX", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := s.Client().Get(s.URL + tt.path)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			var latest struct {
+				Base  string             `json:"base"`
+				Rates map[string]float64 `json:"rates"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&latest))
+			assert.Equal(t, tt.base, latest.Base)
+			if tt.rate != "" {
+				assert.Greater(t, latest.Rates[tt.rate], 0.0)
+				assert.Equal(t, 1.0, latest.Rates[tt.base])
+			}
+		})
+	}
+}
+
+func TestFlakyExchangeRateMockRecovers(t *testing.T) {
+	s := NewServer("../mocks/exchangerate-api")
+	defer s.Close()
+
+	resp, err := s.Client().Get(s.URL + "/v4/flaky/USD")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	resp, err = s.Client().Get(s.URL + "/v4/flaky/USD")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"camouflage-example/camouflage"
+)
+
+func main() {
+	dir := flag.String("mocks", "mocks/exchangerate-api", "directory of the mock files")
+	addr := flag.String("addr", ":8001", "address to listen on")
+	flag.Parse()
+
+	log.Printf("serving the mocks of %s at %s", *dir, *addr)
+	if err := http.ListenAndServe(*addr, camouflage.NewHandler(*dir)); err != nil {
+		log.Fatalf("failed to serve: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+// Account actions
+const (
+	ActionAccountCreate        = "account.create"
+	ActionAccountUpdate        = "account.update"
+	ActionAccountUpdateBalance = "account.update_balance"
+	ActionAccountUpdateLimits  = "account.update_limits"
+	ActionAccountDelete        = "account.delete"
+)
+
+// accounts records the changes made through an AccountRepository, reads go
+// straight to it
+type accounts struct {
+	repository.AccountRepository
+	recorder
+}
+
+// Accounts returns accounts recording every change in log. Each change and
+// its entry are written in one transaction of txManager.
+func Accounts(accountRepo repository.AccountRepository, log repository.AuditRepository, txManager repository.TxManager) repository.AccountRepository {
+	return &accounts{AccountRepository: accountRepo, recorder: recorder{log: log, txManager: txManager, now: time.Now}}
+}
+
+func (a *accounts) Create(ctx context.Context, account *dto.AccountDTO) error {
+	create := func(ctx context.Context) error {
+		if err := a.AccountRepository.Create(ctx, account); err != nil {
+			return err
+		}
+		return a.append(ctx, ActionAccountCreate, ResourceAccount, account.ID, nil, account)
+	}
+
+	return a.atomically(ctx, create)
+}
+
+func (a *accounts) Update(ctx context.Context, id string, account *dto.UpdateAccountRequest) error {
+	return a.change(ctx, ActionAccountUpdate, ResourceAccount, id, a.snapshot(id), func(ctx context.Context) error {
+		return a.AccountRepository.Update(ctx, id, account)
+	})
+}
+
+func (a *accounts) UpdateBalance(ctx context.Context, id string, newBalance float64) error {
+	return a.change(ctx, ActionAccountUpdateBalance, ResourceAccount, id, a.snapshot(id), func(ctx context.Context) error {
+		return a.AccountRepository.UpdateBalance(ctx, id, newBalance)
+	})
+}
+
+func (a *accounts) UpdateLimits(ctx context.Context, id string, limits dto.AccountLimits) error {
+	return a.change(ctx, ActionAccountUpdateLimits, ResourceAccount, id, a.snapshot(id), func(ctx context.Context) error {
+		return a.AccountRepository.UpdateLimits(ctx, id, limits)
+	})
+}
+
+func (a *accounts) Delete(ctx context.Context, id string) error {
+	return a.change(ctx, ActionAccountDelete, ResourceAccount, id, a.snapshot(id), func(ctx context.Context) error {
+		return a.AccountRepository.Delete(ctx, id)
+	})
+}
+
+// snapshot reads the account, nil when it doesn't exist
+func (a *accounts) snapshot(id string) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		account, err := a.AccountRepository.GetByID(ctx, id)
+		if err != nil || account == nil {
+			return nil, err
+		}
+		return account, nil
+	}
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+// API key actions
+const (
+	ActionAPIKeyCreate = "api_key.create"
+	ActionAPIKeyRevoke = "api_key.revoke"
+)
+
+// apiKeys records the changes made through an APIKeyRepository, reads go
+// straight to it. The key hash is not part of the JSON of a key and so not
+// of its entries.
+type apiKeys struct {
+	repository.APIKeyRepository
+	recorder
+}
+
+// APIKeys returns apiKeys recording every change in log. Each change and its
+// entry are written in one transaction of txManager.
+func APIKeys(apiKeyRepo repository.APIKeyRepository, log repository.AuditRepository, txManager repository.TxManager) repository.APIKeyRepository {
+	return &apiKeys{APIKeyRepository: apiKeyRepo, recorder: recorder{log: log, txManager: txManager, now: time.Now}}
+}
+
+func (a *apiKeys) Create(ctx context.Context, apiKey *dto.APIKeyDTO) error {
+	return a.atomically(ctx, func(ctx context.Context) error {
+		if err := a.APIKeyRepository.Create(ctx, apiKey); err != nil {
+			return err
+		}
+		return a.append(ctx, ActionAPIKeyCreate, ResourceAPIKey, apiKey.ID, nil, apiKey)
+	})
+}
+
+func (a *apiKeys) Revoke(ctx context.Context, id string) error {
+	return a.change(ctx, ActionAPIKeyRevoke, ResourceAPIKey, id, a.snapshot(id), func(ctx context.Context) error {
+		return a.APIKeyRepository.Revoke(ctx, id)
+	})
+}
+
+// snapshot reads the API key, nil when it doesn't exist
+func (a *apiKeys) snapshot(id string) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		apiKey, err := a.APIKeyRepository.GetByID(ctx, id)
+		if err != nil || apiKey == nil {
+			return nil, err
+		}
+		return apiKey, nil
+	}
+}
@@ -0,0 +1,122 @@
+// Package audit keeps the append-only log of changes to accounts,
+// transactions, holds, scheduled transactions, webhooks and API keys. The
+// repositories returned by Accounts, Transactions, Holds, Schedules, Webhooks
+// and APIKeys record every mutation, with the actor, the request ID and
+// snapshots of the resource before and after it, in the database transaction
+// of the change. Entries are hash-chained and Verify checks the chain.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+// SystemActor is the actor of changes made without a principal, e.g. by CLI commands
+const SystemActor = "system"
+
+// Resource types
+const (
+	ResourceAccount                 = "account"
+	ResourceTransaction             = "transaction"
+	ResourceHold                    = "hold"
+	ResourceScheduledTransaction    = "scheduled_transaction"
+	ResourceScheduledTransactionRun = "scheduled_transaction_run"
+	ResourceWebhookSubscription     = "webhook_subscription"
+	ResourceWebhookDelivery         = "webhook_delivery"
+	ResourceAPIKey                  = "api_key"
+)
+
+// recorder appends the entries of the changes made by a decorated repository
+type recorder struct {
+	log       repository.AuditRepository
+	txManager repository.TxManager
+	now       func() time.Time
+}
+
+// change runs mutate, between the before and after snapshots, and records
+// the entry atomically with it
+func (r *recorder) change(ctx context.Context, action, resourceType, resourceID string,
+	snapshot func(ctx context.Context) (interface{}, error), mutate func(ctx context.Context) error) error {
+	_, err := r.changeIf(ctx, action, resourceType, resourceID, snapshot, func(ctx context.Context) (bool, error) {
+		return true, mutate(ctx)
+	})
+	return err
+}
+
+// changeIf is change for mutations reporting whether they changed anything,
+// nothing is recorded when they didn't
+func (r *recorder) changeIf(ctx context.Context, action, resourceType, resourceID string,
+	snapshot func(ctx context.Context) (interface{}, error), mutate func(ctx context.Context) (bool, error)) (bool, error) {
+	var changed bool
+	err := r.atomically(ctx, func(ctx context.Context) error {
+		before, err := snapshot(ctx)
+		if err != nil {
+			return err
+		}
+		if changed, err = mutate(ctx); err != nil || !changed {
+			return err
+		}
+		after, err := snapshot(ctx)
+		if err != nil {
+			return err
+		}
+		return r.append(ctx, action, resourceType, resourceID, before, after)
+	})
+	return changed, err
+}
+
+// atomically runs fn in a transaction of txManager, when there is one
+func (r *recorder) atomically(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.txManager == nil {
+		return fn(ctx)
+	}
+	return r.txManager.WithinTransaction(ctx, fn)
+}
+
+// append records an entry with the actor and request ID in ctx
+func (r *recorder) append(ctx context.Context, action, resourceType, resourceID string, before, after interface{}) error {
+	entry := &dto.AuditEntryDTO{
+		Actor:        SystemActor,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		RequestID:    logger.RequestIDFromContext(ctx),
+		OccurredAt:   r.now().UTC().Truncate(time.Millisecond),
+	}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		entry.Actor = principal.ID
+	}
+
+	var err error
+	if entry.Before, err = encode(before); err != nil {
+		return err
+	}
+	if entry.After, err = encode(after); err != nil {
+		return err
+	}
+
+	if err := r.log.Append(ctx, entry); err != nil {
+		logger.ErrorContext(ctx, "Failed to record "+action+" in the audit log", err)
+		return err
+	}
+	return nil
+}
+
+// encode returns the JSON of a snapshot, nil when the resource doesn't exist
+func encode(snapshot interface{}) (json.RawMessage, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+	return data, nil
+}
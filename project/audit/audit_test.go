@@ -0,0 +1,302 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func asAlice() context.Context {
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ID: "alice", Roles: []string{"admin"}})
+	return logger.WithRequestID(ctx, "req-1")
+}
+
+// balance decodes the balance of an account snapshot
+func balance(t *testing.T, snapshot json.RawMessage) float64 {
+	var account dto.AccountDTO
+	require.NoError(t, json.Unmarshal(snapshot, &account))
+	return account.Balance
+}
+
+func TestAccountChangesAreRecorded(t *testing.T) {
+	log := &fakeLog{}
+	accounts := Accounts(&fakeAccounts{accounts: map[string]*dto.AccountDTO{}}, log, nil)
+	ctx := asAlice()
+	overwritten := 5.0
+
+	require.NoError(t, accounts.Create(ctx, &dto.AccountDTO{Name: "Alice", Balance: 100}))
+	require.NoError(t, accounts.UpdateBalance(ctx, "acc-1", 80))
+	require.NoError(t, accounts.Update(ctx, "acc-1", &dto.UpdateAccountRequest{Balance: &overwritten}))
+	require.NoError(t, accounts.Delete(ctx, "acc-1"))
+
+	require.Len(t, log.entries, 4)
+	actions := make([]string, len(log.entries))
+	for i, entry := range log.entries {
+		actions[i] = entry.Action
+		assert.Equal(t, "alice", entry.Actor)
+		assert.Equal(t, "req-1", entry.RequestID)
+		assert.Equal(t, ResourceAccount, entry.ResourceType)
+		assert.Equal(t, "acc-1", entry.ResourceID)
+	}
+	assert.Equal(t, []string{ActionAccountCreate, ActionAccountUpdateBalance, ActionAccountUpdate, ActionAccountDelete}, actions)
+
+	assert.Nil(t, log.entries[0].Before)
+	assert.Equal(t, float64(100), balance(t, log.entries[0].After))
+	assert.Equal(t, float64(100), balance(t, log.entries[1].Before))
+	assert.Equal(t, float64(80), balance(t, log.entries[1].After))
+	assert.Equal(t, float64(80), balance(t, log.entries[2].Before))
+	assert.Equal(t, float64(5), balance(t, log.entries[2].After), "direct balance overwrites are on record")
+	assert.Equal(t, float64(5), balance(t, log.entries[3].Before))
+	assert.Nil(t, log.entries[3].After)
+}
+
+func TestFailedChangesAreNotRecorded(t *testing.T) {
+	log := &fakeLog{}
+	accounts := Accounts(&fakeAccounts{accounts: map[string]*dto.AccountDTO{}}, log, nil)
+
+	err := accounts.UpdateBalance(asAlice(), "missing", 10)
+
+	assert.True(t, errors.Is(err, errNotFound), "got %v", err)
+	assert.Empty(t, log.entries)
+}
+
+func TestTransactionChangesAreRecorded(t *testing.T) {
+	log := &fakeLog{}
+	transactions := Transactions(&fakeTransactions{transactions: map[string]*dto.TransactionDTO{}}, log, nil)
+	ctx := context.Background()
+
+	require.NoError(t, transactions.Create(ctx, &dto.TransactionDTO{AccountID: "acc-1", Amount: 50, Type: "deposit"}))
+	require.NoError(t, transactions.Update(ctx, "txn-1", &dto.TransactionDTO{AccountID: "acc-1", Amount: 500, Type: "deposit"}))
+	require.NoError(t, transactions.Delete(ctx, "txn-1"))
+
+	require.Len(t, log.entries, 3)
+	assert.Equal(t, SystemActor, log.entries[0].Actor, "changes without a principal")
+	assert.Equal(t, ActionTransactionUpdate, log.entries[1].Action)
+	assert.JSONEq(t, `50`, amount(t, log.entries[1].Before))
+	assert.JSONEq(t, `500`, amount(t, log.entries[1].After))
+	assert.Equal(t, ActionTransactionDelete, log.entries[2].Action)
+	assert.JSONEq(t, `500`, amount(t, log.entries[2].Before))
+	assert.Nil(t, log.entries[2].After)
+}
+
+func amount(t *testing.T, snapshot json.RawMessage) string {
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(snapshot, &fields))
+	return string(fields["amount"])
+}
+
+func TestHoldChangesAreRecorded(t *testing.T) {
+	log := &fakeLog{}
+	repo := &fakeHolds{holds: map[string]*dto.HoldDTO{}}
+	holds := Holds(repo, log, nil)
+	ctx := asAlice()
+
+	hold := &dto.HoldDTO{AccountID: "acc-1", Amount: 25, Status: dto.HoldActive}
+	require.NoError(t, holds.Create(ctx, hold))
+	released := *hold
+	released.Status = dto.HoldReleased
+	closed, err := holds.Close(ctx, &released)
+	require.NoError(t, err)
+	require.True(t, closed)
+	closed, err = holds.Close(ctx, &released)
+	require.NoError(t, err)
+	assert.False(t, closed)
+
+	require.Len(t, log.entries, 2, "closing a hold that is no longer active changes nothing")
+	assert.Equal(t, ActionHoldCreate, log.entries[0].Action)
+	assert.Equal(t, "hold-1", log.entries[0].ResourceID)
+	assert.Equal(t, ActionHoldClose, log.entries[1].Action)
+	assert.Contains(t, string(log.entries[1].Before), `"status":"active"`)
+	assert.Contains(t, string(log.entries[1].After), `"status":"released"`)
+
+	due := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	_, err = holds.ExpireDue(ctx, due)
+	require.NoError(t, err)
+	require.Len(t, log.entries, 2, "nothing expired")
+
+	repo.expired = 3
+	expired, err := holds.ExpireDue(ctx, due)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), expired)
+	require.Len(t, log.entries, 3)
+	assert.Equal(t, ActionHoldExpireDue, log.entries[2].Action)
+	assert.JSONEq(t, `{"expired":3,"due":"2025-03-10T12:00:00Z"}`, string(log.entries[2].After))
+}
+
+func TestScheduleChangesAreRecorded(t *testing.T) {
+	log := &fakeLog{}
+	schedules := Schedules(&fakeSchedules{schedules: map[string]*dto.ScheduledTransactionDTO{}}, log, nil)
+	ctx := context.Background()
+	due := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	next := due.AddDate(0, 0, 1)
+
+	require.NoError(t, schedules.Create(ctx, &dto.ScheduledTransactionDTO{AccountID: "acc-1", Amount: 10, NextRunAt: &due}))
+	advanced, err := schedules.Advance(ctx, "sched-1", due, &next)
+	require.NoError(t, err)
+	require.True(t, advanced)
+	advanced, err = schedules.Advance(ctx, "sched-1", due, &next)
+	require.NoError(t, err)
+	assert.False(t, advanced)
+	require.NoError(t, schedules.RecordRun(ctx, &dto.ScheduledTransactionRunDTO{ScheduledTransactionID: "sched-1", Status: "succeeded"}))
+
+	require.Len(t, log.entries, 3, "a run another worker advanced first is not recorded")
+	assert.Equal(t, ActionScheduledTransactionCreate, log.entries[0].Action)
+	assert.Equal(t, ActionScheduledTransactionAdvance, log.entries[1].Action)
+	assert.Contains(t, string(log.entries[1].Before), `"next_run_at":"2025-03-10T00:00:00Z"`)
+	assert.Contains(t, string(log.entries[1].After), `"next_run_at":"2025-03-11T00:00:00Z"`)
+	assert.Equal(t, ActionScheduledTransactionRun, log.entries[2].Action)
+	assert.Equal(t, ResourceScheduledTransactionRun, log.entries[2].ResourceType)
+	assert.Equal(t, "run-1", log.entries[2].ResourceID)
+}
+
+func TestWebhookSecretsAreNotRecorded(t *testing.T) {
+	log := &fakeLog{}
+	webhooks := Webhooks(&fakeWebhooks{subscriptions: map[string]*dto.WebhookSubscriptionDTO{}}, log, nil)
+	ctx := asAlice()
+
+	subscription := &dto.WebhookSubscriptionDTO{URL: "https://example.com/hook", Secret: "0123456789abcdef"}
+	require.NoError(t, webhooks.CreateSubscription(ctx, subscription))
+	require.NoError(t, webhooks.DeleteSubscription(ctx, "sub-1"))
+
+	assert.Equal(t, "0123456789abcdef", subscription.Secret, "the caller still returns it")
+	require.Len(t, log.entries, 2)
+	assert.Equal(t, ActionWebhookSubscriptionCreate, log.entries[0].Action)
+	assert.Equal(t, ActionWebhookSubscriptionDelete, log.entries[1].Action)
+	for _, entry := range log.entries {
+		assert.NotContains(t, string(entry.Before), "0123456789abcdef")
+		assert.NotContains(t, string(entry.After), "0123456789abcdef")
+	}
+	assert.Contains(t, string(log.entries[1].Before), "https://example.com/hook")
+	assert.Nil(t, log.entries[1].After)
+}
+
+func TestAPIKeyChangesAreRecorded(t *testing.T) {
+	log := &fakeLog{}
+	apiKeys := APIKeys(&fakeAPIKeys{apiKeys: map[string]*dto.APIKeyDTO{}}, log, nil)
+	ctx := context.Background()
+
+	require.NoError(t, apiKeys.Create(ctx, &dto.APIKeyDTO{Name: "ci", KeyHash: "secret-hash", PrincipalID: "ci-bot"}))
+	require.NoError(t, apiKeys.Revoke(ctx, "key-1"))
+
+	require.Len(t, log.entries, 2)
+	assert.Equal(t, SystemActor, log.entries[0].Actor, "keys are created from the CLI")
+	assert.Equal(t, ActionAPIKeyCreate, log.entries[0].Action)
+	assert.NotContains(t, string(log.entries[0].After), "secret-hash")
+	assert.Equal(t, ActionAPIKeyRevoke, log.entries[1].Action)
+	assert.NotContains(t, string(log.entries[1].Before), "revoked_at")
+	assert.Contains(t, string(log.entries[1].After), "revoked_at")
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name         string
+		tamper       func(log *fakeLog)
+		wantSequence int64
+		wantErr      error
+	}{
+		{
+			name:   "intact",
+			tamper: func(log *fakeLog) {},
+		},
+		{
+			name:         "snapshot rewritten",
+			tamper:       func(log *fakeLog) { log.entries[2].After = json.RawMessage(`{"balance":1000000}`) },
+			wantSequence: 3,
+		},
+		{
+			name:         "actor rewritten",
+			tamper:       func(log *fakeLog) { log.entries[0].Actor = "bob" },
+			wantSequence: 1,
+		},
+		{
+			name:         "entry removed",
+			tamper:       func(log *fakeLog) { log.entries = append(log.entries[:2], log.entries[3:]...) },
+			wantSequence: 4,
+		},
+		{
+			name:    "last entry removed",
+			tamper:  func(log *fakeLog) { log.entries = log.entries[:3] },
+			wantErr: ErrHeadNotFound,
+		},
+		{
+			name: "entry rewritten and rehashed",
+			tamper: func(log *fakeLog) {
+				log.entries[2].Actor = "bob"
+				log.entries[2].Hash = log.entries[2].ComputeHash()
+			},
+			wantSequence: 4,
+		},
+		{
+			name: "entry moved to another resource and rehashed",
+			tamper: func(log *fakeLog) {
+				log.entries[2].ResourceID = "acc-2"
+				log.entries[2].Hash = log.entries[2].ComputeHash()
+			},
+			wantSequence: 3,
+		},
+		{
+			name: "entries reordered",
+			tamper: func(log *fakeLog) {
+				log.entries[2], log.entries[3] = log.entries[3], log.entries[2]
+			},
+			wantSequence: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &fakeLog{}
+			accounts := Accounts(&fakeAccounts{accounts: map[string]*dto.AccountDTO{}}, log, nil)
+			transactions := Transactions(&fakeTransactions{transactions: map[string]*dto.TransactionDTO{}}, log, nil)
+			ctx := asAlice()
+			// The chains of acc-1 and txn-1 interleave
+			require.NoError(t, accounts.Create(ctx, &dto.AccountDTO{Name: "Alice", Balance: 100}))
+			require.NoError(t, transactions.Create(ctx, &dto.TransactionDTO{AccountID: "acc-1", Amount: 20, Type: "withdrawal"}))
+			require.NoError(t, accounts.UpdateBalance(ctx, "acc-1", 80))
+			require.NoError(t, accounts.UpdateBalance(ctx, "acc-1", 60))
+			head := log.entries[3].Hash
+
+			tt.tamper(log)
+			result, err := Verify(context.Background(), log, head)
+
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			if tt.wantSequence == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, int64(4), result.Entries)
+				assert.Equal(t, int64(2), result.Chains)
+				assert.Equal(t, head, result.Head)
+				return
+			}
+			var chainErr *ChainError
+			require.True(t, errors.As(err, &chainErr), "got %v", err)
+			assert.Equal(t, tt.wantSequence, chainErr.Sequence)
+		})
+	}
+}
+
+func TestChainsFollowTheirResource(t *testing.T) {
+	log := &fakeLog{}
+	accounts := Accounts(&fakeAccounts{accounts: map[string]*dto.AccountDTO{}}, log, nil)
+	transactions := Transactions(&fakeTransactions{transactions: map[string]*dto.TransactionDTO{}}, log, nil)
+	ctx := context.Background()
+
+	require.NoError(t, accounts.Create(ctx, &dto.AccountDTO{Name: "Alice", Balance: 100}))
+	require.NoError(t, transactions.Create(ctx, &dto.TransactionDTO{AccountID: "acc-1", Amount: 20, Type: "withdrawal"}))
+	require.NoError(t, accounts.UpdateBalance(ctx, "acc-1", 80))
+
+	require.Len(t, log.entries, 3)
+	assert.Equal(t, []int64{1, 1, 2}, []int64{log.entries[0].ChainSequence, log.entries[1].ChainSequence, log.entries[2].ChainSequence})
+	assert.Empty(t, log.entries[1].PrevHash, "the first entry of the transaction")
+	assert.Equal(t, log.entries[0].Hash, log.entries[2].PrevHash, "links to the account's entry, not the log's last")
+}
@@ -0,0 +1,256 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+var errNotFound = errors.New("not found")
+
+// fakeAccounts is an in-memory AccountRepository with the methods the
+// decorator calls, the rest panic
+type fakeAccounts struct {
+	repository.AccountRepository
+	accounts map[string]*dto.AccountDTO
+}
+
+func (f *fakeAccounts) Create(ctx context.Context, account *dto.AccountDTO) error {
+	account.ID = "acc-1"
+	stored := *account
+	f.accounts[account.ID] = &stored
+	return nil
+}
+
+func (f *fakeAccounts) GetByID(ctx context.Context, id string) (*dto.AccountDTO, error) {
+	if account, ok := f.accounts[id]; ok {
+		copied := *account
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeAccounts) Update(ctx context.Context, id string, update *dto.UpdateAccountRequest) error {
+	account, ok := f.accounts[id]
+	if !ok {
+		return errNotFound
+	}
+	if update.Balance != nil {
+		account.Balance = *update.Balance
+	}
+	if update.Name != nil {
+		account.Name = *update.Name
+	}
+	return nil
+}
+
+func (f *fakeAccounts) UpdateBalance(ctx context.Context, id string, newBalance float64) error {
+	account, ok := f.accounts[id]
+	if !ok {
+		return errNotFound
+	}
+	account.Balance = newBalance
+	return nil
+}
+
+func (f *fakeAccounts) Delete(ctx context.Context, id string) error {
+	delete(f.accounts, id)
+	return nil
+}
+
+// fakeTransactions is an in-memory TransactionRepository with the methods
+// the decorator calls, the rest panic
+type fakeTransactions struct {
+	repository.TransactionRepository
+	transactions map[string]*dto.TransactionDTO
+}
+
+func (f *fakeTransactions) Create(ctx context.Context, transaction *dto.TransactionDTO) error {
+	transaction.ID = "txn-1"
+	stored := *transaction
+	f.transactions[transaction.ID] = &stored
+	return nil
+}
+
+func (f *fakeTransactions) GetByID(ctx context.Context, id string) (*dto.TransactionDTO, error) {
+	if transaction, ok := f.transactions[id]; ok {
+		copied := *transaction
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeTransactions) Update(ctx context.Context, id string, transaction *dto.TransactionDTO) error {
+	stored := *transaction
+	stored.ID = id
+	f.transactions[id] = &stored
+	return nil
+}
+
+func (f *fakeTransactions) Delete(ctx context.Context, id string) error {
+	delete(f.transactions, id)
+	return nil
+}
+
+// fakeLog is an in-memory AuditRepository
+type fakeLog struct {
+	entries []*dto.AuditEntryDTO
+}
+
+func (f *fakeLog) Append(ctx context.Context, entry *dto.AuditEntryDTO) error {
+	var last *dto.AuditEntryDTO
+	for _, appended := range f.entries {
+		if appended.Chain() == entry.Chain() {
+			last = appended
+		}
+	}
+	entry.Sequence = int64(len(f.entries)) + 1
+	entry.Seal(last)
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+func (f *fakeLog) Find(ctx context.Context, query dto.AuditQuery) ([]*dto.AuditEntryDTO, error) {
+	return f.entries, nil
+}
+
+func (f *fakeLog) Walk(ctx context.Context, fn func(*dto.AuditEntryDTO) error) error {
+	for _, entry := range f.entries {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fakeHolds is an in-memory HoldRepository with the methods the decorator
+// calls, the rest panic
+type fakeHolds struct {
+	repository.HoldRepository
+	holds   map[string]*dto.HoldDTO
+	expired int64
+}
+
+func (f *fakeHolds) Create(ctx context.Context, hold *dto.HoldDTO) error {
+	hold.ID = "hold-1"
+	stored := *hold
+	f.holds[hold.ID] = &stored
+	return nil
+}
+
+func (f *fakeHolds) GetByID(ctx context.Context, id string) (*dto.HoldDTO, error) {
+	if hold, ok := f.holds[id]; ok {
+		copied := *hold
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeHolds) Close(ctx context.Context, hold *dto.HoldDTO) (bool, error) {
+	stored, ok := f.holds[hold.ID]
+	if !ok || stored.Status != dto.HoldActive {
+		return false, nil
+	}
+	*stored = *hold
+	return true, nil
+}
+
+func (f *fakeHolds) ExpireDue(ctx context.Context, now time.Time) (int64, error) {
+	return f.expired, nil
+}
+
+// fakeSchedules is an in-memory ScheduledTransactionRepository with the
+// methods the decorator calls, the rest panic
+type fakeSchedules struct {
+	repository.ScheduledTransactionRepository
+	schedules map[string]*dto.ScheduledTransactionDTO
+}
+
+func (f *fakeSchedules) Create(ctx context.Context, scheduled *dto.ScheduledTransactionDTO) error {
+	scheduled.ID = "sched-1"
+	stored := *scheduled
+	f.schedules[scheduled.ID] = &stored
+	return nil
+}
+
+func (f *fakeSchedules) GetByID(ctx context.Context, id string) (*dto.ScheduledTransactionDTO, error) {
+	if scheduled, ok := f.schedules[id]; ok {
+		copied := *scheduled
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeSchedules) Advance(ctx context.Context, id string, due time.Time, next *time.Time) (bool, error) {
+	scheduled, ok := f.schedules[id]
+	if !ok || scheduled.NextRunAt == nil || !scheduled.NextRunAt.Equal(due) {
+		return false, nil
+	}
+	scheduled.NextRunAt = next
+	return true, nil
+}
+
+func (f *fakeSchedules) RecordRun(ctx context.Context, run *dto.ScheduledTransactionRunDTO) error {
+	run.ID = "run-1"
+	return nil
+}
+
+// fakeWebhooks is an in-memory WebhookRepository with the methods the
+// decorator calls, the rest panic
+type fakeWebhooks struct {
+	repository.WebhookRepository
+	subscriptions map[string]*dto.WebhookSubscriptionDTO
+}
+
+func (f *fakeWebhooks) CreateSubscription(ctx context.Context, subscription *dto.WebhookSubscriptionDTO) error {
+	subscription.ID = "sub-1"
+	stored := *subscription
+	f.subscriptions[subscription.ID] = &stored
+	return nil
+}
+
+func (f *fakeWebhooks) GetSubscription(ctx context.Context, id string) (*dto.WebhookSubscriptionDTO, error) {
+	if subscription, ok := f.subscriptions[id]; ok {
+		copied := *subscription
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeWebhooks) DeleteSubscription(ctx context.Context, id string) error {
+	delete(f.subscriptions, id)
+	return nil
+}
+
+// fakeAPIKeys is an in-memory APIKeyRepository with the methods the
+// decorator calls, the rest panic
+type fakeAPIKeys struct {
+	repository.APIKeyRepository
+	apiKeys map[string]*dto.APIKeyDTO
+}
+
+func (f *fakeAPIKeys) Create(ctx context.Context, apiKey *dto.APIKeyDTO) error {
+	apiKey.ID = "key-1"
+	stored := *apiKey
+	f.apiKeys[apiKey.ID] = &stored
+	return nil
+}
+
+func (f *fakeAPIKeys) GetByID(ctx context.Context, id string) (*dto.APIKeyDTO, error) {
+	if apiKey, ok := f.apiKeys[id]; ok {
+		copied := *apiKey
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeAPIKeys) Revoke(ctx context.Context, id string) error {
+	if apiKey, ok := f.apiKeys[id]; ok && apiKey.RevokedAt == nil {
+		now := time.Now()
+		apiKey.RevokedAt = &now
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+// Hold actions
+const (
+	ActionHoldCreate    = "hold.create"
+	ActionHoldClose     = "hold.close"
+	ActionHoldExpireDue = "hold.expire_due"
+)
+
+// expiredHolds is the entry of an ExpireDue call, which expires holds
+// without naming them
+type expiredHolds struct {
+	Expired int64     `json:"expired"`
+	Due     time.Time `json:"due"`
+}
+
+// holds records the changes made through a HoldRepository, reads go
+// straight to it
+type holds struct {
+	repository.HoldRepository
+	recorder
+}
+
+// Holds returns holds recording every change in log. Each change and its
+// entry are written in one transaction of txManager.
+func Holds(holdRepo repository.HoldRepository, log repository.AuditRepository, txManager repository.TxManager) repository.HoldRepository {
+	return &holds{HoldRepository: holdRepo, recorder: recorder{log: log, txManager: txManager, now: time.Now}}
+}
+
+func (h *holds) Create(ctx context.Context, hold *dto.HoldDTO) error {
+	return h.atomically(ctx, func(ctx context.Context) error {
+		if err := h.HoldRepository.Create(ctx, hold); err != nil {
+			return err
+		}
+		return h.append(ctx, ActionHoldCreate, ResourceHold, hold.ID, nil, hold)
+	})
+}
+
+func (h *holds) Close(ctx context.Context, hold *dto.HoldDTO) (bool, error) {
+	return h.changeIf(ctx, ActionHoldClose, ResourceHold, hold.ID, h.snapshot(hold.ID), func(ctx context.Context) (bool, error) {
+		return h.HoldRepository.Close(ctx, hold)
+	})
+}
+
+// ExpireDue records one entry for the holds it expired, under an empty
+// resource ID, with how many there were and the time they were due by
+func (h *holds) ExpireDue(ctx context.Context, now time.Time) (int64, error) {
+	var expired int64
+	err := h.atomically(ctx, func(ctx context.Context) error {
+		var err error
+		if expired, err = h.HoldRepository.ExpireDue(ctx, now); err != nil || expired == 0 {
+			return err
+		}
+		return h.append(ctx, ActionHoldExpireDue, ResourceHold, "", nil, expiredHolds{Expired: expired, Due: now})
+	})
+	return expired, err
+}
+
+// snapshot reads the hold, nil when it doesn't exist
+func (h *holds) snapshot(id string) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		hold, err := h.HoldRepository.GetByID(ctx, id)
+		if err != nil || hold == nil {
+			return nil, err
+		}
+		return hold, nil
+	}
+}
@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+// Scheduled transaction actions
+const (
+	ActionScheduledTransactionCreate  = "scheduled_transaction.create"
+	ActionScheduledTransactionUpdate  = "scheduled_transaction.update"
+	ActionScheduledTransactionDelete  = "scheduled_transaction.delete"
+	ActionScheduledTransactionAdvance = "scheduled_transaction.advance"
+	ActionScheduledTransactionRun     = "scheduled_transaction.run"
+)
+
+// schedules records the changes made through a
+// ScheduledTransactionRepository, reads go straight to it
+type schedules struct {
+	repository.ScheduledTransactionRepository
+	recorder
+}
+
+// Schedules returns schedules recording every change in log. Each change and
+// its entry are written in one transaction of txManager.
+func Schedules(scheduledRepo repository.ScheduledTransactionRepository, log repository.AuditRepository, txManager repository.TxManager) repository.ScheduledTransactionRepository {
+	return &schedules{ScheduledTransactionRepository: scheduledRepo, recorder: recorder{log: log, txManager: txManager, now: time.Now}}
+}
+
+func (s *schedules) Create(ctx context.Context, scheduled *dto.ScheduledTransactionDTO) error {
+	return s.atomically(ctx, func(ctx context.Context) error {
+		if err := s.ScheduledTransactionRepository.Create(ctx, scheduled); err != nil {
+			return err
+		}
+		return s.append(ctx, ActionScheduledTransactionCreate, ResourceScheduledTransaction, scheduled.ID, nil, scheduled)
+	})
+}
+
+func (s *schedules) Update(ctx context.Context, scheduled *dto.ScheduledTransactionDTO) error {
+	return s.change(ctx, ActionScheduledTransactionUpdate, ResourceScheduledTransaction, scheduled.ID, s.snapshot(scheduled.ID), func(ctx context.Context) error {
+		return s.ScheduledTransactionRepository.Update(ctx, scheduled)
+	})
+}
+
+func (s *schedules) Delete(ctx context.Context, id string) error {
+	return s.change(ctx, ActionScheduledTransactionDelete, ResourceScheduledTransaction, id, s.snapshot(id), func(ctx context.Context) error {
+		return s.ScheduledTransactionRepository.Delete(ctx, id)
+	})
+}
+
+func (s *schedules) Advance(ctx context.Context, id string, due time.Time, next *time.Time) (bool, error) {
+	return s.changeIf(ctx, ActionScheduledTransactionAdvance, ResourceScheduledTransaction, id, s.snapshot(id), func(ctx context.Context) (bool, error) {
+		return s.ScheduledTransactionRepository.Advance(ctx, id, due, next)
+	})
+}
+
+// RecordRun records the run as a resource of its own, the last run it sets
+// on the scheduled transaction is a copy of it
+func (s *schedules) RecordRun(ctx context.Context, run *dto.ScheduledTransactionRunDTO) error {
+	return s.atomically(ctx, func(ctx context.Context) error {
+		if err := s.ScheduledTransactionRepository.RecordRun(ctx, run); err != nil {
+			return err
+		}
+		return s.append(ctx, ActionScheduledTransactionRun, ResourceScheduledTransactionRun, run.ID, nil, run)
+	})
+}
+
+// snapshot reads the scheduled transaction, nil when it doesn't exist
+func (s *schedules) snapshot(id string) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		scheduled, err := s.ScheduledTransactionRepository.GetByID(ctx, id)
+		if err != nil || scheduled == nil {
+			return nil, err
+		}
+		return scheduled, nil
+	}
+}
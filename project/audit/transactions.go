@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+// Transaction actions
+const (
+	ActionTransactionCreate = "transaction.create"
+	ActionTransactionUpdate = "transaction.update"
+	ActionTransactionDelete = "transaction.delete"
+)
+
+// transactions records the changes made through a TransactionRepository,
+// reads go straight to it
+type transactions struct {
+	repository.TransactionRepository
+	recorder
+}
+
+// Transactions returns transactions recording every change in log. Each
+// change and its entry are written in one transaction of txManager.
+func Transactions(transactionRepo repository.TransactionRepository, log repository.AuditRepository, txManager repository.TxManager) repository.TransactionRepository {
+	return &transactions{TransactionRepository: transactionRepo, recorder: recorder{log: log, txManager: txManager, now: time.Now}}
+}
+
+func (t *transactions) Create(ctx context.Context, transaction *dto.TransactionDTO) error {
+	create := func(ctx context.Context) error {
+		if err := t.TransactionRepository.Create(ctx, transaction); err != nil {
+			return err
+		}
+		return t.append(ctx, ActionTransactionCreate, ResourceTransaction, transaction.ID, nil, transaction)
+	}
+
+	return t.atomically(ctx, create)
+}
+
+func (t *transactions) Update(ctx context.Context, id string, transaction *dto.TransactionDTO) error {
+	return t.change(ctx, ActionTransactionUpdate, ResourceTransaction, id, t.snapshot(id), func(ctx context.Context) error {
+		return t.TransactionRepository.Update(ctx, id, transaction)
+	})
+}
+
+func (t *transactions) Delete(ctx context.Context, id string) error {
+	return t.change(ctx, ActionTransactionDelete, ResourceTransaction, id, t.snapshot(id), func(ctx context.Context) error {
+		return t.TransactionRepository.Delete(ctx, id)
+	})
+}
+
+// snapshot reads the transaction, nil when it doesn't exist
+func (t *transactions) snapshot(id string) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		transaction, err := t.TransactionRepository.GetByID(ctx, id)
+		if err != nil || transaction == nil {
+			return nil, err
+		}
+		return transaction, nil
+	}
+}
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+// ErrHeadNotFound is returned when the log doesn't contain the head it was
+// verified against, because entries were removed from its end or it was
+// rewritten
+var ErrHeadNotFound = errors.New("audit log does not contain the expected head")
+
+// ChainError reports the first entry where the audit log chain breaks
+type ChainError struct {
+	Sequence int64
+	Reason   string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit log chain broken at entry %d: %s", e.Sequence, e.Reason)
+}
+
+// Verify walks the audit log in sequence order and checks that the
+// sequences increase, that each entry follows the previous entry of its
+// resource's chain, without a gap, and links to its hash, and that its own
+// hash matches its fields. It returns a *ChainError at the first entry that
+// doesn't.
+//
+// A chain can't tell that its last entries were removed. When head is set,
+// e.g. to the head of an earlier verification kept elsewhere, the log must
+// still contain the entry with that hash, which detects entries removed from
+// the end of the log. The last entries of other resources are only covered
+// once the resource has a later entry linking to them.
+func Verify(ctx context.Context, log repository.AuditRepository, head string) (*dto.AuditVerification, error) {
+	result := &dto.AuditVerification{}
+	headFound := head == ""
+	var sequence int64
+	chains := map[string]*dto.AuditEntryDTO{}
+
+	err := log.Walk(ctx, func(entry *dto.AuditEntryDTO) error {
+		prev := chains[entry.Chain()]
+		position, prevHash := int64(1), ""
+		if prev != nil {
+			position, prevHash = prev.ChainSequence+1, prev.Hash
+		}
+
+		switch {
+		case entry.Sequence <= sequence:
+			return &ChainError{Sequence: entry.Sequence, Reason: fmt.Sprintf("expected a sequence above %d", sequence)}
+		case entry.ChainSequence != position:
+			return &ChainError{Sequence: entry.Sequence, Reason: fmt.Sprintf("expected entry %d of %s", position, entry.Chain())}
+		case entry.PrevHash != prevHash:
+			return &ChainError{Sequence: entry.Sequence, Reason: "previous hash does not match the entry before it"}
+		case entry.Hash != entry.ComputeHash():
+			return &ChainError{Sequence: entry.Sequence, Reason: "hash does not match the entry's contents"}
+		}
+
+		sequence, chains[entry.Chain()] = entry.Sequence, entry
+		result.Entries++
+		result.Head = entry.Hash
+		headFound = headFound || entry.Hash == head
+		return nil
+	})
+	result.Chains = int64(len(chains))
+	if err != nil {
+		return result, err
+	}
+	if !headFound {
+		return result, ErrHeadNotFound
+	}
+	return result, nil
+}
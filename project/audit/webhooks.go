@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+// Webhook actions
+const (
+	ActionWebhookSubscriptionCreate = "webhook_subscription.create"
+	ActionWebhookSubscriptionDelete = "webhook_subscription.delete"
+	ActionWebhookDeliveryUpdate     = "webhook_delivery.update"
+)
+
+// webhooks records the changes made through a WebhookRepository, reads go
+// straight to it. Creating and claiming deliveries is left out: a delivery
+// is created for an event already on record and claimed before each attempt,
+// and the outcome of every attempt and redelivery is recorded with the
+// delivery as it was before it.
+type webhooks struct {
+	repository.WebhookRepository
+	recorder
+}
+
+// Webhooks returns webhooks recording the changes to subscriptions and
+// deliveries in log. Each change and its entry are written in one
+// transaction of txManager. Subscription secrets are left out of the entries.
+func Webhooks(webhookRepo repository.WebhookRepository, log repository.AuditRepository, txManager repository.TxManager) repository.WebhookRepository {
+	return &webhooks{WebhookRepository: webhookRepo, recorder: recorder{log: log, txManager: txManager, now: time.Now}}
+}
+
+func (w *webhooks) CreateSubscription(ctx context.Context, subscription *dto.WebhookSubscriptionDTO) error {
+	return w.atomically(ctx, func(ctx context.Context) error {
+		if err := w.WebhookRepository.CreateSubscription(ctx, subscription); err != nil {
+			return err
+		}
+		return w.append(ctx, ActionWebhookSubscriptionCreate, ResourceWebhookSubscription, subscription.ID, nil, withoutSecret(subscription))
+	})
+}
+
+func (w *webhooks) DeleteSubscription(ctx context.Context, id string) error {
+	return w.change(ctx, ActionWebhookSubscriptionDelete, ResourceWebhookSubscription, id, w.subscription(id), func(ctx context.Context) error {
+		return w.WebhookRepository.DeleteSubscription(ctx, id)
+	})
+}
+
+func (w *webhooks) UpdateDelivery(ctx context.Context, delivery *dto.WebhookDeliveryDTO) error {
+	return w.change(ctx, ActionWebhookDeliveryUpdate, ResourceWebhookDelivery, delivery.ID, w.delivery(delivery.ID), func(ctx context.Context) error {
+		return w.WebhookRepository.UpdateDelivery(ctx, delivery)
+	})
+}
+
+// subscription reads the subscription without its secret, nil when it doesn't exist
+func (w *webhooks) subscription(id string) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		subscription, err := w.WebhookRepository.GetSubscription(ctx, id)
+		if err != nil || subscription == nil {
+			return nil, err
+		}
+		return withoutSecret(subscription), nil
+	}
+}
+
+// delivery reads the delivery, nil when it doesn't exist
+func (w *webhooks) delivery(id string) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		delivery, err := w.WebhookRepository.GetDelivery(ctx, id)
+		if err != nil || delivery == nil {
+			return nil, err
+		}
+		return delivery, nil
+	}
+}
+
+// withoutSecret returns a copy of the subscription without its secret, the
+// audit log is readable by every admin
+func withoutSecret(subscription *dto.WebhookSubscriptionDTO) *dto.WebhookSubscriptionDTO {
+	copied := *subscription
+	copied.Secret = ""
+	return &copied
+}
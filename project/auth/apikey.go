@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// apiKeyPrefix makes bank API keys easy to recognise in secret scanners
+const apiKeyPrefix = "bk_"
+
+// GenerateAPIKey returns a new random API key and the hash to store for it.
+// Only the hash is persisted, the plaintext key is shown to the caller once.
+func GenerateAPIKey() (key string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key = apiKeyPrefix + hex.EncodeToString(b)
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the SHA-256 hex digest under which an API key is stored
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
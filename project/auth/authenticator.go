@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+var (
+	// ErrMissingCredentials is returned when the request carries no API key or token
+	ErrMissingCredentials = errors.New("missing credentials")
+	// ErrInvalidCredentials is returned when the API key or token is rejected
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// APIKeyHeader is the header carrying an API key
+const APIKeyHeader = "X-API-Key"
+
+// Authenticator resolves the principal of a request from an API key or a JWT bearer token
+type Authenticator struct {
+	apiKeys repository.APIKeyRepository
+	jwt     *JWTVerifier
+
+	// Headers set by a trusted upstream gateway, empty when not trusted
+	trustedPrincipalHeader string
+	trustedRolesHeader     string
+}
+
+// NewAuthenticator creates an authenticator. Either argument may be nil to
+// disable that authentication method.
+func NewAuthenticator(apiKeys repository.APIKeyRepository, verifier *JWTVerifier) *Authenticator {
+	return &Authenticator{apiKeys: apiKeys, jwt: verifier}
+}
+
+// NewAuthenticatorFromEnv creates an authenticator configured from environment variables
+func NewAuthenticatorFromEnv(apiKeys repository.APIKeyRepository) (*Authenticator, error) {
+	config := JWTConfig{
+		HS256Secret: os.Getenv("JWT_HS256_SECRET"),
+		JWKSFile:    os.Getenv("JWT_JWKS_FILE"),
+		Issuer:      os.Getenv("JWT_ISSUER"),
+		Audience:    os.Getenv("JWT_AUDIENCE"),
+	}
+
+	var verifier *JWTVerifier
+	if config.HS256Secret != "" || config.JWKSFile != "" {
+		var err error
+		verifier, err = NewJWTVerifier(config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	authenticator := NewAuthenticator(apiKeys, verifier)
+	if header := os.Getenv("AUTH_TRUSTED_PRINCIPAL_HEADER"); header != "" {
+		rolesHeader := os.Getenv("AUTH_TRUSTED_ROLES_HEADER")
+		if rolesHeader == "" {
+			rolesHeader = "X-Authenticated-Roles"
+		}
+		authenticator.TrustHeaders(header, rolesHeader)
+	}
+
+	return authenticator, nil
+}
+
+// TrustHeaders makes the authenticator accept the principal ID and comma
+// separated roles set by an upstream gateway in the given headers. Only enable
+// this when the gateway strips these headers from client requests.
+func (a *Authenticator) TrustHeaders(principalHeader, rolesHeader string) {
+	a.trustedPrincipalHeader = principalHeader
+	a.trustedRolesHeader = rolesHeader
+}
+
+// Authenticate returns the principal of the request
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	return a.AuthenticateHeader(r.Context(), r.Header)
+}
+
+// AuthenticateHeader returns the principal of a request carrying header,
+// including the principal set by a trusted gateway in the trusted headers
+func (a *Authenticator) AuthenticateHeader(ctx context.Context, header http.Header) (*Principal, error) {
+	principal, err := a.AuthenticateCredentials(ctx, header)
+	if !errors.Is(err, ErrMissingCredentials) || a.trustedPrincipalHeader == "" {
+		return principal, err
+	}
+
+	if principalID := strings.TrimSpace(header.Get(a.trustedPrincipalHeader)); principalID != "" {
+		return &Principal{
+			ID:     principalID,
+			Method: MethodTrustedHeader,
+			Roles:  splitRoles(header.Get(a.trustedRolesHeader)),
+		}, nil
+	}
+	return nil, ErrMissingCredentials
+}
+
+// AuthenticateCredentials returns the principal of the API key or bearer
+// token in header and ignores the trusted headers. Calls that no gateway
+// sits in front of, e.g. the metadata of a gRPC call, must use it.
+func (a *Authenticator) AuthenticateCredentials(ctx context.Context, header http.Header) (*Principal, error) {
+	if key := apiKeyFromHeader(header); key != "" {
+		return a.authenticateAPIKey(ctx, key)
+	}
+
+	if token := bearerToken(header); token != "" {
+		return a.authenticateJWT(ctx, token)
+	}
+
+	return nil, ErrMissingCredentials
+}
+
+func (a *Authenticator) authenticateAPIKey(ctx context.Context, key string) (*Principal, error) {
+	if a.apiKeys == nil {
+		logger.WarnContext(ctx, "API key authentication is not available", nil)
+		return nil, ErrInvalidCredentials
+	}
+
+	apiKey, err := a.apiKeys.GetByHash(ctx, HashAPIKey(key))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to look up API key", err)
+		return nil, ErrInvalidCredentials
+	}
+	if apiKey == nil || apiKey.RevokedAt != nil {
+		logger.WarnContext(ctx, "Unknown or revoked API key", nil)
+		return nil, ErrInvalidCredentials
+	}
+
+	return &Principal{
+		ID:     apiKey.PrincipalID,
+		Name:   apiKey.Name,
+		Method: MethodAPIKey,
+		Roles:  apiKey.Roles,
+	}, nil
+}
+
+func (a *Authenticator) authenticateJWT(ctx context.Context, token string) (*Principal, error) {
+	if a.jwt == nil {
+		logger.WarnContext(ctx, "JWT authentication is not configured", nil)
+		return nil, ErrInvalidCredentials
+	}
+
+	claims, err := a.jwt.Verify(token)
+	if err != nil {
+		logger.WarnContext(ctx, "Rejected bearer token", map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return nil, ErrInvalidCredentials
+	}
+
+	return &Principal{
+		ID:     claims.Subject,
+		Name:   claims.Name,
+		Method: MethodJWT,
+		Roles:  claims.Roles,
+	}, nil
+}
+
+// apiKeyFromHeader reads the key from X-API-Key or an "Authorization: ApiKey <key>" header
+func apiKeyFromHeader(header http.Header) string {
+	if key := header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	scheme, value, ok := strings.Cut(header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "ApiKey") {
+		return strings.TrimSpace(value)
+	}
+	return ""
+}
+
+// bearerToken reads the token from an "Authorization: Bearer <token>" header
+func bearerToken(header http.Header) string {
+	scheme, value, ok := strings.Cut(header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(value)
+	}
+	return ""
+}
+
+// splitRoles parses a comma separated role list
+func splitRoles(value string) []string {
+	var roles []string
+	for _, role := range strings.Split(value, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
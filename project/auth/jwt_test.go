@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signHS256(t *testing.T, secret string, claims jwt.Claims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return token
+}
+
+func validClaims() *Claims {
+	return &Claims{
+		Name: "Jane Doe",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user-1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+
+func TestJWTVerifierHS256(t *testing.T) {
+	verifier, err := NewJWTVerifier(JWTConfig{HS256Secret: "secret"})
+	require.NoError(t, err)
+
+	claims, err := verifier.Verify(signHS256(t, "secret", validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, "Jane Doe", claims.Name)
+
+	_, err = verifier.Verify(signHS256(t, "wrong-secret", validClaims()))
+	assert.Error(t, err)
+}
+
+func TestJWTVerifierRejectsExpiredToken(t *testing.T) {
+	verifier, err := NewJWTVerifier(JWTConfig{HS256Secret: "secret"})
+	require.NoError(t, err)
+
+	claims := validClaims()
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+
+	_, err = verifier.Verify(signHS256(t, "secret", claims))
+	assert.Error(t, err)
+}
+
+func TestJWTVerifierChecksAudience(t *testing.T) {
+	verifier, err := NewJWTVerifier(JWTConfig{HS256Secret: "secret", Audience: "bank-api"})
+	require.NoError(t, err)
+
+	_, err = verifier.Verify(signHS256(t, "secret", validClaims()))
+	assert.Error(t, err)
+
+	claims := validClaims()
+	claims.Audience = jwt.ClaimStrings{"bank-api"}
+	_, err = verifier.Verify(signHS256(t, "secret", claims))
+	assert.NoError(t, err)
+}
+
+func TestJWTVerifierRS256WithJWKSFile(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	jwksJSON := fmt.Sprintf(`{"keys":[{"kty":"RSA","kid":"key-1","use":"sig","alg":"RS256","n":%q,"e":%q}]}`,
+		base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()))
+	require.NoError(t, os.WriteFile(jwksFile, []byte(jwksJSON), 0o600))
+
+	verifier, err := NewJWTVerifier(JWTConfig{JWKSFile: jwksFile})
+	require.NoError(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims())
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	claims, err := verifier.Verify(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+
+	// HS256 tokens are refused when only RS256 is configured
+	_, err = verifier.Verify(signHS256(t, "secret", validClaims()))
+	assert.Error(t, err)
+}
+
+func TestAuthenticatorMissingCredentials(t *testing.T) {
+	authenticator := NewAuthenticator(nil, nil)
+
+	req, err := http.NewRequest("GET", "/accounts", nil)
+	require.NoError(t, err)
+
+	_, err = authenticator.Authenticate(req)
+	assert.ErrorIs(t, err, ErrMissingCredentials)
+}
+
+func TestAuthenticatorBearerToken(t *testing.T) {
+	verifier, err := NewJWTVerifier(JWTConfig{HS256Secret: "secret"})
+	require.NoError(t, err)
+	authenticator := NewAuthenticator(nil, verifier)
+
+	req, err := http.NewRequest("GET", "/accounts", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+signHS256(t, "secret", validClaims()))
+
+	principal, err := authenticator.Authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", principal.ID)
+	assert.Equal(t, MethodJWT, principal.Method)
+}
+
+func TestAuthenticatorRolesClaim(t *testing.T) {
+	verifier, err := NewJWTVerifier(JWTConfig{HS256Secret: "secret"})
+	require.NoError(t, err)
+	authenticator := NewAuthenticator(nil, verifier)
+
+	claims := validClaims()
+	claims.Roles = []string{"teller"}
+
+	req, err := http.NewRequest("GET", "/accounts", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+signHS256(t, "secret", claims))
+
+	principal, err := authenticator.Authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"teller"}, principal.Roles)
+}
+
+func TestAuthenticatorTrustedHeaders(t *testing.T) {
+	authenticator := NewAuthenticator(nil, nil)
+
+	req, err := http.NewRequest("GET", "/accounts", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Authenticated-User", "customer-1")
+	req.Header.Set("X-Authenticated-Roles", "customer, teller")
+
+	// Headers are ignored until the gateway is trusted
+	_, err = authenticator.Authenticate(req)
+	assert.ErrorIs(t, err, ErrMissingCredentials)
+
+	authenticator.TrustHeaders("X-Authenticated-User", "X-Authenticated-Roles")
+	principal, err := authenticator.Authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, "customer-1", principal.ID)
+	assert.Equal(t, MethodTrustedHeader, principal.Method)
+	assert.Equal(t, []string{"customer", "teller"}, principal.Roles)
+}
@@ -0,0 +1,36 @@
+package auth
+
+import "context"
+
+// Authentication methods recorded on the principal
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+	// MethodTrustedHeader is used when an upstream gateway already authenticated the caller
+	MethodTrustedHeader = "trusted_header"
+	// MethodSystem is used by background jobs acting on behalf of the bank
+	MethodSystem = "system"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name,omitempty"`
+	Method string   `json:"method"`
+	Roles  []string `json:"roles,omitempty"`
+}
+
+type contextKey int
+
+const principalKey contextKey = iota
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// PrincipalFromContext returns the authenticated principal stored in ctx
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(*Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,96 @@
+// Package authz holds the authorization policy of the bank API. Handlers ask
+// it whether the authenticated principal may perform an action instead of
+// encoding role and ownership rules inline.
+package authz
+
+import (
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/dto"
+)
+
+// Role is a role granted to a principal
+type Role string
+
+const (
+	// RoleCustomer may only see and transact on the accounts they own
+	RoleCustomer Role = "customer"
+	// RoleTeller may see every account and post transactions on behalf of customers
+	RoleTeller Role = "teller"
+	// RoleAdmin may do everything
+	RoleAdmin Role = "admin"
+)
+
+// HasRole reports whether the principal was granted the role
+func HasRole(principal *auth.Principal, role Role) bool {
+	if principal == nil {
+		return false
+	}
+	for _, r := range principal.Roles {
+		if Role(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// isStaff reports whether the principal acts on behalf of the bank rather than as a customer
+func isStaff(principal *auth.Principal) bool {
+	return HasRole(principal, RoleAdmin) || HasRole(principal, RoleTeller)
+}
+
+// owns reports whether the principal owns the account
+func owns(principal *auth.Principal, account *dto.AccountDTO) bool {
+	return principal != nil && account != nil && account.OwnerID != "" && account.OwnerID == principal.ID
+}
+
+// CanListAllAccounts reports whether the principal may list every account.
+// Other principals only see the accounts they own.
+func CanListAllAccounts(principal *auth.Principal) bool {
+	return isStaff(principal)
+}
+
+// CanViewAccount reports whether the principal may read the account and its transactions
+func CanViewAccount(principal *auth.Principal, account *dto.AccountDTO) bool {
+	return isStaff(principal) || owns(principal, account)
+}
+
+// CanTransact reports whether the principal may post a transaction on the account
+func CanTransact(principal *auth.Principal, account *dto.AccountDTO) bool {
+	return isStaff(principal) || owns(principal, account)
+}
+
+// CanSettleHold reports whether the principal may capture or release the
+// hold. Only the principal that placed it and staff may, so a merchant's
+// hold can't be released by the account owner.
+func CanSettleHold(principal *auth.Principal, hold *dto.HoldDTO) bool {
+	if isStaff(principal) {
+		return true
+	}
+	return principal != nil && hold != nil && hold.CreatedBy != "" && hold.CreatedBy == principal.ID
+}
+
+// CanCreateAccountFor reports whether the principal may open an account owned by ownerID.
+// Customers may only open accounts for themselves.
+func CanCreateAccountFor(principal *auth.Principal, ownerID string) bool {
+	if principal == nil {
+		return false
+	}
+	return isStaff(principal) || ownerID == principal.ID
+}
+
+// CanManageAccountLimits reports whether the principal may change the
+// overdraft and transaction limits of accounts
+func CanManageAccountLimits(principal *auth.Principal) bool {
+	return HasRole(principal, RoleAdmin)
+}
+
+// CanManageWebhooks reports whether the principal may subscribe URLs to the
+// bank's events and read their delivery logs
+func CanManageWebhooks(principal *auth.Principal) bool {
+	return HasRole(principal, RoleAdmin)
+}
+
+// CanViewAuditLog reports whether the principal may read the audit log
+func CanViewAuditLog(principal *auth.Principal) bool {
+	return HasRole(principal, RoleAdmin)
+}
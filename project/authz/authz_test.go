@@ -0,0 +1,135 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	customer = &auth.Principal{ID: "customer-1", Roles: []string{string(RoleCustomer)}}
+	other    = &auth.Principal{ID: "customer-2", Roles: []string{string(RoleCustomer)}}
+	teller   = &auth.Principal{ID: "teller-1", Roles: []string{string(RoleTeller)}}
+	admin    = &auth.Principal{ID: "admin-1", Roles: []string{string(RoleAdmin)}}
+	noRoles  = &auth.Principal{ID: "customer-1"}
+
+	customerAccount = &dto.AccountDTO{ID: "acc-1", OwnerID: "customer-1"}
+	unownedAccount  = &dto.AccountDTO{ID: "acc-2"}
+)
+
+func TestHasRole(t *testing.T) {
+	assert.True(t, HasRole(admin, RoleAdmin))
+	assert.False(t, HasRole(admin, RoleTeller))
+	assert.False(t, HasRole(nil, RoleAdmin))
+}
+
+func TestCanListAllAccounts(t *testing.T) {
+	assert.False(t, CanListAllAccounts(customer))
+	assert.False(t, CanListAllAccounts(noRoles))
+	assert.True(t, CanListAllAccounts(teller))
+	assert.True(t, CanListAllAccounts(admin))
+	assert.False(t, CanListAllAccounts(nil))
+}
+
+func TestCanViewAccount(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		account   *dto.AccountDTO
+		allowed   bool
+	}{
+		{"owner", customer, customerAccount, true},
+		{"owner without roles", noRoles, customerAccount, true},
+		{"other customer", other, customerAccount, false},
+		{"teller", teller, customerAccount, true},
+		{"admin", admin, customerAccount, true},
+		{"account without owner", customer, unownedAccount, false},
+		{"admin on account without owner", admin, unownedAccount, true},
+		{"no principal", nil, customerAccount, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, CanViewAccount(tt.principal, tt.account))
+		})
+	}
+}
+
+func TestCanTransact(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		account   *dto.AccountDTO
+		allowed   bool
+	}{
+		{"owner", customer, customerAccount, true},
+		{"other customer", other, customerAccount, false},
+		{"teller", teller, customerAccount, true},
+		{"admin", admin, customerAccount, true},
+		{"no principal", nil, customerAccount, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, CanTransact(tt.principal, tt.account))
+		})
+	}
+}
+
+func TestCanSettleHold(t *testing.T) {
+	tellersHold := &dto.HoldDTO{ID: "hold-1", AccountID: "acc-1", CreatedBy: "teller-1"}
+	customersHold := &dto.HoldDTO{ID: "hold-2", AccountID: "acc-1", CreatedBy: "customer-1"}
+	unattributedHold := &dto.HoldDTO{ID: "hold-3", AccountID: "acc-1"}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		hold      *dto.HoldDTO
+		allowed   bool
+	}{
+		{"creator", customer, customersHold, true},
+		{"account owner on a teller's hold", customer, tellersHold, false},
+		{"other customer", other, customersHold, false},
+		{"teller", teller, customersHold, true},
+		{"admin", admin, tellersHold, true},
+		{"hold without creator", customer, unattributedHold, false},
+		{"no principal", nil, customersHold, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, CanSettleHold(tt.principal, tt.hold))
+		})
+	}
+}
+
+func TestCanCreateAccountFor(t *testing.T) {
+	assert.True(t, CanCreateAccountFor(customer, "customer-1"))
+	assert.False(t, CanCreateAccountFor(customer, "customer-2"))
+	assert.True(t, CanCreateAccountFor(teller, "customer-2"))
+	assert.True(t, CanCreateAccountFor(admin, "customer-2"))
+	assert.False(t, CanCreateAccountFor(nil, ""))
+}
+
+func TestCanManageAccountLimits(t *testing.T) {
+	assert.True(t, CanManageAccountLimits(admin))
+	assert.False(t, CanManageAccountLimits(teller))
+	assert.False(t, CanManageAccountLimits(customer))
+	assert.False(t, CanManageAccountLimits(nil))
+}
+
+func TestCanManageWebhooks(t *testing.T) {
+	assert.True(t, CanManageWebhooks(admin))
+	assert.False(t, CanManageWebhooks(teller))
+	assert.False(t, CanManageWebhooks(customer))
+	assert.False(t, CanManageWebhooks(nil))
+}
+
+func TestCanViewAuditLog(t *testing.T) {
+	assert.True(t, CanViewAuditLog(admin))
+	assert.False(t, CanViewAuditLog(teller))
+	assert.False(t, CanViewAuditLog(customer))
+	assert.False(t, CanViewAuditLog(nil))
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gcalvocr/go-testing/interest"
+	"github.com/gcalvocr/go-testing/product"
+	"github.com/gcalvocr/go-testing/service"
+)
+
+// accrue posts the interest and fees of a month and prints a report per account
+func accrue(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("accrue", flag.ContinueOnError)
+	period := flags.String("period", "", "month to post as YYYY-MM, defaults to the previous month")
+	accountID := flags.String("account", "", "only accrue this account")
+	dryRun := flags.Bool("dry-run", false, "report the interest and fees without posting them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	opts := interest.Options{
+		Period:    interest.PeriodOf(time.Now()).Previous(),
+		AccountID: *accountID,
+		DryRun:    *dryRun,
+	}
+	if *period != "" {
+		var err error
+		if opts.Period, err = interest.ParsePeriod(*period); err != nil {
+			return err
+		}
+	}
+
+	catalog, err := product.CatalogFromEnv()
+	if err != nil {
+		return err
+	}
+
+	repos, err := repositories()
+	if err != nil {
+		return err
+	}
+
+	transactions := service.NewTransactionService(repos.AccountRepo, repos.TransactionRepo, repos.TxManager)
+	transactions.SetOutbox(repos.OutboxRepo)
+	engine := interest.NewEngine(repos.AccountRepo, repos.TransactionRepo, repos.AccrualRepo, repos.TxManager, transactions, catalog)
+
+	results, err := engine.Run(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+	return writeAccrualReport(stdout, opts, results)
+}
+
+// writeAccrualReport prints one line per account and fails when any account failed
+func writeAccrualReport(stdout io.Writer, opts interest.Options, results []interest.Result) error {
+	mode := ""
+	if opts.DryRun {
+		mode = " (dry run)"
+	}
+	fmt.Fprintf(stdout, "Interest and fees for %s%s\n\n", opts.Period, mode)
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACCOUNT\tPRODUCT\tDAYS\tAVG BALANCE\tINTEREST\tFEE\tSTATUS")
+
+	var interestTotal, feeTotal float64
+	failed := 0
+	for _, r := range results {
+		fee := fmt.Sprintf("%.2f", r.Fee)
+		if r.FeeWaived {
+			fee = "waived"
+		}
+		status := r.Status
+		if r.Reason != "" {
+			status += ": " + r.Reason
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.2f\t%.2f\t%s\t%s\n",
+			r.AccountID, r.Product, r.Days, r.AverageBalance, r.Interest, fee, status)
+
+		switch r.Status {
+		case interest.StatusPosted, interest.StatusWouldPost:
+			interestTotal += r.Interest
+			feeTotal += r.Fee
+		case interest.StatusFailed:
+			failed++
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	verb := "Posted"
+	if opts.DryRun {
+		verb = "Would post"
+	}
+	fmt.Fprintf(stdout, "\n%s %.2f interest and %.2f fees on %d accounts\n", verb, interestTotal, feeTotal, len(results))
+
+	if failed > 0 {
+		return fmt.Errorf("%d accounts failed, run the period again to retry them", failed)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/dto"
+)
+
+// createAPIKey stores the hash of a new API key and prints the plaintext key once
+func createAPIKey(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("create-api-key", flag.ContinueOnError)
+	name := flags.String("name", "", "human readable name of the key")
+	principal := flags.String("principal", "", "ID of the principal the key authenticates as")
+	roles := flags.String("roles", "customer", "comma separated roles granted to the key (customer, teller, admin)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *principal == "" {
+		return errors.New("-name and -principal are required")
+	}
+
+	repos, err := repositories()
+	if err != nil {
+		return err
+	}
+
+	key, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return err
+	}
+
+	apiKey := &dto.APIKeyDTO{
+		Name:        *name,
+		KeyHash:     hash,
+		PrincipalID: *principal,
+		Roles:       strings.Split(*roles, ","),
+	}
+	if err := repos.APIKeyRepo.Create(context.Background(), apiKey); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "id:  %s\nkey: %s\n\nStore the key now, it cannot be shown again.\n", apiKey.ID, key)
+	return nil
+}
+
+// revokeAPIKey marks an API key as revoked
+func revokeAPIKey(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("revoke-api-key", flag.ContinueOnError)
+	id := flags.String("id", "", "ID of the API key to revoke")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errors.New("-id is required")
+	}
+
+	repos, err := repositories()
+	if err != nil {
+		return err
+	}
+
+	if err := repos.APIKeyRepo.Revoke(context.Background(), *id); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "revoked %s\n", *id)
+	return nil
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/gcalvocr/go-testing/audit"
+)
+
+// verifyAuditLog checks the hash chains of the audit log and prints its head.
+// With -head, e.g. the head printed by an earlier run, it also detects
+// entries removed from the end.
+func verifyAuditLog(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("verify-audit-log", flag.ContinueOnError)
+	head := flags.String("head", "", "hash of an entry recorded earlier that must still be in the log")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	repos, err := repositories()
+	if err != nil {
+		return err
+	}
+
+	result, err := audit.Verify(context.Background(), repos.AuditRepo, *head)
+	if err != nil {
+		fmt.Fprintf(stdout, "verified: %d entries before the failure\n", result.Entries)
+		return err
+	}
+
+	fmt.Fprintf(stdout, "verified: %d entries in %d chains\nhead:     %s\n", result.Entries, result.Chains, result.Head)
+	return nil
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gcalvocr/go-testing/repository"
+	"github.com/gcalvocr/go-testing/server"
+)
+
+// command is a subcommand of the bank binary
+type command struct {
+	name        string
+	description string
+	run         func(args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{name: "create-api-key", description: "Create an API key for a principal", run: createAPIKey},
+	{name: "revoke-api-key", description: "Revoke an API key by ID", run: revokeAPIKey},
+	{name: "accrue", description: "Post a month's interest and fees", run: accrue},
+	{name: "verify-audit-log", description: "Check the hash chain of the audit log", run: verifyAuditLog},
+}
+
+// Run executes the subcommand named by args[0] and returns the process exit code
+func Run(args []string) int {
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			if err := cmd.run(args[1:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.name, err)
+				return 1
+			}
+			return 0
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\nAvailable commands:\n", args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", cmd.name, cmd.description)
+	}
+	return 2
+}
+
+// repositories connects to the configured database like the API server does
+func repositories() (*repository.RepositoryFactory, error) {
+	srv := server.NewServer()
+	if err := srv.InitializeDatabase(); err != nil {
+		return nil, err
+	}
+	return srv.GetRepositoryFactory(), nil
+}
@@ -8,8 +8,26 @@ type AccountDTO struct {
 	Name      string    `json:"name" bson:"name" validate:"required,min=1,max=100"`
 	Balance   float64   `json:"balance" bson:"balance" validate:"min=0"`
 	Currency  string    `json:"currency" bson:"currency" validate:"required,len=3"`
+	OwnerID   string    `json:"owner_id" bson:"owner_id"`
 	CreatedAt time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+
+	// Product is the code of the account's product, empty means the default one
+	Product string        `json:"product" bson:"product,omitempty"`
+	Limits  AccountLimits `json:"limits" bson:"limits"`
+}
+
+// AccountLimits is the posting policy of an account, only admins may change
+// it. Limits that are not set don't apply.
+type AccountLimits struct {
+	// OverdraftLimit is how far below zero withdrawals may take the balance
+	OverdraftLimit float64 `json:"overdraft_limit" bson:"overdraft_limit" validate:"gte=0"`
+	// MinimumBalance is the lowest balance a withdrawal may leave
+	MinimumBalance *float64 `json:"minimum_balance,omitempty" bson:"minimum_balance,omitempty"`
+	// DailyWithdrawalLimit caps the withdrawals of a UTC calendar day
+	DailyWithdrawalLimit *float64 `json:"daily_withdrawal_limit,omitempty" bson:"daily_withdrawal_limit,omitempty" validate:"omitempty,gt=0"`
+	// MaxTransactionAmount caps the amount of a single deposit or withdrawal
+	MaxTransactionAmount *float64 `json:"max_transaction_amount,omitempty" bson:"max_transaction_amount,omitempty" validate:"omitempty,gt=0"`
 }
 
 // CreateAccountRequest represents the request to create an account
@@ -17,6 +35,10 @@ type CreateAccountRequest struct {
 	Name     string  `json:"name" validate:"required,min=1,max=100"`
 	Balance  float64 `json:"balance" validate:"min=0"`
 	Currency string  `json:"currency" validate:"required,len=3"`
+	// OwnerID defaults to the caller, only tellers and admins may open accounts for someone else
+	OwnerID string `json:"owner_id,omitempty"`
+	// Product defaults to checking
+	Product string `json:"product,omitempty"`
 }
 
 // UpdateAccountRequest represents the request to update an account
@@ -32,6 +54,14 @@ type AccountResponse struct {
 	Name      string    `json:"name"`
 	Balance   float64   `json:"balance"`
 	Currency  string    `json:"currency"`
+	OwnerID   string    `json:"owner_id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// Balance is the ledger balance, AvailableBalance is the balance minus
+	// the active holds and is what withdrawals are checked against
+	AvailableBalance float64 `json:"available_balance"`
+
+	Product string        `json:"product"`
+	Limits  AccountLimits `json:"limits"`
 }
@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+// AccrualDTO records that the interest and fee of an account were posted for
+// a month. There is at most one per account and period, which makes the
+// accrual batch idempotent.
+type AccrualDTO struct {
+	ID        string `json:"id" bson:"_id,omitempty"`
+	AccountID string `json:"account_id" bson:"account_id"`
+	// Period is the month the accrual covers, as YYYY-MM
+	Period  string `json:"period" bson:"period"`
+	Product string `json:"product" bson:"product"`
+
+	// Days is the number of days interest accrued on
+	Days           int     `json:"days" bson:"days"`
+	AverageBalance float64 `json:"average_balance" bson:"average_balance"`
+	Interest       float64 `json:"interest" bson:"interest"`
+	Fee            float64 `json:"fee" bson:"fee"`
+	FeeWaived      bool    `json:"fee_waived" bson:"fee_waived"`
+
+	// The posted transactions, empty when the amount was zero
+	InterestTransactionID string `json:"interest_transaction_id,omitempty" bson:"interest_transaction_id,omitempty"`
+	FeeTransactionID      string `json:"fee_transaction_id,omitempty" bson:"fee_transaction_id,omitempty"`
+
+	PostedAt time.Time `json:"posted_at" bson:"posted_at"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+import "time"
+
+// APIKeyDTO represents a stored API key. Only the SHA-256 hash of the key is kept.
+type APIKeyDTO struct {
+	ID          string     `json:"id" bson:"_id,omitempty"`
+	Name        string     `json:"name" bson:"name" validate:"required"`
+	KeyHash     string     `json:"-" bson:"key_hash" validate:"required"`
+	PrincipalID string     `json:"principal_id" bson:"principal_id" validate:"required"`
+	Roles       []string   `json:"roles" bson:"roles"`
+	CreatedAt   time.Time  `json:"created_at" bson:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
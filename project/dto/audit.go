@@ -0,0 +1,87 @@
+package dto
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// AuditEntryDTO records a change to a resource: who made it, in which
+// request, and the resource before and after it. The entries of each
+// resource are chained: each carries the hash of the resource's entry
+// before it, so changing or removing an entry breaks every hash after it.
+// Resources have a chain of their own so changes to different resources
+// don't wait for each other to be recorded.
+type AuditEntryDTO struct {
+	ID string `json:"id" bson:"_id,omitempty"`
+	// Sequence orders the entries of the whole log. It is assigned by the
+	// store and skips the numbers of appends that were rolled back.
+	Sequence int64 `json:"sequence" bson:"sequence"`
+	// ChainSequence is the position of the entry in its resource's chain, from 1
+	ChainSequence int64 `json:"chain_sequence" bson:"chain_sequence"`
+
+	// Actor is the principal that made the change, or "system"
+	Actor        string `json:"actor" bson:"actor"`
+	Action       string `json:"action" bson:"action"`
+	ResourceType string `json:"resource_type" bson:"resource_type"`
+	ResourceID   string `json:"resource_id" bson:"resource_id"`
+	// Before is nil for created resources and After for deleted ones
+	Before     json.RawMessage `json:"before" bson:"-"`
+	After      json.RawMessage `json:"after" bson:"-"`
+	RequestID  string          `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at" bson:"occurred_at"`
+
+	PrevHash string `json:"prev_hash" bson:"prev_hash"`
+	Hash     string `json:"hash" bson:"hash"`
+}
+
+// Chain identifies the chain of the entry, the one of its resource
+func (e *AuditEntryDTO) Chain() string {
+	return e.ResourceType + "|" + e.ResourceID
+}
+
+// Seal links the entry, whose Sequence is assigned, after prev, the last
+// entry of its chain or nil for the first one, and computes its hash
+func (e *AuditEntryDTO) Seal(prev *AuditEntryDTO) {
+	e.ChainSequence, e.PrevHash = 1, ""
+	if prev != nil {
+		e.ChainSequence, e.PrevHash = prev.ChainSequence+1, prev.Hash
+	}
+	e.Hash = e.ComputeHash()
+}
+
+// ComputeHash returns the hex SHA-256 of the entry's fields and PrevHash.
+// OccurredAt counts to the millisecond, the precision both databases keep.
+func (e *AuditEntryDTO) ComputeHash() string {
+	fields, _ := json.Marshal([]interface{}{
+		e.Sequence, e.ChainSequence, e.PrevHash, e.Actor, e.Action, e.ResourceType, e.ResourceID,
+		string(e.Before), string(e.After), e.RequestID,
+		e.OccurredAt.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(fields)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditQuery filters the audit log, empty fields match every entry
+type AuditQuery struct {
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	// From and To bound OccurredAt to [From, To)
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+// AuditVerification is the outcome of checking the audit log chains
+type AuditVerification struct {
+	Entries int64 `json:"entries"`
+	// Chains is the number of resources with entries
+	Chains int64 `json:"chains"`
+	// Head is the hash of the last entry of the log. Keeping a copy
+	// elsewhere detects entries removed from the end, which the chains alone
+	// cannot.
+	Head string `json:"head"`
+}
@@ -0,0 +1,44 @@
+package dto
+
+// Batch import modes
+const (
+	// BatchAllOrNothing applies every row or none of them
+	BatchAllOrNothing = "all_or_nothing"
+	// BatchBestEffort applies the rows that succeed and reports the others
+	BatchBestEffort = "best_effort"
+)
+
+// Batch row statuses
+const (
+	// BatchRowApplied rows were committed
+	BatchRowApplied = "applied"
+	// BatchRowInvalid rows failed validation and were not attempted
+	BatchRowInvalid = "invalid"
+	// BatchRowFailed rows were rejected, e.g. for insufficient funds
+	BatchRowFailed = "failed"
+	// BatchRowRolledBack rows succeeded but were undone because another row of
+	// an all-or-nothing batch failed
+	BatchRowRolledBack = "rolled_back"
+	// BatchRowSkipped rows were not attempted because the batch was aborted
+	BatchRowSkipped = "skipped"
+)
+
+// BatchRowResult reports the outcome of one row of a batch import
+type BatchRowResult struct {
+	// Row is the 1-based position of the row in the upload, not counting a CSV header
+	Row           int      `json:"row"`
+	Status        string   `json:"status"`
+	AccountID     string   `json:"account_id,omitempty"`
+	TransactionID string   `json:"transaction_id,omitempty"`
+	Balance       *float64 `json:"balance,omitempty"`
+	Error         string   `json:"error,omitempty"`
+}
+
+// BatchTransactionResponse is the validation and result report of a batch import
+type BatchTransactionResponse struct {
+	Mode    string           `json:"mode"`
+	Total   int              `json:"total"`
+	Applied int              `json:"applied"`
+	Failed  int              `json:"failed"`
+	Results []BatchRowResult `json:"results"`
+}
@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Domain event types
+const (
+	EventAccountCreated    = "AccountCreated"
+	EventTransactionPosted = "TransactionPosted"
+	EventTransferCompleted = "TransferCompleted"
+)
+
+// EventDTO is a domain event recorded in the outbox in the same database
+// transaction as the change it describes. Sequence orders the events of the
+// outbox and is what consumers track their progress with.
+type EventDTO struct {
+	ID        string          `json:"id"`
+	Sequence  int64           `json:"sequence"`
+	Type      string          `json:"type"`
+	AccountID string          `json:"account_id"`
+	Payload   json.RawMessage `json:"payload"`
+	// RequestID is the ID of the request that caused the event, if any
+	RequestID  string    `json:"request_id,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// AccountCreatedEvent is the payload of an AccountCreated event
+type AccountCreatedEvent struct {
+	AccountID string    `json:"account_id"`
+	Name      string    `json:"name"`
+	OwnerID   string    `json:"owner_id"`
+	Currency  string    `json:"currency"`
+	Product   string    `json:"product"`
+	Balance   float64   `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TransactionPostedEvent is the payload of a TransactionPosted event,
+// Balance is the ledger balance after the transaction
+type TransactionPostedEvent struct {
+	TransactionID string    `json:"transaction_id"`
+	AccountID     string    `json:"account_id"`
+	Type          string    `json:"type"`
+	Amount        float64   `json:"amount"`
+	Balance       float64   `json:"balance"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TransferCompletedEvent is the payload of a TransferCompleted event, which
+// is recorded once for each account of the transfer. The withdrawal and the
+// deposit are reported by their own TransactionPosted events.
+type TransferCompletedEvent struct {
+	FromAccountID string    `json:"from_account_id"`
+	ToAccountID   string    `json:"to_account_id"`
+	Amount        float64   `json:"amount"`
+	Currency      string    `json:"currency"`
+	WithdrawalID  string    `json:"withdrawal_id"`
+	DepositID     string    `json:"deposit_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,53 @@
+package dto
+
+import "time"
+
+// Hold statuses
+const (
+	HoldActive   = "active"
+	HoldCaptured = "captured"
+	HoldReleased = "released"
+	HoldExpired  = "expired"
+)
+
+// HoldDTO reserves funds of an account until they are captured as a
+// withdrawal, released or the hold expires. Active holds reduce the
+// available balance but not the ledger balance.
+type HoldDTO struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	AccountID string    `json:"account_id" bson:"account_id"`
+	Amount    float64   `json:"amount" bson:"amount"`
+	Status    string    `json:"status" bson:"status"`
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+
+	// CapturedAmount and TransactionID are set when the hold is captured,
+	// the rest of a partial capture is released
+	CapturedAmount float64 `json:"captured_amount,omitempty" bson:"captured_amount,omitempty"`
+	TransactionID  string  `json:"transaction_id,omitempty" bson:"transaction_id,omitempty"`
+
+	CreatedBy string    `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// CreateHoldRequest represents the request to place a hold on an account
+type CreateHoldRequest struct {
+	AccountID string  `json:"account_id" validate:"required"`
+	Amount    float64 `json:"amount" validate:"required,gt=0"`
+	// ExpiresAt defaults to seven days from now
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CaptureHoldRequest represents the request to capture a hold
+type CaptureHoldRequest struct {
+	// Amount defaults to the full hold
+	Amount *float64 `json:"amount,omitempty" validate:"omitempty,gt=0"`
+}
+
+// CaptureHoldResponse is a captured hold, the withdrawal it posted and the
+// ledger balance after it
+type CaptureHoldResponse struct {
+	Hold        *HoldDTO            `json:"hold"`
+	Transaction TransactionResponse `json:"transaction"`
+	Balance     float64             `json:"balance"`
+}
@@ -0,0 +1,78 @@
+package dto
+
+import "time"
+
+// Scheduled transaction schedules
+const (
+	ScheduleOnce    = "once"
+	ScheduleDaily   = "daily"
+	ScheduleWeekly  = "weekly"
+	ScheduleMonthly = "monthly"
+	ScheduleCron    = "cron"
+)
+
+// Scheduled transaction run statuses
+const (
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+)
+
+// ScheduledTransactionDTO is a standing order that posts a deposit or
+// withdrawal on a schedule
+type ScheduledTransactionDTO struct {
+	ID        string  `json:"id" bson:"_id,omitempty"`
+	AccountID string  `json:"account_id" bson:"account_id"`
+	Amount    float64 `json:"amount" bson:"amount"`
+	Type      string  `json:"type" bson:"type"`
+
+	// Schedule is once, daily, weekly, monthly or cron. Daily, weekly and
+	// monthly schedules repeat from StartAt, cron schedules run at the times
+	// matching Cron (in UTC) from StartAt on.
+	Schedule string     `json:"schedule" bson:"schedule"`
+	StartAt  time.Time  `json:"start_at" bson:"start_at"`
+	Cron     string     `json:"cron,omitempty" bson:"cron,omitempty"`
+	EndAt    *time.Time `json:"end_at,omitempty" bson:"end_at,omitempty"`
+
+	// Active is false while paused
+	Active bool `json:"active" bson:"active"`
+	// NextRunAt is nil once the schedule has no more runs
+	NextRunAt     *time.Time `json:"next_run_at" bson:"next_run_at"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty" bson:"last_run_at,omitempty"`
+	LastRunStatus string     `json:"last_run_status,omitempty" bson:"last_run_status,omitempty"`
+
+	CreatedBy string    `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// CreateScheduledTransactionRequest represents the request to schedule a transaction
+type CreateScheduledTransactionRequest struct {
+	AccountID string  `json:"account_id" validate:"required"`
+	Amount    float64 `json:"amount" validate:"required,gt=0"`
+	Type      string  `json:"type" validate:"required,oneof=deposit withdrawal"`
+	Schedule  string  `json:"schedule" validate:"required,oneof=once daily weekly monthly cron"`
+	// StartAt is the first run, or for cron schedules the earliest one
+	StartAt *time.Time `json:"start_at,omitempty" validate:"required_unless=Schedule cron"`
+	// Cron is a standard five field cron expression, evaluated in UTC
+	Cron  string     `json:"cron,omitempty" validate:"required_if=Schedule cron"`
+	EndAt *time.Time `json:"end_at,omitempty"`
+}
+
+// UpdateScheduledTransactionRequest represents the request to change or pause a scheduled transaction
+type UpdateScheduledTransactionRequest struct {
+	Amount *float64   `json:"amount,omitempty" validate:"omitempty,gt=0"`
+	Active *bool      `json:"active,omitempty"`
+	EndAt  *time.Time `json:"end_at,omitempty"`
+}
+
+// ScheduledTransactionRunDTO records the outcome of one run of a scheduled transaction
+type ScheduledTransactionRunDTO struct {
+	ID                     string    `json:"id" bson:"_id,omitempty"`
+	ScheduledTransactionID string    `json:"scheduled_transaction_id" bson:"scheduled_transaction_id"`
+	AccountID              string    `json:"account_id" bson:"account_id"`
+	DueAt                  time.Time `json:"due_at" bson:"due_at"`
+	ExecutedAt             time.Time `json:"executed_at" bson:"executed_at"`
+	Status                 string    `json:"status" bson:"status"`
+	TransactionID          string    `json:"transaction_id,omitempty" bson:"transaction_id,omitempty"`
+	Error                  string    `json:"error,omitempty" bson:"error,omitempty"`
+}
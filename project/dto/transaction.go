@@ -2,12 +2,26 @@ package dto
 
 import "time"
 
+// Transaction types. Customers make deposits and withdrawals, the accrual
+// batch posts interest and fees.
+const (
+	TransactionDeposit    = "deposit"
+	TransactionWithdrawal = "withdrawal"
+	TransactionInterest   = "interest"
+	TransactionFee        = "fee"
+)
+
+// IsDebit reports whether a transaction of the type takes money out of the account
+func IsDebit(transactionType string) bool {
+	return transactionType == TransactionWithdrawal || transactionType == TransactionFee
+}
+
 // TransactionDTO represents the data transfer object for Transaction
 type TransactionDTO struct {
 	ID        string    `json:"id" bson:"_id,omitempty"`
 	AccountID string    `json:"account_id" bson:"account_id" validate:"required"`
 	Amount    float64   `json:"amount" bson:"amount" validate:"required"`
-	Type      string    `json:"type" bson:"type" validate:"required,oneof=deposit withdrawal"`
+	Type      string    `json:"type" bson:"type" validate:"required,oneof=deposit withdrawal interest fee"`
 	CreatedAt time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
 }
@@ -15,7 +29,7 @@ type TransactionDTO struct {
 // CreateTransactionRequest represents the request to create a transaction
 type CreateTransactionRequest struct {
 	AccountID string  `json:"account_id" validate:"required"`
-	Amount    float64 `json:"amount" validate:"required"`
+	Amount    float64 `json:"amount" validate:"required,gt=0"`
 	Type      string  `json:"type" validate:"required,oneof=deposit withdrawal"`
 }
 
@@ -35,6 +49,13 @@ type TransactionSummary struct {
 	TotalTransactions int        `json:"total_transactions"`
 	TotalDeposits     float64    `json:"total_deposits"`
 	TotalWithdrawals  float64    `json:"total_withdrawals"`
+	TotalInterest     float64    `json:"total_interest"`
+	TotalFees         float64    `json:"total_fees"`
 	CurrentBalance    float64    `json:"current_balance"`
 	LastTransactionAt *time.Time `json:"last_transaction_at,omitempty"`
 }
+
+// Net is the balance change of the summarized transactions
+func (s *TransactionSummary) Net() float64 {
+	return s.TotalDeposits + s.TotalInterest - s.TotalWithdrawals - s.TotalFees
+}
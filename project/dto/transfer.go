@@ -0,0 +1,17 @@
+package dto
+
+// CreateTransferRequest represents the request to move money between two accounts
+type CreateTransferRequest struct {
+	FromAccountID string  `json:"from_account_id" validate:"required"`
+	ToAccountID   string  `json:"to_account_id" validate:"required"`
+	Amount        float64 `json:"amount" validate:"required,gt=0"`
+}
+
+// TransferResponse represents a completed transfer: the withdrawal from the
+// source account, the deposit into the destination account and the balance
+// of the source account afterwards
+type TransferResponse struct {
+	Withdrawal TransactionResponse `json:"withdrawal"`
+	Deposit    TransactionResponse `json:"deposit"`
+	Balance    float64             `json:"balance"`
+}
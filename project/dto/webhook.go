@@ -0,0 +1,76 @@
+package dto
+
+import "time"
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	// DeliveryDead is a delivery that failed every attempt, it is only sent
+	// again when redelivered by hand
+	DeliveryDead = "dead"
+)
+
+// WebhookSubscriptionDTO posts the events of the listed types to a URL
+type WebhookSubscriptionDTO struct {
+	ID         string   `json:"id" bson:"_id,omitempty"`
+	URL        string   `json:"url" bson:"url"`
+	EventTypes []string `json:"event_types" bson:"event_types"`
+	// AccountID limits the subscription to the events of one account
+	AccountID string `json:"account_id,omitempty" bson:"account_id,omitempty"`
+	// Secret signs the deliveries, it is only returned when the subscription is created
+	Secret string `json:"secret,omitempty" bson:"secret"`
+
+	CreatedBy string    `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Matches reports whether the subscription wants the event. Events that
+// occurred before the subscription was created are not sent to it.
+func (s *WebhookSubscriptionDTO) Matches(event *EventDTO) bool {
+	if event.OccurredAt.Before(s.CreatedAt) {
+		return false
+	}
+	if s.AccountID != "" && s.AccountID != event.AccountID {
+		return false
+	}
+	for _, eventType := range s.EventTypes {
+		if eventType == event.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateWebhookSubscriptionRequest represents the request to subscribe a URL to events
+type CreateWebhookSubscriptionRequest struct {
+	URL        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,oneof=AccountCreated TransactionPosted TransferCompleted"`
+	AccountID  string   `json:"account_id,omitempty"`
+	// Secret defaults to a random one, returned in the response
+	Secret string `json:"secret,omitempty" validate:"omitempty,min=16"`
+}
+
+// WebhookDeliveryDTO is the delivery of an event to a subscription and the
+// outcome of its latest attempt
+type WebhookDeliveryDTO struct {
+	ID             string `json:"id" bson:"_id"`
+	SubscriptionID string `json:"subscription_id" bson:"subscription_id"`
+	EventID        string `json:"event_id" bson:"event_id"`
+	EventType      string `json:"event_type" bson:"event_type"`
+	// Body is the JSON posted to the subscription, the event as published
+	Body string `json:"body" bson:"body"`
+
+	Status   string `json:"status" bson:"status"`
+	Attempts int    `json:"attempts" bson:"attempts"`
+	// NextAttemptAt is nil once the delivery is delivered or dead
+	NextAttemptAt  *time.Time `json:"next_attempt_at" bson:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty" bson:"last_attempt_at,omitempty"`
+	LastStatusCode int        `json:"last_status_code,omitempty" bson:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
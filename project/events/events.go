@@ -0,0 +1,32 @@
+// Package events publishes the domain events recorded in the outbox. The
+// services append events in the database transaction of the change they
+// describe, and a Relay per consumer reads them in order and hands them to a
+// Sink, recording how far it got so that every event is delivered at least
+// once across restarts.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+)
+
+// New builds an event of the given type about an account. The payload is
+// stored as JSON and the event carries the request ID in ctx, if any.
+func New(ctx context.Context, eventType, accountID string, payload interface{}) (*dto.EventDTO, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	return &dto.EventDTO{
+		Type:       eventType,
+		AccountID:  accountID,
+		Payload:    data,
+		RequestID:  logger.RequestIDFromContext(ctx),
+		OccurredAt: time.Now().UTC(),
+	}, nil
+}
@@ -0,0 +1,105 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+const (
+	// DefaultInterval is how often Run polls the outbox once it is drained
+	DefaultInterval = 5 * time.Second
+	// batchSize caps the events loaded per query
+	batchSize = 100
+)
+
+// Relay delivers the outbox to a sink on behalf of a consumer. The consumer's
+// offset is only moved past an event once the sink accepted it, so an event
+// is published again after a failure or a crash between the two. A failing
+// event is retried before any later one, keeping the sink in sequence order.
+type Relay struct {
+	outbox   repository.OutboxRepository
+	consumer string
+	sink     Sink
+	interval time.Duration
+}
+
+// NewRelay creates a relay polling every DefaultInterval
+func NewRelay(outbox repository.OutboxRepository, consumer string, sink Sink) *Relay {
+	return &Relay{outbox: outbox, consumer: consumer, sink: sink, interval: DefaultInterval}
+}
+
+// SetInterval sets how often Run polls the outbox
+func (r *Relay) SetInterval(interval time.Duration) {
+	if interval > 0 {
+		r.interval = interval
+	}
+}
+
+// Run relays events until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	logger.Info("Event relay started", map[string]interface{}{
+		"consumer": r.consumer,
+		"interval": r.interval.String(),
+	})
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Drain(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("Failed to relay events", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Event relay stopped", map[string]interface{}{
+				"consumer": r.consumer,
+			})
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain publishes the events after the consumer's offset until the outbox is
+// exhausted or the sink fails, and returns how many it published
+func (r *Relay) Drain(ctx context.Context) (int, error) {
+	offset, err := r.outbox.Offset(ctx, r.consumer)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for {
+		events, err := r.outbox.After(ctx, offset, batchSize)
+		if err != nil {
+			return published, err
+		}
+
+		for _, event := range events {
+			if err := r.sink.Publish(ctx, event); err != nil {
+				logger.WarnContext(ctx, "Event delivery failed, it will be retried", map[string]interface{}{
+					"consumer":   r.consumer,
+					"event_id":   event.ID,
+					"event_type": event.Type,
+					"sequence":   event.Sequence,
+					"error":      err.Error(),
+				})
+				return published, err
+			}
+
+			if err := r.outbox.SetOffset(ctx, r.consumer, event.Sequence); err != nil {
+				return published, err
+			}
+			offset = event.Sequence
+			published++
+		}
+
+		if len(events) < batchSize {
+			return published, nil
+		}
+	}
+}
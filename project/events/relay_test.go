@@ -0,0 +1,113 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOutbox is an in-memory OutboxRepository
+type fakeOutbox struct {
+	events  []*dto.EventDTO
+	offsets map[string]int64
+}
+
+func newFakeOutbox(n int) *fakeOutbox {
+	outbox := &fakeOutbox{offsets: make(map[string]int64)}
+	for i := 0; i < n; i++ {
+		outbox.Append(context.Background(), &dto.EventDTO{Type: dto.EventTransactionPosted, AccountID: "acc-1"})
+	}
+	return outbox
+}
+
+func (o *fakeOutbox) Append(ctx context.Context, event *dto.EventDTO) error {
+	event.Sequence = int64(len(o.events) + 1)
+	event.ID = fmt.Sprintf("evt-%d", event.Sequence)
+	o.events = append(o.events, event)
+	return nil
+}
+
+func (o *fakeOutbox) After(ctx context.Context, after int64, limit int) ([]*dto.EventDTO, error) {
+	var events []*dto.EventDTO
+	for _, event := range o.events {
+		if event.Sequence > after && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (o *fakeOutbox) Offset(ctx context.Context, consumer string) (int64, error) {
+	return o.offsets[consumer], nil
+}
+
+func (o *fakeOutbox) SetOffset(ctx context.Context, consumer string, sequence int64) error {
+	o.offsets[consumer] = sequence
+	return nil
+}
+
+// recordingSink records the IDs it published and fails on the IDs in failOn
+type recordingSink struct {
+	published []string
+	failOn    map[string]bool
+}
+
+func (s *recordingSink) Publish(ctx context.Context, event *dto.EventDTO) error {
+	if s.failOn[event.ID] {
+		return errors.New("sink unavailable")
+	}
+	s.published = append(s.published, event.ID)
+	return nil
+}
+
+func TestRelayDrainsTheOutboxInOrder(t *testing.T) {
+	outbox := newFakeOutbox(batchSize + 5)
+	sink := &recordingSink{}
+
+	published, err := NewRelay(outbox, "file", sink).Drain(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, batchSize+5, published)
+	assert.Equal(t, "evt-1", sink.published[0])
+	assert.Equal(t, fmt.Sprintf("evt-%d", batchSize+5), sink.published[len(sink.published)-1])
+	assert.Equal(t, int64(batchSize+5), outbox.offsets["file"])
+}
+
+func TestRelayRetriesFailedEvents(t *testing.T) {
+	outbox := newFakeOutbox(3)
+	sink := &recordingSink{failOn: map[string]bool{"evt-2": true}}
+	relay := NewRelay(outbox, "webhook", sink)
+
+	published, err := relay.Drain(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, int64(1), outbox.offsets["webhook"], "the offset stops before the failed event")
+
+	sink.failOn = nil
+	published, err = relay.Drain(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, []string{"evt-1", "evt-2", "evt-3"}, sink.published)
+}
+
+func TestConsumersTrackTheirOwnOffsets(t *testing.T) {
+	outbox := newFakeOutbox(2)
+	first, second := &recordingSink{}, &recordingSink{}
+
+	_, err := NewRelay(outbox, "first", first).Drain(context.Background())
+	require.NoError(t, err)
+	outbox.Append(context.Background(), &dto.EventDTO{Type: dto.EventAccountCreated, AccountID: "acc-2"})
+	_, err = NewRelay(outbox, "second", second).Drain(context.Background())
+	require.NoError(t, err)
+	_, err = NewRelay(outbox, "first", first).Drain(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"evt-1", "evt-2", "evt-3"}, first.published)
+	assert.Equal(t, []string{"evt-1", "evt-2", "evt-3"}, second.published)
+	assert.Equal(t, map[string]int64{"first": 3, "second": 3}, outbox.offsets)
+}
@@ -0,0 +1,121 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+)
+
+// Sink delivers events to a downstream system. Publish may be called again
+// with an event it already delivered, consumers deduplicate on the event ID.
+type Sink interface {
+	Publish(ctx context.Context, event *dto.EventDTO) error
+}
+
+// FileSink writes each event as a line of JSON, e.g. for tests and local runs
+type FileSink struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+// NewFileSink creates a sink writing to w
+func NewFileSink(w io.Writer) *FileSink {
+	return &FileSink{writer: w}
+}
+
+// OpenFileSink creates a sink appending to the file at path
+func OpenFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	return NewFileSink(file), nil
+}
+
+func (s *FileSink) Publish(ctx context.Context, event *dto.EventDTO) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.writer.Write(append(line, '\n'))
+	return err
+}
+
+// WebhookSink posts each event as JSON to a URL. Any status other than 2xx
+// is a failed delivery.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink creates a sink posting to url, with a 10 second timeout when client is nil
+func NewWebhookSink(url string, client *http.Client) *WebhookSink {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WebhookSink{url: url, client: client}
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, event *dto.EventDTO) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", event.ID)
+	req.Header.Set("X-Event-Type", event.Type)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook delivery failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Publisher is the client of a message broker. It fits NATS (subject) and
+// Kafka (topic, with the key choosing the partition) clients alike.
+type Publisher interface {
+	Publish(ctx context.Context, subject, key string, data []byte) error
+}
+
+// BrokerSink publishes each event as JSON to the subject prefix.<type>,
+// keyed by account so a partitioned broker keeps each account's events in order
+type BrokerSink struct {
+	publisher Publisher
+	prefix    string
+}
+
+// NewBrokerSink creates a sink publishing through publisher
+func NewBrokerSink(publisher Publisher, prefix string) *BrokerSink {
+	return &BrokerSink{publisher: publisher, prefix: prefix}
+}
+
+func (s *BrokerSink) Publish(ctx context.Context, event *dto.EventDTO) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.publisher.Publish(ctx, s.prefix+"."+event.Type, event.AccountID, data)
+}
@@ -0,0 +1,86 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCarriesThePayloadAndRequestID(t *testing.T) {
+	ctx := logger.WithRequestID(context.Background(), "req-1")
+
+	event, err := New(ctx, dto.EventAccountCreated, "acc-1", dto.AccountCreatedEvent{AccountID: "acc-1", Name: "Savings"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "req-1", event.RequestID)
+	var payload dto.AccountCreatedEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	assert.Equal(t, "Savings", payload.Name)
+}
+
+func TestFileSinkWritesJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewFileSink(&buf)
+
+	require.NoError(t, sink.Publish(context.Background(), &dto.EventDTO{ID: "evt-1", Sequence: 1, Payload: []byte(`{"amount":5}`)}))
+	require.NoError(t, sink.Publish(context.Background(), &dto.EventDTO{ID: "evt-2", Sequence: 2, Payload: []byte(`{}`)}))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var event dto.EventDTO
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &event))
+	assert.Equal(t, "evt-1", event.ID)
+	assert.JSONEq(t, `{"amount":5}`, string(event.Payload))
+}
+
+func TestWebhookSink(t *testing.T) {
+	status := http.StatusNoContent
+	var received *http.Request
+	var body dto.EventDTO
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+
+	sink := NewWebhookSink(receiver.URL, receiver.Client())
+	event := &dto.EventDTO{ID: "evt-1", Type: dto.EventTransactionPosted, Payload: []byte(`{}`)}
+
+	require.NoError(t, sink.Publish(context.Background(), event))
+	assert.Equal(t, "evt-1", received.Header.Get("X-Event-ID"))
+	assert.Equal(t, dto.EventTransactionPosted, received.Header.Get("X-Event-Type"))
+	assert.Equal(t, "evt-1", body.ID)
+
+	status = http.StatusServiceUnavailable
+	assert.EqualError(t, sink.Publish(context.Background(), event), "webhook answered 503")
+}
+
+type recordingPublisher struct {
+	subject, key string
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, subject, key string, data []byte) error {
+	p.subject, p.key = subject, key
+	return nil
+}
+
+func TestBrokerSinkPublishesByTypeKeyedByAccount(t *testing.T) {
+	publisher := &recordingPublisher{}
+
+	err := NewBrokerSink(publisher, "bank").Publish(context.Background(),
+		&dto.EventDTO{ID: "evt-1", Type: dto.EventAccountCreated, AccountID: "acc-1", Payload: []byte(`{}`)})
+
+	require.NoError(t, err)
+	assert.Equal(t, "bank.AccountCreated", publisher.subject)
+	assert.Equal(t, "acc-1", publisher.key)
+}
@@ -0,0 +1,91 @@
+// Package exchange fetches currency exchange rates from exchangerate-api.com
+// for GET /exchange.
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// DefaultBaseURL is the exchangerate-api.com endpoint the latest rates of a
+// currency are fetched from, the currency is appended to it
+const DefaultBaseURL = "https://api.exchangerate-api.com/v4/latest/"
+
+// BaseURLEnv replaces DefaultBaseURL in NewClientFromEnv, e.g. with the
+// exchange-rate mocks of a Camouflage server
+const BaseURLEnv = "EXCHANGE_RATE_API_URL"
+
+// timeout bounds a call of the exchange-rate API
+const timeout = 10 * time.Second
+
+// ErrCurrencyNotFound is returned when the API has no rate to the currency
+var ErrCurrencyNotFound = errors.New("currency not found")
+
+// Client fetches exchange rates from exchangerate-api.com
+type Client struct {
+	baseURL string
+	client  *http.Client
+}
+
+// latestResponse is the part of the response of the API Rate reads
+type latestResponse struct {
+	Rates map[string]float64 `json:"rates"`
+}
+
+// NewClient creates a client calling the API with client, or with a client
+// with a 10 second timeout when client is nil. The transport of client is
+// where tests plug in httprecord.
+func NewClient(client *http.Client) *Client {
+	if client == nil {
+		client = &http.Client{Timeout: timeout}
+	}
+	return &Client{baseURL: DefaultBaseURL, client: client}
+}
+
+// NewClientFromEnv creates a client calling the API at EXCHANGE_RATE_API_URL,
+// or at DefaultBaseURL when it is not set
+func NewClientFromEnv() *Client {
+	c := NewClient(nil)
+	if baseURL := os.Getenv(BaseURLEnv); baseURL != "" {
+		c.SetBaseURL(baseURL)
+	}
+	return c
+}
+
+// SetBaseURL replaces the API endpoint, e.g. with a local server
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = baseURL
+}
+
+// Rate returns how many units of to one unit of from buys
+func (c *Client) Rate(ctx context.Context, from, to string) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+url.PathEscape(from), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("exchange rate API returned %s", resp.Status)
+	}
+	var latest latestResponse
+	if err := json.NewDecoder(resp.Body).Decode(&latest); err != nil {
+		return 0, fmt.Errorf("decoding exchange rate response: %w", err)
+	}
+
+	rate, ok := latest.Rates[to]
+	if !ok {
+		return 0, ErrCurrencyNotFound
+	}
+	return rate, nil
+}
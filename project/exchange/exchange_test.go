@@ -0,0 +1,40 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRate(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest/USD":
+			w.Write([]byte(`{"base":"USD","rates":{"USD":1,"EUR":0.923}}`))
+		case "/latest/BAD":
+			w.Write([]byte(`not json`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer api.Close()
+	client := NewClient(api.Client())
+	client.SetBaseURL(api.URL + "/latest/")
+
+	rate, err := client.Rate(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, 0.923, rate)
+
+	_, err = client.Rate(context.Background(), "USD", "XXX")
+	assert.ErrorIs(t, err, ErrCurrencyNotFound)
+
+	_, err = client.Rate(context.Background(), "XXX", "EUR")
+	assert.EqualError(t, err, "exchange rate API returned 404 Not Found")
+
+	_, err = client.Rate(context.Background(), "BAD", "EUR")
+	assert.ErrorContains(t, err, "decoding exchange rate response")
+}
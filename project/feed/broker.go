@@ -0,0 +1,92 @@
+package feed
+
+import (
+	"sync"
+
+	"github.com/gcalvocr/go-testing/dto"
+)
+
+// DefaultBuffer is how many events a subscriber may fall behind by before
+// it is dropped
+const DefaultBuffer = 256
+
+// Broker fans events out to the subscribers of this process. Publishing
+// never blocks: a subscriber whose buffer is full is dropped, its channel
+// closed, so one slow client can't hold up the others. Clients resume from
+// their last event when they reconnect.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	buffer      int
+}
+
+// Subscription receives the events published after it was made
+type Subscription struct {
+	broker    *Broker
+	accountID string
+	events    chan *dto.EventDTO
+}
+
+// NewBroker creates a broker giving each subscriber a buffer of the given size
+func NewBroker(buffer int) *Broker {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &Broker{subscribers: make(map[*Subscription]struct{}), buffer: buffer}
+}
+
+// Subscribe returns a subscription to the events of accountID, or of every
+// account when it is empty. It must be closed.
+func (b *Broker) Subscribe(accountID string) *Subscription {
+	subscription := &Subscription{broker: b, accountID: accountID, events: make(chan *dto.EventDTO, b.buffer)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[subscription] = struct{}{}
+	return subscription
+}
+
+// Publish hands the event to every subscriber that wants it
+func (b *Broker) Publish(event *dto.EventDTO) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for subscription := range b.subscribers {
+		if subscription.accountID != "" && subscription.accountID != event.AccountID {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			b.drop(subscription)
+		}
+	}
+}
+
+// Subscribers returns how many subscriptions are open
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// drop removes a subscription and closes its channel, b.mu must be held
+func (b *Broker) drop(subscription *Subscription) {
+	if _, ok := b.subscribers[subscription]; ok {
+		delete(b.subscribers, subscription)
+		close(subscription.events)
+	}
+}
+
+// Events returns the channel the events are delivered on. It is closed when
+// the subscription is closed or dropped for falling behind.
+func (s *Subscription) Events() <-chan *dto.EventDTO {
+	return s.events
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.drop(s)
+}
@@ -0,0 +1,81 @@
+package feed
+
+import (
+	"testing"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func event(sequence int64, accountID string) *dto.EventDTO {
+	return &dto.EventDTO{Sequence: sequence, Type: dto.EventTransactionPosted, AccountID: accountID}
+}
+
+// received drains the events buffered for a subscription
+func received(subscription *Subscription) []int64 {
+	var sequences []int64
+	for {
+		select {
+		case e, ok := <-subscription.Events():
+			if !ok {
+				return sequences
+			}
+			sequences = append(sequences, e.Sequence)
+		default:
+			return sequences
+		}
+	}
+}
+
+func TestBrokerFansOutByAccount(t *testing.T) {
+	broker := NewBroker(10)
+	all := broker.Subscribe("")
+	acc1 := broker.Subscribe("acc-1")
+	acc2 := broker.Subscribe("acc-2")
+	defer all.Close()
+	defer acc1.Close()
+	defer acc2.Close()
+
+	broker.Publish(event(1, "acc-1"))
+	broker.Publish(event(2, "acc-2"))
+	broker.Publish(event(3, "acc-1"))
+
+	assert.Equal(t, []int64{1, 2, 3}, received(all))
+	assert.Equal(t, []int64{1, 3}, received(acc1))
+	assert.Equal(t, []int64{2}, received(acc2))
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	broker := NewBroker(2)
+	slow := broker.Subscribe("")
+	fast := broker.Subscribe("")
+	defer fast.Close()
+
+	for i := int64(1); i <= 3; i++ {
+		broker.Publish(event(i, "acc-1"))
+		if i < 3 {
+			<-fast.Events()
+		}
+	}
+
+	assert.Equal(t, []int64{1, 2}, received(slow))
+	_, open := <-slow.Events()
+	assert.False(t, open, "the slow subscriber's channel is closed")
+	assert.Equal(t, []int64{3}, received(fast), "the others keep receiving")
+	assert.Equal(t, 1, broker.Subscribers())
+
+	slow.Close() // Closing a dropped subscription is harmless
+}
+
+func TestClosedSubscriptionsReceiveNothing(t *testing.T) {
+	broker := NewBroker(10)
+	subscription := broker.Subscribe("")
+	subscription.Close()
+
+	broker.Publish(event(1, "acc-1"))
+
+	_, open := <-subscription.Events()
+	require.False(t, open)
+	assert.Zero(t, broker.Subscribers())
+}
@@ -0,0 +1,153 @@
+// Package feed streams the domain events of the outbox to live clients. A
+// Feed follows the outbox, through MongoDB change streams or PostgreSQL
+// notifications, and publishes each event to a Broker that fans it out to
+// the connected clients. Clients resume after the last event they received
+// by reading the missed events from the outbox first.
+package feed
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+// ErrDropped is returned by Stream when the client fell too far behind
+// the live events; it should reconnect and resume from its last event
+var ErrDropped = errors.New("event stream subscriber fell behind")
+
+// retryDelay is how long Run waits before watching again after the watch failed
+const retryDelay = 5 * time.Second
+
+// backfillBatch is how many missed events Stream reads per query
+const backfillBatch = 100
+
+// Writer is the client end of a stream
+type Writer interface {
+	WriteEvent(event *dto.EventDTO) error
+	// WriteKeepAlive keeps idle connections from being closed by proxies
+	WriteKeepAlive() error
+}
+
+// Feed publishes the outbox to its broker and streams it to clients
+type Feed struct {
+	outbox repository.OutboxRepository
+	source repository.OutboxFeed
+	broker *Broker
+}
+
+// NewFeed creates a feed following source and reading missed events from outbox
+func NewFeed(outbox repository.OutboxRepository, source repository.OutboxFeed, broker *Broker) *Feed {
+	return &Feed{outbox: outbox, source: source, broker: broker}
+}
+
+// Broker returns the broker the feed publishes to
+func (f *Feed) Broker() *Broker {
+	return f.broker
+}
+
+// Run publishes the events appended to the outbox from now on until ctx is
+// cancelled. When the watch fails it is resumed after the last published
+// event.
+func (f *Feed) Run(ctx context.Context) {
+	logger.Info("Event feed started", nil)
+
+	var after int64 = -1
+	for {
+		if after < 0 {
+			last, err := f.source.Last(ctx)
+			if err == nil {
+				after = last
+			} else if ctx.Err() == nil {
+				logger.Error("Failed to find the end of the outbox", err)
+			}
+		}
+
+		if after >= 0 {
+			err := f.source.Watch(ctx, after, func(event *dto.EventDTO) error {
+				f.broker.Publish(event)
+				after = event.Sequence
+				return nil
+			})
+			if err != nil && ctx.Err() == nil {
+				logger.Error("Event feed watch failed, resuming", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Event feed stopped", nil)
+			return
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+// Stream writes the events of accountID, or of every account when it is
+// empty, until ctx is cancelled. With a positive after, the events after
+// that sequence are read from the outbox first, then the live ones follow
+// without gaps or repeats. A keep-alive is written after every keepAlive
+// without events.
+func (f *Feed) Stream(ctx context.Context, accountID string, after int64, keepAlive time.Duration, w Writer) error {
+	// Subscribing before reading the outbox leaves no gap between the two
+	subscription := f.broker.Subscribe(accountID)
+	defer subscription.Close()
+
+	if after > 0 {
+		var err error
+		if after, err = f.backfill(ctx, accountID, after, w); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := w.WriteKeepAlive(); err != nil {
+				return err
+			}
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return ErrDropped
+			}
+			if event.Sequence <= after {
+				continue // Already sent from the outbox
+			}
+			if err := w.WriteEvent(event); err != nil {
+				return err
+			}
+			after = event.Sequence
+			ticker.Reset(keepAlive)
+		}
+	}
+}
+
+// backfill writes the events of accountID after the sequence and returns
+// the last sequence read
+func (f *Feed) backfill(ctx context.Context, accountID string, after int64, w Writer) (int64, error) {
+	for {
+		events, err := f.outbox.After(ctx, after, backfillBatch)
+		if err != nil {
+			return after, err
+		}
+		for _, event := range events {
+			if accountID == "" || event.AccountID == accountID {
+				if err := w.WriteEvent(event); err != nil {
+					return after, err
+				}
+			}
+			after = event.Sequence
+		}
+		if len(events) < backfillBatch {
+			return after, nil
+		}
+	}
+}
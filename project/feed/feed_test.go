@@ -0,0 +1,210 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOutbox is an in-memory outbox whose Watch follows the events added
+// with append
+type fakeOutbox struct {
+	mu       sync.Mutex
+	events   []*dto.EventDTO
+	appended chan *dto.EventDTO
+	watching bool
+}
+
+func newFakeOutbox(accountIDs ...string) *fakeOutbox {
+	outbox := &fakeOutbox{appended: make(chan *dto.EventDTO, 10)}
+	for i, accountID := range accountIDs {
+		outbox.events = append(outbox.events, event(int64(i+1), accountID))
+	}
+	return outbox
+}
+
+func (o *fakeOutbox) append(accountID string) *dto.EventDTO {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	e := event(int64(len(o.events)+1), accountID)
+	o.events = append(o.events, e)
+	o.appended <- e
+	return e
+}
+
+func (o *fakeOutbox) Append(ctx context.Context, event *dto.EventDTO) error {
+	return errors.New("not used")
+}
+
+func (o *fakeOutbox) After(ctx context.Context, after int64, limit int) ([]*dto.EventDTO, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var events []*dto.EventDTO
+	for _, e := range o.events {
+		if e.Sequence > after && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (o *fakeOutbox) Offset(ctx context.Context, consumer string) (int64, error) { return 0, nil }
+
+func (o *fakeOutbox) SetOffset(ctx context.Context, consumer string, sequence int64) error {
+	return nil
+}
+
+func (o *fakeOutbox) Last(ctx context.Context) (int64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return int64(len(o.events)), nil
+}
+
+func (o *fakeOutbox) isWatched() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.watching
+}
+
+func (o *fakeOutbox) Watch(ctx context.Context, after int64, fn func(*dto.EventDTO) error) error {
+	o.mu.Lock()
+	o.watching = true
+	o.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e := <-o.appended:
+			if e.Sequence > after {
+				if err := fn(e); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// recordingWriter records the sequences written and cancels the stream
+// once it has want of them
+type recordingWriter struct {
+	sequences  []int64
+	keepAlives int
+	want       int
+	cancel     context.CancelFunc
+}
+
+func (w *recordingWriter) WriteEvent(event *dto.EventDTO) error {
+	w.sequences = append(w.sequences, event.Sequence)
+	if len(w.sequences) == w.want {
+		w.cancel()
+	}
+	return nil
+}
+
+func (w *recordingWriter) WriteKeepAlive() error {
+	w.keepAlives++
+	return nil
+}
+
+func TestStreamResumesWithoutGapsOrRepeats(t *testing.T) {
+	outbox := newFakeOutbox("acc-1", "acc-2", "acc-1", "acc-1")
+	feed := NewFeed(outbox, outbox, NewBroker(10))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w := &recordingWriter{want: 4, cancel: cancel}
+
+	done := make(chan error)
+	go func() { done <- feed.Stream(ctx, "acc-1", 1, time.Minute, w) }()
+
+	require.Eventually(t, func() bool { return feed.Broker().Subscribers() == 1 }, time.Second, time.Millisecond)
+	// The broker may publish an event the backfill already read
+	feed.Broker().Publish(event(4, "acc-1"))
+	feed.Broker().Publish(outbox.append("acc-2"))
+	feed.Broker().Publish(outbox.append("acc-1"))
+	feed.Broker().Publish(outbox.append("acc-1"))
+
+	require.NoError(t, <-done)
+	assert.Equal(t, []int64{3, 4, 6, 7}, w.sequences)
+	assert.Zero(t, feed.Broker().Subscribers(), "the subscription is closed")
+}
+
+func TestStreamWithoutLastEventIDOnlySendsLiveEvents(t *testing.T) {
+	outbox := newFakeOutbox("acc-1", "acc-1")
+	feed := NewFeed(outbox, outbox, NewBroker(10))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w := &recordingWriter{want: 1, cancel: cancel}
+
+	done := make(chan error)
+	go func() { done <- feed.Stream(ctx, "", 0, time.Minute, w) }()
+	require.Eventually(t, func() bool { return feed.Broker().Subscribers() == 1 }, time.Second, time.Millisecond)
+	feed.Broker().Publish(outbox.append("acc-2"))
+
+	require.NoError(t, <-done)
+	assert.Equal(t, []int64{3}, w.sequences)
+}
+
+func TestStreamSendsKeepAlives(t *testing.T) {
+	outbox := newFakeOutbox()
+	feed := NewFeed(outbox, outbox, NewBroker(10))
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	w := &recordingWriter{}
+
+	require.NoError(t, feed.Stream(ctx, "", 0, 5*time.Millisecond, w))
+	assert.Positive(t, w.keepAlives)
+}
+
+func TestStreamEndsWhenTheSubscriberIsDropped(t *testing.T) {
+	outbox := newFakeOutbox()
+	feed := NewFeed(outbox, outbox, NewBroker(1))
+	w := blockingWriter{&recordingWriter{}, make(chan struct{})}
+
+	done := make(chan error)
+	go func() { done <- feed.Stream(context.Background(), "", 0, time.Minute, w) }()
+	require.Eventually(t, func() bool { return feed.Broker().Subscribers() == 1 }, time.Second, time.Millisecond)
+	for i := int64(1); i <= 3; i++ {
+		feed.Broker().Publish(event(i, "acc-1"))
+	}
+	close(w.unblock)
+
+	assert.True(t, errors.Is(<-done, ErrDropped))
+}
+
+// blockingWriter holds up the events until unblock is closed
+type blockingWriter struct {
+	*recordingWriter
+	unblock chan struct{}
+}
+
+func (w blockingWriter) WriteEvent(event *dto.EventDTO) error {
+	<-w.unblock
+	return w.recordingWriter.WriteEvent(event)
+}
+
+func TestRunPublishesAppendedEvents(t *testing.T) {
+	outbox := newFakeOutbox("acc-1")
+	broker := NewBroker(10)
+	subscription := broker.Subscribe("")
+	defer subscription.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go NewFeed(outbox, outbox, broker).Run(ctx)
+	require.Eventually(t, outbox.isWatched, time.Second, time.Millisecond)
+	outbox.append("acc-1")
+
+	select {
+	case e := <-subscription.Events():
+		assert.Equal(t, int64(2), e.Sequence)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event published")
+	}
+}
@@ -0,0 +1,200 @@
+package grpcapi
+
+import (
+	"context"
+	"math"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/authz"
+	"github.com/gcalvocr/go-testing/bankpb"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/product"
+	"github.com/gcalvocr/go-testing/repository"
+	"github.com/gcalvocr/go-testing/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// accountServer implements bankpb.AccountServiceServer like the account handlers
+type accountServer struct {
+	bankpb.UnimplementedAccountServiceServer
+	config Config
+	// transcoded is set on the gateway's server. The REST route never
+	// validated CreateAccount requests and its clients keep that behaviour.
+	transcoded bool
+}
+
+func (s *accountServer) CreateAccount(ctx context.Context, req *bankpb.CreateAccountRequest) (*bankpb.Account, error) {
+	create := dto.CreateAccountRequest{
+		Name:     req.GetName(),
+		Balance:  req.GetBalance(),
+		Currency: req.GetCurrency(),
+		OwnerID:  req.GetOwnerId(),
+		Product:  req.GetProduct(),
+	}
+	if !s.transcoded {
+		if err := service.Validate(create); err != nil {
+			return nil, statusFromError(ctx, err, "Failed to create account")
+		}
+	}
+
+	if create.Product == "" {
+		create.Product = product.Default
+	}
+	if _, ok := s.catalog().Lookup(create.Product); !ok {
+		return nil, status.Error(codes.InvalidArgument, "Unknown product "+create.Product)
+	}
+
+	// Accounts belong to the caller unless staff open one for a customer
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if create.OwnerID == "" && principal != nil {
+		create.OwnerID = principal.ID
+	}
+	if !authz.CanCreateAccountFor(principal, create.OwnerID) {
+		return nil, permissionDenied(ctx, "Principal may not open accounts for another owner")
+	}
+
+	if s.config.AccountService == nil {
+		logger.ErrorContext(ctx, "Account service not initialized", nil)
+		return nil, status.Error(codes.Internal, "Database not available")
+	}
+
+	account := &dto.AccountDTO{
+		Name:     create.Name,
+		Balance:  create.Balance,
+		Currency: create.Currency,
+		OwnerID:  create.OwnerID,
+		Product:  create.Product,
+	}
+	if err := s.config.AccountService.Create(ctx, account); err != nil {
+		return nil, status.Error(codes.Internal, "Failed to create account")
+	}
+
+	logger.InfoContext(ctx, "Account created successfully", map[string]interface{}{
+		"account_id": account.ID,
+		"name":       account.Name,
+	})
+	return s.account(ctx, account)
+}
+
+func (s *accountServer) GetAccount(ctx context.Context, req *bankpb.GetAccountRequest) (*bankpb.Account, error) {
+	account, err := findAccount(ctx, s.config, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return s.account(ctx, account)
+}
+
+func (s *accountServer) ListAccounts(ctx context.Context, req *bankpb.ListAccountsRequest) (*bankpb.ListAccountsResponse, error) {
+	if s.config.Accounts == nil {
+		logger.ErrorContext(ctx, "Account repository not initialized", nil)
+		return nil, status.Error(codes.Internal, "Database not available")
+	}
+
+	// Staff see every account, everyone else only the accounts they own
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if principal == nil {
+		return nil, permissionDenied(ctx, "No authenticated principal")
+	}
+
+	size, after, err := pageRequest(req.GetPageSize(), req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+
+	ownerID := principal.ID
+	if authz.CanListAllAccounts(principal) {
+		ownerID = ""
+	}
+	accounts, err := s.config.Accounts.GetPage(ctx, ownerID, after, fetchLimit(size))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get accounts", err)
+		return nil, status.Error(codes.Internal, "Failed to retrieve accounts")
+	}
+	accounts, next := cutPage(accounts, size, func(account *dto.AccountDTO) repository.Cursor {
+		return repository.Cursor{CreatedAt: account.CreatedAt, ID: account.ID}
+	})
+
+	response := &bankpb.ListAccountsResponse{NextPageToken: next}
+	for _, account := range accounts {
+		message, err := s.account(ctx, account)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "Failed to retrieve accounts")
+		}
+		response.Accounts = append(response.Accounts, message)
+	}
+	return response, nil
+}
+
+func (s *accountServer) catalog() product.Catalog {
+	if s.config.Catalog == nil {
+		return product.DefaultCatalog()
+	}
+	return s.config.Catalog
+}
+
+// account converts an account to its message, the available balance is the
+// balance minus the account's active holds
+func (s *accountServer) account(ctx context.Context, account *dto.AccountDTO) (*bankpb.Account, error) {
+	var held float64
+	if s.config.TransactionService != nil {
+		var err error
+		if held, err = s.config.TransactionService.Held(ctx, account.ID); err != nil {
+			return nil, status.Error(codes.Internal, "Failed to retrieve account")
+		}
+	}
+
+	productCode := account.Product
+	if productCode == "" {
+		productCode = product.Default
+	}
+
+	return &bankpb.Account{
+		Id:               account.ID,
+		Name:             account.Name,
+		Balance:          account.Balance,
+		AvailableBalance: math.Round((account.Balance-held)*100) / 100,
+		Currency:         account.Currency,
+		OwnerId:          account.OwnerID,
+		Product:          productCode,
+		Limits: &bankpb.AccountLimits{
+			OverdraftLimit:       account.Limits.OverdraftLimit,
+			MinimumBalance:       account.Limits.MinimumBalance,
+			DailyWithdrawalLimit: account.Limits.DailyWithdrawalLimit,
+			MaxTransactionAmount: account.Limits.MaxTransactionAmount,
+		},
+		CreatedAt: timestamppb.New(account.CreatedAt),
+		UpdatedAt: timestamppb.New(account.UpdatedAt),
+	}, nil
+}
+
+// findAccount loads an account the principal in ctx may view
+func findAccount(ctx context.Context, config Config, id string) (*dto.AccountDTO, error) {
+	if config.Accounts == nil {
+		logger.ErrorContext(ctx, "Account repository not initialized", nil)
+		return nil, status.Error(codes.Internal, "Database not available")
+	}
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "account id is required")
+	}
+
+	account, err := config.Accounts.GetByID(ctx, id)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get account", err)
+		return nil, status.Error(codes.Internal, "Failed to retrieve account")
+	}
+	if account == nil {
+		logger.WarnContext(ctx, "Account not found", map[string]interface{}{
+			"account_id": id,
+		})
+		return nil, status.Error(codes.NotFound, "Account not found")
+	}
+
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if !authz.CanViewAccount(principal, account) {
+		return nil, permissionDenied(ctx, "Principal may not view account")
+	}
+	return account, nil
+}
@@ -0,0 +1,63 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/policy"
+	"github.com/gcalvocr/go-testing/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain identifies the bank in the ErrorInfo of policy violations
+const errorDomain = "bank.gcalvocr.github.com"
+
+// statusFromError maps a domain error to a gRPC status, like
+// writeTransactionError maps it to an HTTP status:
+//
+//	*service.ValidationError, ErrInvalidTransactionType  InvalidArgument
+//	ErrAccountNotFound                                   NotFound
+//	ErrForbidden                                         PermissionDenied
+//	*policy.Violation                                    FailedPrecondition
+//
+// A violation carries an ErrorInfo whose reason is its code, e.g.
+// insufficient_funds. Any other error is Internal with the message given.
+func statusFromError(ctx context.Context, err error, message string) error {
+	var validationErr *service.ValidationError
+	var violation *policy.Violation
+	switch {
+	case errors.As(err, &validationErr):
+		return status.Error(codes.InvalidArgument, validationErr.Error())
+	case errors.Is(err, service.ErrInvalidTransactionType):
+		return status.Error(codes.InvalidArgument, "Invalid transaction type")
+	case errors.Is(err, service.ErrAccountNotFound):
+		return status.Error(codes.NotFound, "Account not found")
+	case errors.Is(err, service.ErrForbidden):
+		return permissionDenied(ctx, "Principal may not transact on account")
+	case errors.As(err, &violation):
+		st, detailErr := status.New(codes.FailedPrecondition, violation.Message).WithDetails(&errdetails.ErrorInfo{
+			Reason: violation.Code,
+			Domain: errorDomain,
+		})
+		if detailErr != nil {
+			return status.Error(codes.FailedPrecondition, violation.Message)
+		}
+		return st.Err()
+	default:
+		return status.Error(codes.Internal, message)
+	}
+}
+
+// permissionDenied rejects a call the authorization policy denied
+func permissionDenied(ctx context.Context, reason string) error {
+	fields := map[string]interface{}{"reason": reason}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		fields["principal_id"] = principal.ID
+	}
+	logger.WarnContext(ctx, "Request forbidden", fields)
+	return status.Error(codes.PermissionDenied, "Forbidden")
+}
@@ -0,0 +1,186 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/feed"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+// fakeBank is an in-memory bank behind the real services, safe for the
+// concurrent calls of a gRPC server. The service package tests the services
+// themselves, so the fake repositories only have the methods the RPCs reach,
+// the rest panic.
+type fakeBank struct {
+	mu           sync.Mutex
+	accounts     map[string]*dto.AccountDTO
+	transactions []*dto.TransactionDTO
+	events       []*dto.EventDTO
+
+	// broker receives every appended event, like from a running feed
+	broker *feed.Broker
+}
+
+func newFakeBank(accounts ...*dto.AccountDTO) *fakeBank {
+	bank := &fakeBank{accounts: make(map[string]*dto.AccountDTO), broker: feed.NewBroker(feed.DefaultBuffer)}
+	for _, account := range accounts {
+		bank.accounts[account.ID] = account
+	}
+	return bank
+}
+
+type fakeAccounts struct {
+	repository.AccountRepository
+	bank *fakeBank
+}
+
+func (r fakeAccounts) Create(ctx context.Context, account *dto.AccountDTO) error {
+	r.bank.mu.Lock()
+	defer r.bank.mu.Unlock()
+	account.ID = fmt.Sprintf("acc-%d", len(r.bank.accounts)+1)
+	account.CreatedAt = time.Now()
+	account.UpdatedAt = account.CreatedAt
+	r.bank.accounts[account.ID] = account
+	return nil
+}
+
+func (r fakeAccounts) GetByID(ctx context.Context, id string) (*dto.AccountDTO, error) {
+	r.bank.mu.Lock()
+	defer r.bank.mu.Unlock()
+	account, ok := r.bank.accounts[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *account
+	return &copied, nil
+}
+
+// GetPage sorts every time, the fake holds a handful of accounts
+func (r fakeAccounts) GetPage(ctx context.Context, ownerID string, after *repository.Cursor, limit int) ([]*dto.AccountDTO, error) {
+	r.bank.mu.Lock()
+	defer r.bank.mu.Unlock()
+	var accounts []*dto.AccountDTO
+	for _, account := range r.bank.accounts {
+		if ownerID == "" || account.OwnerID == ownerID {
+			copied := *account
+			accounts = append(accounts, &copied)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return before(accounts[i].CreatedAt, accounts[i].ID, accounts[j].CreatedAt, accounts[j].ID)
+	})
+
+	var page []*dto.AccountDTO
+	for _, account := range accounts {
+		if after != nil && !before(after.CreatedAt, after.ID, account.CreatedAt, account.ID) {
+			continue
+		}
+		if limit > 0 && len(page) == limit {
+			break
+		}
+		page = append(page, account)
+	}
+	return page, nil
+}
+
+// before reports whether the item created at a with ID aID is listed before
+// the one created at b with ID bID, in creation order
+func before(a time.Time, aID string, b time.Time, bID string) bool {
+	if !a.Equal(b) {
+		return a.Before(b)
+	}
+	return aID < bID
+}
+
+func (r fakeAccounts) UpdateBalance(ctx context.Context, id string, newBalance float64) error {
+	r.bank.mu.Lock()
+	defer r.bank.mu.Unlock()
+	r.bank.accounts[id].Balance = newBalance
+	return nil
+}
+
+type fakeTransactions struct {
+	repository.TransactionRepository
+	bank *fakeBank
+}
+
+func (r fakeTransactions) Create(ctx context.Context, transaction *dto.TransactionDTO) error {
+	r.bank.mu.Lock()
+	defer r.bank.mu.Unlock()
+	transaction.ID = fmt.Sprintf("tx-%d", len(r.bank.transactions)+1)
+	transaction.CreatedAt = time.Now()
+	transaction.UpdatedAt = transaction.CreatedAt
+	r.bank.transactions = append(r.bank.transactions, transaction)
+	return nil
+}
+
+func (r fakeTransactions) GetByID(ctx context.Context, id string) (*dto.TransactionDTO, error) {
+	r.bank.mu.Lock()
+	defer r.bank.mu.Unlock()
+	for _, transaction := range r.bank.transactions {
+		if transaction.ID == id {
+			return transaction, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r fakeTransactions) GetPageByAccountID(ctx context.Context, accountID string, after *repository.Cursor, limit int) ([]*dto.TransactionDTO, error) {
+	r.bank.mu.Lock()
+	defer r.bank.mu.Unlock()
+	var transactions []*dto.TransactionDTO
+	for _, transaction := range r.bank.transactions {
+		if transaction.AccountID == accountID {
+			transactions = append(transactions, transaction)
+		}
+	}
+	// Newest first
+	sort.Slice(transactions, func(i, j int) bool {
+		return before(transactions[j].CreatedAt, transactions[j].ID, transactions[i].CreatedAt, transactions[i].ID)
+	})
+
+	var page []*dto.TransactionDTO
+	for _, transaction := range transactions {
+		if after != nil && !before(transaction.CreatedAt, transaction.ID, after.CreatedAt, after.ID) {
+			continue
+		}
+		if limit > 0 && len(page) == limit {
+			break
+		}
+		page = append(page, transaction)
+	}
+	return page, nil
+}
+
+// fakeOutbox stores the events and publishes them to the bank's broker
+type fakeOutbox struct {
+	repository.OutboxRepository
+	bank *fakeBank
+}
+
+func (o fakeOutbox) Append(ctx context.Context, event *dto.EventDTO) error {
+	o.bank.mu.Lock()
+	event.Sequence = int64(len(o.bank.events) + 1)
+	o.bank.events = append(o.bank.events, event)
+	o.bank.mu.Unlock()
+
+	o.bank.broker.Publish(event)
+	return nil
+}
+
+func (o fakeOutbox) After(ctx context.Context, after int64, limit int) ([]*dto.EventDTO, error) {
+	o.bank.mu.Lock()
+	defer o.bank.mu.Unlock()
+	var events []*dto.EventDTO
+	for _, event := range o.bank.events {
+		if event.Sequence > after && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,216 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gcalvocr/go-testing/bankpb"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/middleware"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// NextPageTokenHeader carries the next_page_token of a transcoded list, whose
+// body is only the array of items
+const NextPageTokenHeader = "X-Next-Page-Token"
+
+// createdMethods answer 201 Created when transcoded
+var createdMethods = map[string]bool{
+	bankpb.AccountService_CreateAccount_FullMethodName:       true,
+	bankpb.TransactionService_PostTransaction_FullMethodName: true,
+}
+
+// NewGateway returns the handler serving the REST routes annotated in
+// bank.proto by transcoding them to the same services NewServer registers.
+// The calls are made in process, so the principal and request ID the HTTP
+// middleware stored in the request context reach the services unchanged.
+//
+// The JSON is the one the handlers wrote: accounts and transactions are
+// encoded through their dto response types, unknown request fields are
+// ignored and errors are written like the handlers write them. The REST
+// routes accept the requests the handlers accepted, so CreateAccount skips
+// the validation of the native gRPC call.
+func NewGateway(config Config) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &restMarshaler{JSONPb: runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}}),
+		runtime.WithForwardResponseOption(forwardResponse),
+		runtime.WithErrorHandler(writeError),
+	)
+
+	ctx := context.Background()
+	if err := bankpb.RegisterAccountServiceHandlerServer(ctx, mux, &accountServer{config: config, transcoded: true}); err != nil {
+		return nil, err
+	}
+	if err := bankpb.RegisterTransactionServiceHandlerServer(ctx, mux, &transactionServer{config: config}); err != nil {
+		return nil, err
+	}
+	return readBody(mux), nil
+}
+
+// readBody rejects request bodies over the body limit and malformed JSON
+// before they are transcoded, with the errors the handlers returned
+func readBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err == nil && !json.Valid(body) {
+			err = errors.New("body is not a JSON document")
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "Failed to decode request JSON", err)
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// errInvalidJSON is reported for request fields of the wrong type, which
+// the handlers failed to decode
+var errInvalidJSON = errors.New("Invalid JSON format")
+
+// restMarshaler decodes requests with protojson and encodes the account and
+// transaction messages with encoding/json through their dto response types,
+// so timestamps and spacing are byte for byte the ones of the handlers
+// rather than protojson's
+type restMarshaler struct {
+	runtime.JSONPb
+}
+
+// Marshal encodes the REST responses like the handlers, other values like JSONPb
+func (m *restMarshaler) Marshal(v interface{}) ([]byte, error) {
+	response, ok := restResponse(v)
+	if !ok {
+		return m.JSONPb.Marshal(v)
+	}
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(response)
+	return buf.Bytes(), err
+}
+
+// NewDecoder reports a body that doesn't fit the request message as invalid
+// JSON, like the handlers did, instead of with the protojson error
+func (m *restMarshaler) NewDecoder(r io.Reader) runtime.Decoder {
+	decoder := m.JSONPb.NewDecoder(r)
+	return runtime.DecoderFunc(func(v interface{}) error {
+		err := decoder.Decode(v)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return errInvalidJSON
+		}
+		return err
+	})
+}
+
+// restResponse converts the messages the REST routes return to their dto
+// response types
+func restResponse(v interface{}) (interface{}, bool) {
+	switch message := v.(type) {
+	case *bankpb.Account:
+		return accountResponse(message), true
+	case []*bankpb.Account:
+		accounts := make([]dto.AccountResponse, len(message))
+		for i, account := range message {
+			accounts[i] = accountResponse(account)
+		}
+		return accounts, true
+	case *bankpb.Transaction:
+		return transactionResponse(message), true
+	case []*bankpb.Transaction:
+		transactions := make([]dto.TransactionResponse, len(message))
+		for i, transaction := range message {
+			transactions[i] = transactionResponse(transaction)
+		}
+		return transactions, true
+	}
+	return nil, false
+}
+
+func accountResponse(account *bankpb.Account) dto.AccountResponse {
+	var limits dto.AccountLimits
+	if l := account.GetLimits(); l != nil {
+		limits = dto.AccountLimits{
+			OverdraftLimit:       l.OverdraftLimit,
+			MinimumBalance:       l.MinimumBalance,
+			DailyWithdrawalLimit: l.DailyWithdrawalLimit,
+			MaxTransactionAmount: l.MaxTransactionAmount,
+		}
+	}
+
+	return dto.AccountResponse{
+		ID:               account.GetId(),
+		Name:             account.GetName(),
+		Balance:          account.GetBalance(),
+		Currency:         account.GetCurrency(),
+		OwnerID:          account.GetOwnerId(),
+		CreatedAt:        account.GetCreatedAt().AsTime(),
+		UpdatedAt:        account.GetUpdatedAt().AsTime(),
+		AvailableBalance: account.GetAvailableBalance(),
+		Product:          account.GetProduct(),
+		Limits:           limits,
+	}
+}
+
+func transactionResponse(transaction *bankpb.Transaction) dto.TransactionResponse {
+	return dto.TransactionResponse{
+		ID:        transaction.GetId(),
+		AccountID: transaction.GetAccountId(),
+		Amount:    transaction.GetAmount(),
+		Type:      transaction.GetType(),
+		CreatedAt: transaction.GetCreatedAt().AsTime(),
+		UpdatedAt: transaction.GetUpdatedAt().AsTime(),
+	}
+}
+
+// forwardResponse sets the status of created resources and the next page
+// header of lists before the response body is written
+func forwardResponse(ctx context.Context, w http.ResponseWriter, resp proto.Message) error {
+	if paged, ok := resp.(interface{ GetNextPageToken() string }); ok && paged.GetNextPageToken() != "" {
+		w.Header().Set(NextPageTokenHeader, paged.GetNextPageToken())
+	}
+	if method, ok := runtime.RPCMethod(ctx); ok && createdMethods[method] {
+		w.WriteHeader(http.StatusCreated)
+	}
+	return nil
+}
+
+// writeError writes a service error like the handlers do: plain text with
+// the HTTP status of the code, and for policy violations a 422 with the JSON
+// error code
+func writeError(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	st := status.Convert(err)
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && st.Code() == codes.FailedPrecondition {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(middleware.ErrorResponse{Error: info.Reason, Message: st.Message()})
+			return
+		}
+	}
+
+	http.Error(w, st.Message(), runtime.HTTPStatusFromCode(st.Code()))
+}
@@ -0,0 +1,183 @@
+package grpcapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newGateway serves the REST routes of bank.proto over the fakes of bank
+func newGateway(t *testing.T, bank *fakeBank) http.Handler {
+	accounts, transactions, outbox := fakeAccounts{bank: bank}, fakeTransactions{bank: bank}, fakeOutbox{bank: bank}
+
+	accountService := service.NewAccountService(accounts, nil)
+	accountService.SetOutbox(outbox)
+	transactionService := service.NewTransactionService(accounts, transactions, nil)
+	transactionService.SetOutbox(outbox)
+
+	gateway, err := NewGateway(Config{
+		Accounts:           accounts,
+		Transactions:       transactions,
+		AccountService:     accountService,
+		TransactionService: transactionService,
+	})
+	require.NoError(t, err)
+	return gateway
+}
+
+// serve sends a request to the gateway as a customer, like the auth
+// middleware would have authenticated it
+func serve(gateway http.Handler, principalID, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{ID: principalID, Roles: []string{"customer"}}))
+
+	rr := httptest.NewRecorder()
+	gateway.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestGatewayKeepsTheShapeOfTheRESTAPI(t *testing.T) {
+	gateway := newGateway(t, newFakeBank())
+
+	rr := serve(gateway, "alice", "POST", "/accounts", `{"name": "Savings", "balance": 100, "currency": "USD", "nickname": "ignored"}`)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var account dto.AccountResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &account))
+	assert.Equal(t, "alice", account.OwnerID)
+	assert.Equal(t, 100.0, account.AvailableBalance)
+	assert.Equal(t, "checking", account.Product)
+	assert.Contains(t, rr.Body.String(), `"overdraft_limit":0`, "zero fields are written")
+
+	rr = serve(gateway, "alice", "GET", "/accounts/"+account.ID, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"name":"Savings"`)
+
+	rr = serve(gateway, "alice", "POST", "/transactions", `{"account_id": "`+account.ID+`", "amount": 25, "type": "deposit"}`)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var transaction dto.TransactionResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &transaction))
+	assert.Equal(t, account.ID, transaction.AccountID)
+	assert.NotContains(t, rr.Body.String(), "balance", "the body is the transaction alone")
+
+	rr = serve(gateway, "alice", "GET", "/transactions/"+transaction.ID, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"type":"deposit"`)
+
+	rr = serve(gateway, "alice", "GET", "/accounts/"+account.ID+"/transactions", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var transactions []dto.TransactionResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &transactions))
+	assert.Len(t, transactions, 1)
+
+	rr = serve(gateway, "bob", "GET", "/accounts", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[]`, rr.Body.String(), "an empty list is an empty array")
+}
+
+func TestGatewayCreatesAccountsLikeTheHandler(t *testing.T) {
+	gateway := newGateway(t, newFakeBank())
+
+	// The handler created accounts without validating the request, the
+	// native gRPC call rejects this one
+	rr := serve(gateway, "alice", "POST", "/accounts", `{"balance": 10}`)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+	var account dto.AccountResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &account))
+	assert.Empty(t, account.Name)
+	assert.Equal(t, 10.0, account.Balance)
+}
+
+func TestGatewayEncodesLikeTheHandlers(t *testing.T) {
+	// protojson would write 12:00:00.100Z, with spaces between the fields
+	created := time.Date(2025, 3, 10, 12, 0, 0, 100000000, time.UTC)
+	gateway := newGateway(t, newFakeBank(&dto.AccountDTO{
+		ID: "acc-1", Name: "Savings", OwnerID: "alice", Currency: "USD", Balance: 50, Product: "savings",
+		CreatedAt: created, UpdatedAt: created,
+	}))
+
+	rr := serve(gateway, "alice", "GET", "/accounts/acc-1", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var want bytes.Buffer
+	require.NoError(t, json.NewEncoder(&want).Encode(dto.AccountResponse{
+		ID: "acc-1", Name: "Savings", Balance: 50, Currency: "USD", OwnerID: "alice",
+		CreatedAt: created, UpdatedAt: created, AvailableBalance: 50, Product: "savings",
+	}))
+	assert.Equal(t, want.String(), rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"created_at":"2025-03-10T12:00:00.1Z"`)
+}
+
+func TestGatewayPagesListsWithTheNextPageHeader(t *testing.T) {
+	bank := newFakeBank()
+	start := time.Now()
+	for i, id := range []string{"a1", "a2", "a3"} {
+		bank.accounts[id] = &dto.AccountDTO{ID: id, OwnerID: "alice", CreatedAt: start.Add(time.Duration(i) * time.Second)}
+	}
+	gateway := newGateway(t, bank)
+
+	rr := serve(gateway, "alice", "GET", "/accounts?page_size=2", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var accounts []dto.AccountResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &accounts))
+	assert.Len(t, accounts, 2)
+	next := rr.Header().Get(NextPageTokenHeader)
+	require.NotEmpty(t, next)
+
+	rr = serve(gateway, "alice", "GET", "/accounts?page_size=2&page_token="+next, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &accounts))
+	require.Len(t, accounts, 1)
+	assert.Equal(t, "a3", accounts[0].ID)
+	assert.Empty(t, rr.Header().Get(NextPageTokenHeader))
+
+	rr = serve(gateway, "alice", "GET", "/accounts", "")
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &accounts))
+	assert.Len(t, accounts, 3, "without a page size the whole list is returned")
+}
+
+func TestGatewayWritesErrorsLikeTheHandlers(t *testing.T) {
+	gateway := newGateway(t, newFakeBank(&dto.AccountDTO{ID: "acc-1", OwnerID: "alice", Balance: 50}))
+
+	tests := []struct {
+		name        string
+		caller      string
+		method      string
+		target      string
+		body        string
+		status      int
+		contentType string
+		want        string
+	}{
+		{"malformed JSON", "alice", "POST", "/transactions", `{"amount":`, http.StatusBadRequest, "text/plain; charset=utf-8", "Invalid JSON format\n"},
+		{"empty body", "alice", "POST", "/accounts", ``, http.StatusBadRequest, "text/plain; charset=utf-8", "Invalid JSON format\n"},
+		{"field of the wrong type", "alice", "POST", "/transactions", `{"account_id": 1, "amount": 5, "type": "deposit"}`, http.StatusBadRequest, "text/plain; charset=utf-8", "Invalid JSON format\n"},
+		{"invalid type", "alice", "POST", "/transactions", `{"account_id": "acc-1", "amount": 5, "type": "gift"}`, http.StatusBadRequest, "text/plain; charset=utf-8", ""},
+		{"unknown account", "alice", "GET", "/accounts/missing", ``, http.StatusNotFound, "text/plain; charset=utf-8", "Account not found\n"},
+		{"another owner", "bob", "GET", "/accounts/acc-1/transactions", ``, http.StatusForbidden, "text/plain; charset=utf-8", "Forbidden\n"},
+		{"invalid page token", "alice", "GET", "/accounts?page_token=!", ``, http.StatusBadRequest, "text/plain; charset=utf-8", "Invalid page_token\n"},
+		{"policy violation", "alice", "POST", "/transactions", `{"account_id": "acc-1", "amount": 80, "type": "withdrawal"}`, http.StatusUnprocessableEntity, "application/json", `{"error":"insufficient_funds","message":"insufficient funds"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(gateway, tt.caller, tt.method, tt.target, tt.body)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.contentType, rr.Header().Get("Content-Type"))
+			assert.Contains(t, rr.Body.String(), tt.want)
+		})
+	}
+}
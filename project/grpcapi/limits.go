@@ -0,0 +1,108 @@
+package grpcapi
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RateLimits throttles gRPC calls like the REST rate limit middleware
+// throttles requests
+type RateLimits struct {
+	// Default applies to methods without an entry in Methods
+	Default ratelimit.Limit
+	// Methods overrides the limit per full method name
+	Methods map[string]ratelimit.Limit
+	// AuthFailures is how many failed authentications a peer address may
+	// make before its calls are refused without being authenticated. The
+	// bucket is the one of the REST middleware for the same address.
+	AuthFailures ratelimit.Limit
+}
+
+// admit authenticates the call and applies the rate limits. A peer that used
+// up its authentication failures is refused before any credentials are
+// checked, an authenticated call takes a token from its principal's bucket.
+func admit(ctx context.Context, config Config, method string) (context.Context, error) {
+	store, limits := config.RateLimitStore, config.RateLimits
+	if store == nil {
+		return authenticate(ctx, config.Authenticator)
+	}
+
+	failuresKey := "auth-failures|ip:" + peerIP(ctx)
+	if !limits.AuthFailures.Unlimited() {
+		result, err := store.Peek(ctx, failuresKey, limits.AuthFailures)
+		if err != nil {
+			logger.ErrorContext(ctx, "Rate limiter unavailable", err)
+		} else if !result.Allowed {
+			logger.WarnContext(ctx, "Authentication failure limit exceeded", map[string]interface{}{
+				"retry_after": result.RetryAfter.String(),
+			})
+			return ctx, rateLimited(ctx, result)
+		}
+	}
+
+	ctx, err := authenticate(ctx, config.Authenticator)
+	if err != nil {
+		if !limits.AuthFailures.Unlimited() {
+			if _, takeErr := store.Take(ctx, failuresKey, limits.AuthFailures); takeErr != nil {
+				logger.ErrorContext(ctx, "Rate limiter unavailable", takeErr)
+			}
+		}
+		return ctx, err
+	}
+
+	limit, ok := limits.Methods[method]
+	if !ok {
+		limit = limits.Default
+	}
+	if limit.Unlimited() {
+		return ctx, nil
+	}
+
+	principal, _ := auth.PrincipalFromContext(ctx)
+	result, err := store.Take(ctx, method+"|principal:"+principal.ID, limit)
+	if err != nil {
+		// Fail open, an unavailable limiter must not take the API down
+		logger.ErrorContext(ctx, "Rate limiter unavailable", err)
+		return ctx, nil
+	}
+	if !result.Allowed {
+		logger.WarnContext(ctx, "Rate limit exceeded", map[string]interface{}{
+			"method":      method,
+			"retry_after": result.RetryAfter.String(),
+		})
+		return ctx, rateLimited(ctx, result)
+	}
+	return ctx, nil
+}
+
+// rateLimited refuses a call with ResourceExhausted and the seconds to retry
+// after in the retry-after header
+func rateLimited(ctx context.Context, result ratelimit.Result) error {
+	retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+	grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+	return status.Error(codes.ResourceExhausted, "Too many requests, retry later")
+}
+
+// peerIP returns the address of the client of the call
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,78 @@
+package grpcapi
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/gcalvocr/go-testing/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxPageSize caps the page_size of list requests
+const maxPageSize = 500
+
+// pageRequest returns the size of the requested page and the cursor it
+// starts after, nil for the first page. Without a page size the page runs to
+// the end of the list, as the REST API always returned whole lists, and the
+// size is 0.
+func pageRequest(pageSize int32, pageToken string) (size int, after *repository.Cursor, err error) {
+	size = int(pageSize)
+	switch {
+	case size < 0:
+		return 0, nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case size > maxPageSize:
+		size = maxPageSize
+	}
+
+	if pageToken != "" {
+		if after, err = decodePageToken(pageToken); err != nil {
+			return 0, nil, err
+		}
+	}
+	return size, after, nil
+}
+
+// fetchLimit is how many items to read for a page of size: one more than
+// the page, to tell whether another page follows
+func fetchLimit(size int) int {
+	if size == 0 {
+		return 0
+	}
+	return size + 1
+}
+
+// cutPage drops the item read past a page of size and returns the token of
+// the next page, empty on the last one. cursor returns the position of an item.
+func cutPage[T any](items []T, size int, cursor func(T) repository.Cursor) ([]T, string) {
+	if size == 0 || len(items) <= size {
+		return items, ""
+	}
+	items = items[:size]
+	return items, encodePageToken(cursor(items[size-1]))
+}
+
+// encodePageToken returns the opaque token of the page after the cursor
+func encodePageToken(cursor repository.Cursor) string {
+	raw := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + cursor.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodePageToken(token string) (*repository.Cursor, error) {
+	invalid := status.Error(codes.InvalidArgument, "Invalid page_token")
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalid
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, invalid
+	}
+	cursor := &repository.Cursor{ID: id}
+	if cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, invalid
+	}
+	return cursor, nil
+}
@@ -0,0 +1,142 @@
+// Package grpcapi serves the bank over gRPC, as defined by bank.proto. The
+// services use the same repositories, business logic and authorization
+// rules as the REST handlers; callers authenticate with the same API keys
+// and bearer tokens, sent as metadata.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/bankpb"
+	"github.com/gcalvocr/go-testing/feed"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/middleware"
+	"github.com/gcalvocr/go-testing/product"
+	"github.com/gcalvocr/go-testing/ratelimit"
+	"github.com/gcalvocr/go-testing/repository"
+	"github.com/gcalvocr/go-testing/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Config holds what the gRPC services need, the same the REST handlers use
+type Config struct {
+	Accounts           repository.AccountRepository
+	Transactions       repository.TransactionRepository
+	AccountService     *service.AccountService
+	TransactionService *service.TransactionService
+	// Feed streams WatchTransactions, it is unavailable when nil
+	Feed          *feed.Feed
+	Catalog       product.Catalog
+	Authenticator *auth.Authenticator
+	// RateLimitStore holds the buckets of RateLimits, calls are not
+	// throttled when it is nil. The gateway's calls are throttled by the
+	// REST middleware instead.
+	RateLimitStore ratelimit.Store
+	RateLimits     RateLimits
+}
+
+// NewServer creates a gRPC server with the bank services registered. Every
+// call is authenticated, rate limited and logged.
+func NewServer(config Config, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(unaryInterceptor(config)),
+		grpc.ChainStreamInterceptor(streamInterceptor(config)))
+
+	server := grpc.NewServer(opts...)
+	bankpb.RegisterAccountServiceServer(server, &accountServer{config: config})
+	bankpb.RegisterTransactionServiceServer(server, &transactionServer{config: config})
+	return server
+}
+
+func unaryInterceptor(config Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx, err := admit(withRequestID(ctx), config, info.FullMethod)
+		var resp interface{}
+		if err == nil {
+			resp, err = handler(ctx, req)
+		}
+		logCall(ctx, info.FullMethod, err, time.Since(start))
+		return resp, err
+	}
+}
+
+func streamInterceptor(config Config) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, err := admit(withRequestID(stream.Context()), config, info.FullMethod)
+		if err == nil {
+			err = handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+		}
+		logCall(ctx, info.FullMethod, err, time.Since(start))
+		return err
+	}
+}
+
+// contextStream replaces the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// withRequestID tags ctx with the x-request-id of the call, or a new one,
+// and sends it back in the response headers
+func withRequestID(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var requestID string
+	if values := md.Get(strings.ToLower(middleware.RequestIDHeader)); len(values) > 0 {
+		requestID = values[0]
+	}
+	if !middleware.ValidRequestID(requestID) {
+		requestID = middleware.NewRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(middleware.RequestIDHeader), requestID))
+	return logger.WithRequestID(ctx, requestID)
+}
+
+// authenticate stores the principal of the call's metadata in ctx. Only the
+// API key and bearer token count: no gateway strips the trusted headers from
+// calls to the gRPC port, so a client could send them itself.
+func authenticate(ctx context.Context, authenticator *auth.Authenticator) (context.Context, error) {
+	if authenticator == nil {
+		return ctx, status.Error(codes.Unauthenticated, "Authentication is not configured")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := make(http.Header, len(md))
+	for key, values := range md {
+		header[http.CanonicalHeaderKey(key)] = values
+	}
+
+	principal, err := authenticator.AuthenticateCredentials(ctx, header)
+	if err != nil {
+		logger.WarnContext(ctx, "Authentication failed", map[string]interface{}{
+			"reason": err.Error(),
+		})
+		if errors.Is(err, auth.ErrMissingCredentials) {
+			return ctx, status.Error(codes.Unauthenticated, "An API key or bearer token is required")
+		}
+		return ctx, status.Error(codes.Unauthenticated, "Invalid API key or bearer token")
+	}
+	return auth.WithPrincipal(ctx, principal), nil
+}
+
+func logCall(ctx context.Context, method string, err error, duration time.Duration) {
+	logger.InfoContext(ctx, "gRPC Request", map[string]interface{}{
+		"method":   method,
+		"code":     status.Code(err).String(),
+		"duration": duration.Milliseconds(),
+	})
+}
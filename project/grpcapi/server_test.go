@@ -0,0 +1,368 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/bankpb"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/feed"
+	"github.com/gcalvocr/go-testing/policy"
+	"github.com/gcalvocr/go-testing/ratelimit"
+	"github.com/gcalvocr/go-testing/service"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testJWTSecret signs the bearer tokens of the test calls
+const testJWTSecret = "grpcapi-test-secret"
+
+// startServer serves the bank over gRPC on a random port. Calls
+// authenticate with a bearer token signed with testJWTSecret; the trusted
+// headers are configured too, so the tests show they are ignored. configure
+// may change the configuration before the server is created.
+func startServer(t *testing.T, bank *fakeBank, configure ...func(*Config)) *grpc.ClientConn {
+	accounts, transactions, outbox := fakeAccounts{bank: bank}, fakeTransactions{bank: bank}, fakeOutbox{bank: bank}
+
+	accountService := service.NewAccountService(accounts, nil)
+	accountService.SetOutbox(outbox)
+	transactionService := service.NewTransactionService(accounts, transactions, nil)
+	transactionService.SetOutbox(outbox)
+
+	verifier, err := auth.NewJWTVerifier(auth.JWTConfig{HS256Secret: testJWTSecret})
+	require.NoError(t, err)
+	authenticator := auth.NewAuthenticator(nil, verifier)
+	authenticator.TrustHeaders("X-Authenticated-User", "X-Authenticated-Roles")
+
+	config := Config{
+		Accounts:           accounts,
+		Transactions:       transactions,
+		AccountService:     accountService,
+		TransactionService: transactionService,
+		Feed:               feed.NewFeed(outbox, nil, bank.broker),
+		Authenticator:      authenticator,
+	}
+	for _, configure := range configure {
+		configure(&config)
+	}
+	server := NewServer(config)
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+		server.Stop()
+	})
+	return conn
+}
+
+// as returns a context sending the bearer token of the principal with the
+// comma separated roles
+func as(principalID string, roles string) context.Context {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.Claims{
+		Roles: strings.Split(roles, ","),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   principalID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		panic(err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestCallsWithoutCredentialsAreUnauthenticated(t *testing.T) {
+	conn := startServer(t, newFakeBank())
+
+	_, err := bankpb.NewAccountServiceClient(conn).ListAccounts(context.Background(), &bankpb.ListAccountsRequest{})
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestTrustedHeadersAreIgnored(t *testing.T) {
+	conn := startServer(t, newFakeBank())
+
+	// Nothing strips these from calls to the gRPC port
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"x-authenticated-user", "mallory", "x-authenticated-roles", "admin")
+	_, err := bankpb.NewAccountServiceClient(conn).ListAccounts(ctx, &bankpb.ListAccountsRequest{})
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestCallsAreRateLimitedPerPrincipal(t *testing.T) {
+	conn := startServer(t, newFakeBank(), func(config *Config) {
+		config.RateLimitStore = ratelimit.NewMemoryStore()
+		config.RateLimits = RateLimits{Default: ratelimit.PerMinute(1, 1)}
+	})
+	client := bankpb.NewAccountServiceClient(conn)
+
+	_, err := client.ListAccounts(as("alice", "customer"), &bankpb.ListAccountsRequest{})
+	require.NoError(t, err)
+
+	var header metadata.MD
+	_, err = client.ListAccounts(as("alice", "customer"), &bankpb.ListAccountsRequest{}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"60"}, header.Get("retry-after"))
+
+	_, err = client.ListAccounts(as("bob", "customer"), &bankpb.ListAccountsRequest{})
+	assert.NoError(t, err, "every principal has its own bucket")
+}
+
+func TestMethodsHaveTheirOwnLimits(t *testing.T) {
+	bank := newFakeBank(&dto.AccountDTO{ID: "acc-1", OwnerID: "alice", Currency: "USD", Balance: 100})
+	conn := startServer(t, bank, func(config *Config) {
+		config.RateLimitStore = ratelimit.NewMemoryStore()
+		config.RateLimits = RateLimits{
+			Methods: map[string]ratelimit.Limit{
+				bankpb.TransactionService_PostTransaction_FullMethodName: ratelimit.PerMinute(1, 1),
+			},
+		}
+	})
+	client := bankpb.NewTransactionServiceClient(conn)
+	deposit := &bankpb.PostTransactionRequest{AccountId: "acc-1", Amount: 5, Type: "deposit"}
+
+	_, err := client.PostTransaction(as("alice", "customer"), deposit)
+	require.NoError(t, err)
+	_, err = client.PostTransaction(as("alice", "customer"), deposit)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = client.ListTransactions(as("alice", "customer"), &bankpb.ListTransactionsRequest{AccountId: "acc-1"})
+	assert.NoError(t, err, "the default limit is unlimited")
+}
+
+func TestFailedAuthenticationsAreLimitedPerPeer(t *testing.T) {
+	conn := startServer(t, newFakeBank(), func(config *Config) {
+		config.RateLimitStore = ratelimit.NewMemoryStore()
+		config.RateLimits = RateLimits{AuthFailures: ratelimit.PerMinute(1, 2)}
+	})
+	client := bankpb.NewAccountServiceClient(conn)
+
+	for i := 0; i < 2; i++ {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "guess")
+		_, err := client.ListAccounts(ctx, &bankpb.ListAccountsRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+
+	// Once the budget is used up valid credentials are not even checked
+	_, err := client.ListAccounts(as("alice", "customer"), &bankpb.ListAccountsRequest{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestCreateAndGetAccount(t *testing.T) {
+	conn := startServer(t, newFakeBank())
+	client := bankpb.NewAccountServiceClient(conn)
+
+	created, err := client.CreateAccount(as("alice", "customer"), &bankpb.CreateAccountRequest{
+		Name: "Savings", Balance: 100, Currency: "USD",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", created.OwnerId, "the owner defaults to the caller")
+	assert.Equal(t, "checking", created.Product)
+	assert.Equal(t, 100.0, created.AvailableBalance)
+
+	account, err := client.GetAccount(as("alice", "customer"), &bankpb.GetAccountRequest{Id: created.Id})
+	require.NoError(t, err)
+	assert.Equal(t, "Savings", account.Name)
+
+	_, err = client.GetAccount(as("bob", "customer"), &bankpb.GetAccountRequest{Id: created.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.GetAccount(as("alice", "customer"), &bankpb.GetAccountRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestCreateAccountValidatesTheRequest(t *testing.T) {
+	conn := startServer(t, newFakeBank())
+	client := bankpb.NewAccountServiceClient(conn)
+
+	tests := []struct {
+		name string
+		req  *bankpb.CreateAccountRequest
+		code codes.Code
+	}{
+		{"missing name", &bankpb.CreateAccountRequest{Currency: "USD"}, codes.InvalidArgument},
+		{"unknown product", &bankpb.CreateAccountRequest{Name: "A", Currency: "USD", Product: "gold"}, codes.InvalidArgument},
+		{"another owner", &bankpb.CreateAccountRequest{Name: "A", Currency: "USD", OwnerId: "bob"}, codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.CreateAccount(as("alice", "customer"), tt.req)
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
+
+func TestListAccountsPaginates(t *testing.T) {
+	bank := newFakeBank()
+	start := time.Now()
+	for i, id := range []string{"a1", "a2", "a3", "a4", "a5"} {
+		bank.accounts[id] = &dto.AccountDTO{ID: id, OwnerID: "alice", CreatedAt: start.Add(time.Duration(i) * time.Second)}
+	}
+	bank.accounts["b1"] = &dto.AccountDTO{ID: "b1", OwnerID: "bob", CreatedAt: start}
+	client := bankpb.NewAccountServiceClient(startServer(t, bank))
+
+	var ids []string
+	var pages int
+	req := &bankpb.ListAccountsRequest{PageSize: 2}
+	for {
+		resp, err := client.ListAccounts(as("alice", "customer"), req)
+		require.NoError(t, err)
+		pages++
+		for _, account := range resp.Accounts {
+			ids = append(ids, account.Id)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+
+	assert.Equal(t, []string{"a1", "a2", "a3", "a4", "a5"}, ids, "customers only list their own accounts")
+	assert.Equal(t, 3, pages)
+
+	resp, err := client.ListAccounts(as("teller-1", "teller"), &bankpb.ListAccountsRequest{})
+	require.NoError(t, err)
+	assert.Len(t, resp.Accounts, 6)
+
+	_, err = client.ListAccounts(as("alice", "customer"), &bankpb.ListAccountsRequest{PageToken: "not a token"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestListAccountsPagesDoNotShiftOnInserts(t *testing.T) {
+	bank := newFakeBank()
+	start := time.Now()
+	for i, id := range []string{"a1", "a2", "a3", "a4"} {
+		bank.accounts[id] = &dto.AccountDTO{ID: id, OwnerID: "alice", CreatedAt: start.Add(time.Duration(i) * time.Second)}
+	}
+	client := bankpb.NewAccountServiceClient(startServer(t, bank))
+
+	first, err := client.ListAccounts(as("alice", "customer"), &bankpb.ListAccountsRequest{PageSize: 2})
+	require.NoError(t, err)
+	require.NotEmpty(t, first.NextPageToken)
+
+	// An account listed before the cursor doesn't push a2 onto the next page
+	bank.mu.Lock()
+	bank.accounts["a0"] = &dto.AccountDTO{ID: "a0", OwnerID: "alice", CreatedAt: start.Add(-time.Second)}
+	bank.mu.Unlock()
+
+	second, err := client.ListAccounts(as("alice", "customer"), &bankpb.ListAccountsRequest{PageSize: 2, PageToken: first.NextPageToken})
+	require.NoError(t, err)
+	var ids []string
+	for _, account := range second.Accounts {
+		ids = append(ids, account.Id)
+	}
+	assert.Equal(t, []string{"a3", "a4"}, ids)
+	assert.Empty(t, second.NextPageToken)
+}
+
+func TestPostTransactionMapsDomainErrors(t *testing.T) {
+	bank := newFakeBank(&dto.AccountDTO{ID: "acc-1", OwnerID: "alice", Balance: 50})
+	client := bankpb.NewTransactionServiceClient(startServer(t, bank))
+
+	tests := []struct {
+		name   string
+		caller string
+		req    *bankpb.PostTransactionRequest
+		code   codes.Code
+		reason string
+	}{
+		{"invalid amount", "alice", &bankpb.PostTransactionRequest{AccountId: "acc-1", Amount: -5, Type: "deposit"}, codes.InvalidArgument, ""},
+		{"unknown account", "alice", &bankpb.PostTransactionRequest{AccountId: "missing", Amount: 5, Type: "deposit"}, codes.NotFound, ""},
+		{"another owner", "bob", &bankpb.PostTransactionRequest{AccountId: "acc-1", Amount: 5, Type: "deposit"}, codes.PermissionDenied, ""},
+		{"insufficient funds", "alice", &bankpb.PostTransactionRequest{AccountId: "acc-1", Amount: 80, Type: "withdrawal"}, codes.FailedPrecondition, policy.CodeInsufficientFunds},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.PostTransaction(as(tt.caller, "customer"), tt.req)
+
+			st := status.Convert(err)
+			assert.Equal(t, tt.code, st.Code())
+			if tt.reason != "" {
+				require.Len(t, st.Details(), 1)
+				info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+				require.True(t, ok)
+				assert.Equal(t, tt.reason, info.Reason)
+			}
+		})
+	}
+	assert.Equal(t, 50.0, bank.accounts["acc-1"].Balance)
+}
+
+func TestPostGetAndListTransactions(t *testing.T) {
+	bank := newFakeBank(&dto.AccountDTO{ID: "acc-1", OwnerID: "alice", Balance: 50})
+	client := bankpb.NewTransactionServiceClient(startServer(t, bank))
+
+	posted, err := client.PostTransaction(as("alice", "customer"), &bankpb.PostTransactionRequest{AccountId: "acc-1", Amount: 20, Type: "withdrawal"})
+	require.NoError(t, err)
+	assert.Equal(t, 30.0, posted.Balance)
+
+	transaction, err := client.GetTransaction(as("alice", "customer"), &bankpb.GetTransactionRequest{Id: posted.Transaction.Id})
+	require.NoError(t, err)
+	assert.Equal(t, "withdrawal", transaction.Type)
+
+	_, err = client.GetTransaction(as("bob", "customer"), &bankpb.GetTransactionRequest{Id: posted.Transaction.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	list, err := client.ListTransactions(as("alice", "customer"), &bankpb.ListTransactionsRequest{AccountId: "acc-1"})
+	require.NoError(t, err)
+	require.Len(t, list.Transactions, 1)
+	assert.Empty(t, list.NextPageToken)
+}
+
+func TestWatchTransactionsResumesThenStreams(t *testing.T) {
+	bank := newFakeBank(
+		&dto.AccountDTO{ID: "acc-1", OwnerID: "alice", Balance: 100},
+		&dto.AccountDTO{ID: "acc-2", OwnerID: "bob", Balance: 100})
+	client := bankpb.NewTransactionServiceClient(startServer(t, bank))
+	post := func(accountID, owner string, amount float64) {
+		_, err := client.PostTransaction(as(owner, "customer"), &bankpb.PostTransactionRequest{AccountId: accountID, Amount: amount, Type: "deposit"})
+		require.NoError(t, err)
+	}
+
+	post("acc-1", "alice", 1)
+	post("acc-1", "alice", 2)
+	post("acc-2", "bob", 3)
+
+	ctx, cancel := context.WithCancel(as("alice", "customer"))
+	defer cancel()
+	stream, err := client.WatchTransactions(ctx, &bankpb.WatchTransactionsRequest{AccountId: "acc-1", AfterSequence: 1})
+	require.NoError(t, err)
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), event.Sequence, "the transactions after the sequence are sent first")
+	assert.Equal(t, 2.0, event.Transaction.Amount)
+	assert.Equal(t, 103.0, event.Balance)
+
+	post("acc-2", "bob", 4)
+	post("acc-1", "alice", 5)
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "acc-1", event.Transaction.AccountId, "other accounts are filtered out")
+	assert.Equal(t, 5.0, event.Transaction.Amount)
+
+	for _, accountID := range []string{"acc-1", ""} {
+		denied, err := client.WatchTransactions(as("bob", "customer"), &bankpb.WatchTransactionsRequest{AccountId: accountID})
+		require.NoError(t, err)
+		_, err = denied.Recv()
+		assert.Equal(t, codes.PermissionDenied, status.Code(err), "account %q", accountID)
+	}
+}
@@ -0,0 +1,204 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/authz"
+	"github.com/gcalvocr/go-testing/bankpb"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/feed"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// watchKeepAlive is how often the feed checks an idle stream; gRPC keeps
+// the connection open itself, so nothing is sent
+const watchKeepAlive = time.Minute
+
+// transactionServer implements bankpb.TransactionServiceServer like the
+// transaction handlers
+type transactionServer struct {
+	bankpb.UnimplementedTransactionServiceServer
+	config Config
+}
+
+func (s *transactionServer) PostTransaction(ctx context.Context, req *bankpb.PostTransactionRequest) (*bankpb.PostTransactionResponse, error) {
+	// Tag every log line of this call, including the repository ones, with the account
+	ctx = logger.WithAccountID(ctx, req.GetAccountId())
+
+	if s.config.TransactionService == nil {
+		logger.ErrorContext(ctx, "Transaction service not initialized", nil)
+		return nil, status.Error(codes.Internal, "Database not available")
+	}
+
+	result, err := s.config.TransactionService.Create(ctx, dto.CreateTransactionRequest{
+		AccountID: req.GetAccountId(),
+		Amount:    req.GetAmount(),
+		Type:      req.GetType(),
+	})
+	if err != nil {
+		return nil, statusFromError(ctx, err, "Failed to create transaction")
+	}
+
+	logger.InfoContext(ctx, "Transaction completed successfully", map[string]interface{}{
+		"transaction_id": result.Transaction.ID,
+		"account_id":     result.Transaction.AccountID,
+		"amount":         result.Transaction.Amount,
+		"type":           result.Transaction.Type,
+		"new_balance":    result.Balance,
+	})
+	return &bankpb.PostTransactionResponse{
+		Transaction: transactionMessage(result.Transaction),
+		Balance:     result.Balance,
+	}, nil
+}
+
+func (s *transactionServer) GetTransaction(ctx context.Context, req *bankpb.GetTransactionRequest) (*bankpb.Transaction, error) {
+	if s.config.Transactions == nil {
+		logger.ErrorContext(ctx, "Transaction repository not initialized", nil)
+		return nil, status.Error(codes.Internal, "Database not available")
+	}
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "transaction id is required")
+	}
+
+	transaction, err := s.config.Transactions.GetByID(ctx, req.GetId())
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get transaction", err)
+		return nil, status.Error(codes.Internal, "Failed to retrieve transaction")
+	}
+	if transaction == nil {
+		return nil, status.Error(codes.NotFound, "Transaction not found")
+	}
+
+	// Transactions are visible to whoever may view their account
+	if _, err := findAccount(ctx, s.config, transaction.AccountID); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, status.Error(codes.NotFound, "Transaction not found")
+		}
+		return nil, err
+	}
+	return transactionMessage(transaction), nil
+}
+
+func (s *transactionServer) ListTransactions(ctx context.Context, req *bankpb.ListTransactionsRequest) (*bankpb.ListTransactionsResponse, error) {
+	if s.config.Transactions == nil {
+		logger.ErrorContext(ctx, "Transaction repository not initialized", nil)
+		return nil, status.Error(codes.Internal, "Database not available")
+	}
+
+	// The account is loaded first so ownership can be checked
+	account, err := findAccount(ctx, s.config, req.GetAccountId())
+	if err != nil {
+		return nil, err
+	}
+
+	size, after, err := pageRequest(req.GetPageSize(), req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+
+	// Newest first, like GET /accounts/{account_id}/transactions
+	transactions, err := s.config.Transactions.GetPageByAccountID(ctx, account.ID, after, fetchLimit(size))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get transactions", err)
+		return nil, status.Error(codes.Internal, "Failed to retrieve transactions")
+	}
+	transactions, next := cutPage(transactions, size, func(transaction *dto.TransactionDTO) repository.Cursor {
+		return repository.Cursor{CreatedAt: transaction.CreatedAt, ID: transaction.ID}
+	})
+
+	response := &bankpb.ListTransactionsResponse{NextPageToken: next}
+	for _, transaction := range transactions {
+		response.Transactions = append(response.Transactions, transactionMessage(transaction))
+	}
+	return response, nil
+}
+
+func (s *transactionServer) WatchTransactions(req *bankpb.WatchTransactionsRequest, stream bankpb.TransactionService_WatchTransactionsServer) error {
+	ctx := stream.Context()
+	if s.config.Feed == nil {
+		logger.ErrorContext(ctx, "Event feed not initialized", nil)
+		return status.Error(codes.Unavailable, "Event feed not available")
+	}
+	if req.GetAfterSequence() < 0 {
+		return status.Error(codes.InvalidArgument, "after_sequence must not be negative")
+	}
+
+	accountID := req.GetAccountId()
+	if accountID == "" {
+		principal, _ := auth.PrincipalFromContext(ctx)
+		if !authz.CanListAllAccounts(principal) {
+			return permissionDenied(ctx, "Principal may not watch the transactions of every account")
+		}
+	} else if _, err := findAccount(ctx, s.config, accountID); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "Transaction watch opened", map[string]interface{}{
+		"account_id":     accountID,
+		"after_sequence": req.GetAfterSequence(),
+	})
+
+	err := s.config.Feed.Stream(ctx, accountID, req.GetAfterSequence(), watchKeepAlive, &transactionWriter{stream: stream})
+	switch {
+	case errors.Is(err, feed.ErrDropped):
+		logger.WarnContext(ctx, "Transaction watch dropped for falling behind", nil)
+		return status.Error(codes.Unavailable, "Watcher fell behind, resume from the last sequence received")
+	case err != nil && ctx.Err() == nil:
+		logger.ErrorContext(ctx, "Transaction watch failed", err)
+		return status.Error(codes.Internal, "Transaction watch failed")
+	}
+	return nil
+}
+
+// transactionWriter sends the TransactionPosted events of a feed to a
+// WatchTransactions stream
+type transactionWriter struct {
+	stream bankpb.TransactionService_WatchTransactionsServer
+}
+
+func (w *transactionWriter) WriteEvent(event *dto.EventDTO) error {
+	if event.Type != dto.EventTransactionPosted {
+		return nil
+	}
+
+	var posted dto.TransactionPostedEvent
+	if err := json.Unmarshal(event.Payload, &posted); err != nil {
+		return err
+	}
+	return w.stream.Send(&bankpb.TransactionEvent{
+		Sequence: event.Sequence,
+		Transaction: &bankpb.Transaction{
+			Id:        posted.TransactionID,
+			AccountId: posted.AccountID,
+			Amount:    posted.Amount,
+			Type:      posted.Type,
+			CreatedAt: timestamppb.New(posted.CreatedAt),
+			UpdatedAt: timestamppb.New(posted.CreatedAt),
+		},
+		Balance: posted.Balance,
+	})
+}
+
+func (w *transactionWriter) WriteKeepAlive() error {
+	return nil
+}
+
+func transactionMessage(transaction *dto.TransactionDTO) *bankpb.Transaction {
+	return &bankpb.Transaction{
+		Id:        transaction.ID,
+		AccountId: transaction.AccountID,
+		Amount:    transaction.Amount,
+		Type:      transaction.Type,
+		CreatedAt: timestamppb.New(transaction.CreatedAt),
+		UpdatedAt: timestamppb.New(transaction.UpdatedAt),
+	}
+}
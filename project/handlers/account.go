@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"math"
 	"net/http"
 
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/authz"
 	"github.com/gcalvocr/go-testing/dto"
 	"github.com/gcalvocr/go-testing/logger"
 	"github.com/gcalvocr/go-testing/repository"
+	"github.com/gcalvocr/go-testing/service"
 	"github.com/gorilla/mux"
 )
 
@@ -17,146 +22,112 @@ func SetAccountRepository(repo repository.AccountRepository) {
 	accountRepo = repo
 }
 
-func GetAccounts(w http.ResponseWriter, r *http.Request) {
-	logger.Info("Getting all accounts", nil)
+func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
+	logger.InfoContext(r.Context(), "Health check requested", nil)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
 
-	if accountRepo == nil {
-		logger.Error("Account repository not initialized", nil)
-		http.Error(w, "Database not available", http.StatusInternalServerError)
-		return
-	}
+// UpdateAccountLimits replaces the overdraft and transaction limits of an account, admins only
+func UpdateAccountLimits(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
 
-	accounts, err := accountRepo.GetAll(r.Context())
-	if err != nil {
-		logger.Error("Failed to get accounts", err)
-		http.Error(w, "Failed to retrieve accounts", http.StatusInternalServerError)
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	if !authz.CanManageAccountLimits(principal) {
+		forbidden(w, r, "Principal may not change account limits")
 		return
 	}
 
-	// Convert to response format
-	response := make([]dto.AccountResponse, len(accounts))
-	for i, acc := range accounts {
-		response[i] = dto.AccountResponse{
-			ID:        acc.ID,
-			Name:      acc.Name,
-			Balance:   acc.Balance,
-			Currency:  acc.Currency,
-			CreatedAt: acc.CreatedAt,
-			UpdatedAt: acc.UpdatedAt,
+	var limits dto.AccountLimits
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		logger.ErrorContext(r.Context(), "Failed to decode account limits JSON", err)
+		if isBodyTooLarge(err) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
 		}
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
 	}
 
-	logger.Info("Retrieved accounts successfully", map[string]interface{}{
-		"count": len(accounts),
-	})
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-func GetAccountByID(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
-
-	logger.Info("Getting account by ID", map[string]interface{}{
-		"account_id": id,
-	})
+	if err := service.Validate(limits); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	if accountRepo == nil {
-		logger.Error("Account repository not initialized", nil)
+		logger.ErrorContext(r.Context(), "Account repository not initialized", nil)
 		http.Error(w, "Database not available", http.StatusInternalServerError)
 		return
 	}
 
 	account, err := accountRepo.GetByID(r.Context(), id)
 	if err != nil {
-		logger.Error("Failed to get account", err)
+		logger.ErrorContext(r.Context(), "Failed to get account", err)
 		http.Error(w, "Failed to retrieve account", http.StatusInternalServerError)
 		return
 	}
 
 	if account == nil {
-		logger.Warn("Account not found", map[string]interface{}{
-			"account_id": id,
-		})
 		http.Error(w, "Account not found", http.StatusNotFound)
 		return
 	}
 
-	response := dto.AccountResponse{
-		ID:        account.ID,
-		Name:      account.Name,
-		Balance:   account.Balance,
-		Currency:  account.Currency,
-		CreatedAt: account.CreatedAt,
-		UpdatedAt: account.UpdatedAt,
+	if err := accountRepo.UpdateLimits(r.Context(), id, limits); err != nil {
+		logger.ErrorContext(r.Context(), "Failed to update account limits", err)
+		http.Error(w, "Failed to update account limits", http.StatusInternalServerError)
+		return
 	}
 
-	logger.Info("Retrieved account successfully", map[string]interface{}{
-		"account_id": id,
-		"name":       account.Name,
+	logger.InfoContext(r.Context(), "Account limits updated", map[string]interface{}{
+		"account_id":             id,
+		"overdraft_limit":        limits.OverdraftLimit,
+		"minimum_balance":        limits.MinimumBalance,
+		"daily_withdrawal_limit": limits.DailyWithdrawalLimit,
+		"max_transaction_amount": limits.MaxTransactionAmount,
 	})
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	logger.Info("Health check requested", nil)
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("OK"))
-}
-
-func CreateAccount(w http.ResponseWriter, r *http.Request) {
-	var req dto.CreateAccountRequest
-	err := json.NewDecoder(r.Body).Decode(&req)
+	account.Limits = limits
+	response, err := newAccountResponse(r.Context(), account)
 	if err != nil {
-		logger.Error("Failed to decode account JSON", err)
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, "Failed to retrieve account", http.StatusInternalServerError)
 		return
 	}
 
-	logger.Info("Creating new account", map[string]interface{}{
-		"name":     req.Name,
-		"balance":  req.Balance,
-		"currency": req.Currency,
-	})
-
-	if accountRepo == nil {
-		logger.Error("Account repository not initialized", nil)
-		http.Error(w, "Database not available", http.StatusInternalServerError)
-		return
-	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
 
-	// Create account DTO
-	account := &dto.AccountDTO{
-		Name:     req.Name,
-		Balance:  req.Balance,
-		Currency: req.Currency,
+// newAccountResponse converts an account to its API representation, the
+// available balance is the balance minus the account's active holds
+func newAccountResponse(ctx context.Context, account *dto.AccountDTO) (dto.AccountResponse, error) {
+	var held float64
+	if transactionService != nil {
+		var err error
+		if held, err = transactionService.Held(ctx, account.ID); err != nil {
+			return dto.AccountResponse{}, err
+		}
 	}
 
-	err = accountRepo.Create(r.Context(), account)
-	if err != nil {
-		logger.Error("Failed to create account", err)
-		http.Error(w, "Failed to create account", http.StatusInternalServerError)
-		return
-	}
+	return dto.AccountResponse{
+		ID:               account.ID,
+		Name:             account.Name,
+		Balance:          account.Balance,
+		Currency:         account.Currency,
+		OwnerID:          account.OwnerID,
+		CreatedAt:        account.CreatedAt,
+		UpdatedAt:        account.UpdatedAt,
+		AvailableBalance: math.Round((account.Balance-held)*100) / 100,
+		Product:          accountProduct(account),
+		Limits:           account.Limits,
+	}, nil
+}
 
-	response := dto.AccountResponse{
-		ID:        account.ID,
-		Name:      account.Name,
-		Balance:   account.Balance,
-		Currency:  account.Currency,
-		CreatedAt: account.CreatedAt,
-		UpdatedAt: account.UpdatedAt,
+// forbidden rejects a request the authorization policy denied
+func forbidden(w http.ResponseWriter, r *http.Request, reason string) {
+	fields := map[string]interface{}{"reason": reason}
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		fields["principal_id"] = principal.ID
 	}
-
-	logger.Info("Account created successfully", map[string]interface{}{
-		"account_id": account.ID,
-		"name":       account.Name,
-	})
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	logger.WarnContext(r.Context(), "Request forbidden", fields)
+	http.Error(w, "Forbidden", http.StatusForbidden)
 }
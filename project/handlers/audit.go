@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/authz"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/repository"
+	"github.com/gcalvocr/go-testing/statement"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+var auditRepo repository.AuditRepository
+
+// SetAuditRepository sets the audit log repository (called from main)
+func SetAuditRepository(repo repository.AuditRepository) {
+	auditRepo = repo
+}
+
+// GetAuditLog returns the audit log entries matching the query, latest
+// first, admins only
+func GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	if !authz.CanViewAuditLog(principal) {
+		forbidden(w, r, "Principal may not view the audit log")
+		return
+	}
+
+	if auditRepo == nil {
+		logger.ErrorContext(r.Context(), "Audit repository not initialized", nil)
+		http.Error(w, "Database not available", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	from, to, err := statement.ParsePeriod(query.Get("from"), query.Get("to"), time.Now().UTC())
+	if err != nil {
+		http.Error(w, "Invalid period: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultAuditLimit
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxAuditLimit {
+			http.Error(w, "limit must be between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := auditRepo.Find(r.Context(), dto.AuditQuery{
+		Actor:        query.Get("actor"),
+		Action:       query.Get("action"),
+		ResourceType: query.Get("resource_type"),
+		ResourceID:   query.Get("resource_id"),
+		From:         from,
+		To:           to,
+		Limit:        limit,
+	})
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to query the audit log", err)
+		http.Error(w, "Failed to query the audit log", http.StatusInternalServerError)
+		return
+	}
+
+	if entries == nil {
+		entries = []*dto.AuditEntryDTO{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
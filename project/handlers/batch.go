@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/service"
+)
+
+// CreateTransactionBatch imports a JSON array or CSV upload of transactions
+// and returns a per-row report. The response status is 201 when every row was
+// applied, 207 when a best-effort batch was applied partially and 422 when
+// an all-or-nothing batch was rejected or no row could be applied.
+func CreateTransactionBatch(w http.ResponseWriter, r *http.Request) {
+	mode, err := service.ParseBatchMode(r.URL.Query().Get("mode"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if transactionService == nil {
+		logger.ErrorContext(r.Context(), "Transaction service not initialized", nil)
+		http.Error(w, "Database not available", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := readBatch(r)
+	if err != nil {
+		logger.WarnContext(r.Context(), "Failed to read transaction batch", map[string]interface{}{
+			"reason": err.Error(),
+		})
+		if isBodyTooLarge(err) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid batch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Importing transaction batch", map[string]interface{}{
+		"mode": mode,
+		"rows": len(rows),
+	})
+
+	report, err := transactionService.Import(r.Context(), rows, mode)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to import transaction batch", err)
+		http.Error(w, "Failed to import transactions", http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusCreated
+	switch {
+	case report.Applied == report.Total:
+	case mode == dto.BatchAllOrNothing || report.Applied == 0:
+		status = http.StatusUnprocessableEntity
+	default:
+		status = http.StatusMultiStatus
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// readBatch parses the rows of a JSON, CSV or multipart CSV upload
+func readBatch(r *http.Request) ([]service.BatchRow, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		return service.ReadJSONBatch(r.Body)
+	case "text/csv":
+		return service.ReadCSVBatch(r.Body)
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			return nil, err
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, errors.New("the CSV must be uploaded in the file field")
+		}
+		defer file.Close()
+		return service.ReadCSVBatch(file)
+	default:
+		return nil, errors.New("unsupported content type")
+	}
+}
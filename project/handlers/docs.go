@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/openapi"
+)
+
+var openAPIDocument *openapi.Document
+
+// SetOpenAPIDocument sets the generated OpenAPI document (called from server.SetupRoutes)
+func SetOpenAPIDocument(doc *openapi.Document) {
+	openAPIDocument = doc
+}
+
+// OpenAPIHandler serves the OpenAPI document as JSON
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if openAPIDocument == nil {
+		logger.ErrorContext(r.Context(), "OpenAPI document not initialized", nil)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(openAPIDocument)
+}
+
+// docsPage renders the OpenAPI document with Redoc
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Bank API Reference</title>
+</head>
+<body>
+    <redoc spec-url="/openapi.json"></redoc>
+    <script src="https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"></script>
+</body>
+</html>`
+
+// DocsHandler serves the interactive API reference
+func DocsHandler(w http.ResponseWriter, r *http.Request) {
+	logger.InfoContext(r.Context(), "Serving API reference page", nil)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(docsPage))
+}
@@ -2,14 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 
+	"github.com/gcalvocr/go-testing/exchange"
 	"github.com/gcalvocr/go-testing/logger"
 )
 
-type ExchangeResponse struct {
-	Rates map[string]float64 `json:"rates"`
+var exchangeClient = exchange.NewClient(nil)
+
+// SetExchangeClient sets the client GetExchangeRate fetches rates with
+func SetExchangeClient(client *exchange.Client) {
+	exchangeClient = client
 }
 
 func GetExchangeRate(w http.ResponseWriter, r *http.Request) {
@@ -17,7 +21,7 @@ func GetExchangeRate(w http.ResponseWriter, r *http.Request) {
 	to := r.URL.Query().Get("to")
 
 	if from == "" || to == "" {
-		logger.Warn("Missing exchange rate parameters", map[string]interface{}{
+		logger.WarnContext(r.Context(), "Missing exchange rate parameters", map[string]interface{}{
 			"from": from,
 			"to":   to,
 		})
@@ -25,39 +29,27 @@ func GetExchangeRate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Info("Fetching exchange rate", map[string]interface{}{
+	logger.InfoContext(r.Context(), "Fetching exchange rate", map[string]interface{}{
 		"from": from,
 		"to":   to,
 	})
 
-	url := fmt.Sprintf("https://api.exchangerate-api.com/v4/latest/%s", from)
-	resp, err := http.Get(url)
-	if err != nil {
-		logger.Error("Failed to fetch exchange rate from external API", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer resp.Body.Close()
-
-	var exchangeResp ExchangeResponse
-	err = json.NewDecoder(resp.Body).Decode(&exchangeResp)
-	if err != nil {
-		logger.Error("Failed to decode exchange rate response", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	rate, exists := exchangeResp.Rates[to]
-	if !exists {
-		logger.Warn("Currency not found in exchange rate response", map[string]interface{}{
+	rate, err := exchangeClient.Rate(r.Context(), from, to)
+	if errors.Is(err, exchange.ErrCurrencyNotFound) {
+		logger.WarnContext(r.Context(), "Currency not found in exchange rate response", map[string]interface{}{
 			"from": from,
 			"to":   to,
 		})
 		http.Error(w, "Currency not found", http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to fetch exchange rate from external API", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	logger.Info("Exchange rate retrieved successfully", map[string]interface{}{
+	logger.InfoContext(r.Context(), "Exchange rate retrieved successfully", map[string]interface{}{
 		"from": from,
 		"to":   to,
 		"rate": rate,
@@ -4,12 +4,34 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
+	"github.com/gcalvocr/go-testing/exchange"
+	"github.com/gcalvocr/go-testing/httprecord"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// useExchangeCassette answers the calls of the exchange-rate API from the
+// cassette testdata/<name>.json. Run the tests with HTTP_RECORD_MODE=record
+// to record it again from the real API.
+func useExchangeCassette(t *testing.T, name string) {
+	mode, err := httprecord.ModeFromEnv()
+	require.NoError(t, err)
+	transport, err := httprecord.NewTransport("testdata/"+name+".json", mode, nil)
+	require.NoError(t, err)
+
+	SetExchangeClient(exchange.NewClient(&http.Client{Transport: transport}))
+	t.Cleanup(func() {
+		SetExchangeClient(exchange.NewClient(nil))
+		assert.NoError(t, transport.Save())
+	})
+}
+
 func TestGetExchangeRate(t *testing.T) {
+	useExchangeCassette(t, "exchange_usd")
+
 	req, err := http.NewRequest("GET", "/exchange?from=USD&to=EUR", nil)
 	assert.NoError(t, err)
 
@@ -17,8 +39,6 @@ func TestGetExchangeRate(t *testing.T) {
 	handler := http.HandlerFunc(GetExchangeRate)
 	handler.ServeHTTP(rr, req)
 
-	// Note: This test makes a real HTTP call to the external API
-	// In a real scenario, you should mock the HTTP client for reliable tests
 	assert.Equal(t, http.StatusOK, rr.Code)
 
 	var response map[string]float64
@@ -28,6 +48,53 @@ func TestGetExchangeRate(t *testing.T) {
 	assert.Greater(t, response["rate"], 0.0)
 }
 
+func TestGetExchangeRateUnknownCurrency(t *testing.T) {
+	useExchangeCassette(t, "exchange_usd")
+
+	req, err := http.NewRequest("GET", "/exchange?from=USD&to=XXX", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(GetExchangeRate)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "Currency not found\n", rr.Body.String())
+}
+
+// TestGetExchangeRateAgainstMockServer targets the exchange-rate mocks of
+// 08_camouflage. Start them with go run . in that module and run the test
+// with EXCHANGE_RATE_API_URL=http://localhost:8001/v4/latest/
+func TestGetExchangeRateAgainstMockServer(t *testing.T) {
+	if os.Getenv(exchange.BaseURLEnv) == "" {
+		t.Skip("EXCHANGE_RATE_API_URL is not set")
+	}
+	SetExchangeClient(exchange.NewClientFromEnv())
+	t.Cleanup(func() { SetExchangeClient(exchange.NewClient(nil)) })
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+	}{
+		{"known currencies", "/exchange?from=USD&to=EUR", http.StatusOK},
+		{"other base", "/exchange?from=EUR&to=USD", http.StatusOK},
+		{"unknown target", "/exchange?from=USD&to=XXX", http.StatusNotFound},
+		{"unsupported base", "/exchange?from=XXX&to=EUR", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.target, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(GetExchangeRate).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code, rr.Body.String())
+		})
+	}
+}
+
 func TestGetExchangeRateMissingParams(t *testing.T) {
 	req, err := http.NewRequest("GET", "/exchange?from=USD", nil)
 	assert.NoError(t, err)
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/service"
+	"github.com/gorilla/mux"
+)
+
+// CreateHold reserves funds of an account until they are captured, released
+// or the hold expires
+func CreateHold(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.ErrorContext(r.Context(), "Failed to decode hold JSON", err)
+		if isBodyTooLarge(err) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	ctx := logger.WithAccountID(r.Context(), req.AccountID)
+
+	logger.InfoContext(ctx, "Creating hold", map[string]interface{}{
+		"account_id": req.AccountID,
+		"amount":     req.Amount,
+	})
+
+	if transactionService == nil {
+		logger.ErrorContext(ctx, "Transaction service not initialized", nil)
+		http.Error(w, "Database not available", http.StatusInternalServerError)
+		return
+	}
+
+	hold, err := transactionService.CreateHold(ctx, req)
+	if err != nil {
+		writeHoldError(w, r, err)
+		return
+	}
+
+	logger.InfoContext(ctx, "Hold created", map[string]interface{}{
+		"hold_id":    hold.ID,
+		"account_id": hold.AccountID,
+		"amount":     hold.Amount,
+		"expires_at": hold.ExpiresAt,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hold)
+}
+
+// GetHold returns a hold
+func GetHold(w http.ResponseWriter, r *http.Request) {
+	if transactionService == nil {
+		logger.ErrorContext(r.Context(), "Transaction service not initialized", nil)
+		http.Error(w, "Database not available", http.StatusInternalServerError)
+		return
+	}
+
+	hold, err := transactionService.GetHold(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeHoldError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hold)
+}
+
+// GetHoldsByAccountID lists the holds of an account, latest first
+func GetHoldsByAccountID(w http.ResponseWriter, r *http.Request) {
+	if transactionService == nil {
+		logger.ErrorContext(r.Context(), "Transaction service not initialized", nil)
+		http.Error(w, "Database not available", http.StatusInternalServerError)
+		return
+	}
+
+	holds, err := transactionService.GetHolds(r.Context(), mux.Vars(r)["account_id"])
+	if err != nil {
+		writeHoldError(w, r, err)
+		return
+	}
+
+	if holds == nil {
+		holds = []*dto.HoldDTO{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(holds)
+}
+
+// CaptureHold posts a withdrawal of the held funds. The body is optional,
+// without an amount the full hold is captured.
+func CaptureHold(w http.ResponseWriter, r *http.Request) {
+	var req dto.CaptureHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		logger.ErrorContext(r.Context(), "Failed to decode hold capture JSON", err)
+		if isBodyTooLarge(err) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if transactionService == nil {
+		logger.ErrorContext(r.Context(), "Transaction service not initialized", nil)
+		http.Error(w, "Database not available", http.StatusInternalServerError)
+		return
+	}
+
+	result, err := transactionService.CaptureHold(r.Context(), mux.Vars(r)["id"], req)
+	if err != nil {
+		writeHoldError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.CaptureHoldResponse{
+		Hold:        result.Hold,
+		Transaction: transactionResponse(result.Transaction),
+		Balance:     result.Balance,
+	})
+}
+
+// ReleaseHold cancels a hold without moving any funds
+func ReleaseHold(w http.ResponseWriter, r *http.Request) {
+	if transactionService == nil {
+		logger.ErrorContext(r.Context(), "Transaction service not initialized", nil)
+		http.Error(w, "Database not available", http.StatusInternalServerError)
+		return
+	}
+
+	hold, err := transactionService.ReleaseHold(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeHoldError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hold)
+}
+
+// writeHoldError maps a hold error to an HTTP error, the rest are mapped
+// like transaction errors
+func writeHoldError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrHoldNotFound):
+		http.Error(w, "Hold not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrHoldNotActive):
+		http.Error(w, "Hold is no longer active", http.StatusConflict)
+	case errors.Is(err, service.ErrCaptureExceedsHold):
+		http.Error(w, "Capture amount exceeds the hold", http.StatusBadRequest)
+	case errors.Is(err, service.ErrHoldsNotSupported):
+		http.Error(w, "Holds are not supported", http.StatusInternalServerError)
+	default:
+		writeTransactionError(w, r, err)
+	}
+}
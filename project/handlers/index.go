@@ -6,11 +6,12 @@ import (
 	"path/filepath"
 
 	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/openapi"
 )
 
 // IndexHandler serves the API documentation page
 func IndexHandler(w http.ResponseWriter, r *http.Request) {
-	logger.Info("Serving API documentation page", nil)
+	logger.InfoContext(r.Context(), "Serving API documentation page", nil)
 
 	// Get the template file path
 	templatePath := filepath.Join("templates", "index.html")
@@ -18,7 +19,7 @@ func IndexHandler(w http.ResponseWriter, r *http.Request) {
 	// Parse the template
 	tmpl, err := template.ParseFiles(templatePath)
 	if err != nil {
-		logger.Error("Failed to parse template", err)
+		logger.ErrorContext(r.Context(), "Failed to parse template", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
@@ -26,10 +27,27 @@ func IndexHandler(w http.ResponseWriter, r *http.Request) {
 	// Set content type
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
+	// The endpoint list comes from the OpenAPI document so the page can't drift from the routes
+	type endpointGroup struct {
+		Tag       string
+		Endpoints []openapi.Endpoint
+	}
+	var groups []endpointGroup
+	if openAPIDocument != nil {
+		for _, endpoint := range openAPIDocument.Endpoints() {
+			if len(groups) == 0 || groups[len(groups)-1].Tag != endpoint.Tag {
+				groups = append(groups, endpointGroup{Tag: endpoint.Tag})
+			}
+			groups[len(groups)-1].Endpoints = append(groups[len(groups)-1].Endpoints, endpoint)
+		}
+	}
+
 	// Execute the template
-	err = tmpl.Execute(w, nil)
+	err = tmpl.Execute(w, map[string]interface{}{
+		"Groups": groups,
+	})
 	if err != nil {
-		logger.Error("Failed to execute template", err)
+		logger.ErrorContext(r.Context(), "Failed to execute template", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
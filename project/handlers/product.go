@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/product"
+)
+
+var productCatalog = product.DefaultCatalog()
+
+// SetProductCatalog sets the account products (called from main)
+func SetProductCatalog(catalog product.Catalog) {
+	productCatalog = catalog
+}
+
+// GetProducts lists the account products with their interest rate and fees
+func GetProducts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(productCatalog.All())
+}
+
+// accountProduct is the product code of an account, accounts created before
+// products were introduced have the default one
+func accountProduct(account *dto.AccountDTO) string {
+	if account.Product == "" {
+		return product.Default
+	}
+	return account.Product
+}
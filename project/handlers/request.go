@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gcalvocr/go-testing/middleware"
+)
+
+// isBodyTooLarge reports whether decoding failed because the body exceeded
+// the cap set by the body limit middleware
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// writeErrorCode writes a JSON error body whose code tells API clients which
+// rule rejected the request
+func writeErrorCode(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(middleware.ErrorResponse{Error: code, Message: message})
+}
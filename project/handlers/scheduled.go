@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/authz"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/repository"
+	"github.com/gcalvocr/go-testing/scheduler"
+	"github.com/gcalvocr/go-testing/service"
+	"github.com/gorilla/mux"
+)
+
+var scheduledRepo repository.ScheduledTransactionRepository
+
+// SetScheduledTransactionRepository sets the scheduled transaction repository (called from main)
+func SetScheduledTransactionRepository(repo repository.ScheduledTransactionRepository) {
+	scheduledRepo = repo
+}
+
+// scheduleClock tells the next run of new and resumed scheduled transactions
+var scheduleClock scheduler.Clock = scheduler.SystemClock{}
+
+// SetScheduleClock sets the clock next runs are computed from, the one the
+// scheduler worker checks them against (called by the server)
+func SetScheduleClock(clock scheduler.Clock) {
+	scheduleClock = clock
+}
+
+// CreateScheduledTransaction schedules a one-off or recurring transaction.
+// The creator must be allowed to transact on the account, the scheduler
+// then posts each run on their behalf.
+func CreateScheduledTransaction(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateScheduledTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.ErrorContext(r.Context(), "Failed to decode scheduled transaction JSON", err)
+		if isBodyTooLarge(err) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	ctx := logger.WithAccountID(r.Context(), req.AccountID)
+
+	logger.InfoContext(ctx, "Creating scheduled transaction", map[string]interface{}{
+		"account_id": req.AccountID,
+		"amount":     req.Amount,
+		"type":       req.Type,
+		"schedule":   req.Schedule,
+	})
+
+	if accountRepo == nil || scheduledRepo == nil {
+		logger.ErrorContext(ctx, "Repositories not initialized", nil)
+		http.Error(w, "Database not available", http.StatusInternalServerError)
+		return
+	}
+
+	if err := service.Validate(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Schedules run on whole seconds so the stored times compare equal in
+	// both databases
+	var start time.Time
+	if req.StartAt != nil {
+		start = req.StartAt.UTC().Truncate(time.Second)
+	}
+	schedule, err := scheduler.NewSchedule(req.Schedule, start, req.Cron, req.EndAt)
+	if err != nil {
+		http.Error(w, "Invalid schedule: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	next, ok := schedule.Next(scheduleClock.Now())
+	if !ok {
+		http.Error(w, "Invalid schedule: it has no runs in the future", http.StatusBadRequest)
+		return
+	}
+
+	account, err := accountRepo.GetByID(ctx, req.AccountID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get account for scheduled transaction", err)
+		http.Error(w, "Failed to retrieve account", http.StatusInternalServerError)
+		return
+	}
+
+	if account == nil {
+		http.Error(w, "Account not found", http.StatusNotFound)
+		return
+	}
+
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if !authz.CanTransact(principal, account) {
+		forbidden(w, r, "Principal may not schedule transactions on account")
+		return
+	}
+
+	scheduled := &dto.ScheduledTransactionDTO{
+		AccountID: req.AccountID,
+		Amount:    req.Amount,
+		Type:      req.Type,
+		Schedule:  req.Schedule,
+		StartAt:   start,
+		Cron:      req.Cron,
+		EndAt:     req.EndAt,
+		Active:    true,
+		NextRunAt: &next,
+	}
+	if principal != nil {
+		scheduled.CreatedBy = principal.ID
+	}
+
+	if err := scheduledRepo.Create(ctx, scheduled); err != nil {
+		logger.ErrorContext(ctx, "Failed to create scheduled transaction", err)
+		http.Error(w, "Failed to create scheduled transaction", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(scheduled)
+}
+
+// GetScheduledTransactionsByAccountID lists the scheduled transactions of an account
+func GetScheduledTransactionsByAccountID(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["account_id"]
+
+	if accountRepo == nil || scheduledRepo == nil {
+		logger.ErrorContext(r.Context(), "Repositories not initialized", nil)
+		http.Error(w, "Database not available", http.StatusInternalServerError)
+		return
+	}
+
+	account, err := accountRepo.GetByID(r.Context(), accountID)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to get account for scheduled transactions", err)
+		http.Error(w, "Failed to retrieve account", http.StatusInternalServerError)
+		return
+	}
+
+	if account == nil {
+		http.Error(w, "Account not found", http.StatusNotFound)
+		return
+	}
+
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	if !authz.CanViewAccount(principal, account) {
+		forbidden(w, r, "Principal may not view account scheduled transactions")
+		return
+	}
+
+	scheduled, err := scheduledRepo.GetByAccountID(r.Context(), accountID)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to get scheduled transactions", err)
+		http.Error(w, "Failed to retrieve scheduled transactions", http.StatusInternalServerError)
+		return
+	}
+
+	if scheduled == nil {
+		scheduled = []*dto.ScheduledTransactionDTO{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scheduled)
+}
+
+// GetScheduledTransaction returns a scheduled transaction
+func GetScheduledTransaction(w http.ResponseWriter, r *http.Request) {
+	scheduled, ok := loadScheduledTransaction(w, r, authz.CanViewAccount)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scheduled)
+}
+
+// UpdateScheduledTransaction changes the amount or end of a scheduled
+// transaction, or pauses and resumes it. Runs missed while paused are
+// skipped: a resumed schedule continues with its next run after now.
+func UpdateScheduledTransaction(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateScheduledTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.ErrorContext(r.Context(), "Failed to decode scheduled transaction update JSON", err)
+		if isBodyTooLarge(err) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := service.Validate(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	scheduled, ok := loadScheduledTransaction(w, r, authz.CanTransact)
+	if !ok {
+		return
+	}
+
+	resumed := req.Active != nil && *req.Active && !scheduled.Active
+	if req.Amount != nil {
+		scheduled.Amount = *req.Amount
+	}
+	if req.Active != nil {
+		scheduled.Active = *req.Active
+	}
+	if req.EndAt != nil {
+		scheduled.EndAt = req.EndAt
+	}
+
+	schedule, err := scheduler.ScheduleOf(scheduled)
+	if err != nil {
+		http.Error(w, "Invalid schedule: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case resumed:
+		scheduled.NextRunAt = nil
+		if next, ok := schedule.Next(scheduleClock.Now()); ok {
+			scheduled.NextRunAt = &next
+		}
+	case scheduled.NextRunAt != nil && scheduled.EndAt != nil && scheduled.NextRunAt.After(*scheduled.EndAt):
+		scheduled.NextRunAt = nil
+	}
+
+	if err := scheduledRepo.Update(r.Context(), scheduled); err != nil {
+		logger.ErrorContext(r.Context(), "Failed to update scheduled transaction", err)
+		http.Error(w, "Failed to update scheduled transaction", http.StatusInternalServerError)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Scheduled transaction updated", map[string]interface{}{
+		"scheduled_transaction_id": scheduled.ID,
+		"active":                   scheduled.Active,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scheduled)
+}
+
+// DeleteScheduledTransaction cancels a scheduled transaction, its run history is kept
+func DeleteScheduledTransaction(w http.ResponseWriter, r *http.Request) {
+	scheduled, ok := loadScheduledTransaction(w, r, authz.CanTransact)
+	if !ok {
+		return
+	}
+
+	if err := scheduledRepo.Delete(r.Context(), scheduled.ID); err != nil {
+		logger.ErrorContext(r.Context(), "Failed to delete scheduled transaction", err)
+		http.Error(w, "Failed to delete scheduled transaction", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetScheduledTransactionRuns returns the outcome of every run of a scheduled transaction, latest first
+func GetScheduledTransactionRuns(w http.ResponseWriter, r *http.Request) {
+	scheduled, ok := loadScheduledTransaction(w, r, authz.CanViewAccount)
+	if !ok {
+		return
+	}
+
+	runs, err := scheduledRepo.GetRuns(r.Context(), scheduled.ID)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to get scheduled transaction runs", err)
+		http.Error(w, "Failed to retrieve scheduled transaction runs", http.StatusInternalServerError)
+		return
+	}
+
+	if runs == nil {
+		runs = []*dto.ScheduledTransactionRunDTO{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runs)
+}
+
+// loadScheduledTransaction loads the scheduled transaction in the path and
+// checks the principal is allowed on its account. It writes the error
+// response and returns false when the request cannot proceed.
+func loadScheduledTransaction(w http.ResponseWriter, r *http.Request, allowed func(*auth.Principal, *dto.AccountDTO) bool) (*dto.ScheduledTransactionDTO, bool) {
+	id := mux.Vars(r)["id"]
+
+	if accountRepo == nil || scheduledRepo == nil {
+		logger.ErrorContext(r.Context(), "Repositories not initialized", nil)
+		http.Error(w, "Database not available", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	scheduled, err := scheduledRepo.GetByID(r.Context(), id)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to get scheduled transaction", err)
+		http.Error(w, "Failed to retrieve scheduled transaction", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if scheduled == nil {
+		logger.WarnContext(r.Context(), "Scheduled transaction not found", map[string]interface{}{
+			"scheduled_transaction_id": id,
+		})
+		http.Error(w, "Scheduled transaction not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	account, err := accountRepo.GetByID(r.Context(), scheduled.AccountID)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to get account for scheduled transaction", err)
+		http.Error(w, "Failed to retrieve account", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	if !allowed(principal, account) {
+		forbidden(w, r, "Principal may not access scheduled transaction")
+		return nil, false
+	}
+	return scheduled, true
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/repository"
+	"github.com/gcalvocr/go-testing/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedClock struct{ now time.Time }
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+// fakeAccounts is an AccountRepository with the accounts to schedule on, the
+// other methods panic
+type fakeAccounts struct {
+	repository.AccountRepository
+	accounts map[string]*dto.AccountDTO
+}
+
+func (f fakeAccounts) GetByID(ctx context.Context, id string) (*dto.AccountDTO, error) {
+	return f.accounts[id], nil
+}
+
+// fakeScheduled keeps the created scheduled transactions, the other methods panic
+type fakeScheduled struct {
+	repository.ScheduledTransactionRepository
+	created []*dto.ScheduledTransactionDTO
+}
+
+func (f *fakeScheduled) Create(ctx context.Context, scheduled *dto.ScheduledTransactionDTO) error {
+	scheduled.ID = "sched-1"
+	f.created = append(f.created, scheduled)
+	return nil
+}
+
+func TestCreateScheduledTransactionUsesTheClock(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	SetAccountRepository(fakeAccounts{accounts: map[string]*dto.AccountDTO{
+		"acc-1": {ID: "acc-1", OwnerID: "alice"},
+	}})
+	SetScheduleClock(fixedClock{now})
+	t.Cleanup(func() {
+		SetAccountRepository(nil)
+		SetScheduledTransactionRepository(nil)
+		SetScheduleClock(scheduler.SystemClock{})
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantNext   time.Time
+	}{
+		{
+			name:       "daily schedule started in the past",
+			body:       `{"account_id":"acc-1","amount":10,"type":"deposit","schedule":"daily","start_at":"2025-03-01T09:00:00Z"}`,
+			wantStatus: http.StatusCreated,
+			wantNext:   time.Date(2025, 3, 11, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "one-off run after now",
+			body:       `{"account_id":"acc-1","amount":10,"type":"deposit","schedule":"once","start_at":"2025-03-12T09:00:00Z"}`,
+			wantStatus: http.StatusCreated,
+			wantNext:   time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "one-off run before now",
+			body:       `{"account_id":"acc-1","amount":10,"type":"deposit","schedule":"once","start_at":"2025-03-09T09:00:00Z"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduled := &fakeScheduled{}
+			SetScheduledTransactionRepository(scheduled)
+
+			req := httptest.NewRequest("POST", "/scheduled-transactions", strings.NewReader(tt.body))
+			req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{ID: "alice", Roles: []string{"customer"}}))
+			rr := httptest.NewRecorder()
+			CreateScheduledTransaction(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code, rr.Body.String())
+			if tt.wantStatus != http.StatusCreated {
+				assert.Empty(t, scheduled.created)
+				return
+			}
+
+			var response dto.ScheduledTransactionDTO
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			require.NotNil(t, response.NextRunAt)
+			assert.True(t, tt.wantNext.Equal(*response.NextRunAt), "next_run_at %v", response.NextRunAt)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/authz"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/statement"
+	"github.com/gorilla/mux"
+)
+
+// GetAccountStatement exports the statement of an account for a period as
+// CSV, JSON Lines or OFX. The ledger lines are streamed from a repository
+// cursor so large accounts are never loaded into memory.
+func GetAccountStatement(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
+	query := r.URL.Query()
+
+	logger.InfoContext(r.Context(), "Exporting account statement", map[string]interface{}{
+		"account_id": accountID,
+		"format":     query.Get("format"),
+		"from":       query.Get("from"),
+		"to":         query.Get("to"),
+	})
+
+	if accountRepo == nil || transactionRepo == nil {
+		logger.ErrorContext(r.Context(), "Repositories not initialized", nil)
+		http.Error(w, "Database not available", http.StatusInternalServerError)
+		return
+	}
+
+	format, err := statement.ParseFormat(query.Get("format"))
+	if err != nil {
+		http.Error(w, "Invalid format, use csv, jsonl or ofx", http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := statement.ParsePeriod(query.Get("from"), query.Get("to"), time.Now().UTC())
+	if err != nil {
+		http.Error(w, "Invalid period: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	account, err := accountRepo.GetByID(r.Context(), accountID)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to get account for statement", err)
+		http.Error(w, "Failed to retrieve account", http.StatusInternalServerError)
+		return
+	}
+
+	if account == nil {
+		logger.WarnContext(r.Context(), "Account not found for statement", map[string]interface{}{
+			"account_id": accountID,
+		})
+		http.Error(w, "Account not found", http.StatusNotFound)
+		return
+	}
+
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	if !authz.CanViewAccount(principal, account) {
+		forbidden(w, r, "Principal may not view account statement")
+		return
+	}
+
+	// The balances are derived from the current balance by undoing the
+	// transactions made since each end of the period
+	sinceFrom, err := transactionRepo.GetTransactionSummarySince(r.Context(), accountID, from)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to summarize transactions for statement", err)
+		http.Error(w, "Failed to retrieve transactions", http.StatusInternalServerError)
+		return
+	}
+	sinceTo, err := transactionRepo.GetTransactionSummarySince(r.Context(), accountID, to)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to summarize transactions for statement", err)
+		http.Error(w, "Failed to retrieve transactions", http.StatusInternalServerError)
+		return
+	}
+
+	header := statement.Header{
+		AccountID:      account.ID,
+		AccountName:    account.Name,
+		Currency:       account.Currency,
+		From:           from,
+		To:             to,
+		OpeningBalance: sinceFrom.CurrentBalance - sinceFrom.Net(),
+		GeneratedAt:    time.Now().UTC(),
+	}
+	if from.IsZero() {
+		header.From = account.CreatedAt
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="statement-%s-%s.%s"`,
+		account.ID, to.Format("20060102"), format))
+
+	writer, err := statement.NewWriter(statement.NewEncoder(format, w), header)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to write statement header", err)
+		return
+	}
+
+	// Once streaming has started the status can't change, a failure leaves
+	// the statement without its closing balance
+	err = transactionRepo.StreamByAccountID(r.Context(), accountID, from, to, writer.Add)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to stream statement transactions", err)
+		return
+	}
+
+	totals, err := writer.Close()
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to write statement totals", err)
+		return
+	}
+
+	// Transactions committed while the statement was generated make the
+	// streamed lines and the summaries disagree
+	expected := periodTotals(sinceFrom, sinceTo)
+	if totals.TotalTransactions != expected.TotalTransactions ||
+		!sameAmount(totals.TotalDeposits, expected.TotalDeposits) ||
+		!sameAmount(totals.TotalWithdrawals, expected.TotalWithdrawals) {
+		logger.WarnContext(r.Context(), "Statement totals disagree with the transaction summary", map[string]interface{}{
+			"account_id":          accountID,
+			"transactions":        totals.TotalTransactions,
+			"summary_count":       expected.TotalTransactions,
+			"deposits":            totals.TotalDeposits,
+			"summary_deposits":    expected.TotalDeposits,
+			"withdrawals":         totals.TotalWithdrawals,
+			"summary_withdrawals": expected.TotalWithdrawals,
+		})
+	}
+
+	logger.InfoContext(r.Context(), "Account statement exported", map[string]interface{}{
+		"account_id":      accountID,
+		"format":          string(format),
+		"transactions":    totals.TotalTransactions,
+		"closing_balance": totals.ClosingBalance,
+	})
+}
+
+// periodTotals are the totals of the transactions between two summaries
+func periodTotals(sinceFrom, sinceTo *dto.TransactionSummary) statement.Totals {
+	return statement.Totals{
+		TotalTransactions: sinceFrom.TotalTransactions - sinceTo.TotalTransactions,
+		TotalDeposits:     credits(sinceFrom) - credits(sinceTo),
+		TotalWithdrawals:  debits(sinceFrom) - debits(sinceTo),
+	}
+}
+
+// credits and debits total the summarized transactions the way statements
+// do, interest is a credit and fees a debit
+func credits(summary *dto.TransactionSummary) float64 {
+	return summary.TotalDeposits + summary.TotalInterest
+}
+
+func debits(summary *dto.TransactionSummary) float64 {
+	return summary.TotalWithdrawals + summary.TotalFees
+}
+
+// sameAmount compares amounts to the cent
+func sameAmount(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/authz"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/feed"
+	"github.com/gcalvocr/go-testing/logger"
+)
+
+// streamKeepAlive is how often an idle event stream sends a comment so
+// proxies don't close the connection
+const streamKeepAlive = 15 * time.Second
+
+var eventFeed *feed.Feed
+
+// SetEventFeed sets the feed streamed by StreamEvents (called from main)
+func SetEventFeed(f *feed.Feed) {
+	eventFeed = f
+}
+
+// StreamEvents pushes the domain events as Server-Sent Events, each with
+// its outbox sequence as ID. Customers must pick one of their accounts with
+// account_id, staff may stream every account. A client reconnecting with
+// Last-Event-ID (or last_event_id, for the first connection) first receives
+// the events it missed.
+func StreamEvents(w http.ResponseWriter, r *http.Request) {
+	if eventFeed == nil || accountRepo == nil {
+		logger.ErrorContext(r.Context(), "Event feed not initialized", nil)
+		http.Error(w, "Event feed not available", http.StatusInternalServerError)
+		return
+	}
+
+	accountID := r.URL.Query().Get("account_id")
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	if accountID == "" {
+		if !authz.CanListAllAccounts(principal) {
+			forbidden(w, r, "Principal may not stream the events of every account")
+			return
+		}
+	} else {
+		account, err := accountRepo.GetByID(r.Context(), accountID)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "Failed to get account for event stream", err)
+			http.Error(w, "Failed to retrieve account", http.StatusInternalServerError)
+			return
+		}
+		if account == nil {
+			http.Error(w, "Account not found", http.StatusNotFound)
+			return
+		}
+		if !authz.CanViewAccount(principal, account) {
+			forbidden(w, r, "Principal may not stream the events of account")
+			return
+		}
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var after int64
+	if lastEventID != "" {
+		var err error
+		if after, err = strconv.ParseInt(lastEventID, 10, 64); err != nil || after < 0 {
+			http.Error(w, "Last-Event-ID must be the sequence of an event", http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &sseWriter{w: w, controller: http.NewResponseController(w)}
+	// Tell EventSource clients how soon to reconnect
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if err := stream.controller.Flush(); err != nil {
+		logger.ErrorContext(r.Context(), "Event stream cannot be flushed", err)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Event stream opened", map[string]interface{}{
+		"account_id":    accountID,
+		"last_event_id": after,
+	})
+
+	err := eventFeed.Stream(r.Context(), accountID, after, streamKeepAlive, stream)
+	switch {
+	case errors.Is(err, feed.ErrDropped):
+		logger.WarnContext(r.Context(), "Event stream dropped for falling behind", nil)
+	case err != nil && r.Context().Err() == nil:
+		logger.ErrorContext(r.Context(), "Event stream failed", err)
+	default:
+		logger.InfoContext(r.Context(), "Event stream closed", nil)
+	}
+}
+
+// sseWriter writes events in the text/event-stream format
+type sseWriter struct {
+	w          http.ResponseWriter
+	controller *http.ResponseController
+}
+
+func (s *sseWriter) WriteEvent(event *dto.EventDTO) error {
+	// Marshal never breaks lines, which would end the data field
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "id: %d\nevent: %s\ndata: %s\n\n", event.Sequence, event.Type, data); err != nil {
+		return err
+	}
+	return s.controller.Flush()
+}
+
+func (s *sseWriter) WriteKeepAlive() error {
+	if _, err := fmt.Fprint(s.w, ": keep-alive\n\n"); err != nil {
+		return err
+	}
+	return s.controller.Flush()
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+)
+
+// CreateTransfer moves money from an account of the principal to another account
+func CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.ErrorContext(r.Context(), "Failed to decode transfer JSON", err)
+		if isBodyTooLarge(err) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	ctx := logger.WithAccountID(r.Context(), req.FromAccountID)
+
+	logger.InfoContext(ctx, "Creating transfer", map[string]interface{}{
+		"from_account_id": req.FromAccountID,
+		"to_account_id":   req.ToAccountID,
+		"amount":          req.Amount,
+	})
+
+	if transactionService == nil {
+		logger.ErrorContext(ctx, "Transaction service not initialized", nil)
+		http.Error(w, "Database not available", http.StatusInternalServerError)
+		return
+	}
+
+	result, err := transactionService.Transfer(ctx, req)
+	if err != nil {
+		writeTransactionError(w, r, err)
+		return
+	}
+
+	logger.InfoContext(ctx, "Transfer completed", map[string]interface{}{
+		"from_account_id": req.FromAccountID,
+		"to_account_id":   req.ToAccountID,
+		"withdrawal_id":   result.Withdrawal.ID,
+		"deposit_id":      result.Deposit.ID,
+		"balance":         result.Balance,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dto.TransferResponse{
+		Withdrawal: transactionResponse(result.Withdrawal),
+		Deposit:    transactionResponse(result.Deposit),
+		Balance:    result.Balance,
+	})
+}
+
+// transactionResponse converts a transaction to its API response
+func transactionResponse(transaction *dto.TransactionDTO) dto.TransactionResponse {
+	return dto.TransactionResponse{
+		ID:        transaction.ID,
+		AccountID: transaction.AccountID,
+		Amount:    transaction.Amount,
+		Type:      transaction.Type,
+		CreatedAt: transaction.CreatedAt,
+		UpdatedAt: transaction.UpdatedAt,
+	}
+}
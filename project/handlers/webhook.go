@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/authz"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/repository"
+	"github.com/gcalvocr/go-testing/service"
+	"github.com/gcalvocr/go-testing/webhook"
+	"github.com/gorilla/mux"
+)
+
+var webhookRepo repository.WebhookRepository
+
+// SetWebhookRepository sets the webhook repository (called from main)
+func SetWebhookRepository(repo repository.WebhookRepository) {
+	webhookRepo = repo
+}
+
+// CreateWebhookSubscription subscribes a URL to events, admins only. The
+// response is the only one that includes the signing secret.
+func CreateWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	principal, ok := canManageWebhooks(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateWebhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.ErrorContext(r.Context(), "Failed to decode webhook subscription JSON", err)
+		if isBodyTooLarge(err) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := service.Validate(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	subscription := &dto.WebhookSubscriptionDTO{
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		AccountID:  req.AccountID,
+		Secret:     req.Secret,
+		CreatedBy:  principal.ID,
+	}
+	if subscription.Secret == "" {
+		subscription.Secret = webhook.NewSecret()
+	}
+
+	if err := webhookRepo.CreateSubscription(r.Context(), subscription); err != nil {
+		logger.ErrorContext(r.Context(), "Failed to create webhook subscription", err)
+		http.Error(w, "Failed to create webhook subscription", http.StatusInternalServerError)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Webhook subscription created", map[string]interface{}{
+		"subscription_id": subscription.ID,
+		"url":             subscription.URL,
+		"event_types":     subscription.EventTypes,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(subscription)
+}
+
+// GetWebhookSubscriptions lists the webhook subscriptions, admins only
+func GetWebhookSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if _, ok := canManageWebhooks(w, r); !ok {
+		return
+	}
+
+	subscriptions, err := webhookRepo.GetSubscriptions(r.Context())
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to get webhook subscriptions", err)
+		http.Error(w, "Failed to get webhook subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	if subscriptions == nil {
+		subscriptions = []*dto.WebhookSubscriptionDTO{}
+	}
+	for _, subscription := range subscriptions {
+		subscription.Secret = ""
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subscriptions)
+}
+
+// GetWebhookSubscription returns a webhook subscription, admins only
+func GetWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	if _, ok := canManageWebhooks(w, r); !ok {
+		return
+	}
+
+	subscription, ok := findWebhookSubscription(w, r)
+	if !ok {
+		return
+	}
+	subscription.Secret = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subscription)
+}
+
+// DeleteWebhookSubscription unsubscribes a URL, admins only. Its pending
+// deliveries turn dead when they are next attempted.
+func DeleteWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	if _, ok := canManageWebhooks(w, r); !ok {
+		return
+	}
+
+	subscription, ok := findWebhookSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	if err := webhookRepo.DeleteSubscription(r.Context(), subscription.ID); err != nil {
+		logger.ErrorContext(r.Context(), "Failed to delete webhook subscription", err)
+		http.Error(w, "Failed to delete webhook subscription", http.StatusInternalServerError)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Webhook subscription deleted", map[string]interface{}{
+		"subscription_id": subscription.ID,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries returns the delivery log of a subscription, latest
+// first, optionally only the deliveries with the given status
+func GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if _, ok := canManageWebhooks(w, r); !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", dto.DeliveryPending, dto.DeliveryDelivered, dto.DeliveryDead:
+	default:
+		http.Error(w, "status must be pending, delivered or dead", http.StatusBadRequest)
+		return
+	}
+
+	subscription, ok := findWebhookSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := webhookRepo.GetDeliveries(r.Context(), subscription.ID, status)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to get webhook deliveries", err)
+		http.Error(w, "Failed to get webhook deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []*dto.WebhookDeliveryDTO{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// RedeliverWebhook queues a delivery to be sent again right away with a
+// fresh set of attempts, typically a dead one once the receiver is fixed
+func RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	if _, ok := canManageWebhooks(w, r); !ok {
+		return
+	}
+
+	subscription, ok := findWebhookSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := webhookRepo.GetDelivery(r.Context(), mux.Vars(r)["delivery_id"])
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to get webhook delivery", err)
+		http.Error(w, "Failed to get webhook delivery", http.StatusInternalServerError)
+		return
+	}
+	if delivery == nil || delivery.SubscriptionID != subscription.ID {
+		http.Error(w, "Webhook delivery not found", http.StatusNotFound)
+		return
+	}
+
+	webhook.Redeliver(delivery, time.Now())
+	if err := webhookRepo.UpdateDelivery(r.Context(), delivery); err != nil {
+		logger.ErrorContext(r.Context(), "Failed to queue webhook redelivery", err)
+		http.Error(w, "Failed to queue webhook redelivery", http.StatusInternalServerError)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Webhook redelivery queued", map[string]interface{}{
+		"subscription_id": subscription.ID,
+		"delivery_id":     delivery.ID,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// canManageWebhooks writes the error and reports false unless the principal
+// may manage webhooks and the repository is available
+func canManageWebhooks(w http.ResponseWriter, r *http.Request) (*auth.Principal, bool) {
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	if !authz.CanManageWebhooks(principal) {
+		forbidden(w, r, "Principal may not manage webhooks")
+		return nil, false
+	}
+
+	if webhookRepo == nil {
+		logger.ErrorContext(r.Context(), "Webhook repository not initialized", nil)
+		http.Error(w, "Database not available", http.StatusInternalServerError)
+		return nil, false
+	}
+	return principal, true
+}
+
+// findWebhookSubscription loads the subscription in the path, writing a 404
+// when it doesn't exist
+func findWebhookSubscription(w http.ResponseWriter, r *http.Request) (*dto.WebhookSubscriptionDTO, bool) {
+	subscription, err := webhookRepo.GetSubscription(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Failed to get webhook subscription", err)
+		http.Error(w, "Failed to get webhook subscription", http.StatusInternalServerError)
+		return nil, false
+	}
+	if subscription == nil {
+		http.Error(w, "Webhook subscription not found", http.StatusNotFound)
+		return nil, false
+	}
+	return subscription, true
+}
@@ -0,0 +1,212 @@
+// Package httprecord is an http.RoundTripper that records the outbound
+// calls of a client to a cassette file and replays them from it, so tests
+// of code calling third-party APIs run offline against committed cassettes:
+//
+//	transport, err := httprecord.NewTransport("testdata/exchange_usd.json", httprecord.ModeFromEnv(), nil)
+//	client := &http.Client{Transport: transport}
+//	...
+//	err = transport.Save()
+//
+// In replay mode a request no interaction of the cassette matches fails with
+// ErrNoInteraction instead of reaching the network.
+package httprecord
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Mode is what a Transport does with a request
+type Mode string
+
+const (
+	// ModeReplay answers from the cassette and never calls the network
+	ModeReplay Mode = "replay"
+	// ModeRecord calls the network and writes every interaction to the
+	// cassette on Save, replacing it
+	ModeRecord Mode = "record"
+	// ModePassthrough calls the network and records nothing
+	ModePassthrough Mode = "passthrough"
+)
+
+// ModeEnv is the environment variable ModeFromEnv reads
+const ModeEnv = "HTTP_RECORD_MODE"
+
+// Redacted replaces the values of the redacted headers in a cassette
+const Redacted = "REDACTED"
+
+// DefaultRedactedHeaders are the headers whose values never reach a cassette
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// ErrNoInteraction is returned in replay mode for requests the cassette has
+// no interaction for
+var ErrNoInteraction = errors.New("httprecord: no recorded interaction matches the request")
+
+// ModeFromEnv returns the mode named by HTTP_RECORD_MODE, ModeReplay when it
+// is not set so tests stay offline by default
+func ModeFromEnv() (Mode, error) {
+	switch mode := Mode(os.Getenv(ModeEnv)); mode {
+	case "":
+		return ModeReplay, nil
+	case ModeReplay, ModeRecord, ModePassthrough:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%s must be replay, record or passthrough, not %q", ModeEnv, mode)
+	}
+}
+
+// Transport records to and replays from one cassette
+type Transport struct {
+	path string
+	mode Mode
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	redacted []string
+	cassette Cassette
+	// used marks the interactions replayed so far, so identical requests
+	// get the interactions in their recorded order
+	used []bool
+}
+
+// NewTransport creates a transport for the cassette at path. Record and
+// passthrough modes send requests with next, or http.DefaultTransport when
+// next is nil. Replay mode loads the cassette now and fails if it is missing.
+func NewTransport(path string, mode Mode, next http.RoundTripper) (*Transport, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	t := &Transport{path: path, mode: mode, next: next, redacted: DefaultRedactedHeaders}
+
+	switch mode {
+	case ModeReplay:
+		cassette, err := LoadCassette(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading cassette (record it with %s=%s): %w", ModeEnv, ModeRecord, err)
+		}
+		t.cassette = *cassette
+		t.used = make([]bool, len(cassette.Interactions))
+	case ModeRecord, ModePassthrough:
+	default:
+		return nil, fmt.Errorf("unknown httprecord mode %q", mode)
+	}
+	return t, nil
+}
+
+// SetRedactedHeaders replaces the headers redacted from recorded requests
+// and responses, DefaultRedactedHeaders unless set
+func (t *Transport) SetRedactedHeaders(headers ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.redacted = headers
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch t.mode {
+	case ModeReplay:
+		return t.replay(req)
+	case ModeRecord:
+		return t.record(req)
+	default:
+		return t.next.RoundTrip(req)
+	}
+}
+
+// Save writes the interactions recorded so far to the cassette. It does
+// nothing outside of record mode.
+func (t *Transport) Save() error {
+	if t.mode != ModeRecord {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cassette.Save(t.path)
+}
+
+// replay answers with the first unused interaction matching req
+func (t *Transport) replay(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, interaction := range t.cassette.Interactions {
+		if !t.used[i] && interaction.Request.matches(req, body) {
+			t.used[i] = true
+			return interaction.Response.toHTTP(req), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s %s in %s", ErrNoInteraction, req.Method, req.URL, t.path)
+}
+
+// record sends req and keeps the interaction, the response body is read
+// whole and handed back to the caller
+func (t *Transport) record(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cassette.Interactions = append(t.cassette.Interactions, Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: redact(req.Header, t.redacted),
+			Body:    string(body),
+		},
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: redact(resp.Header, t.redacted),
+			Body:    string(respBody),
+		},
+	})
+	return resp, nil
+}
+
+// readBody reads the body of req and puts it back so req can still be sent
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// redact copies header with the values of the redacted headers replaced
+func redact(header http.Header, redacted []string) http.Header {
+	out := header.Clone()
+	for key := range out {
+		for _, name := range redacted {
+			if strings.EqualFold(key, name) {
+				out[key] = []string{Redacted}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+package httprecord
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upstream echoes the body of requests to /echo and counts the calls
+type upstream struct {
+	*httptest.Server
+	calls atomic.Int32
+}
+
+func newUpstream(t *testing.T) *upstream {
+	u := &upstream{}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u.calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Set-Cookie", "session=abc")
+		if r.URL.Path != "/echo" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(r.Method + " " + string(body)))
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+// call sends a request with client and returns the status and body
+func call(t *testing.T, client *http.Client, method, url, body string) (int, string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data)
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	u := newUpstream(t)
+	path := filepath.Join(t.TempDir(), "testdata", "echo.json")
+
+	recorder, err := NewTransport(path, ModeRecord, nil)
+	require.NoError(t, err)
+	client := &http.Client{Transport: recorder}
+	status, body := call(t, client, "POST", u.URL+"/echo", "one")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "POST one", body, "the caller gets the body it recorded")
+	call(t, client, "POST", u.URL+"/echo", "two")
+	call(t, client, "GET", u.URL+"/missing", "")
+	require.NoError(t, recorder.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+	assert.NotContains(t, string(data), "session=abc")
+	assert.Contains(t, string(data), Redacted)
+
+	u.Close()
+	player, err := NewTransport(path, ModeReplay, nil)
+	require.NoError(t, err)
+	client = &http.Client{Transport: player}
+
+	// Requests match on method, URL and body, not on their order
+	status, body = call(t, client, "POST", u.URL+"/echo", "two")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "POST two", body)
+	status, _ = call(t, client, "GET", u.URL+"/missing", "")
+	assert.Equal(t, http.StatusNotFound, status)
+	status, body = call(t, client, "POST", u.URL+"/echo", "one")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "POST one", body)
+}
+
+func TestReplayFailsOnUnmatchedRequests(t *testing.T) {
+	u := newUpstream(t)
+	path := filepath.Join(t.TempDir(), "echo.json")
+	recorder, err := NewTransport(path, ModeRecord, nil)
+	require.NoError(t, err)
+	call(t, &http.Client{Transport: recorder}, "POST", u.URL+"/echo", "one")
+	require.NoError(t, recorder.Save())
+
+	player, err := NewTransport(path, ModeReplay, nil)
+	require.NoError(t, err)
+	client := &http.Client{Transport: player}
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+	}{
+		{"other body", "POST", u.URL + "/echo", "two"},
+		{"other method", "PUT", u.URL + "/echo", "one"},
+		{"other URL", "POST", u.URL + "/echo?x=1", "one"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			_, err = client.Do(req)
+			assert.ErrorIs(t, err, ErrNoInteraction)
+		})
+	}
+
+	call(t, client, "POST", u.URL+"/echo", "one")
+	req, err := http.NewRequest("POST", u.URL+"/echo", strings.NewReader("one"))
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, ErrNoInteraction, "every interaction answers once")
+	assert.Equal(t, int32(1), u.calls.Load(), "replay never calls the network")
+}
+
+func TestPassthroughRecordsNothing(t *testing.T) {
+	u := newUpstream(t)
+	path := filepath.Join(t.TempDir(), "echo.json")
+
+	transport, err := NewTransport(path, ModePassthrough, nil)
+	require.NoError(t, err)
+	_, body := call(t, &http.Client{Transport: transport}, "POST", u.URL+"/echo", "one")
+	assert.Equal(t, "POST one", body)
+	require.NoError(t, transport.Save())
+
+	assert.NoFileExists(t, path)
+	_, err = NewTransport(path, ModeReplay, nil)
+	assert.Error(t, err, "replaying needs the cassette")
+}
+
+func TestModeFromEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Mode
+		wantErr bool
+	}{
+		{"", ModeReplay, false},
+		{"record", ModeRecord, false},
+		{"passthrough", ModePassthrough, false},
+		{"live", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv(ModeEnv, tt.value)
+			mode, err := ModeFromEnv()
+			assert.Equal(t, tt.want, mode)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Package interest accrues interest daily on the end-of-day balance of each
+// account and posts it, together with the product's monthly fee, once the
+// month is over.
+package interest
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gcalvocr/go-testing/product"
+)
+
+// Period is a calendar month in UTC, the unit interest and fees are posted for
+type Period struct {
+	Year  int
+	Month time.Month
+}
+
+// ParsePeriod parses a period written as YYYY-MM
+func ParsePeriod(value string) (Period, error) {
+	t, err := time.Parse("2006-01", value)
+	if err != nil {
+		return Period{}, fmt.Errorf("invalid period %q, use YYYY-MM", value)
+	}
+	return PeriodOf(t), nil
+}
+
+// PeriodOf returns the period containing t
+func PeriodOf(t time.Time) Period {
+	t = t.UTC()
+	return Period{Year: t.Year(), Month: t.Month()}
+}
+
+// Start is the first instant of the period
+func (p Period) Start() time.Time {
+	return time.Date(p.Year, p.Month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// End is the first instant after the period
+func (p Period) End() time.Time {
+	return p.Start().AddDate(0, 1, 0)
+}
+
+// Previous is the month before the period
+func (p Period) Previous() Period {
+	return PeriodOf(p.Start().AddDate(0, -1, 0))
+}
+
+func (p Period) String() string {
+	return p.Start().Format("2006-01")
+}
+
+// DayBalance is the balance of an account at the end of a day
+type DayBalance struct {
+	Date    time.Time
+	Balance float64
+}
+
+// Accrual is the interest and fee of an account for a period
+type Accrual struct {
+	Days           int
+	AverageBalance float64
+	// Interest is rounded to the cent once, after accruing every day
+	Interest  float64
+	Fee       float64
+	FeeWaived bool
+}
+
+// Accrue computes the interest and fee of a period from the end-of-day
+// balances of the days the account was open. Interest accrues on positive
+// balances at the product's annual rate divided by the days of the year of
+// its day count. The monthly fee is only charged for full periods, and is
+// waived when no end-of-day balance fell below the product's waiver balance.
+func Accrue(p product.Product, days []DayBalance, fullPeriod bool) Accrual {
+	var accrual Accrual
+	if len(days) == 0 {
+		return accrual
+	}
+
+	var interest, total float64
+	lowest := math.Inf(1)
+	for _, day := range days {
+		if day.Balance > 0 {
+			interest += day.Balance * p.AnnualRate / float64(p.DayCount.DaysInYear(day.Date.Year()))
+		}
+		total += day.Balance
+		lowest = math.Min(lowest, day.Balance)
+	}
+
+	accrual.Days = len(days)
+	accrual.AverageBalance = round(total / float64(len(days)))
+	accrual.Interest = round(interest)
+
+	if fullPeriod && p.MonthlyFee > 0 {
+		if p.FeeWaiverBalance > 0 && round(lowest) >= p.FeeWaiverBalance {
+			accrual.FeeWaived = true
+		} else {
+			accrual.Fee = p.MonthlyFee
+		}
+	}
+	return accrual
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
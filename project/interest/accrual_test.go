@@ -0,0 +1,111 @@
+package interest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gcalvocr/go-testing/product"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePeriod(t *testing.T) {
+	p, err := ParsePeriod("2024-12")
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), p.Start())
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), p.End())
+	assert.Equal(t, "2024-11", p.Previous().String())
+
+	_, err = ParsePeriod("2024-13")
+	assert.EqualError(t, err, `invalid period "2024-13", use YYYY-MM`)
+}
+
+// constantDays returns n end-of-day balances from start
+func constantDays(start time.Time, n int, balance float64) []DayBalance {
+	days := make([]DayBalance, n)
+	for i := range days {
+		days[i] = DayBalance{Date: start.AddDate(0, 0, i), Balance: balance}
+	}
+	return days
+}
+
+func TestAccrue(t *testing.T) {
+	january := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	savings := product.Product{Code: "savings", AnnualRate: 0.02, DayCount: product.Actual365}
+	checking := product.Product{Code: "checking", DayCount: product.Actual365, MonthlyFee: 5, FeeWaiverBalance: 1500}
+
+	tests := []struct {
+		name       string
+		product    product.Product
+		days       []DayBalance
+		fullPeriod bool
+		want       Accrual
+	}{
+		{
+			name:       "interest on a constant balance",
+			product:    savings,
+			days:       constantDays(january, 31, 1000),
+			fullPeriod: true,
+			// 1000 * 0.02 / 365 * 31 = 1.6986
+			want: Accrual{Days: 31, AverageBalance: 1000, Interest: 1.70},
+		},
+		{
+			name:       "actual/360 accrues more per day",
+			product:    product.Product{AnnualRate: 0.02, DayCount: product.Actual360},
+			days:       constantDays(january, 31, 1000),
+			fullPeriod: true,
+			// 1000 * 0.02 / 360 * 31 = 1.7222
+			want: Accrual{Days: 31, AverageBalance: 1000, Interest: 1.72},
+		},
+		{
+			name:    "negative balances earn nothing",
+			product: savings,
+			days: append(constantDays(january, 10, -500),
+				constantDays(january.AddDate(0, 0, 10), 21, 3650)...),
+			fullPeriod: true,
+			// 3650 * 0.02 / 365 * 21 = 4.20
+			want: Accrual{Days: 31, AverageBalance: 2311.29, Interest: 4.20},
+		},
+		{
+			name:       "interest is rounded once, not per day",
+			product:    savings,
+			days:       constantDays(january, 31, 100),
+			fullPeriod: true,
+			// 0.0055 a day would round to 0.01 and post 0.31
+			want: Accrual{Days: 31, AverageBalance: 100, Interest: 0.17},
+		},
+		{
+			name:       "monthly fee",
+			product:    checking,
+			days:       append(constantDays(january, 30, 2000), DayBalance{Date: january.AddDate(0, 0, 30), Balance: 1499.99}),
+			fullPeriod: true,
+			want:       Accrual{Days: 31, AverageBalance: 1983.87, Fee: 5},
+		},
+		{
+			name:       "fee waived above the waiver balance all month",
+			product:    checking,
+			days:       constantDays(january, 31, 1500),
+			fullPeriod: true,
+			want:       Accrual{Days: 31, AverageBalance: 1500, FeeWaived: true},
+		},
+		{
+			name:    "no fee for the month the account was opened",
+			product: checking,
+			days:    constantDays(january.AddDate(0, 0, 20), 11, 10),
+			want:    Accrual{Days: 11, AverageBalance: 10},
+		},
+		{
+			name:       "no days",
+			product:    checking,
+			fullPeriod: true,
+			want:       Accrual{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Accrue(tt.product, tt.days, tt.fullPeriod))
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package interest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/product"
+	"github.com/gcalvocr/go-testing/repository"
+	"github.com/gcalvocr/go-testing/service"
+)
+
+// Statuses of an account in a run
+const (
+	StatusPosted        = "posted"
+	StatusAlreadyPosted = "already_posted"
+	StatusWouldPost     = "would_post"
+	StatusSkipped       = "skipped"
+	StatusFailed        = "failed"
+)
+
+var (
+	// ErrPeriodNotOver is returned when asked to post a period that has not ended
+	ErrPeriodNotOver = errors.New("the period has not ended yet")
+	// ErrAccountNotFound is returned when the requested account does not exist
+	ErrAccountNotFound = errors.New("account not found")
+)
+
+// Poster posts interest and fees, it is implemented by service.TransactionService
+type Poster interface {
+	PostAccrual(ctx context.Context, accountID, transactionType string, amount float64) (*service.TransactionResult, error)
+}
+
+// Options select what a run accrues
+type Options struct {
+	Period Period
+	// AccountID limits the run to one account, all accounts when empty
+	AccountID string
+	// DryRun computes the accruals without posting them
+	DryRun bool
+}
+
+// Result is the outcome of a run for one account
+type Result struct {
+	AccountID string
+	Product   string
+	Status    string
+	// Reason explains a skipped or failed account
+	Reason string
+	Accrual
+}
+
+// Engine posts the interest and fees of a period. Each account's postings
+// and its accrual record are written in one database transaction, and the
+// record is unique per account and period, so running a period again only
+// posts the accounts that are missing.
+type Engine struct {
+	accounts     repository.AccountRepository
+	transactions repository.TransactionRepository
+	accruals     repository.AccrualRepository
+	txManager    repository.TxManager
+	poster       Poster
+	catalog      product.Catalog
+
+	// now tells whether the period is over
+	now func() time.Time
+}
+
+// NewEngine creates an accrual engine
+func NewEngine(accounts repository.AccountRepository, transactions repository.TransactionRepository,
+	accruals repository.AccrualRepository, txManager repository.TxManager, poster Poster, catalog product.Catalog) *Engine {
+	return &Engine{
+		accounts:     accounts,
+		transactions: transactions,
+		accruals:     accruals,
+		txManager:    txManager,
+		poster:       poster,
+		catalog:      catalog,
+		now:          time.Now,
+	}
+}
+
+// Run accrues the period for the selected accounts, ordered by ID. A failing
+// account is reported in its result and doesn't stop the others.
+func (e *Engine) Run(ctx context.Context, opts Options) ([]Result, error) {
+	if opts.Period.End().After(e.now()) {
+		return nil, fmt.Errorf("%w: %s ends on %s", ErrPeriodNotOver, opts.Period, opts.Period.End().Format("2006-01-02"))
+	}
+
+	accounts, err := e.selectAccounts(ctx, opts.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Accruing interest and fees", map[string]interface{}{
+		"period":   opts.Period.String(),
+		"accounts": len(accounts),
+		"dry_run":  opts.DryRun,
+	})
+
+	results := make([]Result, len(accounts))
+	for i, account := range accounts {
+		results[i] = e.accrueAccount(logger.WithAccountID(ctx, account.ID), account, opts)
+	}
+	return results, nil
+}
+
+func (e *Engine) selectAccounts(ctx context.Context, accountID string) ([]*dto.AccountDTO, error) {
+	if accountID != "" {
+		account, err := e.accounts.GetByID(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		if account == nil {
+			return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, accountID)
+		}
+		return []*dto.AccountDTO{account}, nil
+	}
+
+	accounts, err := e.accounts.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID < accounts[j].ID })
+	return accounts, nil
+}
+
+func (e *Engine) accrueAccount(ctx context.Context, account *dto.AccountDTO, opts Options) Result {
+	result := Result{AccountID: account.ID, Product: account.Product}
+	if result.Product == "" {
+		result.Product = product.Default
+	}
+
+	p, ok := e.catalog.Lookup(account.Product)
+	if !ok {
+		result.Status, result.Reason = StatusSkipped, fmt.Sprintf("unknown product %q", result.Product)
+		return result
+	}
+
+	if !account.CreatedAt.Before(opts.Period.End()) {
+		result.Status, result.Reason = StatusSkipped, "opened after the period"
+		return result
+	}
+
+	existing, err := e.accruals.Get(ctx, account.ID, opts.Period.String())
+	if err != nil {
+		return failed(ctx, result, err)
+	}
+	if existing != nil {
+		result.Status = StatusAlreadyPosted
+		result.Accrual = Accrual{
+			Days:           existing.Days,
+			AverageBalance: existing.AverageBalance,
+			Interest:       existing.Interest,
+			Fee:            existing.Fee,
+			FeeWaived:      existing.FeeWaived,
+		}
+		return result
+	}
+
+	days, err := e.dailyBalances(ctx, account, opts.Period)
+	if err != nil {
+		return failed(ctx, result, err)
+	}
+	fullPeriod := !account.CreatedAt.After(opts.Period.Start())
+	result.Accrual = Accrue(p, days, fullPeriod)
+
+	if opts.DryRun {
+		result.Status = StatusWouldPost
+		return result
+	}
+
+	err = e.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		return e.post(ctx, account.ID, result, opts.Period)
+	})
+	switch {
+	case errors.Is(err, repository.ErrAccrualExists):
+		// Another run posted the period after the check above, the postings
+		// of this one were rolled back
+		result.Status = StatusAlreadyPosted
+	case err != nil:
+		return failed(ctx, result, err)
+	default:
+		result.Status = StatusPosted
+		logger.InfoContext(ctx, "Interest and fees posted", map[string]interface{}{
+			"account_id": account.ID,
+			"period":     opts.Period.String(),
+			"interest":   result.Interest,
+			"fee":        result.Fee,
+		})
+	}
+	return result
+}
+
+// post writes the interest and fee transactions and the accrual record, it
+// must run in a transaction
+func (e *Engine) post(ctx context.Context, accountID string, result Result, period Period) error {
+	record := &dto.AccrualDTO{
+		AccountID:      accountID,
+		Period:         period.String(),
+		Product:        result.Product,
+		Days:           result.Days,
+		AverageBalance: result.AverageBalance,
+		Interest:       result.Interest,
+		Fee:            result.Fee,
+		FeeWaived:      result.FeeWaived,
+		PostedAt:       e.now().UTC(),
+	}
+
+	if result.Interest > 0 {
+		posted, err := e.poster.PostAccrual(ctx, accountID, dto.TransactionInterest, result.Interest)
+		if err != nil {
+			return err
+		}
+		record.InterestTransactionID = posted.Transaction.ID
+	}
+
+	if result.Fee > 0 {
+		posted, err := e.poster.PostAccrual(ctx, accountID, dto.TransactionFee, result.Fee)
+		if err != nil {
+			return err
+		}
+		record.FeeTransactionID = posted.Transaction.ID
+	}
+
+	return e.accruals.Create(ctx, record)
+}
+
+// dailyBalances rebuilds the end-of-day balances of the days of the period
+// the account was open. The opening balance is the current balance minus
+// every transaction made since the period started.
+func (e *Engine) dailyBalances(ctx context.Context, account *dto.AccountDTO, period Period) ([]DayBalance, error) {
+	since, err := e.transactions.GetTransactionSummarySince(ctx, account.ID, period.Start())
+	if err != nil {
+		return nil, err
+	}
+	balance := since.CurrentBalance - since.Net()
+
+	opened := account.CreatedAt.UTC().Truncate(24 * time.Hour)
+	var days []DayBalance
+	day := period.Start()
+	closeDay := func() {
+		if !day.Before(opened) {
+			days = append(days, DayBalance{Date: day, Balance: round(balance)})
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	err = e.transactions.StreamByAccountID(ctx, account.ID, period.Start(), period.End(), func(transaction *dto.TransactionDTO) error {
+		for !transaction.CreatedAt.Before(day.AddDate(0, 0, 1)) {
+			closeDay()
+		}
+		if dto.IsDebit(transaction.Type) {
+			balance -= transaction.Amount
+		} else {
+			balance += transaction.Amount
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for day.Before(period.End()) {
+		closeDay()
+	}
+	return days, nil
+}
+
+func failed(ctx context.Context, result Result, err error) Result {
+	logger.ErrorContext(ctx, "Failed to accrue interest and fees", err)
+	result.Status, result.Reason = StatusFailed, err.Error()
+	return result
+}
@@ -0,0 +1,336 @@
+package interest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/product"
+	"github.com/gcalvocr/go-testing/repository"
+	"github.com/gcalvocr/go-testing/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLedger is an in-memory bank shared by the fake repositories. Its
+// WithinTransaction restores the state when fn fails.
+type fakeLedger struct {
+	accounts     map[string]*dto.AccountDTO
+	transactions []*dto.TransactionDTO
+	accruals     map[string]*dto.AccrualDTO
+	// postedAt is the creation time of posted transactions
+	postedAt time.Time
+	// failPosting makes postings on the account fail
+	failPosting string
+}
+
+func newFakeLedger(postedAt time.Time, accounts ...*dto.AccountDTO) *fakeLedger {
+	ledger := &fakeLedger{
+		accounts: make(map[string]*dto.AccountDTO),
+		accruals: make(map[string]*dto.AccrualDTO),
+		postedAt: postedAt,
+	}
+	for _, account := range accounts {
+		ledger.accounts[account.ID] = account
+	}
+	return ledger
+}
+
+func (l *fakeLedger) engine(now time.Time) *Engine {
+	engine := NewEngine(fakeAccounts{l}, fakeTransactions{l}, fakeAccruals{l}, l, l, product.DefaultCatalog())
+	engine.now = func() time.Time { return now }
+	return engine
+}
+
+// add records a transaction that already moved the balance
+func (l *fakeLedger) add(accountID, transactionType string, amount float64, at time.Time) {
+	account := l.accounts[accountID]
+	if dto.IsDebit(transactionType) {
+		account.Balance -= amount
+	} else {
+		account.Balance += amount
+	}
+	l.transactions = append(l.transactions, &dto.TransactionDTO{
+		ID: fmt.Sprintf("tx-%d", len(l.transactions)+1), AccountID: accountID,
+		Type: transactionType, Amount: amount, CreatedAt: at,
+	})
+}
+
+func (l *fakeLedger) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	balances := make(map[string]float64, len(l.accounts))
+	for id, account := range l.accounts {
+		balances[id] = account.Balance
+	}
+	transactions := append([]*dto.TransactionDTO(nil), l.transactions...)
+
+	if err := fn(ctx); err != nil {
+		for id, balance := range balances {
+			l.accounts[id].Balance = balance
+		}
+		l.transactions = transactions
+		return err
+	}
+	return nil
+}
+
+func (l *fakeLedger) PostAccrual(ctx context.Context, accountID, transactionType string, amount float64) (*service.TransactionResult, error) {
+	if accountID == l.failPosting {
+		return nil, errors.New("database unavailable")
+	}
+	l.add(accountID, transactionType, amount, l.postedAt)
+	return &service.TransactionResult{
+		Transaction: l.transactions[len(l.transactions)-1],
+		Balance:     l.accounts[accountID].Balance,
+	}, nil
+}
+
+type fakeAccounts struct{ ledger *fakeLedger }
+
+func (r fakeAccounts) Create(ctx context.Context, account *dto.AccountDTO) error {
+	return errors.New("not implemented")
+}
+
+func (r fakeAccounts) GetByID(ctx context.Context, id string) (*dto.AccountDTO, error) {
+	return r.ledger.accounts[id], nil
+}
+
+func (r fakeAccounts) GetAll(ctx context.Context) ([]*dto.AccountDTO, error) {
+	var accounts []*dto.AccountDTO
+	for _, account := range r.ledger.accounts {
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+func (r fakeAccounts) GetByOwnerID(ctx context.Context, ownerID string) ([]*dto.AccountDTO, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r fakeAccounts) GetPage(ctx context.Context, ownerID string, after *repository.Cursor, limit int) ([]*dto.AccountDTO, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r fakeAccounts) Update(ctx context.Context, id string, account *dto.UpdateAccountRequest) error {
+	return errors.New("not implemented")
+}
+
+func (r fakeAccounts) Delete(ctx context.Context, id string) error {
+	return errors.New("not implemented")
+}
+
+func (r fakeAccounts) GetByName(ctx context.Context, name string) (*dto.AccountDTO, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r fakeAccounts) UpdateBalance(ctx context.Context, id string, newBalance float64) error {
+	return errors.New("not implemented")
+}
+
+func (r fakeAccounts) UpdateLimits(ctx context.Context, id string, limits dto.AccountLimits) error {
+	return errors.New("not implemented")
+}
+
+type fakeTransactions struct{ ledger *fakeLedger }
+
+func (r fakeTransactions) Create(ctx context.Context, transaction *dto.TransactionDTO) error {
+	return errors.New("not implemented")
+}
+
+func (r fakeTransactions) GetByID(ctx context.Context, id string) (*dto.TransactionDTO, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r fakeTransactions) GetByAccountID(ctx context.Context, accountID string) ([]*dto.TransactionDTO, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r fakeTransactions) GetPageByAccountID(ctx context.Context, accountID string, after *repository.Cursor, limit int) ([]*dto.TransactionDTO, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r fakeTransactions) GetAll(ctx context.Context) ([]*dto.TransactionDTO, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r fakeTransactions) Update(ctx context.Context, id string, transaction *dto.TransactionDTO) error {
+	return errors.New("not implemented")
+}
+
+func (r fakeTransactions) Delete(ctx context.Context, id string) error {
+	return errors.New("not implemented")
+}
+
+func (r fakeTransactions) GetTransactionSummary(ctx context.Context, accountID string) (*dto.TransactionSummary, error) {
+	return r.GetTransactionSummarySince(ctx, accountID, time.Time{})
+}
+
+func (r fakeTransactions) GetTransactionSummarySince(ctx context.Context, accountID string, since time.Time) (*dto.TransactionSummary, error) {
+	summary := &dto.TransactionSummary{AccountID: accountID, CurrentBalance: r.ledger.accounts[accountID].Balance}
+	for _, transaction := range r.ledger.transactions {
+		if transaction.AccountID != accountID || transaction.CreatedAt.Before(since) {
+			continue
+		}
+		summary.TotalTransactions++
+		switch transaction.Type {
+		case dto.TransactionDeposit:
+			summary.TotalDeposits += transaction.Amount
+		case dto.TransactionWithdrawal:
+			summary.TotalWithdrawals += transaction.Amount
+		case dto.TransactionInterest:
+			summary.TotalInterest += transaction.Amount
+		case dto.TransactionFee:
+			summary.TotalFees += transaction.Amount
+		}
+	}
+	return summary, nil
+}
+
+func (r fakeTransactions) StreamByAccountID(ctx context.Context, accountID string, from, to time.Time, fn func(*dto.TransactionDTO) error) error {
+	for _, transaction := range r.ledger.transactions {
+		if transaction.AccountID != accountID || transaction.CreatedAt.Before(from) || !transaction.CreatedAt.Before(to) {
+			continue
+		}
+		if err := fn(transaction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type fakeAccruals struct{ ledger *fakeLedger }
+
+func (r fakeAccruals) Create(ctx context.Context, accrual *dto.AccrualDTO) error {
+	key := accrual.AccountID + ":" + accrual.Period
+	if _, ok := r.ledger.accruals[key]; ok {
+		return repository.ErrAccrualExists
+	}
+	r.ledger.accruals[key] = accrual
+	return nil
+}
+
+func (r fakeAccruals) Get(ctx context.Context, accountID, period string) (*dto.AccrualDTO, error) {
+	return r.ledger.accruals[accountID+":"+period], nil
+}
+
+var (
+	march    = Period{Year: 2025, Month: time.March}
+	runAt    = time.Date(2025, 4, 1, 2, 0, 0, 0, time.UTC)
+	marchDay = func(day int) time.Time { return time.Date(2025, 3, day, 12, 0, 0, 0, time.UTC) }
+)
+
+func TestRunPostsInterestAndFees(t *testing.T) {
+	ledger := newFakeLedger(runAt,
+		&dto.AccountDTO{ID: "acc-checking", Product: product.Checking, Balance: 100, CreatedAt: marchDay(1).AddDate(-1, 0, 0)},
+		&dto.AccountDTO{ID: "acc-rich", Balance: 5000, CreatedAt: marchDay(1).AddDate(-1, 0, 0)},
+		&dto.AccountDTO{ID: "acc-savings", Product: product.Savings, Balance: 0, CreatedAt: marchDay(1).AddDate(-1, 0, 0)},
+	)
+	// 3650 for the last 11 days of March, then a deposit after the period
+	ledger.add("acc-savings", dto.TransactionDeposit, 3650, marchDay(21))
+	ledger.add("acc-savings", dto.TransactionDeposit, 100, runAt.Add(-time.Hour))
+
+	results, err := ledger.engine(runAt).Run(context.Background(), Options{Period: march})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, Result{
+		AccountID: "acc-checking", Product: product.Checking, Status: StatusPosted,
+		Accrual: Accrual{Days: 31, AverageBalance: 100, Fee: 5},
+	}, results[0])
+	assert.Equal(t, Result{
+		AccountID: "acc-rich", Product: product.Checking, Status: StatusPosted,
+		Accrual: Accrual{Days: 31, AverageBalance: 5000, FeeWaived: true},
+	}, results[1])
+	// 3650 * 0.02 / 365 * 11 = 2.20
+	assert.Equal(t, Result{
+		AccountID: "acc-savings", Product: product.Savings, Status: StatusPosted,
+		Accrual: Accrual{Days: 31, AverageBalance: 1295.16, Interest: 2.20},
+	}, results[2])
+
+	assert.Equal(t, 95.0, ledger.accounts["acc-checking"].Balance)
+	assert.Equal(t, 5000.0, ledger.accounts["acc-rich"].Balance)
+	assert.Equal(t, 3752.20, ledger.accounts["acc-savings"].Balance)
+
+	accrual := ledger.accruals["acc-savings:2025-03"]
+	require.NotNil(t, accrual)
+	assert.NotEmpty(t, accrual.InterestTransactionID)
+	assert.Empty(t, accrual.FeeTransactionID)
+	assert.Equal(t, runAt, accrual.PostedAt)
+}
+
+func TestRunIsIdempotentPerPeriod(t *testing.T) {
+	ledger := newFakeLedger(runAt,
+		&dto.AccountDTO{ID: "acc-1", Product: product.Savings, Balance: 1000, CreatedAt: marchDay(1).AddDate(-1, 0, 0)})
+	engine := ledger.engine(runAt)
+
+	first, err := engine.Run(context.Background(), Options{Period: march})
+	require.NoError(t, err)
+	second, err := engine.Run(context.Background(), Options{Period: march})
+	require.NoError(t, err)
+
+	assert.Equal(t, StatusPosted, first[0].Status)
+	assert.Equal(t, StatusAlreadyPosted, second[0].Status)
+	assert.Equal(t, first[0].Accrual, second[0].Accrual)
+	assert.Len(t, ledger.transactions, 1, "the interest is posted once")
+
+	// The interest posted on April 1st belongs to April's opening balance
+	april, err := ledger.engine(runAt.AddDate(0, 1, 0)).Run(context.Background(), Options{Period: Period{Year: 2025, Month: time.April}})
+	require.NoError(t, err)
+	assert.Equal(t, 1001.70, april[0].AverageBalance)
+}
+
+func TestRunDryRunPostsNothing(t *testing.T) {
+	ledger := newFakeLedger(runAt,
+		&dto.AccountDTO{ID: "acc-1", Balance: 100, CreatedAt: marchDay(1).AddDate(-1, 0, 0)})
+
+	results, err := ledger.engine(runAt).Run(context.Background(), Options{Period: march, DryRun: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, StatusWouldPost, results[0].Status)
+	assert.Equal(t, 5.0, results[0].Fee)
+	assert.Empty(t, ledger.transactions)
+	assert.Empty(t, ledger.accruals)
+}
+
+func TestRunAccountOpenedDuringThePeriod(t *testing.T) {
+	ledger := newFakeLedger(runAt,
+		&dto.AccountDTO{ID: "acc-new", Product: product.Savings, Balance: 3650, CreatedAt: marchDay(21)},
+		&dto.AccountDTO{ID: "acc-later", Balance: 10, CreatedAt: runAt.Add(-time.Hour)})
+
+	results, err := ledger.engine(runAt).Run(context.Background(), Options{Period: march})
+	require.NoError(t, err)
+
+	assert.Equal(t, Result{AccountID: "acc-later", Product: product.Checking, Status: StatusSkipped, Reason: "opened after the period"}, results[0])
+	assert.Equal(t, StatusPosted, results[1].Status)
+	assert.Equal(t, Accrual{Days: 11, AverageBalance: 3650, Interest: 2.20}, results[1].Accrual)
+}
+
+func TestRunReportsFailuresPerAccount(t *testing.T) {
+	ledger := newFakeLedger(runAt,
+		&dto.AccountDTO{ID: "acc-1", Balance: 100, CreatedAt: marchDay(1).AddDate(-1, 0, 0)},
+		&dto.AccountDTO{ID: "acc-2", Balance: 100, CreatedAt: marchDay(1).AddDate(-1, 0, 0)},
+		&dto.AccountDTO{ID: "acc-3", Product: "brokerage", CreatedAt: marchDay(1).AddDate(-1, 0, 0)})
+	ledger.failPosting = "acc-1"
+
+	results, err := ledger.engine(runAt).Run(context.Background(), Options{Period: march})
+	require.NoError(t, err)
+
+	assert.Equal(t, StatusFailed, results[0].Status)
+	assert.Equal(t, "database unavailable", results[0].Reason)
+	assert.Nil(t, ledger.accruals["acc-1:2025-03"], "a failed account can be posted by the next run")
+	assert.Equal(t, StatusPosted, results[1].Status)
+	assert.Equal(t, StatusSkipped, results[2].Status)
+	assert.Equal(t, `unknown product "brokerage"`, results[2].Reason)
+}
+
+func TestRunRejectsPeriodsThatHaveNotEnded(t *testing.T) {
+	ledger := newFakeLedger(runAt)
+
+	_, err := ledger.engine(marchDay(31)).Run(context.Background(), Options{Period: march})
+	assert.True(t, errors.Is(err, ErrPeriodNotOver), "got %v", err)
+
+	_, err = ledger.engine(runAt).Run(context.Background(), Options{Period: march, AccountID: "missing"})
+	assert.True(t, errors.Is(err, ErrAccountNotFound), "got %v", err)
+}
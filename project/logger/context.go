@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	routeKey
+	traceIDKey
+	accountIDKey
+)
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// WithRoute returns a copy of ctx carrying the matched route template
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey, route)
+}
+
+// WithTraceID returns a copy of ctx carrying the distributed trace ID
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
+}
+
+// WithAccountID returns a copy of ctx carrying the account the request operates on
+func WithAccountID(ctx context.Context, accountID string) context.Context {
+	return context.WithValue(ctx, accountIDKey, accountID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, if any
+func RequestIDFromContext(ctx context.Context) string {
+	return stringFromContext(ctx, requestIDKey)
+}
+
+// TraceIDFromContext returns the trace ID stored in ctx, if any
+func TraceIDFromContext(ctx context.Context) string {
+	return stringFromContext(ctx, traceIDKey)
+}
+
+func stringFromContext(ctx context.Context, key contextKey) string {
+	if ctx == nil {
+		return ""
+	}
+	value, _ := ctx.Value(key).(string)
+	return value
+}
+
+// entry builds a log entry enriched with the request-scoped fields in ctx
+func entry(ctx context.Context, fields map[string]interface{}) *logrus.Entry {
+	e := logrus.NewEntry(Log)
+	if len(fields) > 0 {
+		e = e.WithFields(fields)
+	}
+
+	contextFields := logrus.Fields{}
+	for key, name := range map[contextKey]string{
+		requestIDKey: "request_id",
+		routeKey:     "route",
+		traceIDKey:   "trace_id",
+		accountIDKey: "account_id",
+	} {
+		if value := stringFromContext(ctx, key); value != "" {
+			if _, exists := e.Data[name]; !exists {
+				contextFields[name] = value
+			}
+		}
+	}
+	if len(contextFields) > 0 {
+		e = e.WithFields(contextFields)
+	}
+	return e.WithContext(ctx)
+}
+
+// InfoContext logs info messages with the request-scoped fields in ctx
+func InfoContext(ctx context.Context, message string, fields map[string]interface{}) {
+	entry(ctx, fields).Info(message)
+}
+
+// WarnContext logs warning messages with the request-scoped fields in ctx
+func WarnContext(ctx context.Context, message string, fields map[string]interface{}) {
+	entry(ctx, fields).Warn(message)
+}
+
+// ErrorContext logs error messages with the request-scoped fields in ctx
+func ErrorContext(ctx context.Context, message string, err error) {
+	e := entry(ctx, nil)
+	if err != nil {
+		e = e.WithError(err)
+	}
+	e.Error(message)
+}
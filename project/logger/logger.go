@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"os"
 	"time"
 
@@ -34,8 +35,8 @@ func init() {
 }
 
 // RequestLogger logs HTTP requests
-func RequestLogger(method, path string, statusCode int, duration time.Duration) {
-	Log.WithFields(logrus.Fields{
+func RequestLogger(ctx context.Context, method, path string, statusCode int, duration time.Duration) {
+	entry(ctx, logrus.Fields{
 		"method":      method,
 		"path":        path,
 		"status_code": statusCode,
@@ -3,12 +3,20 @@ package main
 import (
 	"os"
 
+	"github.com/gcalvocr/go-testing/cli"
+	"github.com/gcalvocr/go-testing/exchange"
 	"github.com/gcalvocr/go-testing/handlers"
 	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/product"
 	"github.com/gcalvocr/go-testing/server"
 )
 
 func main() {
+	// Administrative subcommands, e.g. "main create-api-key -name ci -principal svc-ci"
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
 	logger.Info("Starting Bank API application", nil)
 
 	// Create and configure server
@@ -20,6 +28,17 @@ func main() {
 		logger.Error("Failed to initialize database", err)
 		os.Exit(1)
 	}
+	// Account products, with the interest and fee terms of each
+	catalog, err := product.CatalogFromEnv()
+	if err != nil {
+		logger.Error("Failed to load account products", err)
+		os.Exit(1)
+	}
+	handlers.SetProductCatalog(catalog)
+	srv.SetProductCatalog(catalog)
+	// Exchange rates, from EXCHANGE_RATE_API_URL when it points to a mock server
+	handlers.SetExchangeClient(exchange.NewClientFromEnv())
+
 	defer func() {
 		// Close database connection when server shuts down
 		// This would be handled by a graceful shutdown in production
@@ -30,6 +49,11 @@ func main() {
 	if repoFactory != nil {
 		handlers.SetAccountRepository(repoFactory.AccountRepo)
 		handlers.SetTransactionRepository(repoFactory.TransactionRepo)
+		handlers.SetTransactionService(srv.TransactionService())
+		handlers.SetScheduledTransactionRepository(repoFactory.ScheduledRepo)
+		handlers.SetWebhookRepository(repoFactory.WebhookRepo)
+		handlers.SetAuditRepository(repoFactory.AuditRepo)
+		handlers.SetEventFeed(srv.EventFeed())
 		logger.Info("Repositories initialized successfully", nil)
 	} else {
 		logger.Error("Repository factory is nil", nil)
@@ -37,11 +61,23 @@ func main() {
 	}
 
 	// Setup routes
-	srv.SetupRoutes()
+	err = srv.SetupRoutes()
+	if err != nil {
+		logger.Error("Failed to setup routes", err)
+		os.Exit(1)
+	}
+
+	// The gRPC API shares the repositories and authentication of the routes
+	err = srv.SetupGRPC()
+	if err != nil {
+		logger.Error("Failed to setup gRPC", err)
+		os.Exit(1)
+	}
 
 	// Start server
 	logger.Info("Starting server", map[string]interface{}{
-		"port": srv.GetPort(),
+		"port":      srv.GetPort(),
+		"grpc_port": srv.GetGRPCPort(),
 	})
 
 	err = srv.Start()
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gorilla/mux"
+)
+
+// AuthMiddleware authenticates every request with an API key or JWT bearer token
+// and stores the principal in the request context. Routes whose path template
+// is listed in publicRoutes are served without authentication.
+func AuthMiddleware(authenticator *auth.Authenticator, publicRoutes ...string) mux.MiddlewareFunc {
+	public := make(map[string]bool, len(publicRoutes))
+	for _, route := range publicRoutes {
+		public[route] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil && public[template] {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			principal, err := authenticator.Authenticate(r)
+			if err != nil {
+				logger.WarnContext(r.Context(), "Authentication failed", map[string]interface{}{
+					"reason": err.Error(),
+				})
+
+				w.Header().Set("WWW-Authenticate", `Bearer realm="bank-api"`)
+				message := "Invalid API key or bearer token"
+				if errors.Is(err, auth.ErrMissingCredentials) {
+					message = "An API key or bearer token is required"
+				}
+				writeJSONError(w, http.StatusUnauthorized, "unauthorized", message)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
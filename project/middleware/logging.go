@@ -20,7 +20,7 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 
 		// Log the request
 		duration := time.Since(start)
-		logger.RequestLogger(r.Method, r.URL.Path, wrapped.statusCode, duration)
+		logger.RequestLogger(r.Context(), r.Method, r.URL.Path, wrapped.statusCode, duration)
 	})
 }
 
@@ -34,3 +34,9 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush a streamed response
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/ratelimit"
+	"github.com/gorilla/mux"
+)
+
+// RateLimitConfig configures request throttling
+type RateLimitConfig struct {
+	// Default applies to routes without an entry in Routes
+	Default ratelimit.Limit
+	// Routes overrides the limit per "METHOD /path/{template}"
+	Routes map[string]ratelimit.Limit
+	// AuthFailures is how many failed authentications a client IP may make
+	// before its requests are refused without being authenticated
+	AuthFailures ratelimit.Limit
+	// TrustForwardedFor uses the first X-Forwarded-For address as the client IP
+	TrustForwardedFor bool
+}
+
+// RateLimitMiddleware throttles clients with a token bucket per authenticated
+// principal, or per client IP for public routes, and per route. It runs after
+// AuthMiddleware, so credentials are verified before they pick a bucket.
+// Responses carry RateLimit-* headers, throttled requests get 429 with
+// Retry-After.
+func RateLimitMiddleware(store ratelimit.Store, config RateLimitConfig) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeKey(r)
+			limit, ok := config.Routes[route]
+			if !ok {
+				limit = config.Default
+			}
+			if limit.Unlimited() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result, err := store.Take(r.Context(), route+"|"+clientKey(r, config.TrustForwardedFor), limit)
+			if err != nil {
+				// Fail open, an unavailable limiter must not take the API down
+				logger.ErrorContext(r.Context(), "Rate limiter unavailable", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+			if !result.Allowed {
+				logger.WarnContext(r.Context(), "Rate limit exceeded", map[string]interface{}{
+					"route":       route,
+					"retry_after": result.RetryAfter.String(),
+				})
+				writeRateLimited(w, result)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AuthFailureLimitMiddleware throttles unauthenticated clients per IP. It
+// runs before AuthMiddleware: every 401 takes a token from the bucket of the
+// client IP, and once it is empty requests from that IP get 429 before any
+// credentials are checked, so rotating keys can't guess its way past the limit.
+func AuthFailureLimitMiddleware(store ratelimit.Store, config RateLimitConfig) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if config.AuthFailures.Unlimited() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := "auth-failures|ip:" + clientIP(r, config.TrustForwardedFor)
+
+			result, err := store.Peek(r.Context(), key, config.AuthFailures)
+			if err != nil {
+				logger.ErrorContext(r.Context(), "Rate limiter unavailable", err)
+			} else if !result.Allowed {
+				logger.WarnContext(r.Context(), "Authentication failure limit exceeded", map[string]interface{}{
+					"retry_after": result.RetryAfter.String(),
+				})
+				writeRateLimited(w, result)
+				return
+			}
+
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapped, r)
+			if wrapped.statusCode == http.StatusUnauthorized {
+				if _, err := store.Take(r.Context(), key, config.AuthFailures); err != nil {
+					logger.ErrorContext(r.Context(), "Rate limiter unavailable", err)
+				}
+			}
+		})
+	}
+}
+
+// writeRateLimited refuses a request with 429 and the time to retry after
+func writeRateLimited(w http.ResponseWriter, result ratelimit.Result) {
+	w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+	writeJSONError(w, http.StatusTooManyRequests, "rate_limited", "Too many requests, retry later")
+}
+
+// clientKey identifies the client by its authenticated principal, or by IP
+// address on public routes
+func clientKey(r *http.Request, trustForwardedFor bool) string {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		return "principal:" + principal.ID
+	}
+	return "ip:" + clientIP(r, trustForwardedFor)
+}
+
+// clientIP returns the address of the client
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
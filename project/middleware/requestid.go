@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gorilla/mux"
+)
+
+// RequestIDHeader is the header used to accept and echo the request ID
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength caps client supplied request IDs so they can't flood the logs
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware accepts or generates an X-Request-ID, echoes it in the
+// response and stores it, together with the route, trace ID and account ID,
+// in the request context for the context-aware logger
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !ValidRequestID(requestID) {
+			requestID = NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		ctx := logger.WithRequestID(r.Context(), requestID)
+
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				ctx = logger.WithRoute(ctx, template)
+			}
+		}
+
+		if traceID := traceIDFromRequest(r); traceID != "" {
+			ctx = logger.WithTraceID(ctx, traceID)
+		}
+
+		// Routes scoped to a single account expose it as a path variable
+		vars := mux.Vars(r)
+		if accountID := vars["account_id"]; accountID != "" {
+			ctx = logger.WithAccountID(ctx, accountID)
+		} else if accountID := vars["id"]; accountID != "" && strings.HasPrefix(r.URL.Path, "/accounts/") {
+			ctx = logger.WithAccountID(ctx, accountID)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// traceIDFromRequest extracts the trace ID from a W3C traceparent header,
+// falling back to X-Trace-ID
+func traceIDFromRequest(r *http.Request) string {
+	// traceparent: version-traceid-parentid-flags
+	if parts := strings.Split(r.Header.Get("traceparent"), "-"); len(parts) == 4 && len(parts[1]) == 32 {
+		return parts[1]
+	}
+	if traceID := r.Header.Get("X-Trace-ID"); ValidRequestID(traceID) {
+		return traceID
+	}
+	return ""
+}
+
+// ValidRequestID reports whether a client supplied request or trace ID is safe to
+// log and echo
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewRequestID generates a random 128-bit hex request ID
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// routeKey identifies the matched route as "METHOD /path/{template}", the
+// format used to configure per-route limits
+func routeKey(r *http.Request) string {
+	template := r.URL.Path
+	if route := mux.CurrentRoute(r); route != nil {
+		if t, err := route.GetPathTemplate(); err == nil {
+			template = t
+		}
+	}
+	return r.Method + " " + template
+}
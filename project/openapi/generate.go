@@ -0,0 +1,274 @@
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the OpenAPI version of the generated document
+const Version = "3.1.0"
+
+// Operation describes a route of the API for documentation purposes
+type Operation struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Description string
+	Tag         string
+
+	// Public operations do not require authentication
+	Public bool
+
+	// Request is a value of the JSON request body type, nil when there is no body
+	Request interface{}
+	// RequestContentTypes lists the accepted body media types (default: application/json)
+	RequestContentTypes []string
+
+	// Response is a value of the success response body type, nil when there is no body
+	Response interface{}
+	// ResponseContentType is the media type of the success response (default: application/json)
+	ResponseContentType string
+	// ResponseContentTypes lists the media types when the success response has
+	// several, overriding ResponseContentType
+	ResponseContentTypes []string
+	// SuccessStatus is the success status code (default: 200)
+	SuccessStatus int
+
+	// Query lists the query parameters
+	Query []Parameter
+	// Errors lists the error statuses returned by the handler itself
+	Errors []int
+	// CodedErrors lists the error statuses the handler writes as JSON with a
+	// machine readable error code
+	CodedErrors []int
+}
+
+// Parameter documents a query parameter
+type Parameter struct {
+	Name        string
+	Description string
+	Required    bool
+	// Enum restricts the accepted values
+	Enum []string
+}
+
+// ErrorBody is the JSON error body returned by the middleware (401, 413, 415, 429)
+type ErrorBody struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}:]+)(:[^}]+)?\}`)
+
+// Generate builds the OpenAPI document for the operations
+func Generate(info Info, operations []Operation) *Document {
+	registry := newSchemaRegistry()
+	errorSchema := registry.schemaFor(ErrorBody{})
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			SecuritySchemes: map[string]SecurityScheme{
+				"ApiKeyAuth": {Type: "apiKey", In: "header", Name: "X-API-Key"},
+				"BearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		Security: []SecurityRequirement{{"ApiKeyAuth": {}}, {"BearerAuth": {}}},
+	}
+
+	tags := map[string]bool{}
+	for _, op := range operations {
+		path := Path(op.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(op.Method)] = operationObject(registry, errorSchema, op)
+
+		if op.Tag != "" && !tags[op.Tag] {
+			tags[op.Tag] = true
+			doc.Tags = append(doc.Tags, Tag{Name: op.Tag})
+		}
+	}
+
+	doc.Components.Schemas = registry.schemas
+	return doc
+}
+
+// Path converts a mux path template to an OpenAPI path, dropping variable patterns
+func Path(template string) string {
+	return pathParamPattern.ReplaceAllString(template, "{$1}")
+}
+
+func operationObject(registry *schemaRegistry, errorSchema *Schema, op Operation) *OperationObject {
+	operation := &OperationObject{
+		OperationID: op.OperationID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Responses:   make(map[string]Response),
+	}
+	if operation.OperationID == "" {
+		operation.OperationID = defaultOperationID(op)
+	}
+	if op.Tag != "" {
+		operation.Tags = []string{op.Tag}
+	}
+
+	for _, match := range pathParamPattern.FindAllStringSubmatch(op.Path, -1) {
+		operation.Parameters = append(operation.Parameters, ParameterObject{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	for _, param := range op.Query {
+		operation.Parameters = append(operation.Parameters, ParameterObject{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Required:    param.Required,
+			Schema:      &Schema{Type: "string", Enum: param.Enum},
+		})
+	}
+
+	if op.Request != nil {
+		contentTypes := op.RequestContentTypes
+		if len(contentTypes) == 0 {
+			contentTypes = []string{"application/json"}
+		}
+		body := &RequestBody{Required: true, Content: make(map[string]MediaType)}
+		for _, contentType := range contentTypes {
+			body.Content[contentType] = MediaType{Schema: registry.schemaFor(op.Request)}
+		}
+		operation.RequestBody = body
+	}
+
+	status := op.SuccessStatus
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	if op.Response != nil {
+		contentTypes := op.ResponseContentTypes
+		if len(contentTypes) == 0 {
+			contentTypes = []string{op.ResponseContentType}
+			if op.ResponseContentType == "" {
+				contentTypes = []string{"application/json"}
+			}
+		}
+		success.Content = make(map[string]MediaType)
+		for _, contentType := range contentTypes {
+			success.Content[contentType] = MediaType{Schema: registry.schemaFor(op.Response)}
+		}
+	}
+	operation.Responses[strconv.Itoa(status)] = success
+
+	// Errors written by the handler are plain text
+	for _, code := range op.Errors {
+		operation.Responses[strconv.Itoa(code)] = Response{
+			Description: http.StatusText(code),
+			Content:     map[string]MediaType{"text/plain": {Schema: &Schema{Type: "string"}}},
+		}
+	}
+
+	for _, code := range op.CodedErrors {
+		operation.Responses[strconv.Itoa(code)] = Response{
+			Description: http.StatusText(code),
+			Content:     map[string]MediaType{"application/json": {Schema: errorSchema}},
+		}
+	}
+
+	// Errors written by the middleware are JSON
+	middlewareErrors := []int{http.StatusTooManyRequests}
+	if op.Public {
+		operation.Security = &[]SecurityRequirement{}
+	} else {
+		middlewareErrors = append(middlewareErrors, http.StatusUnauthorized)
+	}
+	if op.Request != nil {
+		middlewareErrors = append(middlewareErrors, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType)
+	}
+	for _, code := range middlewareErrors {
+		response := Response{
+			Description: http.StatusText(code),
+			Content:     map[string]MediaType{"application/json": {Schema: errorSchema}},
+		}
+		if code == http.StatusTooManyRequests {
+			response.Headers = map[string]Header{
+				"Retry-After": {Description: "Seconds until the next request is allowed", Schema: &Schema{Type: "integer"}},
+			}
+		}
+		operation.Responses[strconv.Itoa(code)] = response
+	}
+
+	return operation
+}
+
+// defaultOperationID derives an operation ID such as "getAccountsById" from the method and path
+func defaultOperationID(op Operation) string {
+	id := strings.ToLower(op.Method)
+	for _, segment := range strings.Split(Path(op.Path), "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") {
+			segment = "by_" + strings.Trim(segment, "{}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+			id += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return id
+}
+
+// Endpoint is a summary of a documented operation
+type Endpoint struct {
+	Tag     string
+	Method  string
+	Path    string
+	Summary string
+	Public  bool
+}
+
+// Endpoints lists the documented operations grouped by tag in declaration
+// order, then sorted by path and method
+func (d *Document) Endpoints() []Endpoint {
+	tagOrder := map[string]int{}
+	for i, tag := range d.Tags {
+		tagOrder[tag.Name] = i
+	}
+
+	var endpoints []Endpoint
+	for path, item := range d.Paths {
+		for method, operation := range item {
+			endpoint := Endpoint{
+				Method:  strings.ToUpper(method),
+				Path:    path,
+				Summary: operation.Summary,
+				Public:  operation.Security != nil && len(*operation.Security) == 0,
+			}
+			if len(operation.Tags) > 0 {
+				endpoint.Tag = operation.Tags[0]
+			}
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		a, b := endpoints[i], endpoints[j]
+		if tagOrder[a.Tag] != tagOrder[b.Tag] {
+			return tagOrder[a.Tag] < tagOrder[b.Tag]
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+	return endpoints
+}
@@ -0,0 +1,179 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaRegistry converts Go types to schemas, collecting named structs as components
+type schemaRegistry struct {
+	schemas map[string]*Schema
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{schemas: make(map[string]*Schema)}
+}
+
+// schemaFor returns the schema of the type of value, a $ref for named structs
+func (r *schemaRegistry) schemaFor(value interface{}) *Schema {
+	if value == nil {
+		return nil
+	}
+	return r.schemaForType(reflect.TypeOf(value))
+}
+
+func (r *schemaRegistry) schemaForType(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Ptr:
+		schema := r.schemaForType(t.Elem())
+		return nullable(schema)
+	case t.Kind() == reflect.Struct:
+		name := t.Name()
+		if name == "" {
+			return r.structSchema(t)
+		}
+		if _, exists := r.schemas[name]; !exists {
+			// Reserve the name first so recursive types terminate
+			r.schemas[name] = &Schema{}
+			*r.schemas[name] = *r.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return &Schema{Type: "array", Items: r.schemaForType(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaForType(t.Elem())}
+	case t.Kind() == reflect.String:
+		return &Schema{Type: "string"}
+	case t.Kind() == reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return &Schema{Type: "integer"}
+	default:
+		return &Schema{}
+	}
+}
+
+// structSchema documents the JSON encoding of a struct, including the
+// constraints of its validate tags. Structs with validate tags are inputs and
+// only their "required" fields are required; for the other structs every
+// field that is always encoded is required.
+func (r *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	validated := hasValidateTags(t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		// Embedded structs without a JSON name are flattened like encoding/json does
+		if field.Anonymous && field.Tag.Get("json") == "" && field.Type.Kind() == reflect.Struct {
+			embedded := r.structSchema(field.Type)
+			for propName, prop := range embedded.Properties {
+				schema.Properties[propName] = prop
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		property := r.schemaForType(field.Type)
+		required := applyValidateTag(property, field.Tag.Get("validate"))
+		if required || (!validated && !omitEmpty && field.Type.Kind() != reflect.Ptr) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+
+	return schema
+}
+
+func hasValidateTags(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("validate"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonName returns the JSON property name of a field
+func jsonName(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, option := range parts[1:] {
+		if option == "omitempty" || option == "omitzero" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+// applyValidateTag maps go-playground style validate rules onto the schema and
+// reports whether the field is required
+func applyValidateTag(schema *Schema, tag string) bool {
+	required := false
+	if tag == "" || schema.Ref != "" {
+		return strings.Contains(tag, "required")
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "oneof":
+			schema.Enum = strings.Fields(value)
+		case "min", "max", "len", "gt":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			if schema.Type == "string" {
+				length := int(n)
+				if key == "min" || key == "len" {
+					schema.MinLength = &length
+				}
+				if key == "max" || key == "len" {
+					schema.MaxLength = &length
+				}
+			} else if key == "min" || key == "gt" {
+				schema.Minimum = &n
+			} else if key == "max" {
+				schema.Maximum = &n
+			}
+		}
+	}
+	return required
+}
+
+// nullable allows null in addition to the schema's type
+func nullable(schema *Schema) *Schema {
+	if schema.Ref != "" || schema.Type == nil {
+		return schema
+	}
+	if typeName, ok := schema.Type.(string); ok {
+		schema.Type = []string{typeName, "null"}
+	}
+	return schema
+}
@@ -0,0 +1,120 @@
+// Package policy evaluates the posting limits of an account. The
+// transaction service asks it whether a deposit or withdrawal may be posted
+// instead of encoding the limits inline, and every rule that can reject a
+// posting has its own error code.
+package policy
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gcalvocr/go-testing/dto"
+)
+
+// Error codes of the violations, returned to API clients
+const (
+	CodeInsufficientFunds            = "insufficient_funds"
+	CodeOverdraftLimitExceeded       = "overdraft_limit_exceeded"
+	CodeMinimumBalance               = "minimum_balance_not_met"
+	CodeDailyWithdrawalLimitExceeded = "daily_withdrawal_limit_exceeded"
+	CodeTransactionLimitExceeded     = "transaction_limit_exceeded"
+)
+
+// Violation is a posting rejected by the account's policy
+type Violation struct {
+	Code    string
+	Message string
+}
+
+func (v *Violation) Error() string {
+	return v.Message
+}
+
+// Is makes errors.Is match violations by code, so the sentinels below match
+// violations carrying a more detailed message
+func (v *Violation) Is(target error) bool {
+	t, ok := target.(*Violation)
+	return ok && t.Code == v.Code
+}
+
+// Sentinel violations for errors.Is
+var (
+	ErrInsufficientFunds            = &Violation{Code: CodeInsufficientFunds, Message: "insufficient funds"}
+	ErrOverdraftLimitExceeded       = &Violation{Code: CodeOverdraftLimitExceeded, Message: "overdraft limit exceeded"}
+	ErrMinimumBalance               = &Violation{Code: CodeMinimumBalance, Message: "minimum balance not met"}
+	ErrDailyWithdrawalLimitExceeded = &Violation{Code: CodeDailyWithdrawalLimitExceeded, Message: "daily withdrawal limit exceeded"}
+	ErrTransactionLimitExceeded     = &Violation{Code: CodeTransactionLimitExceeded, Message: "transaction limit exceeded"}
+)
+
+// Posting is a deposit or withdrawal about to be applied to an account
+type Posting struct {
+	Account *dto.AccountDTO
+	Type    string
+	Amount  float64
+	// WithdrawnToday is the total of the account's withdrawals posted today,
+	// only needed when the account has a daily withdrawal limit
+	WithdrawnToday float64
+	// Held is the total of the account's active holds, withdrawals may not
+	// spend reserved funds
+	Held float64
+}
+
+// NewBalance is the balance of the account after the posting
+func (p Posting) NewBalance() float64 {
+	if dto.IsDebit(p.Type) {
+		return p.Account.Balance - p.Amount
+	}
+	return p.Account.Balance + p.Amount
+}
+
+// Evaluate checks the posting against the limits of the account and returns
+// the first violation, or nil when it may be posted. Deposits are only
+// checked against the per-transaction maximum. The balance floors apply to
+// the available balance, the balance left after the active holds.
+func Evaluate(p Posting) error {
+	limits := p.Account.Limits
+
+	if limits.MaxTransactionAmount != nil && exceeds(p.Amount, *limits.MaxTransactionAmount) {
+		return &Violation{
+			Code:    CodeTransactionLimitExceeded,
+			Message: fmt.Sprintf("transaction limit exceeded: the maximum amount is %.2f", *limits.MaxTransactionAmount),
+		}
+	}
+
+	if p.Type != dto.TransactionWithdrawal {
+		return nil
+	}
+
+	if limits.DailyWithdrawalLimit != nil && exceeds(p.WithdrawnToday+p.Amount, *limits.DailyWithdrawalLimit) {
+		return &Violation{
+			Code: CodeDailyWithdrawalLimitExceeded,
+			Message: fmt.Sprintf("daily withdrawal limit exceeded: %.2f of %.2f left today",
+				math.Max(*limits.DailyWithdrawalLimit-p.WithdrawnToday, 0), *limits.DailyWithdrawalLimit),
+		}
+	}
+
+	available := p.NewBalance() - p.Held
+	if exceeds(-available, limits.OverdraftLimit) {
+		if limits.OverdraftLimit == 0 {
+			return ErrInsufficientFunds
+		}
+		return &Violation{
+			Code:    CodeOverdraftLimitExceeded,
+			Message: fmt.Sprintf("overdraft limit exceeded: the balance may not go below %.2f", -limits.OverdraftLimit),
+		}
+	}
+
+	if limits.MinimumBalance != nil && exceeds(*limits.MinimumBalance, available) {
+		return &Violation{
+			Code:    CodeMinimumBalance,
+			Message: fmt.Sprintf("minimum balance not met: the balance may not go below %.2f", *limits.MinimumBalance),
+		}
+	}
+	return nil
+}
+
+// exceeds reports whether amount is over limit by at least a cent, so float
+// rounding never rejects a posting that exactly reaches a limit
+func exceeds(amount, limit float64) bool {
+	return math.Round((amount-limit)*100) > 0
+}
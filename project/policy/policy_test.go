@@ -0,0 +1,151 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func limit(amount float64) *float64 {
+	return &amount
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		balance  float64
+		limits   dto.AccountLimits
+		posting  Posting
+		wantErr  error
+		wantText string
+	}{
+		{
+			name:    "withdrawal within the balance",
+			balance: 100,
+			posting: Posting{Type: "withdrawal", Amount: 100},
+		},
+		{
+			name:    "withdrawal without overdraft",
+			balance: 100,
+			posting: Posting{Type: "withdrawal", Amount: 100.01},
+			wantErr: ErrInsufficientFunds,
+		},
+		{
+			name:    "withdrawal into the overdraft",
+			balance: 100,
+			limits:  dto.AccountLimits{OverdraftLimit: 50},
+			posting: Posting{Type: "withdrawal", Amount: 150},
+		},
+		{
+			name:     "withdrawal past the overdraft",
+			balance:  100,
+			limits:   dto.AccountLimits{OverdraftLimit: 50},
+			posting:  Posting{Type: "withdrawal", Amount: 150.5},
+			wantErr:  ErrOverdraftLimitExceeded,
+			wantText: "overdraft limit exceeded: the balance may not go below -50.00",
+		},
+		{
+			name:    "minimum balance",
+			balance: 100,
+			limits:  dto.AccountLimits{MinimumBalance: limit(25)},
+			posting: Posting{Type: "withdrawal", Amount: 80},
+			wantErr: ErrMinimumBalance,
+		},
+		{
+			name:    "minimum balance above the overdraft floor",
+			balance: 100,
+			limits:  dto.AccountLimits{OverdraftLimit: 500, MinimumBalance: limit(-100)},
+			posting: Posting{Type: "withdrawal", Amount: 250},
+			wantErr: ErrMinimumBalance,
+		},
+		{
+			name:    "withdrawal of held funds",
+			balance: 100,
+			posting: Posting{Type: "withdrawal", Amount: 60, Held: 40.01},
+			wantErr: ErrInsufficientFunds,
+		},
+		{
+			name:    "withdrawal of the available balance",
+			balance: 100,
+			limits:  dto.AccountLimits{MinimumBalance: limit(10)},
+			posting: Posting{Type: "withdrawal", Amount: 50, Held: 40},
+		},
+		{
+			name:    "deposits ignore the balance floors",
+			balance: -80,
+			limits:  dto.AccountLimits{MinimumBalance: limit(0)},
+			posting: Posting{Type: "deposit", Amount: 10},
+		},
+		{
+			name:     "daily withdrawal limit",
+			balance:  1000,
+			limits:   dto.AccountLimits{DailyWithdrawalLimit: limit(300)},
+			posting:  Posting{Type: "withdrawal", Amount: 150, WithdrawnToday: 200},
+			wantErr:  ErrDailyWithdrawalLimitExceeded,
+			wantText: "daily withdrawal limit exceeded: 100.00 of 300.00 left today",
+		},
+		{
+			name:    "withdrawal reaching the daily limit",
+			balance: 1000,
+			limits:  dto.AccountLimits{DailyWithdrawalLimit: limit(300)},
+			posting: Posting{Type: "withdrawal", Amount: 100.1, WithdrawnToday: 199.9},
+		},
+		{
+			name:    "transaction limit on deposits",
+			balance: 0,
+			limits:  dto.AccountLimits{MaxTransactionAmount: limit(1000)},
+			posting: Posting{Type: "deposit", Amount: 1000.01},
+			wantErr: ErrTransactionLimitExceeded,
+		},
+		{
+			name:    "transaction limit is checked before the balance",
+			balance: 0,
+			limits:  dto.AccountLimits{MaxTransactionAmount: limit(10)},
+			posting: Posting{Type: "withdrawal", Amount: 20},
+			wantErr: ErrTransactionLimitExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.posting.Account = &dto.AccountDTO{Balance: tt.balance, Limits: tt.limits}
+
+			err := Evaluate(tt.posting)
+
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+			if tt.wantText != "" {
+				assert.EqualError(t, err, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestNewBalance(t *testing.T) {
+	account := &dto.AccountDTO{Balance: 100}
+	tests := []struct {
+		transactionType string
+		want            float64
+	}{
+		{dto.TransactionDeposit, 110},
+		{dto.TransactionWithdrawal, 90},
+		{dto.TransactionInterest, 110},
+		{dto.TransactionFee, 90},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.transactionType, func(t *testing.T) {
+			assert.Equal(t, tt.want, Posting{Account: account, Type: tt.transactionType, Amount: 10}.NewBalance())
+		})
+	}
+}
+
+func TestViolationsAreDistinct(t *testing.T) {
+	assert.False(t, errors.Is(ErrOverdraftLimitExceeded, ErrInsufficientFunds))
+	assert.True(t, errors.Is(&Violation{Code: CodeInsufficientFunds, Message: "details"}, ErrInsufficientFunds))
+}
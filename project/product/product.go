@@ -0,0 +1,161 @@
+// Package product defines the account products offered by the bank and the
+// interest and fee terms of each. Every account belongs to one product, the
+// accrual batch reads the terms from the catalog when it posts interest and
+// fees.
+package product
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Built-in product codes
+const (
+	Checking = "checking"
+	Savings  = "savings"
+
+	// Default is the product of accounts opened without one, and of the
+	// accounts created before products were introduced
+	Default = Checking
+)
+
+// DayCount is the convention turning an annual rate into a daily one
+type DayCount string
+
+const (
+	Actual365    DayCount = "actual/365"
+	Actual360    DayCount = "actual/360"
+	ActualActual DayCount = "actual/actual"
+)
+
+// DaysInYear is the divisor of the annual rate for a day of the given year
+func (d DayCount) DaysInYear(year int) int {
+	switch d {
+	case Actual360:
+		return 360
+	case ActualActual:
+		return time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	default:
+		return 365
+	}
+}
+
+func (d DayCount) valid() bool {
+	return d == Actual365 || d == Actual360 || d == ActualActual
+}
+
+// Product is an account product and its terms
+type Product struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// AnnualRate is the interest rate paid on positive balances, 0.02 is 2%
+	AnnualRate float64  `json:"annual_rate"`
+	DayCount   DayCount `json:"day_count"`
+	// MonthlyFee is charged at the end of every full month the account was open
+	MonthlyFee float64 `json:"monthly_fee"`
+	// FeeWaiverBalance waives the monthly fee when the balance stayed at or
+	// above it all month. Zero never waives the fee.
+	FeeWaiverBalance float64 `json:"fee_waiver_balance"`
+}
+
+// Catalog holds the products by code
+type Catalog map[string]Product
+
+// DefaultCatalog returns the built-in products
+func DefaultCatalog() Catalog {
+	return Catalog{
+		Checking: {
+			Code:             Checking,
+			Name:             "Checking",
+			DayCount:         Actual365,
+			MonthlyFee:       5,
+			FeeWaiverBalance: 1500,
+		},
+		Savings: {
+			Code:       Savings,
+			Name:       "Savings",
+			AnnualRate: 0.02,
+			DayCount:   Actual365,
+		},
+	}
+}
+
+// LoadCatalog reads a catalog from a JSON file holding a list of products
+func LoadCatalog(path string) (Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read products file: %w", err)
+	}
+
+	var products []Product
+	if err := json.Unmarshal(data, &products); err != nil {
+		return nil, fmt.Errorf("failed to parse products file: %w", err)
+	}
+
+	catalog := Catalog{}
+	for _, p := range products {
+		if p.DayCount == "" {
+			p.DayCount = Actual365
+		}
+		if err := p.validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := catalog[p.Code]; ok {
+			return nil, fmt.Errorf("product %q is defined twice", p.Code)
+		}
+		catalog[p.Code] = p
+	}
+	if _, ok := catalog[Default]; !ok {
+		return nil, fmt.Errorf("the products file must define the %q product", Default)
+	}
+	return catalog, nil
+}
+
+// CatalogFromEnv loads the catalog from PRODUCTS_FILE, or returns the
+// built-in products when it is not set
+func CatalogFromEnv() (Catalog, error) {
+	path := os.Getenv("PRODUCTS_FILE")
+	if path == "" {
+		return DefaultCatalog(), nil
+	}
+	return LoadCatalog(path)
+}
+
+// Lookup returns the product with the code, an empty code is the default product
+func (c Catalog) Lookup(code string) (Product, bool) {
+	if code == "" {
+		code = Default
+	}
+	p, ok := c[code]
+	return p, ok
+}
+
+// All returns the products sorted by code
+func (c Catalog) All() []Product {
+	products := make([]Product, 0, len(c))
+	for _, p := range c {
+		products = append(products, p)
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].Code < products[j].Code })
+	return products
+}
+
+func (p Product) validate() error {
+	switch {
+	case p.Code == "":
+		return errors.New("product code is required")
+	case p.AnnualRate < 0:
+		return fmt.Errorf("product %q has a negative annual rate", p.Code)
+	case p.MonthlyFee < 0:
+		return fmt.Errorf("product %q has a negative monthly fee", p.Code)
+	case p.FeeWaiverBalance < 0:
+		return fmt.Errorf("product %q has a negative fee waiver balance", p.Code)
+	case !p.DayCount.valid():
+		return fmt.Errorf("product %q has unknown day count %q", p.Code, p.DayCount)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package product
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDaysInYear(t *testing.T) {
+	assert.Equal(t, 365, Actual365.DaysInYear(2024))
+	assert.Equal(t, 360, Actual360.DaysInYear(2024))
+	assert.Equal(t, 366, ActualActual.DaysInYear(2024))
+	assert.Equal(t, 365, ActualActual.DaysInYear(2025))
+}
+
+func TestLookupDefaultsToChecking(t *testing.T) {
+	p, ok := DefaultCatalog().Lookup("")
+	require.True(t, ok)
+	assert.Equal(t, Checking, p.Code)
+
+	_, ok = DefaultCatalog().Lookup("brokerage")
+	assert.False(t, ok)
+}
+
+func TestLoadCatalog(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			content: `[{"code": "checking", "name": "Checking", "monthly_fee": 3},
+				{"code": "premium_savings", "name": "Premium Savings", "annual_rate": 0.035, "day_count": "actual/360"}]`,
+		},
+		{
+			name:    "default product missing",
+			content: `[{"code": "savings", "annual_rate": 0.02}]`,
+			wantErr: `the products file must define the "checking" product`,
+		},
+		{
+			name:    "unknown day count",
+			content: `[{"code": "checking", "day_count": "30/360"}]`,
+			wantErr: `product "checking" has unknown day count "30/360"`,
+		},
+		{
+			name:    "negative fee",
+			content: `[{"code": "checking", "monthly_fee": -1}]`,
+			wantErr: `product "checking" has a negative monthly fee`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "products.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			catalog, err := LoadCatalog(path)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, Actual365, catalog[Checking].DayCount)
+			assert.Equal(t, Actual360, catalog["premium_savings"].DayCount)
+			assert.Equal(t, []string{"checking", "premium_savings"}, []string{catalog.All()[0].Code, catalog.All()[1].Code})
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often idle buckets are dropped from memory
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// MemoryStore keeps token buckets in process memory. It is suitable for a
+// single API instance; several instances each enforce their own limits.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+
+	// now is the clock, replaceable in tests
+	now func() time.Time
+}
+
+// NewMemoryStore creates an in-process token bucket store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take removes one token from the bucket identified by key
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	return s.use(key, limit, true), nil
+}
+
+// Peek reports what Take would return without removing a token
+func (s *MemoryStore) Peek(ctx context.Context, key string, limit Limit) (Result, error) {
+	return s.use(key, limit, false), nil
+}
+
+// use refills the bucket identified by key and, when take is set, removes a token
+func (s *MemoryStore) use(key string, limit Limit, take bool) Result {
+	if limit.Unlimited() {
+		return Result{Allowed: true}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Burst), last: now, limit: limit}
+		if !take {
+			// A bucket nobody took from is full, no need to keep it
+			return Result{Allowed: true, Limit: limit.Burst, Remaining: limit.Burst}
+		}
+		s.buckets[key] = b
+	}
+
+	// Refill for the time elapsed since the previous request
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	b.last = now
+
+	result := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		if take {
+			b.tokens--
+		}
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / limit.Rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.ResetAfter = secondsToDuration((float64(limit.Burst) - b.tokens) / limit.Rate)
+
+	return result
+}
+
+// sweep drops buckets that have refilled completely, they are equivalent to new ones
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		refill := now.Sub(b.last).Seconds() * b.limit.Rate
+		if b.tokens+refill >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
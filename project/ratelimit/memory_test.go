@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced clock for deterministic tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestStore() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clock.Now
+	return store, clock
+}
+
+func TestMemoryStoreAllowsBurstThenRejects(t *testing.T) {
+	store, _ := newTestStore()
+	limit := PerSecond(1, 3)
+
+	for i := 0; i < 3; i++ {
+		result, err := store.Take(context.Background(), "client", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2-i, result.Remaining)
+		assert.Equal(t, 3, result.Limit)
+	}
+
+	result, err := store.Take(context.Background(), "client", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.ResetAfter)
+}
+
+func TestMemoryStoreRefills(t *testing.T) {
+	store, clock := newTestStore()
+	limit := PerMinute(60, 1)
+
+	result, _ := store.Take(context.Background(), "client", limit)
+	assert.True(t, result.Allowed)
+
+	result, _ = store.Take(context.Background(), "client", limit)
+	assert.False(t, result.Allowed)
+
+	clock.Advance(time.Second)
+	result, _ = store.Take(context.Background(), "client", limit)
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	store, _ := newTestStore()
+	limit := PerSecond(1, 1)
+
+	result, _ := store.Take(context.Background(), "client-a", limit)
+	assert.True(t, result.Allowed)
+
+	result, _ = store.Take(context.Background(), "client-b", limit)
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryStoreUnlimited(t *testing.T) {
+	store, _ := newTestStore()
+
+	for i := 0; i < 100; i++ {
+		result, err := store.Take(context.Background(), "client", Limit{})
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+}
+
+func TestMemoryStoreSweepsIdleBuckets(t *testing.T) {
+	store, clock := newTestStore()
+	limit := PerSecond(1, 1)
+
+	store.Take(context.Background(), "client", limit)
+	assert.Len(t, store.buckets, 1)
+
+	clock.Advance(2 * sweepInterval)
+	store.Take(context.Background(), "other", limit)
+	assert.Len(t, store.buckets, 1)
+	assert.Contains(t, store.buckets, "other")
+}
+
+func TestMemoryStorePeekTakesNoToken(t *testing.T) {
+	store, _ := newTestStore()
+	limit := PerSecond(1, 1)
+
+	result, err := store.Peek(context.Background(), "client", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Empty(t, store.buckets, "peeking keeps no state")
+
+	store.Take(context.Background(), "client", limit)
+	result, err = store.Peek(context.Background(), "client", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+}
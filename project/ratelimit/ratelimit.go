@@ -0,0 +1,50 @@
+// Package ratelimit implements token-bucket rate limiting behind a Store
+// interface so the limiter state can live in-process or in a shared backend.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit describes a token bucket: it refills at Rate tokens per second and holds at most Burst tokens
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerSecond returns a limit of n requests per second with the given burst
+func PerSecond(n float64, burst int) Limit {
+	return Limit{Rate: n, Burst: burst}
+}
+
+// PerMinute returns a limit of n requests per minute with the given burst
+func PerMinute(n float64, burst int) Limit {
+	return Limit{Rate: n / 60, Burst: burst}
+}
+
+// Unlimited reports whether the limit disables rate limiting
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	// Allowed is false when the bucket was empty
+	Allowed bool
+	// Limit is the bucket capacity
+	Limit int
+	// Remaining is the number of whole tokens left after this request
+	Remaining int
+	// RetryAfter is how long until a token is available, zero when Allowed
+	RetryAfter time.Duration
+	// ResetAfter is how long until the bucket is full again
+	ResetAfter time.Duration
+}
+
+// Store takes tokens from the bucket identified by key
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// Peek reports whether a token is available without taking it
+	Peek(ctx context.Context, key string, limit Limit) (Result, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// newID generates a random UUID (version 4) for rows whose ID is not set by the caller
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate ID: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
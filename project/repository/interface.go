@@ -2,19 +2,35 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/gcalvocr/go-testing/dto"
 )
 
+// Cursor is the position of an item in a list ordered by creation time and
+// ID. A page of the list starts after the cursor of the previous page's last item.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
 // AccountRepository defines the interface for account data operations
 type AccountRepository interface {
 	Create(ctx context.Context, account *dto.AccountDTO) error
 	GetByID(ctx context.Context, id string) (*dto.AccountDTO, error)
 	GetAll(ctx context.Context) ([]*dto.AccountDTO, error)
+	GetByOwnerID(ctx context.Context, ownerID string) ([]*dto.AccountDTO, error)
+	// GetPage returns up to limit accounts in creation order, after the
+	// cursor when it is not nil. An empty ownerID pages every account, a
+	// limit of 0 returns the rest of the list.
+	GetPage(ctx context.Context, ownerID string, after *Cursor, limit int) ([]*dto.AccountDTO, error)
 	Update(ctx context.Context, id string, account *dto.UpdateAccountRequest) error
 	Delete(ctx context.Context, id string) error
 	GetByName(ctx context.Context, name string) (*dto.AccountDTO, error)
 	UpdateBalance(ctx context.Context, id string, newBalance float64) error
+	// UpdateLimits replaces the posting limits of the account
+	UpdateLimits(ctx context.Context, id string, limits dto.AccountLimits) error
 }
 
 // TransactionRepository defines the interface for transaction data operations
@@ -22,10 +38,161 @@ type TransactionRepository interface {
 	Create(ctx context.Context, transaction *dto.TransactionDTO) error
 	GetByID(ctx context.Context, id string) (*dto.TransactionDTO, error)
 	GetByAccountID(ctx context.Context, accountID string) ([]*dto.TransactionDTO, error)
+	// GetPageByAccountID returns up to limit transactions of an account,
+	// newest first, after the cursor when it is not nil. A limit of 0 returns
+	// the rest of the list.
+	GetPageByAccountID(ctx context.Context, accountID string, after *Cursor, limit int) ([]*dto.TransactionDTO, error)
 	GetAll(ctx context.Context) ([]*dto.TransactionDTO, error)
 	Update(ctx context.Context, id string, transaction *dto.TransactionDTO) error
 	Delete(ctx context.Context, id string) error
 	GetTransactionSummary(ctx context.Context, accountID string) (*dto.TransactionSummary, error)
+	// GetTransactionSummarySince summarizes the transactions created at or after since
+	GetTransactionSummarySince(ctx context.Context, accountID string, since time.Time) (*dto.TransactionSummary, error)
+	// StreamByAccountID calls fn for each transaction created in [from, to), oldest
+	// first, reading from a database cursor. A zero to means no upper bound.
+	StreamByAccountID(ctx context.Context, accountID string, from, to time.Time, fn func(*dto.TransactionDTO) error) error
+}
+
+// APIKeyRepository defines the interface for API key data operations
+type APIKeyRepository interface {
+	Create(ctx context.Context, apiKey *dto.APIKeyDTO) error
+	GetByID(ctx context.Context, id string) (*dto.APIKeyDTO, error)
+	GetByHash(ctx context.Context, keyHash string) (*dto.APIKeyDTO, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+// ScheduledTransactionRepository defines the interface for scheduled transaction data operations
+type ScheduledTransactionRepository interface {
+	Create(ctx context.Context, scheduled *dto.ScheduledTransactionDTO) error
+	GetByID(ctx context.Context, id string) (*dto.ScheduledTransactionDTO, error)
+	GetByAccountID(ctx context.Context, accountID string) ([]*dto.ScheduledTransactionDTO, error)
+	// Update saves the amount, active flag, end and next run of the scheduled transaction
+	Update(ctx context.Context, scheduled *dto.ScheduledTransactionDTO) error
+	Delete(ctx context.Context, id string) error
+	// GetDue returns up to limit active scheduled transactions whose next run
+	// is at or before now, earliest first
+	GetDue(ctx context.Context, now time.Time, limit int) ([]*dto.ScheduledTransactionDTO, error)
+	// Advance moves the next run from due to next, nil when there are no more
+	// runs. It reports false when the next run is no longer due, because
+	// another worker already advanced it or the schedule was changed.
+	Advance(ctx context.Context, id string, due time.Time, next *time.Time) (bool, error)
+	// RecordRun stores the outcome of a run and updates the last run of the
+	// scheduled transaction
+	RecordRun(ctx context.Context, run *dto.ScheduledTransactionRunDTO) error
+	// GetRuns returns the runs of a scheduled transaction, latest first
+	GetRuns(ctx context.Context, scheduledID string) ([]*dto.ScheduledTransactionRunDTO, error)
+}
+
+// HoldRepository defines the interface for hold data operations
+type HoldRepository interface {
+	Create(ctx context.Context, hold *dto.HoldDTO) error
+	GetByID(ctx context.Context, id string) (*dto.HoldDTO, error)
+	// GetByAccountID returns the holds of an account, latest first
+	GetByAccountID(ctx context.Context, accountID string) ([]*dto.HoldDTO, error)
+	// Close saves the final status, captured amount and transaction of a
+	// hold. It reports false when the hold was no longer active.
+	Close(ctx context.Context, hold *dto.HoldDTO) (bool, error)
+	// ActiveTotal sums the active holds of an account that expire after now
+	ActiveTotal(ctx context.Context, accountID string, now time.Time) (float64, error)
+	// ExpireDue marks the active holds that expired at or before now as
+	// expired and returns how many there were
+	ExpireDue(ctx context.Context, now time.Time) (int64, error)
+}
+
+// ErrAccrualExists is returned when the accrual of an account for a period
+// was already recorded
+var ErrAccrualExists = errors.New("accrual already recorded for the period")
+
+// AccrualRepository defines the interface for interest and fee accrual records
+type AccrualRepository interface {
+	// Create records the accrual of an account for a period, or returns
+	// ErrAccrualExists when the period was already recorded
+	Create(ctx context.Context, accrual *dto.AccrualDTO) error
+	// Get returns the accrual of an account for a period, nil when there is none
+	Get(ctx context.Context, accountID, period string) (*dto.AccrualDTO, error)
+}
+
+// accrualID is the ID of the accrual of an account for a period, it keeps
+// a single accrual per period in both databases
+func accrualID(accountID, period string) string {
+	return accountID + ":" + period
+}
+
+// OutboxRepository stores domain events and the progress of their consumers
+type OutboxRepository interface {
+	// Append records an event and assigns its ID and sequence. Called in the
+	// transaction of the change it describes, appends are serialized until
+	// that transaction ends so events become visible in sequence order.
+	Append(ctx context.Context, event *dto.EventDTO) error
+	// After returns up to limit events with a sequence above after, in sequence order
+	After(ctx context.Context, after int64, limit int) ([]*dto.EventDTO, error)
+	// Offset returns the sequence of the last event the consumer processed, 0 when none
+	Offset(ctx context.Context, consumer string) (int64, error)
+	// SetOffset records the sequence of the last event the consumer processed
+	SetOffset(ctx context.Context, consumer string, sequence int64) error
+}
+
+// WebhookRepository defines the interface for webhook subscriptions and their deliveries
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *dto.WebhookSubscriptionDTO) error
+	GetSubscription(ctx context.Context, id string) (*dto.WebhookSubscriptionDTO, error)
+	GetSubscriptions(ctx context.Context) ([]*dto.WebhookSubscriptionDTO, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	// CreateDelivery records a pending delivery. It does nothing when the
+	// subscription already has a delivery of the event.
+	CreateDelivery(ctx context.Context, delivery *dto.WebhookDeliveryDTO) error
+	GetDelivery(ctx context.Context, id string) (*dto.WebhookDeliveryDTO, error)
+	// GetDeliveries returns the deliveries of a subscription, latest first,
+	// with the given status or all of them when status is empty
+	GetDeliveries(ctx context.Context, subscriptionID, status string) ([]*dto.WebhookDeliveryDTO, error)
+	// GetDueDeliveries returns up to limit pending deliveries whose next
+	// attempt is at or before now, earliest first
+	GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*dto.WebhookDeliveryDTO, error)
+	// ClaimDelivery moves the next attempt of a pending delivery from due to
+	// until. It reports false when another worker claimed it first.
+	ClaimDelivery(ctx context.Context, id string, due, until time.Time) (bool, error)
+	// UpdateDelivery saves the status, attempts and last attempt of a delivery
+	UpdateDelivery(ctx context.Context, delivery *dto.WebhookDeliveryDTO) error
+}
+
+// deliveryID is the ID of the delivery of an event to a subscription, it
+// keeps a single delivery per subscription and event in both databases
+func deliveryID(subscriptionID, eventID string) string {
+	return subscriptionID + ":" + eventID
+}
+
+// OutboxFeed follows the outbox as events are appended
+type OutboxFeed interface {
+	// Last returns the sequence of the last event, 0 when there are none
+	Last(ctx context.Context) (int64, error)
+	// Watch calls fn for every event with a sequence above after, in
+	// sequence order, as they are committed. It returns when ctx is
+	// cancelled, fn fails or the database connection is lost.
+	Watch(ctx context.Context, after int64, fn func(*dto.EventDTO) error) error
+}
+
+// outboxWatchBatch is how many events Watch reads per query while catching up
+const outboxWatchBatch = 100
+
+// AuditRepository stores the append-only audit log
+type AuditRepository interface {
+	// Append assigns the entry's sequence, seals it after the last entry of
+	// its resource's chain with dto.AuditEntryDTO.Seal and records it.
+	// Appends to a chain are serialized until the transaction in ctx ends,
+	// so chains have no gaps or forks; appends to other chains go ahead.
+	Append(ctx context.Context, entry *dto.AuditEntryDTO) error
+	// Find returns the entries matching the query, latest first
+	Find(ctx context.Context, query dto.AuditQuery) ([]*dto.AuditEntryDTO, error)
+	// Walk calls fn for every entry in sequence order, reading from a database cursor
+	Walk(ctx context.Context, fn func(*dto.AuditEntryDTO) error) error
+}
+
+// TxManager runs units of work atomically
+type TxManager interface {
+	// WithinTransaction runs fn in a database transaction, committing when fn
+	// returns nil and rolling back otherwise. Repository calls made with the
+	// context passed to fn join the transaction, and so do nested calls.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 // DatabaseType represents the type of database
@@ -40,6 +207,15 @@ const (
 type RepositoryFactory struct {
 	AccountRepo     AccountRepository
 	TransactionRepo TransactionRepository
+	APIKeyRepo      APIKeyRepository
+	ScheduledRepo   ScheduledTransactionRepository
+	AccrualRepo     AccrualRepository
+	HoldRepo        HoldRepository
+	OutboxRepo      OutboxRepository
+	OutboxFeed      OutboxFeed
+	WebhookRepo     WebhookRepository
+	AuditRepo       AuditRepository
+	TxManager       TxManager
 }
 
 // NewRepositoryFactory creates a new repository factory
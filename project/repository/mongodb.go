@@ -43,9 +43,32 @@ func newMongoDBFactory(connectionString string) (*RepositoryFactory, error) {
 	// Get database name from connection string or use default
 	db := client.Database("bankdb")
 
+	outbox := &MongoDBOutboxRepository{
+		collection: db.Collection("outbox"),
+		counters:   db.Collection("counters"),
+		offsets:    db.Collection("outbox_offsets"),
+	}
 	return &RepositoryFactory{
 		AccountRepo:     &MongoDBAccountRepository{collection: db.Collection("accounts")},
 		TransactionRepo: &MongoDBTransactionRepository{collection: db.Collection("transactions")},
+		APIKeyRepo:      &MongoDBAPIKeyRepository{collection: db.Collection("api_keys")},
+		ScheduledRepo: &MongoDBScheduledTransactionRepository{
+			collection: db.Collection("scheduled_transactions"),
+			runs:       db.Collection("scheduled_transaction_runs"),
+		},
+		AccrualRepo: &MongoDBAccrualRepository{collection: db.Collection("accruals")},
+		HoldRepo:    &MongoDBHoldRepository{collection: db.Collection("holds")},
+		OutboxRepo:  outbox,
+		OutboxFeed:  outbox,
+		WebhookRepo: &MongoDBWebhookRepository{
+			subscriptions: db.Collection("webhook_subscriptions"),
+			deliveries:    db.Collection("webhook_deliveries"),
+		},
+		AuditRepo: &MongoDBAuditRepository{
+			collection: db.Collection("audit_log"),
+			counters:   db.Collection("counters"),
+		},
+		TxManager: &MongoDBTxManager{client: client},
 	}, nil
 }
 
@@ -61,11 +84,11 @@ func (r *MongoDBAccountRepository) Create(ctx context.Context, account *dto.Acco
 
 	_, err := r.collection.InsertOne(ctx, account)
 	if err != nil {
-		logger.Error("Failed to create account in MongoDB", err)
+		logger.ErrorContext(ctx, "Failed to create account in MongoDB", err)
 		return err
 	}
 
-	logger.Info("Account created in MongoDB", map[string]interface{}{
+	logger.InfoContext(ctx, "Account created in MongoDB", map[string]interface{}{
 		"account_id": account.ID,
 		"name":       account.Name,
 	})
@@ -79,7 +102,7 @@ func (r *MongoDBAccountRepository) GetByID(ctx context.Context, id string) (*dto
 		if err == mongo.ErrNoDocuments {
 			return nil, nil // Account not found
 		}
-		logger.Error("Failed to get account from MongoDB", err)
+		logger.ErrorContext(ctx, "Failed to get account from MongoDB", err)
 		return nil, err
 	}
 	return &account, nil
@@ -88,7 +111,74 @@ func (r *MongoDBAccountRepository) GetByID(ctx context.Context, id string) (*dto
 func (r *MongoDBAccountRepository) GetAll(ctx context.Context) ([]*dto.AccountDTO, error) {
 	cursor, err := r.collection.Find(ctx, bson.M{})
 	if err != nil {
-		logger.Error("Failed to query accounts from MongoDB", err)
+		logger.ErrorContext(ctx, "Failed to query accounts from MongoDB", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var accounts []*dto.AccountDTO
+	for cursor.Next(ctx) {
+		var account dto.AccountDTO
+		if err := cursor.Decode(&account); err != nil {
+			logger.ErrorContext(ctx, "Failed to decode account from MongoDB", err)
+			return nil, err
+		}
+		accounts = append(accounts, &account)
+	}
+
+	if err := cursor.Err(); err != nil {
+		logger.ErrorContext(ctx, "Cursor error in MongoDB", err)
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+func (r *MongoDBAccountRepository) GetByOwnerID(ctx context.Context, ownerID string) ([]*dto.AccountDTO, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"owner_id": ownerID})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to query accounts by owner from MongoDB", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var accounts []*dto.AccountDTO
+	for cursor.Next(ctx) {
+		var account dto.AccountDTO
+		if err := cursor.Decode(&account); err != nil {
+			logger.ErrorContext(ctx, "Failed to decode account from MongoDB", err)
+			return nil, err
+		}
+		accounts = append(accounts, &account)
+	}
+
+	if err := cursor.Err(); err != nil {
+		logger.ErrorContext(ctx, "Cursor error in MongoDB", err)
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+func (r *MongoDBAccountRepository) GetPage(ctx context.Context, ownerID string, after *Cursor, limit int) ([]*dto.AccountDTO, error) {
+	filter := bson.M{}
+	if ownerID != "" {
+		filter["owner_id"] = ownerID
+	}
+	if after != nil {
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$gt": after.CreatedAt}},
+			bson.M{"created_at": after.CreatedAt, "_id": bson.M{"$gt": after.ID}},
+		}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to query a page of accounts from MongoDB", err)
 		return nil, err
 	}
 	defer cursor.Close(ctx)
@@ -97,14 +187,14 @@ func (r *MongoDBAccountRepository) GetAll(ctx context.Context) ([]*dto.AccountDT
 	for cursor.Next(ctx) {
 		var account dto.AccountDTO
 		if err := cursor.Decode(&account); err != nil {
-			logger.Error("Failed to decode account from MongoDB", err)
+			logger.ErrorContext(ctx, "Failed to decode account from MongoDB", err)
 			return nil, err
 		}
 		accounts = append(accounts, &account)
 	}
 
 	if err := cursor.Err(); err != nil {
-		logger.Error("Cursor error in MongoDB", err)
+		logger.ErrorContext(ctx, "Cursor error in MongoDB", err)
 		return nil, err
 	}
 
@@ -126,11 +216,11 @@ func (r *MongoDBAccountRepository) Update(ctx context.Context, id string, update
 
 	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updateDoc})
 	if err != nil {
-		logger.Error("Failed to update account in MongoDB", err)
+		logger.ErrorContext(ctx, "Failed to update account in MongoDB", err)
 		return err
 	}
 
-	logger.Info("Account updated in MongoDB", map[string]interface{}{
+	logger.InfoContext(ctx, "Account updated in MongoDB", map[string]interface{}{
 		"account_id": id,
 	})
 	return nil
@@ -139,11 +229,11 @@ func (r *MongoDBAccountRepository) Update(ctx context.Context, id string, update
 func (r *MongoDBAccountRepository) Delete(ctx context.Context, id string) error {
 	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	if err != nil {
-		logger.Error("Failed to delete account from MongoDB", err)
+		logger.ErrorContext(ctx, "Failed to delete account from MongoDB", err)
 		return err
 	}
 
-	logger.Info("Account deleted from MongoDB", map[string]interface{}{
+	logger.InfoContext(ctx, "Account deleted from MongoDB", map[string]interface{}{
 		"account_id":    id,
 		"deleted_count": result.DeletedCount,
 	})
@@ -157,7 +247,7 @@ func (r *MongoDBAccountRepository) GetByName(ctx context.Context, name string) (
 		if err == mongo.ErrNoDocuments {
 			return nil, nil // Account not found
 		}
-		logger.Error("Failed to get account by name from MongoDB", err)
+		logger.ErrorContext(ctx, "Failed to get account by name from MongoDB", err)
 		return nil, err
 	}
 	return &account, nil
@@ -171,17 +261,35 @@ func (r *MongoDBAccountRepository) UpdateBalance(ctx context.Context, id string,
 
 	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updateDoc})
 	if err != nil {
-		logger.Error("Failed to update account balance in MongoDB", err)
+		logger.ErrorContext(ctx, "Failed to update account balance in MongoDB", err)
 		return err
 	}
 
-	logger.Info("Account balance updated in MongoDB", map[string]interface{}{
+	logger.InfoContext(ctx, "Account balance updated in MongoDB", map[string]interface{}{
 		"account_id":  id,
 		"new_balance": newBalance,
 	})
 	return nil
 }
 
+func (r *MongoDBAccountRepository) UpdateLimits(ctx context.Context, id string, limits dto.AccountLimits) error {
+	updateDoc := bson.M{
+		"limits":     limits,
+		"updated_at": time.Now(),
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updateDoc})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to update account limits in MongoDB", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "Account limits updated in MongoDB", map[string]interface{}{
+		"account_id": id,
+	})
+	return nil
+}
+
 // Transaction repository methods for MongoDB
 func (r *MongoDBTransactionRepository) Create(ctx context.Context, transaction *dto.TransactionDTO) error {
 	if transaction.ID == "" {
@@ -194,11 +302,11 @@ func (r *MongoDBTransactionRepository) Create(ctx context.Context, transaction *
 
 	_, err := r.collection.InsertOne(ctx, transaction)
 	if err != nil {
-		logger.Error("Failed to create transaction in MongoDB", err)
+		logger.ErrorContext(ctx, "Failed to create transaction in MongoDB", err)
 		return err
 	}
 
-	logger.Info("Transaction created in MongoDB", map[string]interface{}{
+	logger.InfoContext(ctx, "Transaction created in MongoDB", map[string]interface{}{
 		"transaction_id": transaction.ID,
 		"account_id":     transaction.AccountID,
 		"amount":         transaction.Amount,
@@ -214,7 +322,7 @@ func (r *MongoDBTransactionRepository) GetByID(ctx context.Context, id string) (
 		if err == mongo.ErrNoDocuments {
 			return nil, nil // Transaction not found
 		}
-		logger.Error("Failed to get transaction from MongoDB", err)
+		logger.ErrorContext(ctx, "Failed to get transaction from MongoDB", err)
 		return nil, err
 	}
 	return &transaction, nil
@@ -223,7 +331,7 @@ func (r *MongoDBTransactionRepository) GetByID(ctx context.Context, id string) (
 func (r *MongoDBTransactionRepository) GetByAccountID(ctx context.Context, accountID string) ([]*dto.TransactionDTO, error) {
 	cursor, err := r.collection.Find(ctx, bson.M{"account_id": accountID})
 	if err != nil {
-		logger.Error("Failed to query transactions by account ID from MongoDB", err)
+		logger.ErrorContext(ctx, "Failed to query transactions by account ID from MongoDB", err)
 		return nil, err
 	}
 	defer cursor.Close(ctx)
@@ -232,14 +340,52 @@ func (r *MongoDBTransactionRepository) GetByAccountID(ctx context.Context, accou
 	for cursor.Next(ctx) {
 		var transaction dto.TransactionDTO
 		if err := cursor.Decode(&transaction); err != nil {
-			logger.Error("Failed to decode transaction from MongoDB", err)
+			logger.ErrorContext(ctx, "Failed to decode transaction from MongoDB", err)
 			return nil, err
 		}
 		transactions = append(transactions, &transaction)
 	}
 
 	if err := cursor.Err(); err != nil {
-		logger.Error("Cursor error in MongoDB", err)
+		logger.ErrorContext(ctx, "Cursor error in MongoDB", err)
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+func (r *MongoDBTransactionRepository) GetPageByAccountID(ctx context.Context, accountID string, after *Cursor, limit int) ([]*dto.TransactionDTO, error) {
+	filter := bson.M{"account_id": accountID}
+	if after != nil {
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$lt": after.CreatedAt}},
+			bson.M{"created_at": after.CreatedAt, "_id": bson.M{"$lt": after.ID}},
+		}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to query a page of transactions from MongoDB", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*dto.TransactionDTO
+	for cursor.Next(ctx) {
+		var transaction dto.TransactionDTO
+		if err := cursor.Decode(&transaction); err != nil {
+			logger.ErrorContext(ctx, "Failed to decode transaction from MongoDB", err)
+			return nil, err
+		}
+		transactions = append(transactions, &transaction)
+	}
+
+	if err := cursor.Err(); err != nil {
+		logger.ErrorContext(ctx, "Cursor error in MongoDB", err)
 		return nil, err
 	}
 
@@ -249,7 +395,7 @@ func (r *MongoDBTransactionRepository) GetByAccountID(ctx context.Context, accou
 func (r *MongoDBTransactionRepository) GetAll(ctx context.Context) ([]*dto.TransactionDTO, error) {
 	cursor, err := r.collection.Find(ctx, bson.M{})
 	if err != nil {
-		logger.Error("Failed to query all transactions from MongoDB", err)
+		logger.ErrorContext(ctx, "Failed to query all transactions from MongoDB", err)
 		return nil, err
 	}
 	defer cursor.Close(ctx)
@@ -258,14 +404,14 @@ func (r *MongoDBTransactionRepository) GetAll(ctx context.Context) ([]*dto.Trans
 	for cursor.Next(ctx) {
 		var transaction dto.TransactionDTO
 		if err := cursor.Decode(&transaction); err != nil {
-			logger.Error("Failed to decode transaction from MongoDB", err)
+			logger.ErrorContext(ctx, "Failed to decode transaction from MongoDB", err)
 			return nil, err
 		}
 		transactions = append(transactions, &transaction)
 	}
 
 	if err := cursor.Err(); err != nil {
-		logger.Error("Cursor error in MongoDB", err)
+		logger.ErrorContext(ctx, "Cursor error in MongoDB", err)
 		return nil, err
 	}
 
@@ -284,11 +430,11 @@ func (r *MongoDBTransactionRepository) Update(ctx context.Context, id string, tr
 
 	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updateDoc})
 	if err != nil {
-		logger.Error("Failed to update transaction in MongoDB", err)
+		logger.ErrorContext(ctx, "Failed to update transaction in MongoDB", err)
 		return err
 	}
 
-	logger.Info("Transaction updated in MongoDB", map[string]interface{}{
+	logger.InfoContext(ctx, "Transaction updated in MongoDB", map[string]interface{}{
 		"transaction_id": id,
 	})
 	return nil
@@ -297,11 +443,11 @@ func (r *MongoDBTransactionRepository) Update(ctx context.Context, id string, tr
 func (r *MongoDBTransactionRepository) Delete(ctx context.Context, id string) error {
 	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	if err != nil {
-		logger.Error("Failed to delete transaction from MongoDB", err)
+		logger.ErrorContext(ctx, "Failed to delete transaction from MongoDB", err)
 		return err
 	}
 
-	logger.Info("Transaction deleted from MongoDB", map[string]interface{}{
+	logger.InfoContext(ctx, "Transaction deleted from MongoDB", map[string]interface{}{
 		"transaction_id": id,
 		"deleted_count":  result.DeletedCount,
 	})
@@ -309,32 +455,26 @@ func (r *MongoDBTransactionRepository) Delete(ctx context.Context, id string) er
 }
 
 func (r *MongoDBTransactionRepository) GetTransactionSummary(ctx context.Context, accountID string) (*dto.TransactionSummary, error) {
+	return r.GetTransactionSummarySince(ctx, accountID, time.Time{})
+}
+
+func (r *MongoDBTransactionRepository) GetTransactionSummarySince(ctx context.Context, accountID string, since time.Time) (*dto.TransactionSummary, error) {
 	pipeline := mongo.Pipeline{
-		{{"$match", bson.M{"account_id": accountID}}},
-		{{"$group", bson.M{
-			"_id":                "$account_id",
-			"total_transactions": bson.M{"$sum": 1},
-			"total_deposits": bson.M{"$sum": bson.M{
-				"$cond": []interface{}{
-					bson.M{"$eq": []string{"$type", "deposit"}},
-					"$amount",
-					0,
-				},
-			}},
-			"total_withdrawals": bson.M{"$sum": bson.M{
-				"$cond": []interface{}{
-					bson.M{"$eq": []string{"$type", "withdrawal"}},
-					"$amount",
-					0,
-				},
-			}},
+		{{Key: "$match", Value: bson.M{"account_id": accountID, "created_at": bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":                 "$account_id",
+			"total_transactions":  bson.M{"$sum": 1},
+			"total_deposits":      sumOfType(dto.TransactionDeposit),
+			"total_withdrawals":   sumOfType(dto.TransactionWithdrawal),
+			"total_interest":      sumOfType(dto.TransactionInterest),
+			"total_fees":          sumOfType(dto.TransactionFee),
 			"last_transaction_at": bson.M{"$max": "$created_at"},
 		}}},
 	}
 
 	cursor, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
-		logger.Error("Failed to aggregate transaction summary from MongoDB", err)
+		logger.ErrorContext(ctx, "Failed to aggregate transaction summary from MongoDB", err)
 		return nil, err
 	}
 	defer cursor.Close(ctx)
@@ -347,17 +487,21 @@ func (r *MongoDBTransactionRepository) GetTransactionSummary(ctx context.Context
 			TotalTransactions int       `bson:"total_transactions"`
 			TotalDeposits     float64   `bson:"total_deposits"`
 			TotalWithdrawals  float64   `bson:"total_withdrawals"`
+			TotalInterest     float64   `bson:"total_interest"`
+			TotalFees         float64   `bson:"total_fees"`
 			LastTransactionAt time.Time `bson:"last_transaction_at"`
 		}
 
 		if err := cursor.Decode(&result); err != nil {
-			logger.Error("Failed to decode transaction summary from MongoDB", err)
+			logger.ErrorContext(ctx, "Failed to decode transaction summary from MongoDB", err)
 			return nil, err
 		}
 
 		summary.TotalTransactions = result.TotalTransactions
 		summary.TotalDeposits = result.TotalDeposits
 		summary.TotalWithdrawals = result.TotalWithdrawals
+		summary.TotalInterest = result.TotalInterest
+		summary.TotalFees = result.TotalFees
 		summary.LastTransactionAt = &result.LastTransactionAt
 	}
 
@@ -368,10 +512,53 @@ func (r *MongoDBTransactionRepository) GetTransactionSummary(ctx context.Context
 	}
 	err = accountCollection.FindOne(ctx, bson.M{"_id": accountID}).Decode(&account)
 	if err != nil {
-		logger.Error("Failed to get current balance from MongoDB", err)
+		logger.ErrorContext(ctx, "Failed to get current balance from MongoDB", err)
 		return nil, err
 	}
 
 	summary.CurrentBalance = account.Balance
 	return &summary, nil
 }
+
+// sumOfType sums the amounts of the grouped transactions of one type
+func sumOfType(transactionType string) bson.M {
+	return bson.M{"$sum": bson.M{
+		"$cond": []interface{}{
+			bson.M{"$eq": []string{"$type", transactionType}},
+			"$amount",
+			0,
+		},
+	}}
+}
+
+func (r *MongoDBTransactionRepository) StreamByAccountID(ctx context.Context, accountID string, from, to time.Time, fn func(*dto.TransactionDTO) error) error {
+	createdAt := bson.M{"$gte": from}
+	if !to.IsZero() {
+		createdAt["$lt"] = to
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"account_id": accountID, "created_at": createdAt}, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to stream transactions from MongoDB", err)
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var transaction dto.TransactionDTO
+		if err := cursor.Decode(&transaction); err != nil {
+			logger.ErrorContext(ctx, "Failed to decode transaction from MongoDB", err)
+			return err
+		}
+		if err := fn(&transaction); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		logger.ErrorContext(ctx, "Cursor error in MongoDB", err)
+		return err
+	}
+	return nil
+}
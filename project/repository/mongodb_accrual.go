@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoDBAccrualRepository implements AccrualRepository for MongoDB
+type MongoDBAccrualRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *MongoDBAccrualRepository) Create(ctx context.Context, accrual *dto.AccrualDTO) error {
+	accrual.ID = accrualID(accrual.AccountID, accrual.Period)
+
+	_, err := r.collection.InsertOne(ctx, accrual)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrAccrualExists
+		}
+		logger.ErrorContext(ctx, "Failed to create accrual in MongoDB", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "Accrual created in MongoDB", map[string]interface{}{
+		"account_id": accrual.AccountID,
+		"period":     accrual.Period,
+	})
+	return nil
+}
+
+func (r *MongoDBAccrualRepository) Get(ctx context.Context, accountID, period string) (*dto.AccrualDTO, error) {
+	var accrual dto.AccrualDTO
+	err := r.collection.FindOne(ctx, bson.M{"_id": accrualID(accountID, period)}).Decode(&accrual)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // Accrual not found
+		}
+		logger.ErrorContext(ctx, "Failed to get accrual from MongoDB", err)
+		return nil, err
+	}
+	return &accrual, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoDBAPIKeyRepository implements APIKeyRepository for MongoDB
+type MongoDBAPIKeyRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *MongoDBAPIKeyRepository) Create(ctx context.Context, apiKey *dto.APIKeyDTO) error {
+	if apiKey.ID == "" {
+		apiKey.ID = primitive.NewObjectID().Hex()
+	}
+	apiKey.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, apiKey)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create API key in MongoDB", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "API key created in MongoDB", map[string]interface{}{
+		"api_key_id":   apiKey.ID,
+		"principal_id": apiKey.PrincipalID,
+	})
+	return nil
+}
+
+func (r *MongoDBAPIKeyRepository) GetByID(ctx context.Context, id string) (*dto.APIKeyDTO, error) {
+	return r.get(ctx, bson.M{"_id": id})
+}
+
+func (r *MongoDBAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*dto.APIKeyDTO, error) {
+	return r.get(ctx, bson.M{"key_hash": keyHash})
+}
+
+// get returns the API key matching filter, nil when there is none
+func (r *MongoDBAPIKeyRepository) get(ctx context.Context, filter bson.M) (*dto.APIKeyDTO, error) {
+	var apiKey dto.APIKeyDTO
+	err := r.collection.FindOne(ctx, filter).Decode(&apiKey)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // API key not found
+		}
+		logger.ErrorContext(ctx, "Failed to get API key from MongoDB", err)
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+func (r *MongoDBAPIKeyRepository) Revoke(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}}
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to revoke API key in MongoDB", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "API key revoked in MongoDB", map[string]interface{}{
+		"api_key_id": id,
+	})
+	return nil
+}
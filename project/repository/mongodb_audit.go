@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// auditSequence is the document in the counters collection holding the last
+// audit log sequence. The head of each chain is the document with the ID
+// auditSequence + "|" + the chain, holding the position, sequence and hash
+// of the chain's last entry.
+const auditSequence = "audit_log"
+
+// maxAuditAppendAttempts bounds the retries of an append racing other appends
+const maxAuditAppendAttempts = 10
+
+// MongoDBAuditRepository implements AuditRepository for MongoDB. Appends
+// move the head document of their chain from the last entry to the new one
+// only if it still points at the last entry, so concurrent appends can't
+// fork a chain; in a transaction the losing append fails with a write
+// conflict and the driver retries the transaction. Appends to other chains
+// don't conflict.
+type MongoDBAuditRepository struct {
+	collection *mongo.Collection
+	counters   *mongo.Collection
+}
+
+// auditDocument stores the snapshots as JSON strings, as they were hashed
+type auditDocument struct {
+	ID            string    `bson:"_id"`
+	Sequence      int64     `bson:"sequence"`
+	ChainSequence int64     `bson:"chain_sequence"`
+	Actor         string    `bson:"actor"`
+	Action        string    `bson:"action"`
+	ResourceType  string    `bson:"resource_type"`
+	ResourceID    string    `bson:"resource_id"`
+	Before        string    `bson:"before,omitempty"`
+	After         string    `bson:"after,omitempty"`
+	RequestID     string    `bson:"request_id,omitempty"`
+	OccurredAt    time.Time `bson:"occurred_at"`
+	PrevHash      string    `bson:"prev_hash"`
+	Hash          string    `bson:"hash"`
+}
+
+func (d *auditDocument) entry() *dto.AuditEntryDTO {
+	return &dto.AuditEntryDTO{
+		ID:            d.ID,
+		Sequence:      d.Sequence,
+		ChainSequence: d.ChainSequence,
+		Actor:         d.Actor,
+		Action:        d.Action,
+		ResourceType:  d.ResourceType,
+		ResourceID:    d.ResourceID,
+		Before:        rawSnapshot(d.Before),
+		After:         rawSnapshot(d.After),
+		RequestID:     d.RequestID,
+		OccurredAt:    d.OccurredAt,
+		PrevHash:      d.PrevHash,
+		Hash:          d.Hash,
+	}
+}
+
+func (r *MongoDBAuditRepository) Append(ctx context.Context, entry *dto.AuditEntryDTO) error {
+	if entry.ID == "" {
+		entry.ID = primitive.NewObjectID().Hex()
+	}
+
+	for attempt := 1; ; attempt++ {
+		sequence, err := r.nextSequence(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to allocate audit log sequence in MongoDB", err)
+			return err
+		}
+		advanced, err := r.advanceHead(ctx, entry, sequence)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to advance the MongoDB audit log head", err)
+			return err
+		}
+		if advanced {
+			break
+		}
+		if attempt == maxAuditAppendAttempts {
+			return errors.New("audit log append kept racing other appends")
+		}
+	}
+
+	_, err := r.collection.InsertOne(ctx, auditDocument{
+		ID:            entry.ID,
+		Sequence:      entry.Sequence,
+		ChainSequence: entry.ChainSequence,
+		Actor:         entry.Actor,
+		Action:        entry.Action,
+		ResourceType:  entry.ResourceType,
+		ResourceID:    entry.ResourceID,
+		Before:        string(entry.Before),
+		After:         string(entry.After),
+		RequestID:     entry.RequestID,
+		OccurredAt:    entry.OccurredAt,
+		PrevHash:      entry.PrevHash,
+		Hash:          entry.Hash,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to append to the MongoDB audit log", err)
+		return err
+	}
+	return nil
+}
+
+// nextSequence allocates a sequence outside the transaction of ctx: in it,
+// every append would conflict with the others on the counter until it ends
+func (r *MongoDBAuditRepository) nextSequence(ctx context.Context) (int64, error) {
+	var counter struct {
+		Sequence int64 `bson:"sequence"`
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := r.counters.FindOneAndUpdate(mongo.NewSessionContext(ctx, nil), bson.M{"_id": auditSequence},
+		bson.M{"$inc": bson.M{"sequence": int64(1)}}, opts).Decode(&counter)
+	return counter.Sequence, err
+}
+
+// advanceHead seals the entry, with the sequence, after the head of its
+// chain and points the head at it. It reports false when another append
+// moved the head first or the sequence was allocated before the head's.
+func (r *MongoDBAuditRepository) advanceHead(ctx context.Context, entry *dto.AuditEntryDTO, sequence int64) (bool, error) {
+	var head struct {
+		ChainSequence int64  `bson:"chain_sequence"`
+		Sequence      int64  `bson:"sequence"`
+		Hash          string `bson:"hash"`
+	}
+	headID := auditSequence + "|" + entry.Chain()
+	err := r.counters.FindOne(ctx, bson.M{"_id": headID}).Decode(&head)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return false, err
+	}
+
+	entry.Sequence = sequence
+	if err == mongo.ErrNoDocuments {
+		entry.Seal(nil)
+		_, err := r.counters.InsertOne(ctx, bson.M{
+			"_id": headID, "chain_sequence": entry.ChainSequence, "sequence": entry.Sequence, "hash": entry.Hash})
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	// The chain's entries must be in sequence order
+	if sequence <= head.Sequence {
+		return false, nil
+	}
+	entry.Seal(&dto.AuditEntryDTO{ChainSequence: head.ChainSequence, Hash: head.Hash})
+	result, err := r.counters.UpdateOne(ctx,
+		bson.M{"_id": headID, "chain_sequence": head.ChainSequence},
+		bson.M{"$set": bson.M{"chain_sequence": entry.ChainSequence, "sequence": entry.Sequence, "hash": entry.Hash}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *MongoDBAuditRepository) Find(ctx context.Context, query dto.AuditQuery) ([]*dto.AuditEntryDTO, error) {
+	filter := bson.M{}
+	if query.Actor != "" {
+		filter["actor"] = query.Actor
+	}
+	if query.Action != "" {
+		filter["action"] = query.Action
+	}
+	if query.ResourceType != "" {
+		filter["resource_type"] = query.ResourceType
+	}
+	if query.ResourceID != "" {
+		filter["resource_id"] = query.ResourceID
+	}
+	occurredAt := bson.M{}
+	if !query.From.IsZero() {
+		occurredAt["$gte"] = query.From
+	}
+	if !query.To.IsZero() {
+		occurredAt["$lt"] = query.To
+	}
+	if len(occurredAt) > 0 {
+		filter["occurred_at"] = occurredAt
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "sequence", Value: -1}})
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit))
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to query the MongoDB audit log", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*dto.AuditEntryDTO
+	for cursor.Next(ctx) {
+		var document auditDocument
+		if err := cursor.Decode(&document); err != nil {
+			logger.ErrorContext(ctx, "Failed to decode audit log entry", err)
+			return nil, err
+		}
+		entries = append(entries, document.entry())
+	}
+	return entries, cursor.Err()
+}
+
+func (r *MongoDBAuditRepository) Walk(ctx context.Context, fn func(*dto.AuditEntryDTO) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "sequence", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to read the MongoDB audit log", err)
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var document auditDocument
+		if err := cursor.Decode(&document); err != nil {
+			logger.ErrorContext(ctx, "Failed to decode audit log entry", err)
+			return err
+		}
+		if err := fn(document.entry()); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBHoldRepository implements HoldRepository for MongoDB
+type MongoDBHoldRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *MongoDBHoldRepository) Create(ctx context.Context, hold *dto.HoldDTO) error {
+	if hold.ID == "" {
+		hold.ID = primitive.NewObjectID().Hex()
+	}
+
+	now := time.Now()
+	hold.CreatedAt = now
+	hold.UpdatedAt = now
+
+	_, err := r.collection.InsertOne(ctx, hold)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create hold in MongoDB", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "Hold created in MongoDB", map[string]interface{}{
+		"hold_id":    hold.ID,
+		"account_id": hold.AccountID,
+		"amount":     hold.Amount,
+	})
+	return nil
+}
+
+func (r *MongoDBHoldRepository) GetByID(ctx context.Context, id string) (*dto.HoldDTO, error) {
+	var hold dto.HoldDTO
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&hold)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // Hold not found
+		}
+		logger.ErrorContext(ctx, "Failed to get hold from MongoDB", err)
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func (r *MongoDBHoldRepository) GetByAccountID(ctx context.Context, accountID string) ([]*dto.HoldDTO, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"account_id": accountID}, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get holds from MongoDB", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var holds []*dto.HoldDTO
+	if err := cursor.All(ctx, &holds); err != nil {
+		logger.ErrorContext(ctx, "Failed to decode holds from MongoDB", err)
+		return nil, err
+	}
+	return holds, nil
+}
+
+func (r *MongoDBHoldRepository) Close(ctx context.Context, hold *dto.HoldDTO) (bool, error) {
+	hold.UpdatedAt = time.Now()
+	update := bson.M{"$set": bson.M{
+		"status":          hold.Status,
+		"captured_amount": hold.CapturedAmount,
+		"transaction_id":  hold.TransactionID,
+		"updated_at":      hold.UpdatedAt,
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": hold.ID, "status": dto.HoldActive}, update)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to close hold in MongoDB", err)
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *MongoDBHoldRepository) ActiveTotal(ctx context.Context, accountID string, now time.Time) (float64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"account_id": accountID,
+			"status":     dto.HoldActive,
+			"expires_at": bson.M{"$gt": now},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$amount"}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to sum active holds in MongoDB", err)
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result struct {
+		Total float64 `bson:"total"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			logger.ErrorContext(ctx, "Failed to decode active hold total from MongoDB", err)
+			return 0, err
+		}
+	}
+	return result.Total, cursor.Err()
+}
+
+func (r *MongoDBHoldRepository) ExpireDue(ctx context.Context, now time.Time) (int64, error) {
+	filter := bson.M{"status": dto.HoldActive, "expires_at": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"status": dto.HoldExpired, "updated_at": time.Now()}}
+
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to expire holds in MongoDB", err)
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// outboxCounter is the document in the counters collection holding the
+// last outbox sequence
+const outboxCounter = "outbox"
+
+// MongoDBOutboxRepository implements OutboxRepository for MongoDB. Sequences
+// come from a counter document: a transaction incrementing it conflicts with
+// every other appending transaction until it ends, so events become visible
+// in sequence order.
+type MongoDBOutboxRepository struct {
+	collection *mongo.Collection
+	counters   *mongo.Collection
+	offsets    *mongo.Collection
+}
+
+// outboxDocument stores the payload as a JSON string, as written by the producer
+type outboxDocument struct {
+	ID         string    `bson:"_id"`
+	Sequence   int64     `bson:"sequence"`
+	Type       string    `bson:"type"`
+	AccountID  string    `bson:"account_id"`
+	Payload    string    `bson:"payload"`
+	RequestID  string    `bson:"request_id,omitempty"`
+	OccurredAt time.Time `bson:"occurred_at"`
+}
+
+func (d *outboxDocument) event() *dto.EventDTO {
+	return &dto.EventDTO{
+		ID:         d.ID,
+		Sequence:   d.Sequence,
+		Type:       d.Type,
+		AccountID:  d.AccountID,
+		Payload:    []byte(d.Payload),
+		RequestID:  d.RequestID,
+		OccurredAt: d.OccurredAt,
+	}
+}
+
+func (r *MongoDBOutboxRepository) Append(ctx context.Context, event *dto.EventDTO) error {
+	var counter struct {
+		Sequence int64 `bson:"sequence"`
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := r.counters.FindOneAndUpdate(ctx, bson.M{"_id": outboxCounter},
+		bson.M{"$inc": bson.M{"sequence": int64(1)}}, opts).Decode(&counter)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to allocate outbox sequence in MongoDB", err)
+		return err
+	}
+
+	if event.ID == "" {
+		event.ID = primitive.NewObjectID().Hex()
+	}
+	event.Sequence = counter.Sequence
+
+	_, err = r.collection.InsertOne(ctx, outboxDocument{
+		ID:         event.ID,
+		Sequence:   event.Sequence,
+		Type:       event.Type,
+		AccountID:  event.AccountID,
+		Payload:    string(event.Payload),
+		RequestID:  event.RequestID,
+		OccurredAt: event.OccurredAt,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to append event to the MongoDB outbox", err)
+		return err
+	}
+	return nil
+}
+
+func (r *MongoDBOutboxRepository) After(ctx context.Context, after int64, limit int) ([]*dto.EventDTO, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "sequence", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, bson.M{"sequence": bson.M{"$gt": after}}, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to read the MongoDB outbox", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []*dto.EventDTO
+	for cursor.Next(ctx) {
+		var document outboxDocument
+		if err := cursor.Decode(&document); err != nil {
+			logger.ErrorContext(ctx, "Failed to decode outbox event", err)
+			return nil, err
+		}
+		events = append(events, document.event())
+	}
+	return events, cursor.Err()
+}
+
+func (r *MongoDBOutboxRepository) Offset(ctx context.Context, consumer string) (int64, error) {
+	var offset struct {
+		Sequence int64 `bson:"sequence"`
+	}
+	err := r.offsets.FindOne(ctx, bson.M{"_id": consumer}).Decode(&offset)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil // Nothing processed yet
+		}
+		logger.ErrorContext(ctx, "Failed to get outbox offset from MongoDB", err)
+		return 0, err
+	}
+	return offset.Sequence, nil
+}
+
+func (r *MongoDBOutboxRepository) SetOffset(ctx context.Context, consumer string, sequence int64) error {
+	_, err := r.offsets.UpdateOne(ctx, bson.M{"_id": consumer},
+		bson.M{"$set": bson.M{"sequence": sequence, "updated_at": time.Now()}},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to set outbox offset in MongoDB", err)
+		return err
+	}
+	return nil
+}
+
+func (r *MongoDBOutboxRepository) Last(ctx context.Context) (int64, error) {
+	var last outboxDocument
+	opts := options.FindOne().SetSort(bson.D{{Key: "sequence", Value: -1}})
+	err := r.collection.FindOne(ctx, bson.M{}, opts).Decode(&last)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		logger.ErrorContext(ctx, "Failed to get the last outbox event from MongoDB", err)
+		return 0, err
+	}
+	return last.Sequence, nil
+}
+
+// Watch follows the outbox with a change stream. The stream is opened
+// before the events already after the sequence are read, so none committed
+// in between is missed; events are committed in sequence order, which the
+// change stream preserves.
+func (r *MongoDBOutboxRepository) Watch(ctx context.Context, after int64, fn func(*dto.EventDTO) error) error {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"operationType": "insert"}}}}
+	stream, err := r.collection.Watch(ctx, pipeline)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to open the MongoDB outbox change stream", err)
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	for {
+		events, err := r.After(ctx, after, outboxWatchBatch)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return err
+			}
+			after = event.Sequence
+		}
+		if len(events) < outboxWatchBatch {
+			break
+		}
+	}
+
+	for stream.Next(ctx) {
+		var change struct {
+			FullDocument outboxDocument `bson:"fullDocument"`
+		}
+		if err := stream.Decode(&change); err != nil {
+			logger.ErrorContext(ctx, "Failed to decode outbox change", err)
+			return err
+		}
+		if change.FullDocument.Sequence <= after {
+			continue // Already read before the stream caught up
+		}
+		if err := fn(change.FullDocument.event()); err != nil {
+			return err
+		}
+		after = change.FullDocument.Sequence
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return stream.Err()
+}
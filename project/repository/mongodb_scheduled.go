@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBScheduledTransactionRepository implements ScheduledTransactionRepository for MongoDB
+type MongoDBScheduledTransactionRepository struct {
+	collection *mongo.Collection
+	runs       *mongo.Collection
+}
+
+func (r *MongoDBScheduledTransactionRepository) Create(ctx context.Context, scheduled *dto.ScheduledTransactionDTO) error {
+	if scheduled.ID == "" {
+		scheduled.ID = primitive.NewObjectID().Hex()
+	}
+
+	now := time.Now()
+	scheduled.CreatedAt = now
+	scheduled.UpdatedAt = now
+
+	_, err := r.collection.InsertOne(ctx, scheduled)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create scheduled transaction in MongoDB", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "Scheduled transaction created in MongoDB", map[string]interface{}{
+		"scheduled_transaction_id": scheduled.ID,
+		"account_id":               scheduled.AccountID,
+		"schedule":                 scheduled.Schedule,
+	})
+	return nil
+}
+
+func (r *MongoDBScheduledTransactionRepository) GetByID(ctx context.Context, id string) (*dto.ScheduledTransactionDTO, error) {
+	var scheduled dto.ScheduledTransactionDTO
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&scheduled)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // Scheduled transaction not found
+		}
+		logger.ErrorContext(ctx, "Failed to get scheduled transaction from MongoDB", err)
+		return nil, err
+	}
+	return &scheduled, nil
+}
+
+func (r *MongoDBScheduledTransactionRepository) GetByAccountID(ctx context.Context, accountID string) ([]*dto.ScheduledTransactionDTO, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	return r.find(ctx, bson.M{"account_id": accountID}, opts)
+}
+
+func (r *MongoDBScheduledTransactionRepository) Update(ctx context.Context, scheduled *dto.ScheduledTransactionDTO) error {
+	scheduled.UpdatedAt = time.Now()
+	update := bson.M{"$set": bson.M{
+		"amount":      scheduled.Amount,
+		"active":      scheduled.Active,
+		"end_at":      scheduled.EndAt,
+		"next_run_at": scheduled.NextRunAt,
+		"updated_at":  scheduled.UpdatedAt,
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": scheduled.ID}, update)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to update scheduled transaction in MongoDB", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "Scheduled transaction updated in MongoDB", map[string]interface{}{
+		"scheduled_transaction_id": scheduled.ID,
+	})
+	return nil
+}
+
+func (r *MongoDBScheduledTransactionRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to delete scheduled transaction from MongoDB", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "Scheduled transaction deleted from MongoDB", map[string]interface{}{
+		"scheduled_transaction_id": id,
+	})
+	return nil
+}
+
+func (r *MongoDBScheduledTransactionRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]*dto.ScheduledTransactionDTO, error) {
+	filter := bson.M{"active": true, "next_run_at": bson.M{"$lte": now}}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "next_run_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	return r.find(ctx, filter, opts)
+}
+
+func (r *MongoDBScheduledTransactionRepository) Advance(ctx context.Context, id string, due time.Time, next *time.Time) (bool, error) {
+	filter := bson.M{"_id": id, "active": true, "next_run_at": due}
+	update := bson.M{"$set": bson.M{"next_run_at": next, "updated_at": time.Now()}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to advance scheduled transaction in MongoDB", err)
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *MongoDBScheduledTransactionRepository) RecordRun(ctx context.Context, run *dto.ScheduledTransactionRunDTO) error {
+	if run.ID == "" {
+		run.ID = primitive.NewObjectID().Hex()
+	}
+
+	if _, err := r.runs.InsertOne(ctx, run); err != nil {
+		logger.ErrorContext(ctx, "Failed to record scheduled transaction run in MongoDB", err)
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"last_run_at": run.ExecutedAt, "last_run_status": run.Status}}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": run.ScheduledTransactionID}, update); err != nil {
+		logger.ErrorContext(ctx, "Failed to update last run of scheduled transaction in MongoDB", err)
+		return err
+	}
+	return nil
+}
+
+func (r *MongoDBScheduledTransactionRepository) GetRuns(ctx context.Context, scheduledID string) ([]*dto.ScheduledTransactionRunDTO, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "executed_at", Value: -1}, {Key: "due_at", Value: -1}})
+	cursor, err := r.runs.Find(ctx, bson.M{"scheduled_transaction_id": scheduledID}, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get scheduled transaction runs from MongoDB", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var runs []*dto.ScheduledTransactionRunDTO
+	if err := cursor.All(ctx, &runs); err != nil {
+		logger.ErrorContext(ctx, "Failed to decode scheduled transaction runs from MongoDB", err)
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (r *MongoDBScheduledTransactionRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*dto.ScheduledTransactionDTO, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get scheduled transactions from MongoDB", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var scheduled []*dto.ScheduledTransactionDTO
+	if err := cursor.All(ctx, &scheduled); err != nil {
+		logger.ErrorContext(ctx, "Failed to decode scheduled transactions from MongoDB", err)
+		return nil, err
+	}
+	return scheduled, nil
+}
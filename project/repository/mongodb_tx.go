@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/gcalvocr/go-testing/logger"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoDBTxManager implements TxManager with multi-document transactions.
+// MongoDB only supports transactions on replica sets and sharded clusters.
+type MongoDBTxManager struct {
+	client *mongo.Client
+}
+
+func (m *MongoDBTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := m.client.StartSession()
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to start MongoDB session", err)
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	// The driver retries fn on transient transaction errors, so fn must be
+	// safe to run again from the start
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBWebhookRepository implements WebhookRepository for MongoDB
+type MongoDBWebhookRepository struct {
+	subscriptions *mongo.Collection
+	deliveries    *mongo.Collection
+}
+
+func (r *MongoDBWebhookRepository) CreateSubscription(ctx context.Context, subscription *dto.WebhookSubscriptionDTO) error {
+	if subscription.ID == "" {
+		subscription.ID = primitive.NewObjectID().Hex()
+	}
+
+	now := time.Now()
+	subscription.CreatedAt = now
+	subscription.UpdatedAt = now
+
+	if _, err := r.subscriptions.InsertOne(ctx, subscription); err != nil {
+		logger.ErrorContext(ctx, "Failed to create webhook subscription in MongoDB", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "Webhook subscription created in MongoDB", map[string]interface{}{
+		"subscription_id": subscription.ID,
+		"url":             subscription.URL,
+	})
+	return nil
+}
+
+func (r *MongoDBWebhookRepository) GetSubscription(ctx context.Context, id string) (*dto.WebhookSubscriptionDTO, error) {
+	var subscription dto.WebhookSubscriptionDTO
+	err := r.subscriptions.FindOne(ctx, bson.M{"_id": id}).Decode(&subscription)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // Subscription not found
+		}
+		logger.ErrorContext(ctx, "Failed to get webhook subscription from MongoDB", err)
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *MongoDBWebhookRepository) GetSubscriptions(ctx context.Context) ([]*dto.WebhookSubscriptionDTO, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.subscriptions.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get webhook subscriptions from MongoDB", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var subscriptions []*dto.WebhookSubscriptionDTO
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		logger.ErrorContext(ctx, "Failed to decode webhook subscriptions from MongoDB", err)
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *MongoDBWebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	// The deliveries are kept for the delivery log
+	if _, err := r.subscriptions.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		logger.ErrorContext(ctx, "Failed to delete webhook subscription from MongoDB", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "Webhook subscription deleted from MongoDB", map[string]interface{}{
+		"subscription_id": id,
+	})
+	return nil
+}
+
+func (r *MongoDBWebhookRepository) CreateDelivery(ctx context.Context, delivery *dto.WebhookDeliveryDTO) error {
+	delivery.ID = deliveryID(delivery.SubscriptionID, delivery.EventID)
+
+	now := time.Now()
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+
+	_, err := r.deliveries.InsertOne(ctx, delivery)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		logger.ErrorContext(ctx, "Failed to create webhook delivery in MongoDB", err)
+		return err
+	}
+	return nil
+}
+
+func (r *MongoDBWebhookRepository) GetDelivery(ctx context.Context, id string) (*dto.WebhookDeliveryDTO, error) {
+	var delivery dto.WebhookDeliveryDTO
+	err := r.deliveries.FindOne(ctx, bson.M{"_id": id}).Decode(&delivery)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // Delivery not found
+		}
+		logger.ErrorContext(ctx, "Failed to get webhook delivery from MongoDB", err)
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *MongoDBWebhookRepository) GetDeliveries(ctx context.Context, subscriptionID, status string) ([]*dto.WebhookDeliveryDTO, error) {
+	filter := bson.M{"subscription_id": subscriptionID}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: 1}})
+	return r.findDeliveries(ctx, filter, opts)
+}
+
+func (r *MongoDBWebhookRepository) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*dto.WebhookDeliveryDTO, error) {
+	filter := bson.M{"status": dto.DeliveryPending, "next_attempt_at": bson.M{"$lte": now}}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	return r.findDeliveries(ctx, filter, opts)
+}
+
+func (r *MongoDBWebhookRepository) ClaimDelivery(ctx context.Context, id string, due, until time.Time) (bool, error) {
+	filter := bson.M{"_id": id, "status": dto.DeliveryPending, "next_attempt_at": due}
+	update := bson.M{"$set": bson.M{"next_attempt_at": until, "updated_at": time.Now()}}
+
+	result, err := r.deliveries.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to claim webhook delivery in MongoDB", err)
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *MongoDBWebhookRepository) UpdateDelivery(ctx context.Context, delivery *dto.WebhookDeliveryDTO) error {
+	delivery.UpdatedAt = time.Now()
+	update := bson.M{"$set": bson.M{
+		"status":           delivery.Status,
+		"attempts":         delivery.Attempts,
+		"next_attempt_at":  delivery.NextAttemptAt,
+		"last_attempt_at":  delivery.LastAttemptAt,
+		"last_status_code": delivery.LastStatusCode,
+		"last_error":       delivery.LastError,
+		"delivered_at":     delivery.DeliveredAt,
+		"updated_at":       delivery.UpdatedAt,
+	}}
+
+	if _, err := r.deliveries.UpdateOne(ctx, bson.M{"_id": delivery.ID}, update); err != nil {
+		logger.ErrorContext(ctx, "Failed to update webhook delivery in MongoDB", err)
+		return err
+	}
+	return nil
+}
+
+func (r *MongoDBWebhookRepository) findDeliveries(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*dto.WebhookDeliveryDTO, error) {
+	cursor, err := r.deliveries.Find(ctx, filter, opts)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get webhook deliveries from MongoDB", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var deliveries []*dto.WebhookDeliveryDTO
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		logger.ErrorContext(ctx, "Failed to decode webhook deliveries from MongoDB", err)
+		return nil, err
+	}
+	return deliveries, nil
+}
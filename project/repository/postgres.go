@@ -40,9 +40,19 @@ func newPostgreSQLFactory(connectionString string) (*RepositoryFactory, error) {
 		return nil, fmt.Errorf("failed to create tables: %w", err)
 	}
 
+	outbox := &PostgreSQLOutboxRepository{db: db, connString: connectionString}
 	return &RepositoryFactory{
 		AccountRepo:     &PostgreSQLAccountRepository{db: db},
 		TransactionRepo: &PostgreSQLTransactionRepository{db: db},
+		APIKeyRepo:      &PostgreSQLAPIKeyRepository{db: db},
+		ScheduledRepo:   &PostgreSQLScheduledTransactionRepository{db: db},
+		AccrualRepo:     &PostgreSQLAccrualRepository{db: db},
+		HoldRepo:        &PostgreSQLHoldRepository{db: db},
+		OutboxRepo:      outbox,
+		OutboxFeed:      outbox,
+		WebhookRepo:     &PostgreSQLWebhookRepository{db: db},
+		AuditRepo:       &PostgreSQLAuditRepository{db: db},
+		TxManager:       &PostgreSQLTxManager{db: db},
 	}, nil
 }
 
@@ -54,10 +64,31 @@ func createPostgreSQLTables(db *sql.DB) error {
 		name VARCHAR(255) NOT NULL,
 		balance DECIMAL(15,2) NOT NULL DEFAULT 0,
 		currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+		owner_id VARCHAR(255),
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);`
 
+	// Databases created before accounts had owners are migrated in place
+	accountOwnerColumn := `
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS owner_id VARCHAR(255);
+	CREATE INDEX IF NOT EXISTS idx_accounts_owner_id ON accounts (owner_id);`
+
+	// Posting limits, evaluated by the policy package
+	accountLimitColumns := `
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS overdraft_limit DECIMAL(15,2) NOT NULL DEFAULT 0;
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS minimum_balance DECIMAL(15,2) NULL;
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS daily_withdrawal_limit DECIMAL(15,2) NULL;
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS max_transaction_amount DECIMAL(15,2) NULL;`
+
+	accountProductColumn := `
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS product VARCHAR(50) NOT NULL DEFAULT 'checking';`
+
+	// Account lists are paged in creation order
+	accountPageIndexes := `
+	CREATE INDEX IF NOT EXISTS idx_accounts_created ON accounts (created_at, id);
+	CREATE INDEX IF NOT EXISTS idx_accounts_owner_created ON accounts (owner_id, created_at, id);`
+
 	transactionTable := `
 	CREATE TABLE IF NOT EXISTS transactions (
 		id VARCHAR(36) PRIMARY KEY,
@@ -66,40 +97,286 @@ func createPostgreSQLTables(db *sql.DB) error {
 		type VARCHAR(50) NOT NULL,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_transactions_account_created ON transactions (account_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_transactions_account_created_id ON transactions (account_id, created_at, id);`
+
+	apiKeyTable := `
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id VARCHAR(36) PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		key_hash VARCHAR(64) NOT NULL UNIQUE,
+		principal_id VARCHAR(255) NOT NULL,
+		roles TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		revoked_at TIMESTAMP NULL
+	);
+	ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS roles TEXT NOT NULL DEFAULT '';`
+
+	// Schedule times are compared across runs, so they keep their time zone
+	scheduledTables := `
+	CREATE TABLE IF NOT EXISTS scheduled_transactions (
+		id VARCHAR(36) PRIMARY KEY,
+		account_id VARCHAR(36) REFERENCES accounts(id) ON DELETE CASCADE,
+		amount DECIMAL(15,2) NOT NULL,
+		type VARCHAR(50) NOT NULL,
+		schedule VARCHAR(20) NOT NULL,
+		start_at TIMESTAMPTZ NOT NULL,
+		cron VARCHAR(255) NOT NULL DEFAULT '',
+		end_at TIMESTAMPTZ NULL,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		next_run_at TIMESTAMPTZ NULL,
+		last_run_at TIMESTAMPTZ NULL,
+		last_run_status VARCHAR(20) NOT NULL DEFAULT '',
+		created_by VARCHAR(255) NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_scheduled_transactions_due ON scheduled_transactions (next_run_at) WHERE active;
+	CREATE TABLE IF NOT EXISTS scheduled_transaction_runs (
+		id VARCHAR(36) PRIMARY KEY,
+		scheduled_transaction_id VARCHAR(36) NOT NULL,
+		account_id VARCHAR(36) NOT NULL,
+		due_at TIMESTAMPTZ NOT NULL,
+		executed_at TIMESTAMPTZ NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		transaction_id VARCHAR(36) NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS idx_scheduled_transaction_runs_scheduled ON scheduled_transaction_runs (scheduled_transaction_id, executed_at);`
+
+	accrualTable := `
+	CREATE TABLE IF NOT EXISTS accruals (
+		id VARCHAR(80) PRIMARY KEY,
+		account_id VARCHAR(36) NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+		period CHAR(7) NOT NULL,
+		product VARCHAR(50) NOT NULL,
+		days INTEGER NOT NULL,
+		average_balance DECIMAL(15,2) NOT NULL,
+		interest DECIMAL(15,2) NOT NULL,
+		fee DECIMAL(15,2) NOT NULL,
+		fee_waived BOOLEAN NOT NULL DEFAULT FALSE,
+		interest_transaction_id VARCHAR(36) NOT NULL DEFAULT '',
+		fee_transaction_id VARCHAR(36) NOT NULL DEFAULT '',
+		posted_at TIMESTAMPTZ NOT NULL
+	);`
+
+	holdTable := `
+	CREATE TABLE IF NOT EXISTS holds (
+		id VARCHAR(36) PRIMARY KEY,
+		account_id VARCHAR(36) NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+		amount DECIMAL(15,2) NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL,
+		captured_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+		transaction_id VARCHAR(36) NOT NULL DEFAULT '',
+		created_by VARCHAR(255) NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_holds_account ON holds (account_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_holds_active_expiry ON holds (expires_at) WHERE status = 'active';`
+
+	outboxTables := `
+	CREATE TABLE IF NOT EXISTS outbox (
+		sequence BIGSERIAL PRIMARY KEY,
+		id VARCHAR(36) UNIQUE NOT NULL,
+		type VARCHAR(100) NOT NULL,
+		account_id VARCHAR(36) NOT NULL,
+		payload JSONB NOT NULL,
+		request_id VARCHAR(255) NOT NULL DEFAULT '',
+		occurred_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS outbox_offsets (
+		consumer VARCHAR(255) PRIMARY KEY,
+		sequence BIGINT NOT NULL,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
 	);`
 
+	webhookTables := `
+	CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+		id VARCHAR(36) PRIMARY KEY,
+		url TEXT NOT NULL,
+		event_types TEXT[] NOT NULL,
+		account_id VARCHAR(36) NOT NULL DEFAULT '',
+		secret VARCHAR(255) NOT NULL,
+		created_by VARCHAR(255) NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id VARCHAR(80) PRIMARY KEY,
+		subscription_id VARCHAR(36) NOT NULL,
+		event_id VARCHAR(36) NOT NULL,
+		event_type VARCHAR(100) NOT NULL,
+		body TEXT NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMPTZ,
+		last_attempt_at TIMESTAMPTZ,
+		last_status_code INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		delivered_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries (subscription_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';`
+
+	// The audit log is append-only; the trigger stops accidental rewrites,
+	// the hash chain detects deliberate ones
+	auditTable := `
+	CREATE TABLE IF NOT EXISTS audit_log (
+		sequence BIGSERIAL PRIMARY KEY,
+		chain_sequence BIGINT NOT NULL,
+		id VARCHAR(36) UNIQUE NOT NULL,
+		actor VARCHAR(255) NOT NULL,
+		action VARCHAR(100) NOT NULL,
+		resource_type VARCHAR(50) NOT NULL,
+		resource_id VARCHAR(80) NOT NULL,
+		before_state TEXT NOT NULL DEFAULT '',
+		after_state TEXT NOT NULL DEFAULT '',
+		request_id VARCHAR(255) NOT NULL DEFAULT '',
+		occurred_at TIMESTAMPTZ NOT NULL,
+		prev_hash VARCHAR(64) NOT NULL,
+		hash VARCHAR(64) NOT NULL,
+		UNIQUE (resource_type, resource_id, chain_sequence)
+	);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_resource ON audit_log (resource_type, resource_id, sequence);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor, sequence);
+	CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'audit_log is append-only';
+	END;
+	$$ LANGUAGE plpgsql;
+	DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'audit_log_append_only') THEN
+			CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+			FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+		END IF;
+	END
+	$$;`
+
 	if _, err := db.Exec(accountTable); err != nil {
 		return err
 	}
 
+	if _, err := db.Exec(accountOwnerColumn); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(accountLimitColumns); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(accountProductColumn); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(accountPageIndexes); err != nil {
+		return err
+	}
+
 	if _, err := db.Exec(transactionTable); err != nil {
 		return err
 	}
 
+	if _, err := db.Exec(apiKeyTable); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(scheduledTables); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(accrualTable); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(holdTable); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(outboxTables); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(webhookTables); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(auditTable); err != nil {
+		return err
+	}
+
 	logger.Info("PostgreSQL tables created successfully", nil)
 	return nil
 }
 
+const accountColumns = `id, name, balance, currency, owner_id, created_at, updated_at,
+	overdraft_limit, minimum_balance, daily_withdrawal_limit, max_transaction_amount, product`
+
+// accountSelectColumns reads accountColumns, accounts created before owners
+// were introduced have no owner
+const accountSelectColumns = `id, name, balance, currency, COALESCE(owner_id, ''), created_at, updated_at,
+	overdraft_limit, minimum_balance, daily_withdrawal_limit, max_transaction_amount, product`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAccount(row rowScanner) (*dto.AccountDTO, error) {
+	var account dto.AccountDTO
+	var minimumBalance, dailyWithdrawalLimit, maxTransactionAmount sql.NullFloat64
+	err := row.Scan(
+		&account.ID, &account.Name, &account.Balance,
+		&account.Currency, &account.OwnerID, &account.CreatedAt, &account.UpdatedAt,
+		&account.Limits.OverdraftLimit, &minimumBalance, &dailyWithdrawalLimit, &maxTransactionAmount,
+		&account.Product)
+	if err != nil {
+		return nil, err
+	}
+	account.Limits.MinimumBalance = nullFloatPtr(minimumBalance)
+	account.Limits.DailyWithdrawalLimit = nullFloatPtr(dailyWithdrawalLimit)
+	account.Limits.MaxTransactionAmount = nullFloatPtr(maxTransactionAmount)
+	return &account, nil
+}
+
+func nullFloatPtr(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return &f.Float64
+}
+
 // Account repository methods
 func (r *PostgreSQLAccountRepository) Create(ctx context.Context, account *dto.AccountDTO) error {
 	query := `
-		INSERT INTO accounts (id, name, balance, currency, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)`
+		INSERT INTO accounts (` + accountColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	if account.ID == "" {
+		account.ID = newID()
+	}
 
 	now := time.Now()
 	account.CreatedAt = now
 	account.UpdatedAt = now
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
 		account.ID, account.Name, account.Balance, account.Currency,
-		account.CreatedAt, account.UpdatedAt)
+		account.OwnerID, account.CreatedAt, account.UpdatedAt,
+		account.Limits.OverdraftLimit, account.Limits.MinimumBalance,
+		account.Limits.DailyWithdrawalLimit, account.Limits.MaxTransactionAmount, account.Product)
 
 	if err != nil {
-		logger.Error("Failed to create account in PostgreSQL", err)
+		logger.ErrorContext(ctx, "Failed to create account in PostgreSQL", err)
 		return err
 	}
 
-	logger.Info("Account created in PostgreSQL", map[string]interface{}{
+	logger.InfoContext(ctx, "Account created in PostgreSQL", map[string]interface{}{
 		"account_id": account.ID,
 		"name":       account.Name,
 	})
@@ -108,51 +385,113 @@ func (r *PostgreSQLAccountRepository) Create(ctx context.Context, account *dto.A
 
 func (r *PostgreSQLAccountRepository) GetByID(ctx context.Context, id string) (*dto.AccountDTO, error) {
 	query := `
-		SELECT id, name, balance, currency, created_at, updated_at
+		SELECT ` + accountSelectColumns + `
 		FROM accounts WHERE id = $1`
 
-	var account dto.AccountDTO
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&account.ID, &account.Name, &account.Balance,
-		&account.Currency, &account.CreatedAt, &account.UpdatedAt)
+	// Inside a transaction the row stays locked until commit, so concurrent
+	// balance updates are serialized
+	if inTransaction(ctx) {
+		query += ` FOR UPDATE`
+	}
+
+	account, err := scanAccount(executor(ctx, r.db).QueryRowContext(ctx, query, id))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Account not found
 		}
-		logger.Error("Failed to get account from PostgreSQL", err)
+		logger.ErrorContext(ctx, "Failed to get account from PostgreSQL", err)
 		return nil, err
 	}
 
-	return &account, nil
+	return account, nil
 }
 
 func (r *PostgreSQLAccountRepository) GetAll(ctx context.Context) ([]*dto.AccountDTO, error) {
-	query := `SELECT id, name, balance, currency, created_at, updated_at FROM accounts`
+	query := `SELECT ` + accountSelectColumns + ` FROM accounts`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
-		logger.Error("Failed to query accounts from PostgreSQL", err)
+		logger.ErrorContext(ctx, "Failed to query accounts from PostgreSQL", err)
 		return nil, err
 	}
 	defer rows.Close()
 
 	var accounts []*dto.AccountDTO
 	for rows.Next() {
-		var account dto.AccountDTO
-		err := rows.Scan(
-			&account.ID, &account.Name, &account.Balance,
-			&account.Currency, &account.CreatedAt, &account.UpdatedAt)
+		account, err := scanAccount(rows)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to scan account from PostgreSQL", err)
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
+
+func (r *PostgreSQLAccountRepository) GetByOwnerID(ctx context.Context, ownerID string) ([]*dto.AccountDTO, error) {
+	query := `
+		SELECT ` + accountSelectColumns + `
+		FROM accounts WHERE owner_id = $1`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, ownerID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to query accounts by owner from PostgreSQL", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []*dto.AccountDTO
+	for rows.Next() {
+		account, err := scanAccount(rows)
 		if err != nil {
-			logger.Error("Failed to scan account from PostgreSQL", err)
+			logger.ErrorContext(ctx, "Failed to scan account from PostgreSQL", err)
 			return nil, err
 		}
-		accounts = append(accounts, &account)
+		accounts = append(accounts, account)
 	}
 
 	return accounts, nil
 }
 
+func (r *PostgreSQLAccountRepository) GetPage(ctx context.Context, ownerID string, after *Cursor, limit int) ([]*dto.AccountDTO, error) {
+	query := `SELECT ` + accountSelectColumns + ` FROM accounts WHERE TRUE`
+	var args []interface{}
+	if ownerID != "" {
+		args = append(args, ownerID)
+		query += fmt.Sprintf(` AND owner_id = $%d`, len(args))
+	}
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		query += fmt.Sprintf(` AND (created_at, id) > ($%d, $%d)`, len(args)-1, len(args))
+	}
+	query += ` ORDER BY created_at, id`
+	if limit > 0 {
+		args = append(args, limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to query a page of accounts from PostgreSQL", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []*dto.AccountDTO
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to scan account from PostgreSQL", err)
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
 func (r *PostgreSQLAccountRepository) Update(ctx context.Context, id string, update *dto.UpdateAccountRequest) error {
 	query := `UPDATE accounts SET `
 	args := []interface{}{}
@@ -184,13 +523,13 @@ func (r *PostgreSQLAccountRepository) Update(ctx context.Context, id string, upd
 	query += fmt.Sprintf("updated_at = $%d WHERE id = $%d", argCount, argCount+1)
 	args = append(args, time.Now(), id)
 
-	_, err := r.db.ExecContext(ctx, query, args...)
+	_, err := executor(ctx, r.db).ExecContext(ctx, query, args...)
 	if err != nil {
-		logger.Error("Failed to update account in PostgreSQL", err)
+		logger.ErrorContext(ctx, "Failed to update account in PostgreSQL", err)
 		return err
 	}
 
-	logger.Info("Account updated in PostgreSQL", map[string]interface{}{
+	logger.InfoContext(ctx, "Account updated in PostgreSQL", map[string]interface{}{
 		"account_id": id,
 	})
 	return nil
@@ -199,14 +538,14 @@ func (r *PostgreSQLAccountRepository) Update(ctx context.Context, id string, upd
 func (r *PostgreSQLAccountRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM accounts WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
-		logger.Error("Failed to delete account from PostgreSQL", err)
+		logger.ErrorContext(ctx, "Failed to delete account from PostgreSQL", err)
 		return err
 	}
 
 	rowsAffected, _ := result.RowsAffected()
-	logger.Info("Account deleted from PostgreSQL", map[string]interface{}{
+	logger.InfoContext(ctx, "Account deleted from PostgreSQL", map[string]interface{}{
 		"account_id":    id,
 		"rows_affected": rowsAffected,
 	})
@@ -215,35 +554,53 @@ func (r *PostgreSQLAccountRepository) Delete(ctx context.Context, id string) err
 
 func (r *PostgreSQLAccountRepository) GetByName(ctx context.Context, name string) (*dto.AccountDTO, error) {
 	query := `
-		SELECT id, name, balance, currency, created_at, updated_at
+		SELECT ` + accountSelectColumns + `
 		FROM accounts WHERE name = $1`
 
-	var account dto.AccountDTO
-	err := r.db.QueryRowContext(ctx, query, name).Scan(
-		&account.ID, &account.Name, &account.Balance,
-		&account.Currency, &account.CreatedAt, &account.UpdatedAt)
+	account, err := scanAccount(executor(ctx, r.db).QueryRowContext(ctx, query, name))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Account not found
 		}
-		logger.Error("Failed to get account by name from PostgreSQL", err)
+		logger.ErrorContext(ctx, "Failed to get account by name from PostgreSQL", err)
 		return nil, err
 	}
 
-	return &account, nil
+	return account, nil
+}
+
+func (r *PostgreSQLAccountRepository) UpdateLimits(ctx context.Context, id string, limits dto.AccountLimits) error {
+	query := `
+		UPDATE accounts
+		SET overdraft_limit = $1, minimum_balance = $2, daily_withdrawal_limit = $3,
+			max_transaction_amount = $4, updated_at = $5
+		WHERE id = $6`
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
+		limits.OverdraftLimit, limits.MinimumBalance, limits.DailyWithdrawalLimit,
+		limits.MaxTransactionAmount, time.Now(), id)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to update account limits in PostgreSQL", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "Account limits updated in PostgreSQL", map[string]interface{}{
+		"account_id": id,
+	})
+	return nil
 }
 
 func (r *PostgreSQLAccountRepository) UpdateBalance(ctx context.Context, id string, newBalance float64) error {
 	query := `UPDATE accounts SET balance = $1, updated_at = $2 WHERE id = $3`
 
-	_, err := r.db.ExecContext(ctx, query, newBalance, time.Now(), id)
+	_, err := executor(ctx, r.db).ExecContext(ctx, query, newBalance, time.Now(), id)
 	if err != nil {
-		logger.Error("Failed to update account balance in PostgreSQL", err)
+		logger.ErrorContext(ctx, "Failed to update account balance in PostgreSQL", err)
 		return err
 	}
 
-	logger.Info("Account balance updated in PostgreSQL", map[string]interface{}{
+	logger.InfoContext(ctx, "Account balance updated in PostgreSQL", map[string]interface{}{
 		"account_id":  id,
 		"new_balance": newBalance,
 	})
@@ -256,20 +613,24 @@ func (r *PostgreSQLTransactionRepository) Create(ctx context.Context, transactio
 		INSERT INTO transactions (id, account_id, amount, type, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6)`
 
+	if transaction.ID == "" {
+		transaction.ID = newID()
+	}
+
 	now := time.Now()
 	transaction.CreatedAt = now
 	transaction.UpdatedAt = now
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
 		transaction.ID, transaction.AccountID, transaction.Amount,
 		transaction.Type, transaction.CreatedAt, transaction.UpdatedAt)
 
 	if err != nil {
-		logger.Error("Failed to create transaction in PostgreSQL", err)
+		logger.ErrorContext(ctx, "Failed to create transaction in PostgreSQL", err)
 		return err
 	}
 
-	logger.Info("Transaction created in PostgreSQL", map[string]interface{}{
+	logger.InfoContext(ctx, "Transaction created in PostgreSQL", map[string]interface{}{
 		"transaction_id": transaction.ID,
 		"account_id":     transaction.AccountID,
 		"amount":         transaction.Amount,
@@ -284,7 +645,7 @@ func (r *PostgreSQLTransactionRepository) GetByID(ctx context.Context, id string
 		FROM transactions WHERE id = $1`
 
 	var transaction dto.TransactionDTO
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&transaction.ID, &transaction.AccountID, &transaction.Amount,
 		&transaction.Type, &transaction.CreatedAt, &transaction.UpdatedAt)
 
@@ -292,7 +653,7 @@ func (r *PostgreSQLTransactionRepository) GetByID(ctx context.Context, id string
 		if err == sql.ErrNoRows {
 			return nil, nil // Transaction not found
 		}
-		logger.Error("Failed to get transaction from PostgreSQL", err)
+		logger.ErrorContext(ctx, "Failed to get transaction from PostgreSQL", err)
 		return nil, err
 	}
 
@@ -304,9 +665,9 @@ func (r *PostgreSQLTransactionRepository) GetByAccountID(ctx context.Context, ac
 		SELECT id, account_id, amount, type, created_at, updated_at
 		FROM transactions WHERE account_id = $1 ORDER BY created_at DESC`
 
-	rows, err := r.db.QueryContext(ctx, query, accountID)
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, accountID)
 	if err != nil {
-		logger.Error("Failed to query transactions by account ID from PostgreSQL", err)
+		logger.ErrorContext(ctx, "Failed to query transactions by account ID from PostgreSQL", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -318,7 +679,7 @@ func (r *PostgreSQLTransactionRepository) GetByAccountID(ctx context.Context, ac
 			&transaction.ID, &transaction.AccountID, &transaction.Amount,
 			&transaction.Type, &transaction.CreatedAt, &transaction.UpdatedAt)
 		if err != nil {
-			logger.Error("Failed to scan transaction from PostgreSQL", err)
+			logger.ErrorContext(ctx, "Failed to scan transaction from PostgreSQL", err)
 			return nil, err
 		}
 		transactions = append(transactions, &transaction)
@@ -327,12 +688,50 @@ func (r *PostgreSQLTransactionRepository) GetByAccountID(ctx context.Context, ac
 	return transactions, nil
 }
 
+func (r *PostgreSQLTransactionRepository) GetPageByAccountID(ctx context.Context, accountID string, after *Cursor, limit int) ([]*dto.TransactionDTO, error) {
+	query := `
+		SELECT id, account_id, amount, type, created_at, updated_at
+		FROM transactions WHERE account_id = $1`
+	args := []interface{}{accountID}
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		query += ` AND (created_at, id) < ($2, $3)`
+	}
+	query += ` ORDER BY created_at DESC, id DESC`
+	if limit > 0 {
+		args = append(args, limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to query a page of transactions from PostgreSQL", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*dto.TransactionDTO
+	for rows.Next() {
+		var transaction dto.TransactionDTO
+		err := rows.Scan(
+			&transaction.ID, &transaction.AccountID, &transaction.Amount,
+			&transaction.Type, &transaction.CreatedAt, &transaction.UpdatedAt)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to scan transaction from PostgreSQL", err)
+			return nil, err
+		}
+		transactions = append(transactions, &transaction)
+	}
+
+	return transactions, rows.Err()
+}
+
 func (r *PostgreSQLTransactionRepository) GetAll(ctx context.Context) ([]*dto.TransactionDTO, error) {
 	query := `SELECT id, account_id, amount, type, created_at, updated_at FROM transactions`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
-		logger.Error("Failed to query all transactions from PostgreSQL", err)
+		logger.ErrorContext(ctx, "Failed to query all transactions from PostgreSQL", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -344,7 +743,7 @@ func (r *PostgreSQLTransactionRepository) GetAll(ctx context.Context) ([]*dto.Tr
 			&transaction.ID, &transaction.AccountID, &transaction.Amount,
 			&transaction.Type, &transaction.CreatedAt, &transaction.UpdatedAt)
 		if err != nil {
-			logger.Error("Failed to scan transaction from PostgreSQL", err)
+			logger.ErrorContext(ctx, "Failed to scan transaction from PostgreSQL", err)
 			return nil, err
 		}
 		transactions = append(transactions, &transaction)
@@ -360,16 +759,16 @@ func (r *PostgreSQLTransactionRepository) Update(ctx context.Context, id string,
 
 	transaction.UpdatedAt = time.Now()
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
 		transaction.AccountID, transaction.Amount, transaction.Type,
 		transaction.UpdatedAt, id)
 
 	if err != nil {
-		logger.Error("Failed to update transaction in PostgreSQL", err)
+		logger.ErrorContext(ctx, "Failed to update transaction in PostgreSQL", err)
 		return err
 	}
 
-	logger.Info("Transaction updated in PostgreSQL", map[string]interface{}{
+	logger.InfoContext(ctx, "Transaction updated in PostgreSQL", map[string]interface{}{
 		"transaction_id": id,
 	})
 	return nil
@@ -378,14 +777,14 @@ func (r *PostgreSQLTransactionRepository) Update(ctx context.Context, id string,
 func (r *PostgreSQLTransactionRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM transactions WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
-		logger.Error("Failed to delete transaction from PostgreSQL", err)
+		logger.ErrorContext(ctx, "Failed to delete transaction from PostgreSQL", err)
 		return err
 	}
 
 	rowsAffected, _ := result.RowsAffected()
-	logger.Info("Transaction deleted from PostgreSQL", map[string]interface{}{
+	logger.InfoContext(ctx, "Transaction deleted from PostgreSQL", map[string]interface{}{
 		"transaction_id": id,
 		"rows_affected":  rowsAffected,
 	})
@@ -393,36 +792,83 @@ func (r *PostgreSQLTransactionRepository) Delete(ctx context.Context, id string)
 }
 
 func (r *PostgreSQLTransactionRepository) GetTransactionSummary(ctx context.Context, accountID string) (*dto.TransactionSummary, error) {
+	return r.GetTransactionSummarySince(ctx, accountID, time.Time{})
+}
+
+func (r *PostgreSQLTransactionRepository) GetTransactionSummarySince(ctx context.Context, accountID string, since time.Time) (*dto.TransactionSummary, error) {
 	query := `
 		SELECT
 			COUNT(*) as total_transactions,
 			COALESCE(SUM(CASE WHEN type = 'deposit' THEN amount ELSE 0 END), 0) as total_deposits,
 			COALESCE(SUM(CASE WHEN type = 'withdrawal' THEN amount ELSE 0 END), 0) as total_withdrawals,
+			COALESCE(SUM(CASE WHEN type = 'interest' THEN amount ELSE 0 END), 0) as total_interest,
+			COALESCE(SUM(CASE WHEN type = 'fee' THEN amount ELSE 0 END), 0) as total_fees,
 			MAX(created_at) as last_transaction_at
 		FROM transactions
-		WHERE account_id = $1`
+		WHERE account_id = $1 AND created_at >= $2`
 
 	var summary dto.TransactionSummary
 	summary.AccountID = accountID
 
-	err := r.db.QueryRowContext(ctx, query, accountID).Scan(
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, accountID, since).Scan(
 		&summary.TotalTransactions,
 		&summary.TotalDeposits,
 		&summary.TotalWithdrawals,
+		&summary.TotalInterest,
+		&summary.TotalFees,
 		&summary.LastTransactionAt)
 
 	if err != nil {
-		logger.Error("Failed to get transaction summary from PostgreSQL", err)
+		logger.ErrorContext(ctx, "Failed to get transaction summary from PostgreSQL", err)
 		return nil, err
 	}
 
 	// Get current balance from accounts table
 	balanceQuery := `SELECT balance FROM accounts WHERE id = $1`
-	err = r.db.QueryRowContext(ctx, balanceQuery, accountID).Scan(&summary.CurrentBalance)
+	err = executor(ctx, r.db).QueryRowContext(ctx, balanceQuery, accountID).Scan(&summary.CurrentBalance)
 	if err != nil {
-		logger.Error("Failed to get current balance from PostgreSQL", err)
+		logger.ErrorContext(ctx, "Failed to get current balance from PostgreSQL", err)
 		return nil, err
 	}
 
 	return &summary, nil
 }
+
+func (r *PostgreSQLTransactionRepository) StreamByAccountID(ctx context.Context, accountID string, from, to time.Time, fn func(*dto.TransactionDTO) error) error {
+	query := `
+		SELECT id, account_id, amount, type, created_at, updated_at
+		FROM transactions WHERE account_id = $1 AND created_at >= $2`
+	args := []interface{}{accountID, from}
+	if !to.IsZero() {
+		query += ` AND created_at < $3`
+		args = append(args, to)
+	}
+	query += ` ORDER BY created_at, id`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to stream transactions from PostgreSQL", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transaction dto.TransactionDTO
+		err := rows.Scan(
+			&transaction.ID, &transaction.AccountID, &transaction.Amount,
+			&transaction.Type, &transaction.CreatedAt, &transaction.UpdatedAt)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to scan transaction from PostgreSQL", err)
+			return err
+		}
+		if err := fn(&transaction); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.ErrorContext(ctx, "Failed to stream transactions from PostgreSQL", err)
+		return err
+	}
+	return nil
+}
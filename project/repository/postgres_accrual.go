@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+)
+
+// PostgreSQLAccrualRepository implements AccrualRepository for PostgreSQL
+type PostgreSQLAccrualRepository struct {
+	db *sql.DB
+}
+
+const accrualColumns = `id, account_id, period, product, days, average_balance, interest, fee,
+	fee_waived, interest_transaction_id, fee_transaction_id, posted_at`
+
+func (r *PostgreSQLAccrualRepository) Create(ctx context.Context, accrual *dto.AccrualDTO) error {
+	query := `
+		INSERT INTO accruals (` + accrualColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (id) DO NOTHING`
+
+	accrual.ID = accrualID(accrual.AccountID, accrual.Period)
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query,
+		accrual.ID, accrual.AccountID, accrual.Period, accrual.Product, accrual.Days,
+		accrual.AverageBalance, accrual.Interest, accrual.Fee, accrual.FeeWaived,
+		accrual.InterestTransactionID, accrual.FeeTransactionID, accrual.PostedAt)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create accrual in PostgreSQL", err)
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrAccrualExists
+	}
+
+	logger.InfoContext(ctx, "Accrual created in PostgreSQL", map[string]interface{}{
+		"account_id": accrual.AccountID,
+		"period":     accrual.Period,
+	})
+	return nil
+}
+
+func (r *PostgreSQLAccrualRepository) Get(ctx context.Context, accountID, period string) (*dto.AccrualDTO, error) {
+	query := `SELECT ` + accrualColumns + ` FROM accruals WHERE id = $1`
+
+	var accrual dto.AccrualDTO
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, accrualID(accountID, period)).Scan(
+		&accrual.ID, &accrual.AccountID, &accrual.Period, &accrual.Product, &accrual.Days,
+		&accrual.AverageBalance, &accrual.Interest, &accrual.Fee, &accrual.FeeWaived,
+		&accrual.InterestTransactionID, &accrual.FeeTransactionID, &accrual.PostedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Accrual not found
+		}
+		logger.ErrorContext(ctx, "Failed to get accrual from PostgreSQL", err)
+		return nil, err
+	}
+	return &accrual, nil
+}
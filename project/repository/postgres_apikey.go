@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+)
+
+// PostgreSQLAPIKeyRepository implements APIKeyRepository for PostgreSQL
+type PostgreSQLAPIKeyRepository struct {
+	db *sql.DB
+}
+
+func (r *PostgreSQLAPIKeyRepository) Create(ctx context.Context, apiKey *dto.APIKeyDTO) error {
+	query := `
+		INSERT INTO api_keys (id, name, key_hash, principal_id, roles, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	if apiKey.ID == "" {
+		apiKey.ID = newID()
+	}
+	apiKey.CreatedAt = time.Now()
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
+		apiKey.ID, apiKey.Name, apiKey.KeyHash, apiKey.PrincipalID,
+		strings.Join(apiKey.Roles, ","), apiKey.CreatedAt)
+
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create API key in PostgreSQL", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "API key created in PostgreSQL", map[string]interface{}{
+		"api_key_id":   apiKey.ID,
+		"principal_id": apiKey.PrincipalID,
+	})
+	return nil
+}
+
+func (r *PostgreSQLAPIKeyRepository) GetByID(ctx context.Context, id string) (*dto.APIKeyDTO, error) {
+	return r.get(ctx, "id", id)
+}
+
+func (r *PostgreSQLAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*dto.APIKeyDTO, error) {
+	return r.get(ctx, "key_hash", keyHash)
+}
+
+// get returns the API key whose column equals value, nil when there is none
+func (r *PostgreSQLAPIKeyRepository) get(ctx context.Context, column, value string) (*dto.APIKeyDTO, error) {
+	query := `
+		SELECT id, name, key_hash, principal_id, roles, created_at, revoked_at
+		FROM api_keys WHERE ` + column + ` = $1`
+
+	var apiKey dto.APIKeyDTO
+	var roles string
+	var revokedAt sql.NullTime
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, value).Scan(
+		&apiKey.ID, &apiKey.Name, &apiKey.KeyHash,
+		&apiKey.PrincipalID, &roles, &apiKey.CreatedAt, &revokedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // API key not found
+		}
+		logger.ErrorContext(ctx, "Failed to get API key from PostgreSQL", err)
+		return nil, err
+	}
+
+	if roles != "" {
+		apiKey.Roles = strings.Split(roles, ",")
+	}
+	if revokedAt.Valid {
+		apiKey.RevokedAt = &revokedAt.Time
+	}
+	return &apiKey, nil
+}
+
+func (r *PostgreSQLAPIKeyRepository) Revoke(ctx context.Context, id string) error {
+	query := `UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to revoke API key in PostgreSQL", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "API key revoked in PostgreSQL", map[string]interface{}{
+		"api_key_id": id,
+	})
+	return nil
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+)
+
+// auditLockKey is the first key of the advisory locks serializing the appends
+// to a chain: reading its last entry and inserting the next one must not
+// interleave. The second key is the hash of the chain, so appends to other
+// resources don't wait.
+const auditLockKey = 7_240_412
+
+// PostgreSQLAuditRepository implements AuditRepository for PostgreSQL. A
+// trigger rejects updates and deletes of the audit_log table.
+type PostgreSQLAuditRepository struct {
+	db *sql.DB
+}
+
+const auditColumns = `sequence, chain_sequence, id, actor, action, resource_type, resource_id, before_state, after_state,
+	request_id, occurred_at, prev_hash, hash`
+
+func scanAuditEntry(row rowScanner) (*dto.AuditEntryDTO, error) {
+	var entry dto.AuditEntryDTO
+	var before, after string
+	err := row.Scan(&entry.Sequence, &entry.ChainSequence, &entry.ID, &entry.Actor, &entry.Action, &entry.ResourceType,
+		&entry.ResourceID, &before, &after, &entry.RequestID, &entry.OccurredAt, &entry.PrevHash, &entry.Hash)
+	if err != nil {
+		return nil, err
+	}
+	entry.Before, entry.After = rawSnapshot(before), rawSnapshot(after)
+	return &entry, nil
+}
+
+func (r *PostgreSQLAuditRepository) Append(ctx context.Context, entry *dto.AuditEntryDTO) error {
+	// The lock is held until the transaction ends, so appends made outside
+	// one get a transaction of their own. A lock rather than SELECT … FOR
+	// UPDATE of the last entry, as the first entry of a chain has none.
+	txManager := &PostgreSQLTxManager{db: r.db}
+	return txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		db := executor(ctx, r.db)
+		if _, err := db.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2))`, auditLockKey, entry.Chain()); err != nil {
+			logger.ErrorContext(ctx, "Failed to lock the PostgreSQL audit log chain", err)
+			return err
+		}
+
+		last, err := scanAuditEntry(db.QueryRowContext(ctx, `
+			SELECT `+auditColumns+` FROM audit_log
+			WHERE resource_type = $1 AND resource_id = $2
+			ORDER BY chain_sequence DESC LIMIT 1`, entry.ResourceType, entry.ResourceID))
+		if err != nil && err != sql.ErrNoRows {
+			logger.ErrorContext(ctx, "Failed to get the last audit log entry from PostgreSQL", err)
+			return err
+		}
+
+		// Taken under the lock, after the previous entry of the chain
+		// committed, so the chain's entries are in sequence order
+		err = db.QueryRowContext(ctx, `SELECT nextval(pg_get_serial_sequence('audit_log', 'sequence'))`).Scan(&entry.Sequence)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to allocate audit log sequence in PostgreSQL", err)
+			return err
+		}
+
+		if entry.ID == "" {
+			entry.ID = newID()
+		}
+		entry.Seal(last)
+
+		query := `
+			INSERT INTO audit_log (` + auditColumns + `)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+		_, err = db.ExecContext(ctx, query,
+			entry.Sequence, entry.ChainSequence, entry.ID, entry.Actor, entry.Action, entry.ResourceType, entry.ResourceID,
+			string(entry.Before), string(entry.After), entry.RequestID, entry.OccurredAt, entry.PrevHash, entry.Hash)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to append to the PostgreSQL audit log", err)
+			return err
+		}
+		return nil
+	})
+}
+
+func (r *PostgreSQLAuditRepository) Find(ctx context.Context, query dto.AuditQuery) ([]*dto.AuditEntryDTO, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if query.Actor != "" {
+		where("actor = $%d", query.Actor)
+	}
+	if query.Action != "" {
+		where("action = $%d", query.Action)
+	}
+	if query.ResourceType != "" {
+		where("resource_type = $%d", query.ResourceType)
+	}
+	if query.ResourceID != "" {
+		where("resource_id = $%d", query.ResourceID)
+	}
+	if !query.From.IsZero() {
+		where("occurred_at >= $%d", query.From)
+	}
+	if !query.To.IsZero() {
+		where("occurred_at < $%d", query.To)
+	}
+
+	sqlQuery := `SELECT ` + auditColumns + ` FROM audit_log`
+	if len(conditions) > 0 {
+		sqlQuery += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	sqlQuery += ` ORDER BY sequence DESC`
+	if query.Limit > 0 {
+		args = append(args, query.Limit)
+		sqlQuery += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to query the PostgreSQL audit log", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*dto.AuditEntryDTO
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to scan audit log entry", err)
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (r *PostgreSQLAuditRepository) Walk(ctx context.Context, fn func(*dto.AuditEntryDTO) error) error {
+	rows, err := executor(ctx, r.db).QueryContext(ctx, `SELECT `+auditColumns+` FROM audit_log ORDER BY sequence`)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to read the PostgreSQL audit log", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to scan audit log entry", err)
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// rawSnapshot returns the stored JSON of a snapshot, nil when there was none
+func rawSnapshot(snapshot string) []byte {
+	if snapshot == "" {
+		return nil
+	}
+	return []byte(snapshot)
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+)
+
+// PostgreSQLHoldRepository implements HoldRepository for PostgreSQL
+type PostgreSQLHoldRepository struct {
+	db *sql.DB
+}
+
+const holdColumns = `id, account_id, amount, status, expires_at, captured_amount, transaction_id,
+	created_by, created_at, updated_at`
+
+func scanHold(row rowScanner) (*dto.HoldDTO, error) {
+	var hold dto.HoldDTO
+	err := row.Scan(&hold.ID, &hold.AccountID, &hold.Amount, &hold.Status, &hold.ExpiresAt,
+		&hold.CapturedAmount, &hold.TransactionID, &hold.CreatedBy, &hold.CreatedAt, &hold.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func (r *PostgreSQLHoldRepository) Create(ctx context.Context, hold *dto.HoldDTO) error {
+	query := `
+		INSERT INTO holds (` + holdColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	if hold.ID == "" {
+		hold.ID = newID()
+	}
+
+	now := time.Now()
+	hold.CreatedAt = now
+	hold.UpdatedAt = now
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
+		hold.ID, hold.AccountID, hold.Amount, hold.Status, hold.ExpiresAt,
+		hold.CapturedAmount, hold.TransactionID, hold.CreatedBy, hold.CreatedAt, hold.UpdatedAt)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create hold in PostgreSQL", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "Hold created in PostgreSQL", map[string]interface{}{
+		"hold_id":    hold.ID,
+		"account_id": hold.AccountID,
+		"amount":     hold.Amount,
+	})
+	return nil
+}
+
+func (r *PostgreSQLHoldRepository) GetByID(ctx context.Context, id string) (*dto.HoldDTO, error) {
+	query := `SELECT ` + holdColumns + ` FROM holds WHERE id = $1`
+
+	hold, err := scanHold(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Hold not found
+		}
+		logger.ErrorContext(ctx, "Failed to get hold from PostgreSQL", err)
+		return nil, err
+	}
+	return hold, nil
+}
+
+func (r *PostgreSQLHoldRepository) GetByAccountID(ctx context.Context, accountID string) ([]*dto.HoldDTO, error) {
+	query := `
+		SELECT ` + holdColumns + `
+		FROM holds WHERE account_id = $1 ORDER BY created_at DESC, id`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, accountID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get holds from PostgreSQL", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holds []*dto.HoldDTO
+	for rows.Next() {
+		hold, err := scanHold(rows)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to scan hold row", err)
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+	return holds, rows.Err()
+}
+
+func (r *PostgreSQLHoldRepository) Close(ctx context.Context, hold *dto.HoldDTO) (bool, error) {
+	query := `
+		UPDATE holds
+		SET status = $1, captured_amount = $2, transaction_id = $3, updated_at = $4
+		WHERE id = $5 AND status = 'active'`
+
+	hold.UpdatedAt = time.Now()
+	result, err := executor(ctx, r.db).ExecContext(ctx, query,
+		hold.Status, hold.CapturedAmount, hold.TransactionID, hold.UpdatedAt, hold.ID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to close hold in PostgreSQL", err)
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *PostgreSQLHoldRepository) ActiveTotal(ctx context.Context, accountID string, now time.Time) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0) FROM holds
+		WHERE account_id = $1 AND status = 'active' AND expires_at > $2`
+
+	var total float64
+	if err := executor(ctx, r.db).QueryRowContext(ctx, query, accountID, now).Scan(&total); err != nil {
+		logger.ErrorContext(ctx, "Failed to sum active holds in PostgreSQL", err)
+		return 0, err
+	}
+	return total, nil
+}
+
+func (r *PostgreSQLHoldRepository) ExpireDue(ctx context.Context, now time.Time) (int64, error) {
+	query := `
+		UPDATE holds SET status = 'expired', updated_at = $1
+		WHERE status = 'active' AND expires_at <= $2`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, time.Now(), now)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to expire holds in PostgreSQL", err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
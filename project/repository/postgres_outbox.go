@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/lib/pq"
+)
+
+// outboxLockKey is the advisory lock serializing outbox appends. Sequence
+// values are handed out when a row is inserted, not when it is committed;
+// holding the lock until the appending transaction ends keeps a consumer
+// from reading sequence 6 while 5 is still uncommitted and skipping it.
+const outboxLockKey = 7_240_411
+
+// outboxChannel is notified of every append, when the appending transaction commits
+const outboxChannel = "outbox"
+
+// outboxPollInterval is how often Watch reads the outbox without a
+// notification, covering notifications lost while reconnecting
+const outboxPollInterval = 5 * time.Second
+
+// PostgreSQLOutboxRepository implements OutboxRepository and OutboxFeed for
+// PostgreSQL. Watch listens on its own connection, opened with connString.
+type PostgreSQLOutboxRepository struct {
+	db         *sql.DB
+	connString string
+}
+
+func (r *PostgreSQLOutboxRepository) Append(ctx context.Context, event *dto.EventDTO) error {
+	// Locking and inserting in one statement keeps appends made outside a
+	// transaction ordered too
+	query := `
+		INSERT INTO outbox (id, type, account_id, payload, request_id, occurred_at)
+		SELECT $1, $2, $3, $4, $5, $6 FROM (SELECT pg_advisory_xact_lock($7)) AS lock
+		RETURNING sequence`
+
+	if event.ID == "" {
+		event.ID = newID()
+	}
+
+	err := executor(ctx, r.db).QueryRowContext(ctx, query,
+		event.ID, event.Type, event.AccountID, string(event.Payload), event.RequestID, event.OccurredAt,
+		outboxLockKey).Scan(&event.Sequence)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to append event to the PostgreSQL outbox", err)
+		return err
+	}
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, `SELECT pg_notify($1, '')`, outboxChannel); err != nil {
+		logger.ErrorContext(ctx, "Failed to notify the PostgreSQL outbox channel", err)
+		return err
+	}
+	return nil
+}
+
+func (r *PostgreSQLOutboxRepository) After(ctx context.Context, after int64, limit int) ([]*dto.EventDTO, error) {
+	query := `
+		SELECT sequence, id, type, account_id, payload, request_id, occurred_at
+		FROM outbox WHERE sequence > $1 ORDER BY sequence LIMIT $2`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, after, limit)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to read the PostgreSQL outbox", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*dto.EventDTO
+	for rows.Next() {
+		var event dto.EventDTO
+		var payload []byte
+		if err := rows.Scan(&event.Sequence, &event.ID, &event.Type, &event.AccountID,
+			&payload, &event.RequestID, &event.OccurredAt); err != nil {
+			logger.ErrorContext(ctx, "Failed to scan outbox event", err)
+			return nil, err
+		}
+		event.Payload = payload
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
+
+func (r *PostgreSQLOutboxRepository) Offset(ctx context.Context, consumer string) (int64, error) {
+	var sequence int64
+	err := executor(ctx, r.db).QueryRowContext(ctx,
+		`SELECT sequence FROM outbox_offsets WHERE consumer = $1`, consumer).Scan(&sequence)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil // Nothing processed yet
+		}
+		logger.ErrorContext(ctx, "Failed to get outbox offset from PostgreSQL", err)
+		return 0, err
+	}
+	return sequence, nil
+}
+
+func (r *PostgreSQLOutboxRepository) SetOffset(ctx context.Context, consumer string, sequence int64) error {
+	query := `
+		INSERT INTO outbox_offsets (consumer, sequence, updated_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (consumer) DO UPDATE SET sequence = EXCLUDED.sequence, updated_at = EXCLUDED.updated_at`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, consumer, sequence); err != nil {
+		logger.ErrorContext(ctx, "Failed to set outbox offset in PostgreSQL", err)
+		return err
+	}
+	return nil
+}
+
+func (r *PostgreSQLOutboxRepository) Last(ctx context.Context) (int64, error) {
+	var sequence int64
+	err := executor(ctx, r.db).QueryRowContext(ctx, `SELECT COALESCE(MAX(sequence), 0) FROM outbox`).Scan(&sequence)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get the last outbox sequence from PostgreSQL", err)
+		return 0, err
+	}
+	return sequence, nil
+}
+
+// Watch tails the outbox, reading the new events whenever an append is
+// notified on the outbox channel and every outboxPollInterval
+func (r *PostgreSQLOutboxRepository) Watch(ctx context.Context, after int64, fn func(*dto.EventDTO) error) error {
+	listener := pq.NewListener(r.connString, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.Warn("PostgreSQL outbox listener connection problem", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(outboxChannel); err != nil {
+		logger.ErrorContext(ctx, "Failed to listen on the PostgreSQL outbox channel", err)
+		return err
+	}
+
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Listening started before this read, so an append committed
+		// after it is notified
+		events, err := r.After(ctx, after, outboxWatchBatch)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return err
+			}
+			after = event.Sequence
+		}
+		if len(events) == outboxWatchBatch {
+			continue // Still catching up
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-listener.Notify:
+		case <-ticker.C:
+		}
+	}
+}
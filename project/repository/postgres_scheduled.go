@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+)
+
+// PostgreSQLScheduledTransactionRepository implements ScheduledTransactionRepository for PostgreSQL
+type PostgreSQLScheduledTransactionRepository struct {
+	db *sql.DB
+}
+
+const scheduledTransactionColumns = `id, account_id, amount, type, schedule, start_at, cron, end_at,
+	active, next_run_at, last_run_at, last_run_status, created_by, created_at, updated_at`
+
+func scanScheduledTransaction(row rowScanner) (*dto.ScheduledTransactionDTO, error) {
+	var scheduled dto.ScheduledTransactionDTO
+	var endAt, nextRunAt, lastRunAt sql.NullTime
+	err := row.Scan(
+		&scheduled.ID, &scheduled.AccountID, &scheduled.Amount, &scheduled.Type,
+		&scheduled.Schedule, &scheduled.StartAt, &scheduled.Cron, &endAt,
+		&scheduled.Active, &nextRunAt, &lastRunAt, &scheduled.LastRunStatus,
+		&scheduled.CreatedBy, &scheduled.CreatedAt, &scheduled.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	scheduled.EndAt = nullTimePtr(endAt)
+	scheduled.NextRunAt = nullTimePtr(nextRunAt)
+	scheduled.LastRunAt = nullTimePtr(lastRunAt)
+	return &scheduled, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func (r *PostgreSQLScheduledTransactionRepository) Create(ctx context.Context, scheduled *dto.ScheduledTransactionDTO) error {
+	query := `
+		INSERT INTO scheduled_transactions (` + scheduledTransactionColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
+
+	if scheduled.ID == "" {
+		scheduled.ID = newID()
+	}
+
+	now := time.Now()
+	scheduled.CreatedAt = now
+	scheduled.UpdatedAt = now
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
+		scheduled.ID, scheduled.AccountID, scheduled.Amount, scheduled.Type,
+		scheduled.Schedule, scheduled.StartAt, scheduled.Cron, scheduled.EndAt,
+		scheduled.Active, scheduled.NextRunAt, scheduled.LastRunAt, scheduled.LastRunStatus,
+		scheduled.CreatedBy, scheduled.CreatedAt, scheduled.UpdatedAt)
+
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create scheduled transaction in PostgreSQL", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "Scheduled transaction created in PostgreSQL", map[string]interface{}{
+		"scheduled_transaction_id": scheduled.ID,
+		"account_id":               scheduled.AccountID,
+		"schedule":                 scheduled.Schedule,
+	})
+	return nil
+}
+
+func (r *PostgreSQLScheduledTransactionRepository) GetByID(ctx context.Context, id string) (*dto.ScheduledTransactionDTO, error) {
+	query := `SELECT ` + scheduledTransactionColumns + ` FROM scheduled_transactions WHERE id = $1`
+
+	scheduled, err := scanScheduledTransaction(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Scheduled transaction not found
+		}
+		logger.ErrorContext(ctx, "Failed to get scheduled transaction from PostgreSQL", err)
+		return nil, err
+	}
+	return scheduled, nil
+}
+
+func (r *PostgreSQLScheduledTransactionRepository) GetByAccountID(ctx context.Context, accountID string) ([]*dto.ScheduledTransactionDTO, error) {
+	query := `
+		SELECT ` + scheduledTransactionColumns + `
+		FROM scheduled_transactions WHERE account_id = $1 ORDER BY created_at`
+
+	return r.query(ctx, query, accountID)
+}
+
+func (r *PostgreSQLScheduledTransactionRepository) Update(ctx context.Context, scheduled *dto.ScheduledTransactionDTO) error {
+	query := `
+		UPDATE scheduled_transactions
+		SET amount = $1, active = $2, end_at = $3, next_run_at = $4, updated_at = $5
+		WHERE id = $6`
+
+	scheduled.UpdatedAt = time.Now()
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
+		scheduled.Amount, scheduled.Active, scheduled.EndAt, scheduled.NextRunAt,
+		scheduled.UpdatedAt, scheduled.ID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to update scheduled transaction in PostgreSQL", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "Scheduled transaction updated in PostgreSQL", map[string]interface{}{
+		"scheduled_transaction_id": scheduled.ID,
+	})
+	return nil
+}
+
+func (r *PostgreSQLScheduledTransactionRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM scheduled_transactions WHERE id = $1`
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to delete scheduled transaction from PostgreSQL", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "Scheduled transaction deleted from PostgreSQL", map[string]interface{}{
+		"scheduled_transaction_id": id,
+	})
+	return nil
+}
+
+func (r *PostgreSQLScheduledTransactionRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]*dto.ScheduledTransactionDTO, error) {
+	query := `
+		SELECT ` + scheduledTransactionColumns + `
+		FROM scheduled_transactions
+		WHERE active AND next_run_at <= $1
+		ORDER BY next_run_at, id LIMIT $2`
+
+	return r.query(ctx, query, now, limit)
+}
+
+func (r *PostgreSQLScheduledTransactionRepository) Advance(ctx context.Context, id string, due time.Time, next *time.Time) (bool, error) {
+	query := `
+		UPDATE scheduled_transactions SET next_run_at = $1, updated_at = $2
+		WHERE id = $3 AND active AND next_run_at = $4`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, next, time.Now(), id, due)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to advance scheduled transaction in PostgreSQL", err)
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *PostgreSQLScheduledTransactionRepository) RecordRun(ctx context.Context, run *dto.ScheduledTransactionRunDTO) error {
+	insert := `
+		INSERT INTO scheduled_transaction_runs
+			(id, scheduled_transaction_id, account_id, due_at, executed_at, status, transaction_id, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	update := `
+		UPDATE scheduled_transactions SET last_run_at = $1, last_run_status = $2
+		WHERE id = $3`
+
+	if run.ID == "" {
+		run.ID = newID()
+	}
+
+	db := executor(ctx, r.db)
+	_, err := db.ExecContext(ctx, insert,
+		run.ID, run.ScheduledTransactionID, run.AccountID, run.DueAt,
+		run.ExecutedAt, run.Status, run.TransactionID, run.Error)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to record scheduled transaction run in PostgreSQL", err)
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, update, run.ExecutedAt, run.Status, run.ScheduledTransactionID); err != nil {
+		logger.ErrorContext(ctx, "Failed to update last run of scheduled transaction in PostgreSQL", err)
+		return err
+	}
+	return nil
+}
+
+func (r *PostgreSQLScheduledTransactionRepository) GetRuns(ctx context.Context, scheduledID string) ([]*dto.ScheduledTransactionRunDTO, error) {
+	query := `
+		SELECT id, scheduled_transaction_id, account_id, due_at, executed_at, status, transaction_id, error
+		FROM scheduled_transaction_runs
+		WHERE scheduled_transaction_id = $1
+		ORDER BY executed_at DESC, due_at DESC`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, scheduledID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get scheduled transaction runs from PostgreSQL", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*dto.ScheduledTransactionRunDTO
+	for rows.Next() {
+		var run dto.ScheduledTransactionRunDTO
+		err := rows.Scan(&run.ID, &run.ScheduledTransactionID, &run.AccountID, &run.DueAt,
+			&run.ExecutedAt, &run.Status, &run.TransactionID, &run.Error)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to scan scheduled transaction run row", err)
+			return nil, err
+		}
+		runs = append(runs, &run)
+	}
+	return runs, rows.Err()
+}
+
+func (r *PostgreSQLScheduledTransactionRepository) query(ctx context.Context, query string, args ...interface{}) ([]*dto.ScheduledTransactionDTO, error) {
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get scheduled transactions from PostgreSQL", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scheduled []*dto.ScheduledTransactionDTO
+	for rows.Next() {
+		item, err := scanScheduledTransaction(rows)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to scan scheduled transaction row", err)
+			return nil, err
+		}
+		scheduled = append(scheduled, item)
+	}
+	return scheduled, rows.Err()
+}
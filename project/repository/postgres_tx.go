@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/gcalvocr/go-testing/logger"
+)
+
+// sqlExecutor is implemented by both *sql.DB and *sql.Tx
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type postgresTxKey struct{}
+
+// executor returns the transaction stored in the context by
+// PostgreSQLTxManager, or the database when there is none
+func executor(ctx context.Context, db *sql.DB) sqlExecutor {
+	if tx, ok := ctx.Value(postgresTxKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// inTransaction reports whether the context carries a PostgreSQL transaction
+func inTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(postgresTxKey{}).(*sql.Tx)
+	return ok
+}
+
+// PostgreSQLTxManager implements TxManager with database/sql transactions
+type PostgreSQLTxManager struct {
+	db *sql.DB
+}
+
+func (m *PostgreSQLTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if inTransaction(ctx) {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to begin PostgreSQL transaction", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(context.WithValue(ctx, postgresTxKey{}, tx)); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			logger.ErrorContext(ctx, "Failed to roll back PostgreSQL transaction", rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.ErrorContext(ctx, "Failed to commit PostgreSQL transaction", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/lib/pq"
+)
+
+// PostgreSQLWebhookRepository implements WebhookRepository for PostgreSQL
+type PostgreSQLWebhookRepository struct {
+	db *sql.DB
+}
+
+const webhookSubscriptionColumns = `id, url, event_types, account_id, secret, created_by, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, subscription_id, event_id, event_type, body, status, attempts,
+	next_attempt_at, last_attempt_at, last_status_code, last_error, delivered_at, created_at, updated_at`
+
+func scanWebhookSubscription(row rowScanner) (*dto.WebhookSubscriptionDTO, error) {
+	var subscription dto.WebhookSubscriptionDTO
+	err := row.Scan(&subscription.ID, &subscription.URL, pq.Array(&subscription.EventTypes),
+		&subscription.AccountID, &subscription.Secret, &subscription.CreatedBy,
+		&subscription.CreatedAt, &subscription.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func scanWebhookDelivery(row rowScanner) (*dto.WebhookDeliveryDTO, error) {
+	var delivery dto.WebhookDeliveryDTO
+	var nextAttemptAt, lastAttemptAt, deliveredAt sql.NullTime
+	err := row.Scan(
+		&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &delivery.Body,
+		&delivery.Status, &delivery.Attempts, &nextAttemptAt, &lastAttemptAt, &delivery.LastStatusCode,
+		&delivery.LastError, &deliveredAt, &delivery.CreatedAt, &delivery.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	delivery.NextAttemptAt = nullTimePtr(nextAttemptAt)
+	delivery.LastAttemptAt = nullTimePtr(lastAttemptAt)
+	delivery.DeliveredAt = nullTimePtr(deliveredAt)
+	return &delivery, nil
+}
+
+func (r *PostgreSQLWebhookRepository) CreateSubscription(ctx context.Context, subscription *dto.WebhookSubscriptionDTO) error {
+	query := `
+		INSERT INTO webhook_subscriptions (` + webhookSubscriptionColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	if subscription.ID == "" {
+		subscription.ID = newID()
+	}
+
+	now := time.Now()
+	subscription.CreatedAt = now
+	subscription.UpdatedAt = now
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
+		subscription.ID, subscription.URL, pq.Array(subscription.EventTypes), subscription.AccountID,
+		subscription.Secret, subscription.CreatedBy, subscription.CreatedAt, subscription.UpdatedAt)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create webhook subscription in PostgreSQL", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "Webhook subscription created in PostgreSQL", map[string]interface{}{
+		"subscription_id": subscription.ID,
+		"url":             subscription.URL,
+	})
+	return nil
+}
+
+func (r *PostgreSQLWebhookRepository) GetSubscription(ctx context.Context, id string) (*dto.WebhookSubscriptionDTO, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+
+	subscription, err := scanWebhookSubscription(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Subscription not found
+		}
+		logger.ErrorContext(ctx, "Failed to get webhook subscription from PostgreSQL", err)
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (r *PostgreSQLWebhookRepository) GetSubscriptions(ctx context.Context) ([]*dto.WebhookSubscriptionDTO, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions ORDER BY created_at, id`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get webhook subscriptions from PostgreSQL", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*dto.WebhookSubscriptionDTO
+	for rows.Next() {
+		subscription, err := scanWebhookSubscription(rows)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to scan webhook subscription", err)
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (r *PostgreSQLWebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	// The deliveries are kept for the delivery log
+	query := `DELETE FROM webhook_subscriptions WHERE id = $1`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		logger.ErrorContext(ctx, "Failed to delete webhook subscription in PostgreSQL", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "Webhook subscription deleted in PostgreSQL", map[string]interface{}{
+		"subscription_id": id,
+	})
+	return nil
+}
+
+func (r *PostgreSQLWebhookRepository) CreateDelivery(ctx context.Context, delivery *dto.WebhookDeliveryDTO) error {
+	query := `
+		INSERT INTO webhook_deliveries (` + webhookDeliveryColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (id) DO NOTHING`
+
+	delivery.ID = deliveryID(delivery.SubscriptionID, delivery.EventID)
+
+	now := time.Now()
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
+		delivery.ID, delivery.SubscriptionID, delivery.EventID, delivery.EventType, delivery.Body,
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastAttemptAt,
+		delivery.LastStatusCode, delivery.LastError, delivery.DeliveredAt, delivery.CreatedAt, delivery.UpdatedAt)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create webhook delivery in PostgreSQL", err)
+		return err
+	}
+	return nil
+}
+
+func (r *PostgreSQLWebhookRepository) GetDelivery(ctx context.Context, id string) (*dto.WebhookDeliveryDTO, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+
+	delivery, err := scanWebhookDelivery(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Delivery not found
+		}
+		logger.ErrorContext(ctx, "Failed to get webhook delivery from PostgreSQL", err)
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (r *PostgreSQLWebhookRepository) GetDeliveries(ctx context.Context, subscriptionID, status string) ([]*dto.WebhookDeliveryDTO, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries WHERE subscription_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, id`
+
+	return r.queryDeliveries(ctx, query, subscriptionID, status)
+}
+
+func (r *PostgreSQLWebhookRepository) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*dto.WebhookDeliveryDTO, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= $1
+		ORDER BY next_attempt_at, id LIMIT $2`
+
+	return r.queryDeliveries(ctx, query, now, limit)
+}
+
+func (r *PostgreSQLWebhookRepository) ClaimDelivery(ctx context.Context, id string, due, until time.Time) (bool, error) {
+	query := `
+		UPDATE webhook_deliveries SET next_attempt_at = $1, updated_at = $2
+		WHERE id = $3 AND status = 'pending' AND next_attempt_at = $4`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, until, time.Now(), id, due)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to claim webhook delivery in PostgreSQL", err)
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *PostgreSQLWebhookRepository) UpdateDelivery(ctx context.Context, delivery *dto.WebhookDeliveryDTO) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_attempt_at = $4,
+			last_status_code = $5, last_error = $6, delivered_at = $7, updated_at = $8
+		WHERE id = $9`
+
+	delivery.UpdatedAt = time.Now()
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastAttemptAt,
+		delivery.LastStatusCode, delivery.LastError, delivery.DeliveredAt, delivery.UpdatedAt, delivery.ID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to update webhook delivery in PostgreSQL", err)
+		return err
+	}
+	return nil
+}
+
+func (r *PostgreSQLWebhookRepository) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]*dto.WebhookDeliveryDTO, error) {
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get webhook deliveries from PostgreSQL", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*dto.WebhookDeliveryDTO
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to scan webhook delivery", err)
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
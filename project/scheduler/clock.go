@@ -0,0 +1,16 @@
+package scheduler
+
+import "time"
+
+// Clock tells the worker the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock
+type SystemClock struct{}
+
+// Now returns the current time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
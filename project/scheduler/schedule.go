@@ -0,0 +1,135 @@
+// Package scheduler posts scheduled and recurring transactions when they fall
+// due. Schedule computes run times and Worker polls the repository for due
+// scheduled transactions and posts them through the transaction service.
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule describes when a scheduled transaction runs
+type Schedule struct {
+	// Kind is one of the dto.Schedule* constants
+	Kind string
+	// Start is the first run of once, daily, weekly and monthly schedules and
+	// the earliest run of cron schedules
+	Start time.Time
+	// Cron is a standard five field expression, evaluated in UTC
+	Cron string
+	// End is the latest run, nil for no end
+	End *time.Time
+
+	cron cron.Schedule
+}
+
+// ScheduleOf returns the schedule of a scheduled transaction
+func ScheduleOf(scheduled *dto.ScheduledTransactionDTO) (Schedule, error) {
+	return NewSchedule(scheduled.Schedule, scheduled.StartAt, scheduled.Cron, scheduled.EndAt)
+}
+
+// NewSchedule validates and returns a schedule
+func NewSchedule(kind string, start time.Time, expression string, end *time.Time) (Schedule, error) {
+	schedule := Schedule{Kind: kind, Start: start, Cron: expression, End: end}
+
+	switch kind {
+	case dto.ScheduleOnce, dto.ScheduleDaily, dto.ScheduleWeekly, dto.ScheduleMonthly:
+		if start.IsZero() {
+			return Schedule{}, errors.New("start_at is required")
+		}
+		if expression != "" {
+			return Schedule{}, fmt.Errorf("cron is only allowed for cron schedules")
+		}
+	case dto.ScheduleCron:
+		parsed, err := cron.ParseStandard(expression)
+		if err != nil {
+			return Schedule{}, fmt.Errorf("invalid cron expression: %w", err)
+		}
+		schedule.cron = parsed
+	default:
+		return Schedule{}, fmt.Errorf("unknown schedule %q", kind)
+	}
+
+	if end != nil && end.Before(start) {
+		return Schedule{}, errors.New("end_at must not be before start_at")
+	}
+	return schedule, nil
+}
+
+// Next returns the first run strictly after the given time, or false when
+// the schedule has no more runs
+func (s Schedule) Next(after time.Time) (time.Time, bool) {
+	next, ok := s.next(after)
+	if !ok || (s.End != nil && next.After(*s.End)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+func (s Schedule) next(after time.Time) (time.Time, bool) {
+	if after.Before(s.Start) && s.Kind != dto.ScheduleCron {
+		return s.Start, true
+	}
+
+	switch s.Kind {
+	case dto.ScheduleOnce:
+		return time.Time{}, false
+	case dto.ScheduleDaily:
+		return s.repeat(after, int(after.Sub(s.Start)/(24*time.Hour)), func(n int) time.Time {
+			return s.Start.AddDate(0, 0, n)
+		}), true
+	case dto.ScheduleWeekly:
+		return s.repeat(after, int(after.Sub(s.Start)/(7*24*time.Hour)), func(n int) time.Time {
+			return s.Start.AddDate(0, 0, 7*n)
+		}), true
+	case dto.ScheduleMonthly:
+		months := (after.Year()-s.Start.Year())*12 + int(after.Month()-s.Start.Month()) - 1
+		return s.repeat(after, months, func(n int) time.Time {
+			return addMonths(s.Start, n)
+		}), true
+	case dto.ScheduleCron:
+		if s.cron == nil {
+			return time.Time{}, false
+		}
+		// cron.Schedule.Next is exclusive, so start one second early to
+		// include a run exactly at Start
+		from := after
+		if from.Before(s.Start) {
+			from = s.Start.Add(-time.Second)
+		}
+		next := s.cron.Next(from.UTC())
+		return next, !next.IsZero()
+	default:
+		return time.Time{}, false
+	}
+}
+
+// repeat returns the first occurrence after the given time, starting the
+// search at the estimated occurrence n
+func (s Schedule) repeat(after time.Time, n int, occurrence func(n int) time.Time) time.Time {
+	if n < 0 {
+		n = 0
+	}
+	for n > 0 && occurrence(n).After(after) {
+		n--
+	}
+	for !occurrence(n).After(after) {
+		n++
+	}
+	return occurrence(n)
+}
+
+// addMonths adds months to t, clamping the day to the end of shorter months
+// so that a schedule starting on the 31st runs on the last day of February
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func at(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestScheduleNext(t *testing.T) {
+	end := at("2025-03-31T09:00:00Z")
+
+	tests := []struct {
+		name     string
+		kind     string
+		start    string
+		cron     string
+		end      *time.Time
+		after    string
+		want     string
+		wantNone bool
+	}{
+		{name: "once before start", kind: dto.ScheduleOnce, start: "2025-01-10T09:00:00Z", after: "2025-01-01T00:00:00Z", want: "2025-01-10T09:00:00Z"},
+		{name: "once after its run", kind: dto.ScheduleOnce, start: "2025-01-10T09:00:00Z", after: "2025-01-10T09:00:00Z", wantNone: true},
+		{name: "daily", kind: dto.ScheduleDaily, start: "2025-01-10T09:00:00Z", after: "2025-01-12T09:00:00Z", want: "2025-01-13T09:00:00Z"},
+		{name: "daily between runs", kind: dto.ScheduleDaily, start: "2025-01-10T09:00:00Z", after: "2025-01-12T10:30:00Z", want: "2025-01-13T09:00:00Z"},
+		{name: "weekly", kind: dto.ScheduleWeekly, start: "2025-01-06T09:00:00Z", after: "2025-01-14T00:00:00Z", want: "2025-01-20T09:00:00Z"},
+		{name: "monthly", kind: dto.ScheduleMonthly, start: "2025-01-15T09:00:00Z", after: "2025-01-15T09:00:00Z", want: "2025-02-15T09:00:00Z"},
+		{name: "monthly clamps to the end of february", kind: dto.ScheduleMonthly, start: "2025-01-31T09:00:00Z", after: "2025-02-01T00:00:00Z", want: "2025-02-28T09:00:00Z"},
+		{name: "monthly keeps the start day after a short month", kind: dto.ScheduleMonthly, start: "2025-01-31T09:00:00Z", after: "2025-02-28T09:00:00Z", want: "2025-03-31T09:00:00Z"},
+		{name: "monthly across years", kind: dto.ScheduleMonthly, start: "2024-11-30T09:00:00Z", after: "2025-01-29T00:00:00Z", want: "2025-01-30T09:00:00Z"},
+		{name: "cron", kind: dto.ScheduleCron, cron: "0 9 * * 1-5", after: "2025-01-10T09:00:00Z", want: "2025-01-13T09:00:00Z"},
+		{name: "cron waits for start", kind: dto.ScheduleCron, cron: "0 9 * * *", start: "2025-02-01T09:00:00Z", after: "2025-01-10T00:00:00Z", want: "2025-02-01T09:00:00Z"},
+		{name: "last run before end", kind: dto.ScheduleMonthly, start: "2025-01-31T09:00:00Z", end: &end, after: "2025-02-28T09:00:00Z", want: "2025-03-31T09:00:00Z"},
+		{name: "past end", kind: dto.ScheduleMonthly, start: "2025-01-31T09:00:00Z", end: &end, after: "2025-03-31T09:00:00Z", wantNone: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var start time.Time
+			if tt.start != "" {
+				start = at(tt.start)
+			}
+			schedule, err := NewSchedule(tt.kind, start, tt.cron, tt.end)
+			require.NoError(t, err)
+
+			next, ok := schedule.Next(at(tt.after))
+
+			if tt.wantNone {
+				assert.False(t, ok, "got %v", next)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, at(tt.want), next.UTC())
+		})
+	}
+}
+
+func TestNewScheduleErrors(t *testing.T) {
+	start := at("2025-01-10T09:00:00Z")
+	before := start.Add(-time.Hour)
+
+	_, err := NewSchedule("yearly", start, "", nil)
+	assert.EqualError(t, err, `unknown schedule "yearly"`)
+
+	_, err = NewSchedule(dto.ScheduleDaily, time.Time{}, "", nil)
+	assert.EqualError(t, err, "start_at is required")
+
+	_, err = NewSchedule(dto.ScheduleDaily, start, "0 9 * * *", nil)
+	assert.EqualError(t, err, "cron is only allowed for cron schedules")
+
+	_, err = NewSchedule(dto.ScheduleCron, time.Time{}, "every day", nil)
+	assert.ErrorContains(t, err, "invalid cron expression")
+
+	_, err = NewSchedule(dto.ScheduleOnce, start, "", &before)
+	assert.EqualError(t, err, "end_at must not be before start_at")
+}
@@ -0,0 +1,215 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/authz"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/repository"
+	"github.com/gcalvocr/go-testing/service"
+)
+
+const (
+	// DefaultInterval is how often Run polls for due scheduled transactions
+	DefaultInterval = 30 * time.Second
+	// batchSize caps the scheduled transactions loaded per query
+	batchSize = 100
+)
+
+// Principal is the identity the worker posts scheduled transactions as.
+// Whether the creator may transact on the account is checked when the
+// scheduled transaction is created.
+var Principal = &auth.Principal{
+	ID:     "scheduler",
+	Name:   "Transaction scheduler",
+	Method: auth.MethodSystem,
+	Roles:  []string{string(authz.RoleTeller)},
+}
+
+// errNotClaimed rolls back a run that another worker already claimed
+var errNotClaimed = errors.New("scheduled transaction already claimed")
+
+// TransactionCreator posts transactions, it is implemented by service.TransactionService
+type TransactionCreator interface {
+	Create(ctx context.Context, req dto.CreateTransactionRequest) (*service.TransactionResult, error)
+}
+
+// Worker posts scheduled transactions when they fall due. Each run is
+// claimed, posted and recorded in one database transaction, so several
+// workers may poll the same database without posting a run twice.
+type Worker struct {
+	schedules    repository.ScheduledTransactionRepository
+	transactions TransactionCreator
+	txManager    repository.TxManager
+	clock        Clock
+	interval     time.Duration
+}
+
+// NewWorker creates a worker polling every DefaultInterval on the system clock
+func NewWorker(schedules repository.ScheduledTransactionRepository, transactions TransactionCreator, txManager repository.TxManager) *Worker {
+	return &Worker{
+		schedules:    schedules,
+		transactions: transactions,
+		txManager:    txManager,
+		clock:        SystemClock{},
+		interval:     DefaultInterval,
+	}
+}
+
+// SetClock replaces the clock used to decide which runs are due
+func (w *Worker) SetClock(clock Clock) {
+	w.clock = clock
+}
+
+// SetInterval sets how often Run polls for due scheduled transactions
+func (w *Worker) SetInterval(interval time.Duration) {
+	if interval > 0 {
+		w.interval = interval
+	}
+}
+
+// Run polls for due scheduled transactions until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	logger.Info("Transaction scheduler started", map[string]interface{}{
+		"interval": w.interval.String(),
+	})
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.RunDue(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("Failed to run due scheduled transactions", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Transaction scheduler stopped", nil)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue posts every run due at the current time of the clock and returns
+// the number of runs recorded. Runs missed while the worker was stopped are
+// caught up, oldest first.
+func (w *Worker) RunDue(ctx context.Context) (int, error) {
+	ctx = auth.WithPrincipal(ctx, Principal)
+	now := w.clock.Now()
+	runs := 0
+
+	for {
+		due, err := w.schedules.GetDue(ctx, now, batchSize)
+		if err != nil {
+			return runs, err
+		}
+
+		progressed := false
+		for _, scheduled := range due {
+			if ctx.Err() != nil {
+				return runs, ctx.Err()
+			}
+			ran, err := w.run(ctx, scheduled)
+			if err != nil {
+				return runs, err
+			}
+			if ran {
+				runs++
+				progressed = true
+			}
+		}
+
+		// Poll again so schedules still behind now catch up, until nothing
+		// is due or every due run was claimed elsewhere
+		if !progressed {
+			return runs, nil
+		}
+	}
+}
+
+// run posts one due run of the scheduled transaction and advances it to its
+// next run. A rejected transaction is recorded as a failed run, a database
+// error rolls the run back so it is retried on the next poll.
+func (w *Worker) run(ctx context.Context, scheduled *dto.ScheduledTransactionDTO) (bool, error) {
+	due := *scheduled.NextRunAt
+	ctx = logger.WithAccountID(ctx, scheduled.AccountID)
+
+	// A schedule that no longer parses is stopped with a failed run
+	var next *time.Time
+	schedule, scheduleErr := ScheduleOf(scheduled)
+	if scheduleErr == nil {
+		if at, ok := schedule.Next(due); ok {
+			next = &at
+		}
+	}
+
+	var run *dto.ScheduledTransactionRunDTO
+	err := w.withinTransaction(ctx, func(ctx context.Context) error {
+		claimed, err := w.schedules.Advance(ctx, scheduled.ID, due, next)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return errNotClaimed
+		}
+
+		run = &dto.ScheduledTransactionRunDTO{
+			ScheduledTransactionID: scheduled.ID,
+			AccountID:              scheduled.AccountID,
+			DueAt:                  due,
+			ExecutedAt:             w.clock.Now(),
+			Status:                 dto.RunSucceeded,
+		}
+		if scheduleErr != nil {
+			run.Status = dto.RunFailed
+			run.Error = scheduleErr.Error()
+			return w.schedules.RecordRun(ctx, run)
+		}
+
+		result, err := w.transactions.Create(ctx, dto.CreateTransactionRequest{
+			AccountID: scheduled.AccountID,
+			Amount:    scheduled.Amount,
+			Type:      scheduled.Type,
+		})
+		switch {
+		case err == nil:
+			run.TransactionID = result.Transaction.ID
+		case service.IsRejection(err):
+			run.Status = dto.RunFailed
+			run.Error = err.Error()
+		default:
+			return err
+		}
+
+		return w.schedules.RecordRun(ctx, run)
+	})
+	if errors.Is(err, errNotClaimed) {
+		return false, nil
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to run scheduled transaction", err)
+		return false, err
+	}
+
+	logger.InfoContext(ctx, "Scheduled transaction ran", map[string]interface{}{
+		"scheduled_transaction_id": scheduled.ID,
+		"due_at":                   due,
+		"status":                   run.Status,
+		"transaction_id":           run.TransactionID,
+		"error":                    run.Error,
+	})
+	return true, nil
+}
+
+// withinTransaction runs fn atomically when a TxManager is configured
+func (w *Worker) withinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if w.txManager == nil {
+		return fn(ctx)
+	}
+	return w.txManager.WithinTransaction(ctx, fn)
+}
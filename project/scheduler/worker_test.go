@@ -0,0 +1,232 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+// fakeSchedules is an in-memory ScheduledTransactionRepository
+type fakeSchedules struct {
+	items map[string]*dto.ScheduledTransactionDTO
+	runs  []*dto.ScheduledTransactionRunDTO
+}
+
+func (r *fakeSchedules) Create(ctx context.Context, scheduled *dto.ScheduledTransactionDTO) error {
+	r.items[scheduled.ID] = scheduled
+	return nil
+}
+
+func (r *fakeSchedules) GetByID(ctx context.Context, id string) (*dto.ScheduledTransactionDTO, error) {
+	return r.items[id], nil
+}
+
+func (r *fakeSchedules) GetByAccountID(ctx context.Context, accountID string) ([]*dto.ScheduledTransactionDTO, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *fakeSchedules) Update(ctx context.Context, scheduled *dto.ScheduledTransactionDTO) error {
+	return errors.New("not implemented")
+}
+
+func (r *fakeSchedules) Delete(ctx context.Context, id string) error {
+	delete(r.items, id)
+	return nil
+}
+
+func (r *fakeSchedules) GetDue(ctx context.Context, now time.Time, limit int) ([]*dto.ScheduledTransactionDTO, error) {
+	var due []*dto.ScheduledTransactionDTO
+	for _, item := range r.items {
+		if item.Active && item.NextRunAt != nil && !item.NextRunAt.After(now) {
+			copied := *item
+			due = append(due, &copied)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextRunAt.Before(*due[j].NextRunAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *fakeSchedules) Advance(ctx context.Context, id string, due time.Time, next *time.Time) (bool, error) {
+	item, ok := r.items[id]
+	if !ok || !item.Active || item.NextRunAt == nil || !item.NextRunAt.Equal(due) {
+		return false, nil
+	}
+	item.NextRunAt = next
+	return true, nil
+}
+
+func (r *fakeSchedules) RecordRun(ctx context.Context, run *dto.ScheduledTransactionRunDTO) error {
+	r.runs = append(r.runs, run)
+	item := r.items[run.ScheduledTransactionID]
+	item.LastRunAt = &run.ExecutedAt
+	item.LastRunStatus = run.Status
+	return nil
+}
+
+func (r *fakeSchedules) GetRuns(ctx context.Context, scheduledID string) ([]*dto.ScheduledTransactionRunDTO, error) {
+	return r.runs, nil
+}
+
+// fakeBank posts transactions against in-memory balances
+type fakeBank struct {
+	balances   map[string]float64
+	posted     int
+	principals []string
+	err        error
+}
+
+func (b *fakeBank) Create(ctx context.Context, req dto.CreateTransactionRequest) (*service.TransactionResult, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	principal, _ := auth.PrincipalFromContext(ctx)
+	b.principals = append(b.principals, principal.ID)
+
+	balance, ok := b.balances[req.AccountID]
+	if !ok {
+		return nil, service.ErrAccountNotFound
+	}
+	if req.Type == "withdrawal" {
+		if req.Amount > balance {
+			return nil, service.ErrInsufficientFunds
+		}
+		balance -= req.Amount
+	} else {
+		balance += req.Amount
+	}
+	b.balances[req.AccountID] = balance
+	b.posted++
+	return &service.TransactionResult{
+		Transaction: &dto.TransactionDTO{ID: fmt.Sprintf("tx-%d", b.posted), AccountID: req.AccountID},
+		Balance:     balance,
+	}, nil
+}
+
+func scheduled(id, kind, start string, amount float64, txType string) *dto.ScheduledTransactionDTO {
+	startAt := at(start)
+	return &dto.ScheduledTransactionDTO{
+		ID: id, AccountID: "acc-1", Amount: amount, Type: txType,
+		Schedule: kind, StartAt: startAt, Active: true, NextRunAt: &startAt,
+	}
+}
+
+func newTestWorker(bank *fakeBank, items ...*dto.ScheduledTransactionDTO) (*Worker, *fakeSchedules, *fakeClock) {
+	schedules := &fakeSchedules{items: make(map[string]*dto.ScheduledTransactionDTO)}
+	for _, item := range items {
+		schedules.items[item.ID] = item
+	}
+	clock := &fakeClock{}
+	worker := NewWorker(schedules, bank, nil)
+	worker.SetClock(clock)
+	return worker, schedules, clock
+}
+
+func TestRunDuePostsDueRuns(t *testing.T) {
+	bank := &fakeBank{balances: map[string]float64{"acc-1": 0}}
+	worker, schedules, clock := newTestWorker(bank,
+		scheduled("salary", dto.ScheduleMonthly, "2025-01-31T09:00:00Z", 1000, "deposit"),
+		scheduled("later", dto.ScheduleOnce, "2025-06-01T09:00:00Z", 5, "deposit"),
+	)
+
+	clock.now = at("2025-01-30T12:00:00Z")
+	runs, err := worker.RunDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, runs, "nothing is due yet")
+
+	clock.now = at("2025-01-31T09:00:30Z")
+	runs, err = worker.RunDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, runs)
+	assert.Equal(t, 1000.0, bank.balances["acc-1"])
+	assert.Equal(t, at("2025-02-28T09:00:00Z"), *schedules.items["salary"].NextRunAt)
+	assert.Equal(t, dto.RunSucceeded, schedules.items["salary"].LastRunStatus)
+	assert.Equal(t, "tx-1", schedules.runs[0].TransactionID)
+	assert.Equal(t, []string{"scheduler"}, bank.principals)
+
+	// Running again at the same time posts nothing twice
+	runs, err = worker.RunDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, runs)
+	assert.Equal(t, 1, bank.posted)
+}
+
+func TestRunDueCatchesUpMissedRuns(t *testing.T) {
+	bank := &fakeBank{balances: map[string]float64{"acc-1": 0}}
+	worker, schedules, clock := newTestWorker(bank,
+		scheduled("allowance", dto.ScheduleDaily, "2025-01-01T08:00:00Z", 10, "deposit"),
+	)
+
+	clock.now = at("2025-01-03T09:00:00Z")
+	runs, err := worker.RunDue(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, runs)
+	assert.Equal(t, 30.0, bank.balances["acc-1"])
+	require.Len(t, schedules.runs, 3)
+	assert.Equal(t, at("2025-01-02T08:00:00Z"), schedules.runs[1].DueAt)
+	assert.Equal(t, at("2025-01-04T08:00:00Z"), *schedules.items["allowance"].NextRunAt)
+}
+
+func TestRunDueRecordsRejectedRuns(t *testing.T) {
+	bank := &fakeBank{balances: map[string]float64{"acc-1": 20}}
+	rent := scheduled("rent", dto.ScheduleOnce, "2025-01-01T08:00:00Z", 50, "withdrawal")
+	worker, schedules, clock := newTestWorker(bank, rent)
+
+	clock.now = at("2025-01-01T08:00:00Z")
+	runs, err := worker.RunDue(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, runs)
+	assert.Equal(t, 20.0, bank.balances["acc-1"])
+	require.Len(t, schedules.runs, 1)
+	assert.Equal(t, dto.RunFailed, schedules.runs[0].Status)
+	assert.Equal(t, "insufficient funds", schedules.runs[0].Error)
+	assert.Nil(t, schedules.items["rent"].NextRunAt, "a one-off schedule is done after its run")
+}
+
+func TestRunDueStopsOnDatabaseErrors(t *testing.T) {
+	errDatabase := errors.New("database unavailable")
+	bank := &fakeBank{balances: map[string]float64{"acc-1": 0}, err: errDatabase}
+	worker, schedules, clock := newTestWorker(bank,
+		scheduled("salary", dto.ScheduleMonthly, "2025-01-31T09:00:00Z", 1000, "deposit"),
+	)
+	// Without a TxManager the claim isn't rolled back, so check the error
+	// is returned rather than recorded as a run
+	clock.now = at("2025-01-31T09:00:00Z")
+
+	runs, err := worker.RunDue(context.Background())
+
+	assert.True(t, errors.Is(err, errDatabase))
+	assert.Equal(t, 0, runs)
+	assert.Empty(t, schedules.runs)
+}
+
+func TestRunDueSkipsPausedSchedules(t *testing.T) {
+	bank := &fakeBank{balances: map[string]float64{"acc-1": 0}}
+	paused := scheduled("paused", dto.ScheduleDaily, "2025-01-01T08:00:00Z", 10, "deposit")
+	paused.Active = false
+	worker, _, clock := newTestWorker(bank, paused)
+
+	clock.now = at("2025-01-05T08:00:00Z")
+	runs, err := worker.RunDue(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, runs)
+	assert.Equal(t, 0, bank.posted)
+}
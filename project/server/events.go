@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gcalvocr/go-testing/events"
+	"github.com/gcalvocr/go-testing/logger"
+)
+
+// newRelay creates the relay publishing the outbox to the sink chosen by
+// EVENT_SINK: file (EVENT_FILE, default events.jsonl) or webhook
+// (EVENT_WEBHOOK_URL). Events are recorded either way, a relay added later
+// publishes them from the start of the outbox. Each relay tracks its offset
+// under EVENT_CONSUMER, which defaults to the sink name.
+func (s *Server) newRelay() (*events.Relay, error) {
+	kind := getEnv("EVENT_SINK", "")
+
+	var sink events.Sink
+	switch kind {
+	case "", "none":
+		return nil, nil
+	case "file":
+		fileSink, err := events.OpenFileSink(getEnv("EVENT_FILE", "events.jsonl"))
+		if err != nil {
+			return nil, err
+		}
+		sink = fileSink
+	case "webhook":
+		url := getEnv("EVENT_WEBHOOK_URL", "")
+		if url == "" {
+			return nil, fmt.Errorf("EVENT_WEBHOOK_URL is required with EVENT_SINK=webhook")
+		}
+		sink = events.NewWebhookSink(url, nil)
+	default:
+		return nil, fmt.Errorf("unsupported EVENT_SINK %q, use file or webhook", kind)
+	}
+
+	relay := events.NewRelay(s.repoFactory.OutboxRepo, getEnv("EVENT_CONSUMER", kind), sink)
+	relay.SetInterval(time.Duration(getEnvInt("EVENT_RELAY_INTERVAL_SECONDS", 5)) * time.Second)
+	return relay, nil
+}
+
+// startRelay publishes events in the background until ctx is cancelled
+func (s *Server) startRelay(ctx context.Context) {
+	if s.relay == nil {
+		logger.Info("Event relay disabled", nil)
+		return
+	}
+	go s.relay.Run(ctx)
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net"
+
+	"github.com/gcalvocr/go-testing/grpcapi"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/product"
+)
+
+// SetProductCatalog sets the account products offered by the account
+// service. It must be called before SetupRoutes.
+func (s *Server) SetProductCatalog(catalog product.Catalog) {
+	s.catalog = catalog
+}
+
+// SetupGRPC creates the gRPC server, unless GRPC_ENABLED is false. It serves
+// the same repositories and services as the REST API, and authenticates and
+// rate limits calls like SetupRoutes does, so it must be called after both
+// InitializeDatabase and SetupRoutes.
+func (s *Server) SetupGRPC() error {
+	if getEnv("GRPC_ENABLED", "true") == "false" {
+		return nil
+	}
+
+	s.grpcServer = grpcapi.NewServer(s.grpcConfig())
+	return nil
+}
+
+// grpcConfig returns the configuration of the gRPC services. Without a
+// database the repositories are left nil and the services answer Internal.
+func (s *Server) grpcConfig() grpcapi.Config {
+	config := grpcapi.Config{
+		AccountService:     s.accountService,
+		TransactionService: s.transactionService,
+		Feed:               s.feed,
+		Catalog:            s.catalog,
+		Authenticator:      s.authenticator,
+		RateLimitStore:     s.rateLimitStore,
+		RateLimits:         grpcRateLimits(s.rateLimits),
+	}
+	if s.repoFactory != nil {
+		config.Accounts = s.repoFactory.AccountRepo
+		config.Transactions = s.repoFactory.TransactionRepo
+	}
+	return config
+}
+
+// GetGRPCPort returns the port of the gRPC server
+func (s *Server) GetGRPCPort() string {
+	return s.grpcPort
+}
+
+// startGRPC serves gRPC on GRPC_PORT in the background
+func (s *Server) startGRPC() error {
+	if s.grpcServer == nil {
+		logger.Info("gRPC server disabled", nil)
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", ":"+s.grpcPort)
+	if err != nil {
+		logger.Error("Failed to listen for gRPC", err)
+		return err
+	}
+
+	logger.Info("gRPC server starting", map[string]interface{}{
+		"port": s.grpcPort,
+	})
+	go func() {
+		if err := s.grpcServer.Serve(listener); err != nil {
+			logger.Error("gRPC server stopped", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"strconv"
+
+	"github.com/gcalvocr/go-testing/bankpb"
+	"github.com/gcalvocr/go-testing/grpcapi"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/middleware"
+	"github.com/gcalvocr/go-testing/ratelimit"
+)
+
+// rateLimitConfigFromEnv builds the per-route rate limits. Creating
+// transactions and transfers moves money, so it gets a stricter limit than reads. Failed
+// authentications get a budget of their own per IP.
+func rateLimitConfigFromEnv() middleware.RateLimitConfig {
+	return middleware.RateLimitConfig{
+		Default: ratelimit.PerSecond(
+			getEnvFloat("RATE_LIMIT_RPS", 10),
+			getEnvInt("RATE_LIMIT_BURST", 20)),
+		Routes: map[string]ratelimit.Limit{
+			"POST /transactions": ratelimit.PerMinute(
+				getEnvFloat("RATE_LIMIT_TRANSACTIONS_PER_MINUTE", 30),
+				getEnvInt("RATE_LIMIT_TRANSACTIONS_BURST", 5)),
+			"POST /transfers": ratelimit.PerMinute(
+				getEnvFloat("RATE_LIMIT_TRANSACTIONS_PER_MINUTE", 30),
+				getEnvInt("RATE_LIMIT_TRANSACTIONS_BURST", 5)),
+			"POST /transactions/batch": ratelimit.PerMinute(
+				getEnvFloat("RATE_LIMIT_BATCHES_PER_MINUTE", 5),
+				getEnvInt("RATE_LIMIT_BATCHES_BURST", 2)),
+			// Load balancer probes are never throttled
+			"GET /health": {},
+		},
+		AuthFailures: ratelimit.PerMinute(
+			getEnvFloat("RATE_LIMIT_AUTH_FAILURES_PER_MINUTE", 10),
+			getEnvInt("RATE_LIMIT_AUTH_FAILURES_BURST", 10)),
+		TrustForwardedFor: getEnv("RATE_LIMIT_TRUST_PROXY", "false") == "true",
+	}
+}
+
+// grpcRateLimits applies the REST limits to the RPCs of the same operations,
+// so calling the gRPC port gets no more than calling the routes
+func grpcRateLimits(config middleware.RateLimitConfig) grpcapi.RateLimits {
+	methods := make(map[string]ratelimit.Limit)
+	if limit, ok := config.Routes["POST /transactions"]; ok {
+		methods[bankpb.TransactionService_PostTransaction_FullMethodName] = limit
+	}
+	return grpcapi.RateLimits{
+		Default:      config.Default,
+		Methods:      methods,
+		AuthFailures: config.AuthFailures,
+	}
+}
+
+// bodyLimitsFromEnv caps request bodies and only accepts JSON, except for batch imports
+func bodyLimitsFromEnv() (middleware.BodyLimit, map[string]middleware.BodyLimit) {
+	jsonOnly := []string{"application/json"}
+
+	defaultLimit := middleware.BodyLimit{
+		MaxBytes:     int64(getEnvInt("MAX_BODY_BYTES", 1<<20)),
+		ContentTypes: jsonOnly,
+	}
+	overrides := map[string]middleware.BodyLimit{
+		"POST /transactions": {
+			MaxBytes:     int64(getEnvInt("MAX_TRANSACTION_BODY_BYTES", 16<<10)),
+			ContentTypes: jsonOnly,
+		},
+		// Batch imports also accept CSV, as a body or a multipart upload
+		"POST /transactions/batch": {
+			MaxBytes:     int64(getEnvInt("MAX_BATCH_BODY_BYTES", 5<<20)),
+			ContentTypes: []string{"application/json", "text/csv", "multipart/form-data"},
+		},
+	}
+	return defaultLimit, overrides
+}
+
+// getEnvInt gets an integer environment variable with fallback
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(getEnv(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		logger.Warn("Invalid integer environment variable, using default", map[string]interface{}{
+			"key":     key,
+			"default": defaultValue,
+		})
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvFloat gets a float environment variable with fallback
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(getEnv(key, strconv.FormatFloat(defaultValue, 'f', -1, 64)), 64)
+	if err != nil {
+		logger.Warn("Invalid number environment variable, using default", map[string]interface{}{
+			"key":     key,
+			"default": defaultValue,
+		})
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,478 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/handlers"
+	"github.com/gcalvocr/go-testing/openapi"
+	"github.com/gcalvocr/go-testing/product"
+)
+
+// Route is an API route: the handler plus the metadata that documents it.
+// The route table is the single source for the mux registrations and the
+// OpenAPI document served at /openapi.json.
+type Route struct {
+	openapi.Operation
+	Handler http.HandlerFunc
+}
+
+// apiInfo describes the API in the OpenAPI document
+var apiInfo = openapi.Info{
+	Title:       "Bank API",
+	Version:     "1.0.0",
+	Description: "REST API for bank operations with PostgreSQL and MongoDB support",
+}
+
+// pageQuery documents the paging of the lists served by the gRPC gateway
+var pageQuery = []openapi.Parameter{
+	{Name: "page_size", Description: "Maximum number of items, up to 500. Without it the whole list is returned."},
+	{Name: "page_token", Description: "The X-Next-Page-Token of the previous page"},
+}
+
+// routes returns the route table of the API
+func (s *Server) routes() []Route {
+	return []Route{
+		// Documentation
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/", Tag: "Documentation", Public: true,
+				Summary:  "API documentation page",
+				Response: "", ResponseContentType: "text/html",
+			},
+			Handler: handlers.IndexHandler,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/docs", Tag: "Documentation", Public: true,
+				Summary:  "Interactive API reference rendered from the OpenAPI document",
+				Response: "", ResponseContentType: "text/html",
+			},
+			Handler: handlers.DocsHandler,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/openapi.json", Tag: "Documentation", Public: true,
+				Summary:  "OpenAPI 3.1 document of the API",
+				Response: map[string]interface{}{},
+			},
+			Handler: handlers.OpenAPIHandler,
+		},
+
+		// Health check endpoint
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/health", Tag: "Health", Public: true,
+				Summary:  "API health status",
+				Response: "", ResponseContentType: "text/plain",
+			},
+			Handler: handlers.HealthCheckHandler,
+		},
+
+		// Account products
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/products", Tag: "Products",
+				Summary: "List the account products",
+				Description: "The interest rate, day count convention and monthly fee of each product. " +
+					"Interest and fees are posted monthly by the accrual batch.",
+				Response: []product.Product{},
+			},
+			Handler: handlers.GetProducts,
+		},
+
+		// Account routes
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/accounts", Tag: "Accounts",
+				Summary: "List accounts",
+				Description: "Tellers and admins see every account, customers only the accounts they own, in the order they " +
+					"were opened. With page_size the list is paged and the X-Next-Page-Token header carries the token of " +
+					"the next page.",
+				Query:    pageQuery,
+				Response: []dto.AccountResponse{},
+				Errors:   []int{http.StatusBadRequest, http.StatusForbidden, http.StatusInternalServerError},
+			},
+			Handler: s.gateway.ServeHTTP,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "POST", Path: "/accounts", Tag: "Accounts",
+				Summary:       "Create an account",
+				Description:   "The account is owned by the caller unless a teller or admin sets owner_id. The product defaults to checking.",
+				Request:       dto.CreateAccountRequest{},
+				Response:      dto.AccountResponse{},
+				SuccessStatus: http.StatusCreated,
+				Errors:        []int{http.StatusBadRequest, http.StatusForbidden, http.StatusInternalServerError},
+			},
+			Handler: s.gateway.ServeHTTP,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/accounts/{id}", Tag: "Accounts",
+				Summary:  "Get an account by ID",
+				Response: dto.AccountResponse{},
+				Errors:   []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: s.gateway.ServeHTTP,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "PUT", Path: "/accounts/{id}/limits", Tag: "Accounts",
+				Summary: "Replace the posting limits of an account",
+				Description: "Admins only. The overdraft limit lets withdrawals take the balance below zero, the minimum balance " +
+					"keeps it above a floor, the daily withdrawal limit caps the withdrawals of a UTC day and the maximum " +
+					"transaction amount caps single deposits and withdrawals. Omitted limits don't apply.",
+				Request:  dto.AccountLimits{},
+				Response: dto.AccountResponse{},
+				Errors:   []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: handlers.UpdateAccountLimits,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/accounts/{id}/statement", Tag: "Accounts",
+				Summary: "Export the statement of an account",
+				Description: "Opening and closing balances for the period and every transaction with the running balance. " +
+					"The closing record carries the period totals, which agree with the transaction summary.",
+				Query: []openapi.Parameter{
+					{Name: "from", Description: "Start of the period, a date (YYYY-MM-DD) or RFC 3339 timestamp. Defaults to the account opening."},
+					{Name: "to", Description: "End of the period, a date (inclusive) or RFC 3339 timestamp (exclusive). Defaults to now."},
+					{Name: "format", Description: "Export format, defaults to csv", Enum: []string{"csv", "jsonl", "ofx"}},
+				},
+				Response:             "",
+				ResponseContentTypes: []string{"text/csv", "application/x-ndjson", "application/x-ofx"},
+				Errors:               []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: handlers.GetAccountStatement,
+		},
+
+		// Transaction routes
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/accounts/{account_id}/transactions", Tag: "Transactions",
+				Summary: "List the transactions of an account",
+				Description: "Newest first. With page_size the list is paged and the X-Next-Page-Token header carries " +
+					"the token of the next page.",
+				Query:    pageQuery,
+				Response: []dto.TransactionResponse{},
+				Errors:   []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: s.gateway.ServeHTTP,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/transactions/{id}", Tag: "Transactions",
+				Summary:  "Get a transaction by ID",
+				Response: dto.TransactionResponse{},
+				Errors:   []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: s.gateway.ServeHTTP,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "POST", Path: "/transactions", Tag: "Transactions",
+				Summary: "Create a deposit or withdrawal",
+				Description: "A transaction the account's limits don't allow is rejected with 422 and the error code of the " +
+					"limit: insufficient_funds, overdraft_limit_exceeded, minimum_balance_not_met, " +
+					"daily_withdrawal_limit_exceeded or transaction_limit_exceeded.",
+				Request:       dto.CreateTransactionRequest{},
+				Response:      dto.TransactionResponse{},
+				SuccessStatus: http.StatusCreated,
+				Errors:        []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+				CodedErrors:   []int{http.StatusUnprocessableEntity},
+			},
+			Handler: s.gateway.ServeHTTP,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "POST", Path: "/transactions/batch", Tag: "Transactions",
+				Summary: "Import a batch of deposits and withdrawals",
+				Description: "Accepts a JSON array of transactions, a CSV body or a multipart upload with the CSV in the file field. " +
+					"CSV files need a header row naming the account_id, amount and type columns. Rows are validated like " +
+					"single transactions and applied in order. Every row gets a result in the report. The status is " +
+					"201 when every row was applied, 207 when a best-effort batch was applied partially and 422 otherwise.",
+				Query: []openapi.Parameter{
+					{Name: "mode", Description: "all_or_nothing (default) applies every row or none, best_effort applies the rows that succeed", Enum: []string{dto.BatchAllOrNothing, dto.BatchBestEffort}},
+				},
+				Request:             []dto.CreateTransactionRequest{},
+				RequestContentTypes: []string{"application/json", "text/csv", "multipart/form-data"},
+				Response:            dto.BatchTransactionResponse{},
+				SuccessStatus:       http.StatusCreated,
+				Errors:              []int{http.StatusBadRequest, http.StatusInternalServerError},
+			},
+			Handler: handlers.CreateTransactionBatch,
+		},
+
+		// Transfer routes
+		{
+			Operation: openapi.Operation{
+				Method: "POST", Path: "/transfers", Tag: "Transfers",
+				Summary: "Transfer money between two accounts",
+				Description: "Posts a withdrawal from from_account_id and a deposit into to_account_id in one database " +
+					"transaction, with a TransferCompleted event for each account. The principal must be allowed to transact on the source " +
+					"account and both accounts must have the same currency. The withdrawal is checked against the limits " +
+					"of the source account and rejected with 422 like any other.",
+				Request:       dto.CreateTransferRequest{},
+				Response:      dto.TransferResponse{},
+				SuccessStatus: http.StatusCreated,
+				Errors:        []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+				CodedErrors:   []int{http.StatusUnprocessableEntity},
+			},
+			Handler: handlers.CreateTransfer,
+		},
+
+		// Scheduled transaction routes
+		{
+			Operation: openapi.Operation{
+				Method: "POST", Path: "/scheduled-transactions", Tag: "Scheduled Transactions",
+				Summary: "Schedule a one-off or recurring transaction",
+				Description: "once runs at start_at, daily, weekly and monthly repeat from start_at (monthly runs move to the " +
+					"last day of shorter months) and cron runs at the times matching a five field cron expression in UTC. " +
+					"Each run is posted like a single transaction and recorded with its outcome.",
+				Request:       dto.CreateScheduledTransactionRequest{},
+				Response:      dto.ScheduledTransactionDTO{},
+				SuccessStatus: http.StatusCreated,
+				Errors:        []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: handlers.CreateScheduledTransaction,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/accounts/{account_id}/scheduled-transactions", Tag: "Scheduled Transactions",
+				Summary:  "List the scheduled transactions of an account",
+				Response: []dto.ScheduledTransactionDTO{},
+				Errors:   []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: handlers.GetScheduledTransactionsByAccountID,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/scheduled-transactions/{id}", Tag: "Scheduled Transactions",
+				Summary:  "Get a scheduled transaction",
+				Response: dto.ScheduledTransactionDTO{},
+				Errors:   []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: handlers.GetScheduledTransaction,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "PATCH", Path: "/scheduled-transactions/{id}", Tag: "Scheduled Transactions",
+				Summary:     "Change, pause or resume a scheduled transaction",
+				Description: "Runs missed while paused are skipped, a resumed schedule continues with its next run.",
+				Request:     dto.UpdateScheduledTransactionRequest{},
+				Response:    dto.ScheduledTransactionDTO{},
+				Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: handlers.UpdateScheduledTransaction,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "DELETE", Path: "/scheduled-transactions/{id}", Tag: "Scheduled Transactions",
+				Summary:       "Cancel a scheduled transaction",
+				SuccessStatus: http.StatusNoContent,
+				Errors:        []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: handlers.DeleteScheduledTransaction,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/scheduled-transactions/{id}/runs", Tag: "Scheduled Transactions",
+				Summary:  "List the runs of a scheduled transaction, latest first",
+				Response: []dto.ScheduledTransactionRunDTO{},
+				Errors:   []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: handlers.GetScheduledTransactionRuns,
+		},
+
+		// Hold routes
+		{
+			Operation: openapi.Operation{
+				Method: "POST", Path: "/holds", Tag: "Holds",
+				Summary: "Place a hold on an account",
+				Description: "Reserves funds until they are captured, released or the hold expires at expires_at " +
+					"(seven days by default). Active holds reduce the available balance, which withdrawals and new " +
+					"holds are checked against, but not the ledger balance.",
+				Request:       dto.CreateHoldRequest{},
+				Response:      dto.HoldDTO{},
+				SuccessStatus: http.StatusCreated,
+				Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound,
+					http.StatusUnprocessableEntity, http.StatusInternalServerError},
+				CodedErrors: []int{http.StatusUnprocessableEntity},
+			},
+			Handler: handlers.CreateHold,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/accounts/{account_id}/holds", Tag: "Holds",
+				Summary:  "List the holds of an account, latest first",
+				Response: []dto.HoldDTO{},
+				Errors:   []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: handlers.GetHoldsByAccountID,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/holds/{id}", Tag: "Holds",
+				Summary:  "Get a hold",
+				Response: dto.HoldDTO{},
+				Errors:   []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: handlers.GetHold,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "POST", Path: "/holds/{id}/capture", Tag: "Holds",
+				Summary: "Capture a hold as a withdrawal",
+				Description: "Captures the full hold unless amount is set, the rest of a partial capture is released. " +
+					"The funds were reserved by the hold, so the capture is not checked against the account limits again. " +
+					"Only the principal that placed the hold, tellers and admins may capture it.",
+				Request:  dto.CaptureHoldRequest{},
+				Response: dto.CaptureHoldResponse{},
+				Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound,
+					http.StatusConflict, http.StatusInternalServerError},
+			},
+			Handler: handlers.CaptureHold,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "POST", Path: "/holds/{id}/release", Tag: "Holds",
+				Summary:     "Release a hold",
+				Description: "Only the principal that placed the hold, tellers and admins may release it.",
+				Response:    dto.HoldDTO{},
+				Errors:      []int{http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+			},
+			Handler: handlers.ReleaseHold,
+		},
+
+		// Webhook routes, admins only
+		{
+			Operation: openapi.Operation{
+				Method: "POST", Path: "/webhooks", Tag: "Webhooks",
+				Summary: "Subscribe a URL to events",
+				Description: "Events of the listed types, optionally of a single account, are posted to url from the " +
+					"moment the subscription is created. Each delivery is signed: X-Webhook-Signature is sha256= and the " +
+					"hex HMAC-SHA256 of X-Webhook-Timestamp, a dot and the body, keyed with the secret. A random secret is " +
+					"generated when none is given; this response is the only one that includes it. Failed deliveries are " +
+					"retried with exponential backoff and are dead after the last attempt.",
+				Request:       dto.CreateWebhookSubscriptionRequest{},
+				Response:      dto.WebhookSubscriptionDTO{},
+				SuccessStatus: http.StatusCreated,
+				Errors:        []int{http.StatusBadRequest, http.StatusForbidden, http.StatusInternalServerError},
+			},
+			Handler: handlers.CreateWebhookSubscription,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/webhooks", Tag: "Webhooks",
+				Summary:  "List the webhook subscriptions",
+				Response: []dto.WebhookSubscriptionDTO{},
+				Errors:   []int{http.StatusForbidden, http.StatusInternalServerError},
+			},
+			Handler: handlers.GetWebhookSubscriptions,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/webhooks/{id}", Tag: "Webhooks",
+				Summary:  "Get a webhook subscription",
+				Response: dto.WebhookSubscriptionDTO{},
+				Errors:   []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: handlers.GetWebhookSubscription,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "DELETE", Path: "/webhooks/{id}", Tag: "Webhooks",
+				Summary:       "Delete a webhook subscription",
+				Description:   "Pending deliveries of the subscription turn dead when they are next attempted.",
+				SuccessStatus: http.StatusNoContent,
+				Errors:        []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: handlers.DeleteWebhookSubscription,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/webhooks/{id}/deliveries", Tag: "Webhooks",
+				Summary: "Get the delivery log of a webhook subscription, latest first",
+				Query: []openapi.Parameter{
+					{Name: "status", Description: "Only deliveries with this status: pending, delivered or dead"},
+				},
+				Response: []dto.WebhookDeliveryDTO{},
+				Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound,
+					http.StatusInternalServerError},
+			},
+			Handler: handlers.GetWebhookDeliveries,
+		},
+		{
+			Operation: openapi.Operation{
+				Method: "POST", Path: "/webhooks/{id}/deliveries/{delivery_id}/redeliver", Tag: "Webhooks",
+				Summary:       "Redeliver a webhook delivery",
+				Description:   "Queues the delivery to be sent again right away with a fresh set of attempts, whatever its status.",
+				Response:      dto.WebhookDeliveryDTO{},
+				SuccessStatus: http.StatusAccepted,
+				Errors:        []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: handlers.RedeliverWebhook,
+		},
+
+		// Event stream route
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/events/stream", Tag: "Events",
+				Summary: "Stream account and transaction events as Server-Sent Events",
+				Description: "Pushes AccountCreated, TransactionPosted and TransferCompleted events as they are committed, each with its " +
+					"outbox sequence as id and its type as event. Customers must pass one of their accounts as " +
+					"account_id, staff may stream every account. Clients reconnecting with a Last-Event-ID header " +
+					"first receive the events they missed. A comment is sent every 15 seconds on idle streams.",
+				Query: []openapi.Parameter{
+					{Name: "account_id", Description: "Only the events of this account"},
+					{Name: "last_event_id", Description: "Resume after this event, for clients that can't send Last-Event-ID"},
+				},
+				Response:            "",
+				ResponseContentType: "text/event-stream",
+				Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound,
+					http.StatusInternalServerError},
+			},
+			Handler: handlers.StreamEvents,
+		},
+
+		// Audit log route, admins only
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/audit", Tag: "Audit",
+				Summary: "Query the audit log, latest first",
+				Description: "Every change to accounts, transactions, holds, scheduled transactions, webhooks and API keys " +
+					"is recorded with the actor, the request ID and the resource before and after it. The entries of " +
+					"each resource are hash-chained; verify the chains with the verify-audit-log command.",
+				Query: []openapi.Parameter{
+					{Name: "resource_type", Description: "account, transaction, hold, scheduled_transaction, scheduled_transaction_run, " +
+						"webhook_subscription, webhook_delivery or api_key"},
+					{Name: "resource_id", Description: "ID of the resource"},
+					{Name: "actor", Description: "ID of the principal that made the changes, system for changes made without one"},
+					{Name: "action", Description: "e.g. account.update_balance or transaction.delete"},
+					{Name: "from", Description: "Start of the period (YYYY-MM-DD or RFC 3339), inclusive"},
+					{Name: "to", Description: "End of the period (YYYY-MM-DD, inclusive, or RFC 3339, exclusive), defaults to now"},
+					{Name: "limit", Description: "Maximum number of entries, 1 to 1000 (default 100)"},
+				},
+				Response: []dto.AuditEntryDTO{},
+				Errors:   []int{http.StatusBadRequest, http.StatusForbidden, http.StatusInternalServerError},
+			},
+			Handler: handlers.GetAuditLog,
+		},
+
+		// Exchange rate route
+		{
+			Operation: openapi.Operation{
+				Method: "GET", Path: "/exchange", Tag: "Exchange Rates",
+				Summary: "Get the exchange rate between two currencies",
+				Query: []openapi.Parameter{
+					{Name: "from", Description: "ISO 4217 code of the source currency", Required: true},
+					{Name: "to", Description: "ISO 4217 code of the target currency", Required: true},
+				},
+				Response: map[string]float64{},
+				Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			Handler: handlers.GetExchangeRate,
+		},
+	}
+}
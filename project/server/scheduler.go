@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcalvocr/go-testing/handlers"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/scheduler"
+	"github.com/gcalvocr/go-testing/service"
+)
+
+// newScheduler creates the worker posting scheduled transactions, polling
+// every SCHEDULER_INTERVAL_SECONDS (default 30)
+func (s *Server) newScheduler() *scheduler.Worker {
+	worker := scheduler.NewWorker(s.repoFactory.ScheduledRepo, s.transactionService, s.repoFactory.TxManager)
+	worker.SetClock(s.clock)
+	worker.SetInterval(time.Duration(getEnvInt("SCHEDULER_INTERVAL_SECONDS", 30)) * time.Second)
+	return worker
+}
+
+// startScheduler runs the scheduler and the hold expiry in the background
+// until ctx is cancelled, unless SCHEDULER_ENABLED is false. Several instances may run
+// the scheduler against the same database.
+func (s *Server) startScheduler(ctx context.Context) {
+	if s.scheduler == nil {
+		return
+	}
+	if getEnv("SCHEDULER_ENABLED", "true") == "false" {
+		logger.Info("Transaction scheduler disabled", nil)
+		return
+	}
+	go s.scheduler.Run(ctx)
+	go s.expireHolds(ctx, time.Duration(getEnvInt("SCHEDULER_INTERVAL_SECONDS", 30))*time.Second)
+}
+
+// expireHolds marks the holds past their expiry as expired every interval
+// until ctx is cancelled. Expired holds stop reducing the available balance
+// at their expiry either way, this only updates their status.
+func (s *Server) expireHolds(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.transactionService.ExpireHolds(ctx)
+			if err != nil {
+				logger.Error("Failed to expire holds", err)
+				continue
+			}
+			if expired > 0 {
+				logger.Info("Holds expired", map[string]interface{}{
+					"count": expired,
+				})
+			}
+		}
+	}
+}
+
+// SetClock replaces the clock the scheduler uses to decide which scheduled
+// transactions are due and the API computes their next run with
+func (s *Server) SetClock(clock scheduler.Clock) {
+	s.clock = clock
+	handlers.SetScheduleClock(clock)
+	if s.scheduler != nil {
+		s.scheduler.SetClock(clock)
+	}
+}
+
+// Scheduler returns the worker posting scheduled transactions, nil before InitializeDatabase
+func (s *Server) Scheduler() *scheduler.Worker {
+	return s.scheduler
+}
+
+// AccountService returns the service opening accounts, nil before InitializeDatabase
+func (s *Server) AccountService() *service.AccountService {
+	return s.accountService
+}
+
+// TransactionService returns the service applying transactions, nil before InitializeDatabase
+func (s *Server) TransactionService() *service.TransactionService {
+	return s.transactionService
+}
@@ -1,54 +1,130 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 
+	"github.com/gcalvocr/go-testing/audit"
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/events"
+	"github.com/gcalvocr/go-testing/feed"
+	"github.com/gcalvocr/go-testing/grpcapi"
 	"github.com/gcalvocr/go-testing/handlers"
 	"github.com/gcalvocr/go-testing/logger"
 	"github.com/gcalvocr/go-testing/middleware"
+	"github.com/gcalvocr/go-testing/openapi"
+	"github.com/gcalvocr/go-testing/product"
+	"github.com/gcalvocr/go-testing/ratelimit"
 	"github.com/gcalvocr/go-testing/repository"
+	"github.com/gcalvocr/go-testing/scheduler"
+	"github.com/gcalvocr/go-testing/service"
+	"github.com/gcalvocr/go-testing/webhook"
 	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
 )
 
 // Server holds the server configuration
 type Server struct {
 	router      *mux.Router
 	port        string
+	grpcPort    string
 	repoFactory *repository.RepositoryFactory
+
+	// authenticator is created by SetupRoutes and shared with the gRPC server
+	authenticator *auth.Authenticator
+	catalog       product.Catalog
+	// grpcServer is nil until SetupGRPC and when gRPC is disabled
+	grpcServer *grpc.Server
+	// gateway transcodes the account and transaction routes to the gRPC
+	// services, it is created by SetupRoutes
+	gateway http.Handler
+
+	// rateLimitStore holds the token buckets of the rate limiter, shared by
+	// the REST middleware and the gRPC interceptors
+	rateLimitStore ratelimit.Store
+	// rateLimits are read from the environment by SetupRoutes
+	rateLimits middleware.RateLimitConfig
+
+	// openAPIDoc is generated from the route table by SetupRoutes
+	openAPIDoc *openapi.Document
+
+	// the services, scheduler and relays are created by InitializeDatabase
+	accountService     *service.AccountService
+	transactionService *service.TransactionService
+	scheduler          *scheduler.Worker
+	clock              scheduler.Clock
+	// relay is nil when EVENT_SINK is not set
+	relay *events.Relay
+	// feed streams the outbox to the clients of GET /events/stream
+	feed *feed.Feed
+	// webhookRelay and webhookWorker are nil when webhooks are disabled
+	webhookRelay  *events.Relay
+	webhookWorker *webhook.Worker
 }
 
 // NewServer creates a new server instance
 func NewServer() *Server {
 	return &Server{
-		router: mux.NewRouter(),
-		port:   getEnv("PORT", "8080"),
+		router:         mux.NewRouter(),
+		port:           getEnv("PORT", "8080"),
+		grpcPort:       getEnv("GRPC_PORT", "9090"),
+		rateLimitStore: ratelimit.NewMemoryStore(),
+		clock:          scheduler.SystemClock{},
 	}
 }
 
 // SetupRoutes configures all the API routes
-func (s *Server) SetupRoutes() {
-	// Add logging middleware
+func (s *Server) SetupRoutes() error {
+	authenticator, err := auth.NewAuthenticatorFromEnv(s.apiKeyRepository())
+	if err != nil {
+		logger.Error("Failed to configure authentication", err)
+		return err
+	}
+	s.authenticator = authenticator
+
+	// Add request ID and logging middleware
+	s.router.Use(middleware.RequestIDMiddleware)
 	s.router.Use(middleware.LoggingMiddleware)
 
-	// Root route - API documentation
-	s.router.HandleFunc("/", handlers.IndexHandler).Methods("GET")
+	// Cap request bodies and throttle failed authentications per IP before doing any authentication work
+	s.rateLimits = rateLimitConfigFromEnv()
+	s.router.Use(middleware.BodyLimitMiddleware(bodyLimitsFromEnv()))
+	s.router.Use(middleware.AuthFailureLimitMiddleware(s.rateLimitStore, s.rateLimits))
 
-	// Health check endpoint
-	s.router.HandleFunc("/health", handlers.HealthCheckHandler).Methods("GET")
+	// The account and transaction routes are served by the gRPC services
+	s.gateway, err = grpcapi.NewGateway(s.grpcConfig())
+	if err != nil {
+		logger.Error("Failed to configure the gRPC gateway", err)
+		return err
+	}
+
+	routes := s.routes()
+
+	// Every route except the documentation pages and health check requires authentication
+	var publicRoutes []string
+	for _, route := range routes {
+		if route.Public {
+			publicRoutes = append(publicRoutes, route.Path)
+		}
+	}
+	s.router.Use(middleware.AuthMiddleware(authenticator, publicRoutes...))
+	// Authenticated clients are throttled per principal, public routes per IP
+	s.router.Use(middleware.RateLimitMiddleware(s.rateLimitStore, s.rateLimits))
 
-	// Account routes
-	s.router.HandleFunc("/accounts", handlers.GetAccounts).Methods("GET")
-	s.router.HandleFunc("/accounts", handlers.CreateAccount).Methods("POST")
-	s.router.HandleFunc("/accounts/{id}", handlers.GetAccountByID).Methods("GET")
+	operations := make([]openapi.Operation, len(routes))
+	for i, route := range routes {
+		s.router.HandleFunc(route.Path, route.Handler).Methods(route.Method)
+		operations[i] = route.Operation
+	}
 
-	// Transaction routes
-	s.router.HandleFunc("/accounts/{account_id}/transactions", handlers.GetTransactionsByAccountID).Methods("GET")
-	s.router.HandleFunc("/transactions", handlers.CreateTransaction).Methods("POST")
+	// The OpenAPI document is generated from the same route table
+	s.openAPIDoc = openapi.Generate(apiInfo, operations)
+	handlers.SetOpenAPIDocument(s.openAPIDoc)
+	handlers.SetScheduleClock(s.clock)
 
-	// Exchange rate route
-	s.router.HandleFunc("/exchange", handlers.GetExchangeRate).Methods("GET")
+	return nil
 }
 
 // InitializeDatabase sets up the database connection and repositories
@@ -76,7 +152,7 @@ func (s *Server) InitializeDatabase() error {
 		})
 
 	case repository.MongoDB:
-		connectionString = getEnv("MONGODB_URI", "mongodb://localhost:27017")
+		connectionString = getEnv("MONGODB_URI", "mongodb://localhost:27017/?directConnection=true")
 
 		logger.Info("Connecting to MongoDB database", map[string]interface{}{
 			"uri": connectionString,
@@ -92,26 +168,73 @@ func (s *Server) InitializeDatabase() error {
 		return err
 	}
 
+	// Every change to accounts, transactions, holds, schedules, webhooks and
+	// API keys goes through the audit log
+	repos := s.repoFactory
+	repos.AccountRepo = audit.Accounts(repos.AccountRepo, repos.AuditRepo, repos.TxManager)
+	repos.TransactionRepo = audit.Transactions(repos.TransactionRepo, repos.AuditRepo, repos.TxManager)
+	repos.HoldRepo = audit.Holds(repos.HoldRepo, repos.AuditRepo, repos.TxManager)
+	repos.ScheduledRepo = audit.Schedules(repos.ScheduledRepo, repos.AuditRepo, repos.TxManager)
+	repos.WebhookRepo = audit.Webhooks(repos.WebhookRepo, repos.AuditRepo, repos.TxManager)
+	repos.APIKeyRepo = audit.APIKeys(repos.APIKeyRepo, repos.AuditRepo, repos.TxManager)
+
+	s.transactionService = service.NewTransactionService(
+		s.repoFactory.AccountRepo, s.repoFactory.TransactionRepo, s.repoFactory.TxManager)
+	s.transactionService.SetHoldRepository(s.repoFactory.HoldRepo)
+	s.transactionService.SetOutbox(s.repoFactory.OutboxRepo)
+	s.accountService = service.NewAccountService(s.repoFactory.AccountRepo, s.repoFactory.TxManager)
+	s.accountService.SetOutbox(s.repoFactory.OutboxRepo)
+	s.scheduler = s.newScheduler()
+
+	if s.relay, err = s.newRelay(); err != nil {
+		logger.Error("Failed to configure the event relay", err)
+		return err
+	}
+	s.webhookRelay, s.webhookWorker = s.newWebhooks()
+	s.feed = feed.NewFeed(s.repoFactory.OutboxRepo, s.repoFactory.OutboxFeed, feed.NewBroker(feed.DefaultBuffer))
+
 	logger.Info("Database initialized successfully", map[string]interface{}{
 		"db_type": dbType,
 	})
 	return nil
 }
 
-// Start starts the HTTP server
+// Start starts the scheduler, the event relays, the webhook worker, the event
+// feed, the gRPC server and the HTTP server
 func (s *Server) Start() error {
 	logger.Info("Server starting", map[string]interface{}{
 		"port": s.port,
 	})
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.startScheduler(ctx)
+	s.startRelay(ctx)
+	s.startWebhooks(ctx)
+	go s.feed.Run(ctx)
+	if err := s.startGRPC(); err != nil {
+		return err
+	}
+
 	return http.ListenAndServe(":"+s.port, s.router)
 }
 
+// SetRateLimitStore replaces the in-process rate limiter state, e.g. with a shared store.
+// It must be called before SetupRoutes.
+func (s *Server) SetRateLimitStore(store ratelimit.Store) {
+	s.rateLimitStore = store
+}
+
 // GetRouter returns the router (useful for testing)
 func (s *Server) GetRouter() *mux.Router {
 	return s.router
 }
 
+// OpenAPIDocument returns the OpenAPI document generated by SetupRoutes
+func (s *Server) OpenAPIDocument() *openapi.Document {
+	return s.openAPIDoc
+}
+
 // GetPort returns the server port
 func (s *Server) GetPort() string {
 	return s.port
@@ -122,6 +245,19 @@ func (s *Server) GetRepositoryFactory() *repository.RepositoryFactory {
 	return s.repoFactory
 }
 
+// EventFeed returns the feed of GET /events/stream, nil before InitializeDatabase
+func (s *Server) EventFeed() *feed.Feed {
+	return s.feed
+}
+
+// apiKeyRepository returns the API key repository, or nil before the database is initialized
+func (s *Server) apiKeyRepository() repository.APIKeyRepository {
+	if s.repoFactory == nil {
+		return nil
+	}
+	return s.repoFactory.APIKeyRepo
+}
+
 // getEnv gets environment variable with fallback
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
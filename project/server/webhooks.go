@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/gcalvocr/go-testing/events"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/webhook"
+)
+
+// webhookConsumer is the outbox consumer queuing webhook deliveries
+const webhookConsumer = "webhooks"
+
+// newWebhooks creates the relay queuing a delivery of each event per matching
+// subscription and the worker sending them, unless WEBHOOKS_ENABLED is
+// false. Both poll every WEBHOOK_INTERVAL_SECONDS; a delivery gets
+// WEBHOOK_MAX_ATTEMPTS attempts, the first retry after
+// WEBHOOK_BACKOFF_SECONDS.
+func (s *Server) newWebhooks() (*events.Relay, *webhook.Worker) {
+	if getEnv("WEBHOOKS_ENABLED", "true") == "false" {
+		return nil, nil
+	}
+	interval := time.Duration(getEnvInt("WEBHOOK_INTERVAL_SECONDS", 5)) * time.Second
+
+	relay := events.NewRelay(s.repoFactory.OutboxRepo, webhookConsumer, webhook.NewDispatcher(s.repoFactory.WebhookRepo))
+	relay.SetInterval(interval)
+
+	worker := webhook.NewWorker(s.repoFactory.WebhookRepo, nil)
+	worker.SetInterval(interval)
+	worker.SetRetryPolicy(
+		getEnvInt("WEBHOOK_MAX_ATTEMPTS", webhook.DefaultMaxAttempts),
+		time.Duration(getEnvInt("WEBHOOK_BACKOFF_SECONDS", int(webhook.DefaultBackoff/time.Second)))*time.Second)
+	return relay, worker
+}
+
+// startWebhooks queues and sends webhook deliveries in the background until
+// ctx is cancelled
+func (s *Server) startWebhooks(ctx context.Context) {
+	if s.webhookRelay == nil {
+		logger.Info("Webhooks disabled", nil)
+		return
+	}
+	go s.webhookRelay.Run(ctx)
+	go s.webhookWorker.Run(ctx)
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+// AccountService opens accounts. The account and its AccountCreated event
+// are written atomically.
+type AccountService struct {
+	accounts  repository.AccountRepository
+	txManager repository.TxManager
+	// outbox is nil when no events are recorded
+	outbox repository.OutboxRepository
+}
+
+// NewAccountService creates an account service. Without a TxManager the
+// writes are not atomic.
+func NewAccountService(accounts repository.AccountRepository, txManager repository.TxManager) *AccountService {
+	return &AccountService{accounts: accounts, txManager: txManager}
+}
+
+// SetOutbox makes the service record an AccountCreated event for every account
+func (s *AccountService) SetOutbox(outbox repository.OutboxRepository) {
+	s.outbox = outbox
+}
+
+// Create stores a new account, the caller has checked it may open it
+func (s *AccountService) Create(ctx context.Context, account *dto.AccountDTO) error {
+	return withinTransaction(ctx, s.txManager, func(ctx context.Context) error {
+		if err := s.accounts.Create(ctx, account); err != nil {
+			logger.ErrorContext(ctx, "Failed to create account", err)
+			return err
+		}
+
+		return record(ctx, s.outbox, dto.EventAccountCreated, account.ID, dto.AccountCreatedEvent{
+			AccountID: account.ID,
+			Name:      account.Name,
+			OwnerID:   account.OwnerID,
+			Currency:  account.Currency,
+			Product:   account.Product,
+			Balance:   account.Balance,
+			CreatedAt: account.CreatedAt,
+		})
+	})
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingOutbox fails every append like a database error
+type failingOutbox struct{ fakeOutbox }
+
+func (o failingOutbox) Append(ctx context.Context, event *dto.EventDTO) error {
+	return errDatabase
+}
+
+func TestCreateAccountRecordsAccountCreated(t *testing.T) {
+	store := newFakeStore()
+	svc := NewAccountService(fakeAccounts{store}, store)
+	svc.SetOutbox(fakeOutbox{store})
+
+	err := svc.Create(context.Background(), &dto.AccountDTO{ID: "acc-1", Name: "Savings", OwnerID: "alice", Balance: 50})
+	require.NoError(t, err)
+
+	require.Len(t, store.events, 1)
+	assert.Equal(t, dto.EventAccountCreated, store.events[0].Type)
+	var payload dto.AccountCreatedEvent
+	require.NoError(t, json.Unmarshal(store.events[0].Payload, &payload))
+	assert.Equal(t, dto.AccountCreatedEvent{AccountID: "acc-1", Name: "Savings", OwnerID: "alice", Balance: 50}, payload)
+}
+
+func TestCreateAccountRollsBackWhenTheEventFails(t *testing.T) {
+	store := newFakeStore()
+	svc := NewAccountService(fakeAccounts{store}, store)
+	svc.SetOutbox(failingOutbox{fakeOutbox{store}})
+
+	err := svc.Create(context.Background(), &dto.AccountDTO{ID: "acc-1", Name: "Savings", OwnerID: "alice"})
+
+	assert.True(t, errors.Is(err, errDatabase))
+	assert.Empty(t, store.accounts, "the account is rolled back with its event")
+}
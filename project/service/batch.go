@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+)
+
+// MaxBatchRows caps the number of rows in a batch import
+const MaxBatchRows = 10000
+
+var (
+	// ErrEmptyBatch is returned for an upload without rows
+	ErrEmptyBatch = errors.New("batch contains no transactions")
+	// ErrBatchTooLarge is returned for an upload with more than MaxBatchRows rows
+	ErrBatchTooLarge = fmt.Errorf("batch exceeds %d transactions", MaxBatchRows)
+	// ErrInvalidBatchMode is returned for an unknown import mode
+	ErrInvalidBatchMode = errors.New("mode must be all_or_nothing or best_effort")
+
+	// errBatchRejected rolls back an all-or-nothing batch with failed rows
+	errBatchRejected = errors.New("batch rejected")
+)
+
+// BatchRow is a row of a batch upload
+type BatchRow struct {
+	Request dto.CreateTransactionRequest
+	// Err is set when the row could not be parsed
+	Err error
+}
+
+// ParseBatchMode parses an import mode, defaulting to all-or-nothing
+func ParseBatchMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return dto.BatchAllOrNothing, nil
+	case dto.BatchAllOrNothing, dto.BatchBestEffort:
+		return mode, nil
+	default:
+		return "", ErrInvalidBatchMode
+	}
+}
+
+// ReadJSONBatch reads a JSON array of CreateTransactionRequest objects. An
+// element that doesn't decode fails its row only.
+func ReadJSONBatch(r io.Reader) ([]BatchRow, error) {
+	decoder := json.NewDecoder(r)
+	if token, err := decoder.Token(); err != nil {
+		return nil, err
+	} else if token != json.Delim('[') {
+		return nil, errors.New("body must be a JSON array of transactions")
+	}
+
+	var rows []BatchRow
+	for decoder.More() {
+		if len(rows) == MaxBatchRows {
+			return nil, ErrBatchTooLarge
+		}
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, err
+		}
+		var row BatchRow
+		if err := json.Unmarshal(raw, &row.Request); err != nil {
+			row.Err = fmt.Errorf("invalid transaction: %w", err)
+		}
+		rows = append(rows, row)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	return rows, nil
+}
+
+// ReadCSVBatch reads a CSV upload whose header row names the account_id,
+// amount and type columns, in any order
+func ReadCSVBatch(r io.Reader) ([]BatchRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrEmptyBatch
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"account_id", "amount", "type"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV header is missing the %s column", name)
+		}
+	}
+
+	var rows []BatchRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == MaxBatchRows {
+			return nil, ErrBatchTooLarge
+		}
+		rows = append(rows, csvRow(record, columns, len(header)))
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	return rows, nil
+}
+
+func csvRow(record []string, columns map[string]int, width int) BatchRow {
+	if len(record) != width {
+		return BatchRow{Err: fmt.Errorf("row has %d fields, the header has %d", len(record), width)}
+	}
+
+	row := BatchRow{Request: dto.CreateTransactionRequest{
+		AccountID: strings.TrimSpace(record[columns["account_id"]]),
+		Type:      strings.TrimSpace(record[columns["type"]]),
+	}}
+	if amount := strings.TrimSpace(record[columns["amount"]]); amount != "" {
+		value, err := strconv.ParseFloat(amount, 64)
+		if err != nil {
+			row.Err = fmt.Errorf("amount %q is not a number", amount)
+			return row
+		}
+		row.Request.Amount = value
+	}
+	return row
+}
+
+// Import validates every row and applies the valid ones in row order, so the
+// balance checks of each row see the rows before it for the same account.
+//
+// In all-or-nothing mode nothing is applied when any row is invalid, and the
+// whole batch runs in one database transaction that is rolled back when a row
+// fails. In best-effort mode every row is applied in its own transaction and
+// failed rows are reported. An error is only returned when the database fails
+// during an all-or-nothing batch.
+func (s *TransactionService) Import(ctx context.Context, rows []BatchRow, mode string) (*dto.BatchTransactionResponse, error) {
+	report := &dto.BatchTransactionResponse{
+		Mode:    mode,
+		Total:   len(rows),
+		Results: make([]dto.BatchRowResult, len(rows)),
+	}
+
+	invalid := 0
+	for i, row := range rows {
+		report.Results[i] = dto.BatchRowResult{Row: i + 1, AccountID: row.Request.AccountID}
+		err := row.Err
+		if err == nil {
+			err = Validate(row.Request)
+		}
+		if err != nil {
+			report.Results[i].Status = dto.BatchRowInvalid
+			report.Results[i].Error = err.Error()
+			invalid++
+		}
+	}
+
+	var err error
+	switch {
+	case mode == dto.BatchAllOrNothing && invalid > 0:
+		// Nothing is attempted until every row is valid
+		markUnattempted(report, dto.BatchRowSkipped)
+	case mode == dto.BatchAllOrNothing:
+		err = s.importAllOrNothing(ctx, rows, report)
+	default:
+		s.importBestEffort(ctx, rows, report)
+	}
+
+	for _, result := range report.Results {
+		switch result.Status {
+		case dto.BatchRowApplied:
+			report.Applied++
+		case dto.BatchRowInvalid, dto.BatchRowFailed:
+			report.Failed++
+		}
+	}
+
+	logger.InfoContext(ctx, "Batch import finished", map[string]interface{}{
+		"mode":    mode,
+		"total":   report.Total,
+		"applied": report.Applied,
+		"failed":  report.Failed,
+	})
+	return report, err
+}
+
+func (s *TransactionService) importAllOrNothing(ctx context.Context, rows []BatchRow, report *dto.BatchTransactionResponse) error {
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		// MongoDB retries transient transaction errors from the start
+		resetResults(report)
+
+		failed := false
+		for i, row := range rows {
+			result := &report.Results[i]
+			if result.Status != "" {
+				continue
+			}
+			err := s.applyRow(ctx, row.Request, result)
+			if err == nil {
+				continue
+			}
+			failed = true
+			if !IsRejection(err) {
+				markUnattempted(report, dto.BatchRowSkipped)
+				return err
+			}
+		}
+		if failed {
+			return errBatchRejected
+		}
+		return nil
+	})
+
+	if err == nil {
+		return nil
+	}
+
+	// Rows that succeeded before the rollback were undone
+	for i := range report.Results {
+		result := &report.Results[i]
+		if result.Status == dto.BatchRowApplied {
+			result.Status = dto.BatchRowRolledBack
+			result.TransactionID = ""
+			result.Balance = nil
+		}
+	}
+
+	if errors.Is(err, errBatchRejected) {
+		return nil
+	}
+	logger.ErrorContext(ctx, "Batch import rolled back", err)
+	return err
+}
+
+func (s *TransactionService) importBestEffort(ctx context.Context, rows []BatchRow, report *dto.BatchTransactionResponse) {
+	for i, row := range rows {
+		result := &report.Results[i]
+		if result.Status != "" {
+			continue
+		}
+		err := s.withinTransaction(ctx, func(ctx context.Context) error {
+			return s.applyRow(ctx, row.Request, result)
+		})
+		if err != nil && !IsRejection(err) {
+			// The row may have been applied before the commit failed
+			result.Status = dto.BatchRowFailed
+			result.TransactionID = ""
+			result.Balance = nil
+			result.Error = "internal error"
+			logger.ErrorContext(ctx, "Failed to import batch row", err)
+		}
+	}
+}
+
+// applyRow applies one row and records the outcome in its result
+func (s *TransactionService) applyRow(ctx context.Context, req dto.CreateTransactionRequest, result *dto.BatchRowResult) error {
+	applied, err := s.apply(logger.WithAccountID(ctx, req.AccountID), req)
+	if err != nil {
+		result.Status = dto.BatchRowFailed
+		result.Error = err.Error()
+		if !IsRejection(err) {
+			result.Error = "internal error"
+		}
+		return err
+	}
+
+	balance := applied.Balance
+	result.Status = dto.BatchRowApplied
+	result.TransactionID = applied.Transaction.ID
+	result.Balance = &balance
+	result.Error = ""
+	return nil
+}
+
+// resetResults clears the outcome of the rows that passed validation
+func resetResults(report *dto.BatchTransactionResponse) {
+	for i := range report.Results {
+		result := &report.Results[i]
+		if result.Status != dto.BatchRowInvalid {
+			*result = dto.BatchRowResult{Row: result.Row, AccountID: result.AccountID}
+		}
+	}
+}
+
+// markUnattempted sets the status of the rows that have no outcome yet
+func markUnattempted(report *dto.BatchTransactionResponse, status string) {
+	for i := range report.Results {
+		if report.Results[i].Status == "" {
+			report.Results[i].Status = status
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func batchStore() *fakeStore {
+	return newFakeStore(
+		&dto.AccountDTO{ID: "acc-1", OwnerID: "alice", Balance: 0},
+		&dto.AccountDTO{ID: "acc-2", OwnerID: "alice", Balance: 50},
+	)
+}
+
+func rows(requests ...dto.CreateTransactionRequest) []BatchRow {
+	batch := make([]BatchRow, len(requests))
+	for i, request := range requests {
+		batch[i] = BatchRow{Request: request}
+	}
+	return batch
+}
+
+func statuses(report *dto.BatchTransactionResponse) []string {
+	result := make([]string, len(report.Results))
+	for i, row := range report.Results {
+		result[i] = row.Status
+	}
+	return result
+}
+
+func TestReadCSVBatch(t *testing.T) {
+	input := "type, Amount ,account_id\n" +
+		"deposit,100,acc-1\n" +
+		"withdrawal,abc,acc-1\n" +
+		"deposit,5\n" +
+		"withdrawal,,acc-2\n"
+
+	batch, err := ReadCSVBatch(strings.NewReader(input))
+
+	require.NoError(t, err)
+	require.Len(t, batch, 4)
+	assert.Equal(t, dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 100, Type: "deposit"}, batch[0].Request)
+	assert.EqualError(t, batch[1].Err, `amount "abc" is not a number`)
+	assert.EqualError(t, batch[2].Err, "row has 2 fields, the header has 3")
+	assert.NoError(t, batch[3].Err, "a missing amount is left to validation")
+}
+
+func TestReadCSVBatchErrors(t *testing.T) {
+	_, err := ReadCSVBatch(strings.NewReader("account_id,amount\nacc-1,5\n"))
+	assert.EqualError(t, err, "CSV header is missing the type column")
+
+	_, err = ReadCSVBatch(strings.NewReader("account_id,amount,type\n"))
+	assert.True(t, errors.Is(err, ErrEmptyBatch))
+
+	_, err = ReadCSVBatch(strings.NewReader(""))
+	assert.True(t, errors.Is(err, ErrEmptyBatch))
+}
+
+func TestReadJSONBatch(t *testing.T) {
+	input := `[
+		{"account_id": "acc-1", "amount": 10, "type": "deposit"},
+		{"account_id": "acc-1", "amount": "ten", "type": "deposit"}
+	]`
+
+	batch, err := ReadJSONBatch(strings.NewReader(input))
+
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+	assert.Equal(t, 10.0, batch[0].Request.Amount)
+	assert.Error(t, batch[1].Err)
+
+	_, err = ReadJSONBatch(strings.NewReader(`{"account_id": "acc-1"}`))
+	assert.Error(t, err)
+
+	_, err = ReadJSONBatch(strings.NewReader(`[]`))
+	assert.True(t, errors.Is(err, ErrEmptyBatch))
+}
+
+func TestParseBatchMode(t *testing.T) {
+	mode, err := ParseBatchMode("")
+	require.NoError(t, err)
+	assert.Equal(t, dto.BatchAllOrNothing, mode)
+
+	mode, err = ParseBatchMode("best_effort")
+	require.NoError(t, err)
+	assert.Equal(t, dto.BatchBestEffort, mode)
+
+	_, err = ParseBatchMode("some")
+	assert.True(t, errors.Is(err, ErrInvalidBatchMode))
+}
+
+func TestImportAppliesRowsInOrder(t *testing.T) {
+	store := batchStore()
+
+	// The withdrawal is only covered by the deposit before it
+	report, err := store.service().Import(asPrincipal("alice"), rows(
+		dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 100, Type: "deposit"},
+		dto.CreateTransactionRequest{AccountID: "acc-2", Amount: 50, Type: "withdrawal"},
+		dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 60, Type: "withdrawal"},
+	), dto.BatchAllOrNothing)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"applied", "applied", "applied"}, statuses(report))
+	assert.Equal(t, 3, report.Applied)
+	assert.Equal(t, 0, report.Failed)
+	assert.Equal(t, 40.0, *report.Results[2].Balance)
+	assert.Equal(t, 40.0, store.balance("acc-1"))
+	assert.Equal(t, 0.0, store.balance("acc-2"))
+	assert.Len(t, store.transactions, 3)
+}
+
+func TestImportAllOrNothing(t *testing.T) {
+	t.Run("invalid rows stop the batch before it starts", func(t *testing.T) {
+		store := batchStore()
+		batch := rows(
+			dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 100, Type: "deposit"},
+			dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 0, Type: "deposit"},
+		)
+
+		report, err := store.service().Import(asPrincipal("alice"), batch, dto.BatchAllOrNothing)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"skipped", "invalid"}, statuses(report))
+		assert.Equal(t, "amount is required", report.Results[1].Error)
+		assert.Equal(t, 1, report.Failed)
+		assert.Empty(t, store.transactions)
+	})
+
+	t.Run("a failed row rolls back the batch and every failure is reported", func(t *testing.T) {
+		store := batchStore()
+		batch := rows(
+			dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 100, Type: "deposit"},
+			dto.CreateTransactionRequest{AccountID: "acc-2", Amount: 80, Type: "withdrawal"},
+			dto.CreateTransactionRequest{AccountID: "missing", Amount: 1, Type: "deposit"},
+			dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 100, Type: "withdrawal"},
+		)
+
+		report, err := store.service().Import(asPrincipal("alice"), batch, dto.BatchAllOrNothing)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"rolled_back", "failed", "failed", "rolled_back"}, statuses(report))
+		assert.Equal(t, "insufficient funds", report.Results[1].Error)
+		assert.Equal(t, "account not found", report.Results[2].Error)
+		assert.Empty(t, report.Results[0].TransactionID)
+		assert.Equal(t, 0, report.Applied)
+		assert.Equal(t, 2, report.Failed)
+		assert.Equal(t, 0.0, store.balance("acc-1"))
+		assert.Empty(t, store.transactions)
+	})
+
+	t.Run("a database error aborts the batch", func(t *testing.T) {
+		store := batchStore()
+		store.failOnCreate = 2
+		batch := rows(
+			dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 100, Type: "deposit"},
+			dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 10, Type: "deposit"},
+			dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 10, Type: "deposit"},
+		)
+
+		report, err := store.service().Import(asPrincipal("alice"), batch, dto.BatchAllOrNothing)
+
+		assert.True(t, errors.Is(err, errDatabase))
+		assert.Equal(t, []string{"rolled_back", "failed", "skipped"}, statuses(report))
+		assert.Equal(t, "internal error", report.Results[1].Error)
+		assert.Equal(t, 0.0, store.balance("acc-1"))
+	})
+}
+
+func TestImportBestEffort(t *testing.T) {
+	store := batchStore()
+	batch := rows(
+		dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 100, Type: "deposit"},
+		dto.CreateTransactionRequest{AccountID: "acc-2", Amount: 80, Type: "withdrawal"},
+		dto.CreateTransactionRequest{AccountID: "acc-1", Type: "deposit"},
+		dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 100, Type: "withdrawal"},
+	)
+	batch = append(batch, BatchRow{Err: errors.New("row has 2 fields, the header has 3")})
+
+	report, err := store.service().Import(asPrincipal("alice"), batch, dto.BatchBestEffort)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"applied", "failed", "invalid", "applied", "invalid"}, statuses(report))
+	assert.Equal(t, 5, report.Total)
+	assert.Equal(t, 2, report.Applied)
+	assert.Equal(t, 3, report.Failed)
+	assert.Equal(t, 0.0, store.balance("acc-1"))
+	assert.Equal(t, 50.0, store.balance("acc-2"))
+}
+
+func TestImportChecksOwnershipPerRow(t *testing.T) {
+	store := batchStore()
+	store.accounts["acc-3"] = &dto.AccountDTO{ID: "acc-3", OwnerID: "bob"}
+
+	report, err := store.service().Import(asPrincipal("alice", "customer"), rows(
+		dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 1, Type: "deposit"},
+		dto.CreateTransactionRequest{AccountID: "acc-3", Amount: 1, Type: "deposit"},
+	), dto.BatchBestEffort)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"applied", "failed"}, statuses(report))
+	assert.Equal(t, ErrForbidden.Error(), report.Results[1].Error)
+}
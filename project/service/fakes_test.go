@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+// fakeStore is an in-memory bank shared by the fake repositories. Its
+// WithinTransaction snapshots the state and restores it when fn fails.
+type fakeStore struct {
+	accounts     map[string]*dto.AccountDTO
+	transactions []*dto.TransactionDTO
+	holds        map[string]*dto.HoldDTO
+	events       []*dto.EventDTO
+
+	// failOnCreate makes the nth transaction insert fail like a database
+	// error, 0 disables it
+	failOnCreate int
+	creates      int
+}
+
+var errDatabase = errors.New("database unavailable")
+
+func newFakeStore(accounts ...*dto.AccountDTO) *fakeStore {
+	store := &fakeStore{accounts: make(map[string]*dto.AccountDTO), holds: make(map[string]*dto.HoldDTO)}
+	for _, account := range accounts {
+		store.accounts[account.ID] = account
+	}
+	return store
+}
+
+func (s *fakeStore) service() *TransactionService {
+	return NewTransactionService(fakeAccounts{s}, fakeTransactions{s}, s)
+}
+
+func (s *fakeStore) balance(id string) float64 {
+	return s.accounts[id].Balance
+}
+
+func (s *fakeStore) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	accounts := make(map[string]*dto.AccountDTO, len(s.accounts))
+	for id, account := range s.accounts {
+		copied := *account
+		accounts[id] = &copied
+	}
+	transactions := append([]*dto.TransactionDTO(nil), s.transactions...)
+	holds := make(map[string]*dto.HoldDTO, len(s.holds))
+	for id, hold := range s.holds {
+		copied := *hold
+		holds[id] = &copied
+	}
+
+	events := append([]*dto.EventDTO(nil), s.events...)
+
+	if err := fn(ctx); err != nil {
+		s.accounts, s.transactions, s.holds, s.events = accounts, transactions, holds, events
+		return err
+	}
+	return nil
+}
+
+type fakeAccounts struct{ store *fakeStore }
+
+func (r fakeAccounts) Create(ctx context.Context, account *dto.AccountDTO) error {
+	r.store.accounts[account.ID] = account
+	return nil
+}
+
+func (r fakeAccounts) GetByID(ctx context.Context, id string) (*dto.AccountDTO, error) {
+	account, ok := r.store.accounts[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *account
+	return &copied, nil
+}
+
+func (r fakeAccounts) GetAll(ctx context.Context) ([]*dto.AccountDTO, error) {
+	var accounts []*dto.AccountDTO
+	for _, account := range r.store.accounts {
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+func (r fakeAccounts) GetByOwnerID(ctx context.Context, ownerID string) ([]*dto.AccountDTO, error) {
+	var accounts []*dto.AccountDTO
+	for _, account := range r.store.accounts {
+		if account.OwnerID == ownerID {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts, nil
+}
+
+func (r fakeAccounts) GetPage(ctx context.Context, ownerID string, after *repository.Cursor, limit int) ([]*dto.AccountDTO, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r fakeAccounts) Update(ctx context.Context, id string, update *dto.UpdateAccountRequest) error {
+	return errors.New("not implemented")
+}
+
+func (r fakeAccounts) Delete(ctx context.Context, id string) error {
+	delete(r.store.accounts, id)
+	return nil
+}
+
+func (r fakeAccounts) GetByName(ctx context.Context, name string) (*dto.AccountDTO, error) {
+	for _, account := range r.store.accounts {
+		if account.Name == name {
+			return account, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r fakeAccounts) UpdateBalance(ctx context.Context, id string, newBalance float64) error {
+	r.store.accounts[id].Balance = newBalance
+	return nil
+}
+
+func (r fakeAccounts) UpdateLimits(ctx context.Context, id string, limits dto.AccountLimits) error {
+	r.store.accounts[id].Limits = limits
+	return nil
+}
+
+type fakeTransactions struct{ store *fakeStore }
+
+func (r fakeTransactions) Create(ctx context.Context, transaction *dto.TransactionDTO) error {
+	r.store.creates++
+	if r.store.creates == r.store.failOnCreate {
+		return errDatabase
+	}
+	transaction.ID = fmt.Sprintf("tx-%d", len(r.store.transactions)+1)
+	transaction.CreatedAt = time.Now()
+	transaction.UpdatedAt = transaction.CreatedAt
+	r.store.transactions = append(r.store.transactions, transaction)
+	return nil
+}
+
+func (r fakeTransactions) GetByID(ctx context.Context, id string) (*dto.TransactionDTO, error) {
+	for _, transaction := range r.store.transactions {
+		if transaction.ID == id {
+			return transaction, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r fakeTransactions) GetByAccountID(ctx context.Context, accountID string) ([]*dto.TransactionDTO, error) {
+	var transactions []*dto.TransactionDTO
+	for _, transaction := range r.store.transactions {
+		if transaction.AccountID == accountID {
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions, nil
+}
+
+func (r fakeTransactions) GetPageByAccountID(ctx context.Context, accountID string, after *repository.Cursor, limit int) ([]*dto.TransactionDTO, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r fakeTransactions) GetAll(ctx context.Context) ([]*dto.TransactionDTO, error) {
+	return r.store.transactions, nil
+}
+
+func (r fakeTransactions) Update(ctx context.Context, id string, transaction *dto.TransactionDTO) error {
+	return errors.New("not implemented")
+}
+
+func (r fakeTransactions) Delete(ctx context.Context, id string) error {
+	return errors.New("not implemented")
+}
+
+func (r fakeTransactions) GetTransactionSummary(ctx context.Context, accountID string) (*dto.TransactionSummary, error) {
+	return r.GetTransactionSummarySince(ctx, accountID, time.Time{})
+}
+
+func (r fakeTransactions) GetTransactionSummarySince(ctx context.Context, accountID string, since time.Time) (*dto.TransactionSummary, error) {
+	summary := &dto.TransactionSummary{AccountID: accountID, CurrentBalance: r.store.balance(accountID)}
+	for _, transaction := range r.store.transactions {
+		if transaction.AccountID != accountID || transaction.CreatedAt.Before(since) {
+			continue
+		}
+		summary.TotalTransactions++
+		switch transaction.Type {
+		case dto.TransactionDeposit:
+			summary.TotalDeposits += transaction.Amount
+		case dto.TransactionWithdrawal:
+			summary.TotalWithdrawals += transaction.Amount
+		case dto.TransactionInterest:
+			summary.TotalInterest += transaction.Amount
+		case dto.TransactionFee:
+			summary.TotalFees += transaction.Amount
+		}
+	}
+	return summary, nil
+}
+
+func (r fakeTransactions) StreamByAccountID(ctx context.Context, accountID string, from, to time.Time, fn func(*dto.TransactionDTO) error) error {
+	for _, transaction := range r.store.transactions {
+		if transaction.AccountID != accountID || transaction.CreatedAt.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !transaction.CreatedAt.Before(to) {
+			continue
+		}
+		if err := fn(transaction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type fakeHolds struct{ store *fakeStore }
+
+func (r fakeHolds) Create(ctx context.Context, hold *dto.HoldDTO) error {
+	hold.ID = fmt.Sprintf("hold-%d", len(r.store.holds)+1)
+	copied := *hold
+	r.store.holds[hold.ID] = &copied
+	return nil
+}
+
+func (r fakeHolds) GetByID(ctx context.Context, id string) (*dto.HoldDTO, error) {
+	hold, ok := r.store.holds[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *hold
+	return &copied, nil
+}
+
+func (r fakeHolds) GetByAccountID(ctx context.Context, accountID string) ([]*dto.HoldDTO, error) {
+	var holds []*dto.HoldDTO
+	for _, hold := range r.store.holds {
+		if hold.AccountID == accountID {
+			copied := *hold
+			holds = append(holds, &copied)
+		}
+	}
+	return holds, nil
+}
+
+func (r fakeHolds) Close(ctx context.Context, hold *dto.HoldDTO) (bool, error) {
+	stored, ok := r.store.holds[hold.ID]
+	if !ok || stored.Status != dto.HoldActive {
+		return false, nil
+	}
+	copied := *hold
+	r.store.holds[hold.ID] = &copied
+	return true, nil
+}
+
+func (r fakeHolds) ActiveTotal(ctx context.Context, accountID string, now time.Time) (float64, error) {
+	var total float64
+	for _, hold := range r.store.holds {
+		if hold.AccountID == accountID && hold.Status == dto.HoldActive && hold.ExpiresAt.After(now) {
+			total += hold.Amount
+		}
+	}
+	return total, nil
+}
+
+func (r fakeHolds) ExpireDue(ctx context.Context, now time.Time) (int64, error) {
+	var expired int64
+	for _, hold := range r.store.holds {
+		if hold.Status == dto.HoldActive && !hold.ExpiresAt.After(now) {
+			hold.Status = dto.HoldExpired
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+type fakeOutbox struct{ store *fakeStore }
+
+func (o fakeOutbox) Append(ctx context.Context, event *dto.EventDTO) error {
+	event.Sequence = int64(len(o.store.events) + 1)
+	event.ID = fmt.Sprintf("evt-%d", event.Sequence)
+	o.store.events = append(o.store.events, event)
+	return nil
+}
+
+func (o fakeOutbox) After(ctx context.Context, after int64, limit int) ([]*dto.EventDTO, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (o fakeOutbox) Offset(ctx context.Context, consumer string) (int64, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (o fakeOutbox) SetOffset(ctx context.Context, consumer string, sequence int64) error {
+	return errors.New("not implemented")
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/authz"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+// DefaultHoldDuration is how long a hold lasts when the request sets no expiry
+const DefaultHoldDuration = 7 * 24 * time.Hour
+
+var (
+	// ErrHoldNotFound is returned when the hold does not exist
+	ErrHoldNotFound = errors.New("hold not found")
+	// ErrHoldNotActive is returned when capturing or releasing a hold that
+	// was already captured, released or has expired
+	ErrHoldNotActive = errors.New("hold is no longer active")
+	// ErrCaptureExceedsHold is returned when capturing more than the hold
+	ErrCaptureExceedsHold = errors.New("capture amount exceeds the hold")
+	// ErrHoldsNotSupported is returned when no hold repository is configured
+	ErrHoldsNotSupported = errors.New("holds are not supported")
+)
+
+// HoldResult is a captured hold, the withdrawal it posted and the resulting balance
+type HoldResult struct {
+	Hold        *dto.HoldDTO
+	Transaction *dto.TransactionDTO
+	Balance     float64
+}
+
+// SetHoldRepository enables holds. Withdrawals are then checked against the
+// available balance, the balance minus the active holds.
+func (s *TransactionService) SetHoldRepository(holds repository.HoldRepository) {
+	s.holds = holds
+}
+
+// Held returns the total of the active holds of an account
+func (s *TransactionService) Held(ctx context.Context, accountID string) (float64, error) {
+	if s.holds == nil {
+		return 0, nil
+	}
+	held, err := s.holds.ActiveTotal(ctx, accountID, s.now())
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get the active holds of the account", err)
+		return 0, err
+	}
+	return held, nil
+}
+
+// CreateHold reserves funds on behalf of the principal in ctx. The hold is
+// checked against the account's policy like a withdrawal of the same amount.
+func (s *TransactionService) CreateHold(ctx context.Context, req dto.CreateHoldRequest) (*dto.HoldDTO, error) {
+	if s.holds == nil {
+		return nil, ErrHoldsNotSupported
+	}
+	if err := Validate(req); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	expiresAt := now.Add(DefaultHoldDuration)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			return nil, &ValidationError{Problems: []string{"expires_at must be in the future"}}
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	hold := &dto.HoldDTO{
+		AccountID: req.AccountID,
+		Amount:    req.Amount,
+		Status:    dto.HoldActive,
+		ExpiresAt: expiresAt.UTC(),
+	}
+	if principal, _ := auth.PrincipalFromContext(ctx); principal != nil {
+		hold.CreatedBy = principal.ID
+	}
+
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		account, err := s.transactableAccount(ctx, req.AccountID)
+		if err != nil {
+			return err
+		}
+		if err := s.evaluate(ctx, account, dto.TransactionWithdrawal, req.Amount); err != nil {
+			return err
+		}
+		return s.holds.Create(ctx, hold)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// CaptureHold posts a withdrawal of the held funds, the full hold unless
+// req.Amount is set. The rest of a partial capture is released. The funds
+// were reserved when the hold was created, so the capture is not checked
+// against the account's limits again.
+func (s *TransactionService) CaptureHold(ctx context.Context, id string, req dto.CaptureHoldRequest) (*HoldResult, error) {
+	if err := Validate(req); err != nil {
+		return nil, err
+	}
+
+	var result *HoldResult
+	err := s.closeHold(ctx, id, func(ctx context.Context, account *dto.AccountDTO, hold *dto.HoldDTO) error {
+		amount := hold.Amount
+		if req.Amount != nil {
+			amount = *req.Amount
+		}
+		if amount > hold.Amount {
+			return ErrCaptureExceedsHold
+		}
+
+		posted, err := s.post(ctx, account, dto.TransactionWithdrawal, amount)
+		if err != nil {
+			return err
+		}
+		hold.Status = dto.HoldCaptured
+		hold.CapturedAmount = amount
+		hold.TransactionID = posted.Transaction.ID
+		result = &HoldResult{Hold: hold, Transaction: posted.Transaction, Balance: posted.Balance}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReleaseHold cancels a hold, its funds become available again. Like
+// CaptureHold it is only allowed to the principal that placed the hold and staff.
+func (s *TransactionService) ReleaseHold(ctx context.Context, id string) (*dto.HoldDTO, error) {
+	var released *dto.HoldDTO
+	err := s.closeHold(ctx, id, func(ctx context.Context, account *dto.AccountDTO, hold *dto.HoldDTO) error {
+		hold.Status = dto.HoldReleased
+		released = hold
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return released, nil
+}
+
+// ExpireHolds marks the holds past their expiry as expired. Expired holds
+// stop counting against the available balance at their expiry whether or
+// not this has run, it only updates their status.
+func (s *TransactionService) ExpireHolds(ctx context.Context) (int64, error) {
+	if s.holds == nil {
+		return 0, nil
+	}
+	return s.holds.ExpireDue(ctx, s.now())
+}
+
+// GetHold returns a hold the principal in ctx may view. Holds past their
+// expiry are reported as expired even before ExpireHolds has run.
+func (s *TransactionService) GetHold(ctx context.Context, id string) (*dto.HoldDTO, error) {
+	if s.holds == nil {
+		return nil, ErrHoldsNotSupported
+	}
+
+	hold, err := s.holds.GetByID(ctx, id)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get hold", err)
+		return nil, err
+	}
+	if hold == nil {
+		return nil, ErrHoldNotFound
+	}
+
+	account, err := s.accounts.GetByID(ctx, hold.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if !authz.CanViewAccount(principal, account) {
+		return nil, ErrForbidden
+	}
+
+	s.markExpired(hold)
+	return hold, nil
+}
+
+// GetHolds returns the holds of an account the principal in ctx may view, latest first
+func (s *TransactionService) GetHolds(ctx context.Context, accountID string) ([]*dto.HoldDTO, error) {
+	if s.holds == nil {
+		return nil, ErrHoldsNotSupported
+	}
+
+	account, err := s.accounts.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if !authz.CanViewAccount(principal, account) {
+		return nil, ErrForbidden
+	}
+
+	holds, err := s.holds.GetByAccountID(ctx, accountID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get holds", err)
+		return nil, err
+	}
+	for _, hold := range holds {
+		s.markExpired(hold)
+	}
+	return holds, nil
+}
+
+// closeHold loads an active hold and its locked account, checks that the
+// principal in ctx may settle it, lets fn settle it and saves its final
+// status, all in one transaction
+func (s *TransactionService) closeHold(ctx context.Context, id string, fn func(ctx context.Context, account *dto.AccountDTO, hold *dto.HoldDTO) error) error {
+	if s.holds == nil {
+		return ErrHoldsNotSupported
+	}
+
+	return s.withinTransaction(ctx, func(ctx context.Context) error {
+		hold, err := s.holds.GetByID(ctx, id)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to get hold", err)
+			return err
+		}
+		if hold == nil {
+			return ErrHoldNotFound
+		}
+
+		account, err := s.transactableAccount(ctx, hold.AccountID)
+		if err != nil {
+			return err
+		}
+		principal, _ := auth.PrincipalFromContext(ctx)
+		if !authz.CanSettleHold(principal, hold) {
+			return ErrForbidden
+		}
+
+		if hold.Status != dto.HoldActive || !hold.ExpiresAt.After(s.now()) {
+			return ErrHoldNotActive
+		}
+
+		if err := fn(ctx, account, hold); err != nil {
+			return err
+		}
+
+		// Another request may have settled the hold since it was read
+		closed, err := s.holds.Close(ctx, hold)
+		if err != nil {
+			return err
+		}
+		if !closed {
+			return ErrHoldNotActive
+		}
+
+		logger.InfoContext(ctx, "Hold "+hold.Status, map[string]interface{}{
+			"hold_id":         hold.ID,
+			"account_id":      hold.AccountID,
+			"amount":          hold.Amount,
+			"captured_amount": hold.CapturedAmount,
+		})
+		return nil
+	})
+}
+
+// transactableAccount loads and locks an account the principal in ctx may transact on
+func (s *TransactionService) transactableAccount(ctx context.Context, accountID string) (*dto.AccountDTO, error) {
+	account, err := s.accounts.GetByID(ctx, accountID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get account for hold", err)
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if !authz.CanTransact(principal, account) {
+		return nil, ErrForbidden
+	}
+	return account, nil
+}
+
+// markExpired reports an active hold past its expiry as expired
+func (s *TransactionService) markExpired(hold *dto.HoldDTO) {
+	if hold.Status == dto.HoldActive && !hold.ExpiresAt.After(s.now()) {
+		hold.Status = dto.HoldExpired
+	}
+}
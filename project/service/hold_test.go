@@ -0,0 +1,223 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var holdNow = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+// holdService returns a service with holds enabled over a store holding
+// acc-1, owned by alice with a balance of 100
+func holdService() (*TransactionService, *fakeStore) {
+	store := newFakeStore(&dto.AccountDTO{ID: "acc-1", OwnerID: "alice", Balance: 100})
+	s := store.service()
+	s.SetHoldRepository(fakeHolds{store})
+	s.now = func() time.Time { return holdNow }
+	return s, store
+}
+
+func TestCreateHold(t *testing.T) {
+	past := holdNow.Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		request dto.CreateHoldRequest
+		wantErr error
+	}{
+		{
+			name:    "the rest of the available balance",
+			request: dto.CreateHoldRequest{AccountID: "acc-1", Amount: 40},
+		},
+		{
+			name:    "more than the available balance",
+			request: dto.CreateHoldRequest{AccountID: "acc-1", Amount: 40.01},
+			wantErr: ErrInsufficientFunds,
+		},
+		{
+			name:    "expiry in the past",
+			request: dto.CreateHoldRequest{AccountID: "acc-1", Amount: 1, ExpiresAt: &past},
+			wantErr: &ValidationError{},
+		},
+		{
+			name:    "account not found",
+			request: dto.CreateHoldRequest{AccountID: "missing", Amount: 1},
+			wantErr: ErrAccountNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := holdService()
+			_, err := s.CreateHold(asPrincipal("alice", "customer"), dto.CreateHoldRequest{AccountID: "acc-1", Amount: 60})
+			require.NoError(t, err)
+
+			hold, err := s.CreateHold(asPrincipal("alice", "customer"), tt.request)
+
+			if tt.wantErr != nil {
+				var validation *ValidationError
+				if errors.As(tt.wantErr, &validation) {
+					assert.True(t, errors.As(err, &validation), "got %v", err)
+				} else {
+					assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				}
+				assert.Len(t, store.holds, 1)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, store.holds, 2)
+			assert.Equal(t, dto.HoldActive, hold.Status)
+			assert.Equal(t, "alice", hold.CreatedBy)
+			assert.Equal(t, holdNow.Add(DefaultHoldDuration), hold.ExpiresAt)
+			assert.Equal(t, float64(100), store.balance("acc-1"), "holds don't move the ledger balance")
+		})
+	}
+}
+
+func TestWithdrawalChecksAvailableBalance(t *testing.T) {
+	s, store := holdService()
+	ctx := asPrincipal("alice", "customer")
+	_, err := s.CreateHold(ctx, dto.CreateHoldRequest{AccountID: "acc-1", Amount: 70})
+	require.NoError(t, err)
+
+	_, err = s.Create(ctx, dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 30.01, Type: "withdrawal"})
+	assert.True(t, errors.Is(err, ErrInsufficientFunds), "got %v", err)
+
+	result, err := s.Create(ctx, dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 30, Type: "withdrawal"})
+	require.NoError(t, err)
+	assert.Equal(t, float64(70), result.Balance)
+	assert.Equal(t, float64(70), store.balance("acc-1"))
+}
+
+func TestCaptureHold(t *testing.T) {
+	partial := 25.0
+	tooMuch := 60.01
+
+	tests := []struct {
+		name        string
+		request     dto.CaptureHoldRequest
+		wantErr     error
+		wantBalance float64
+		wantHeld    float64
+	}{
+		{
+			name:        "full capture",
+			wantBalance: 40,
+		},
+		{
+			name:        "partial capture releases the rest",
+			request:     dto.CaptureHoldRequest{Amount: &partial},
+			wantBalance: 75,
+		},
+		{
+			name:        "more than the hold",
+			request:     dto.CaptureHoldRequest{Amount: &tooMuch},
+			wantErr:     ErrCaptureExceedsHold,
+			wantBalance: 100,
+			wantHeld:    60,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := holdService()
+			ctx := asPrincipal("alice", "customer")
+			hold, err := s.CreateHold(ctx, dto.CreateHoldRequest{AccountID: "acc-1", Amount: 60})
+			require.NoError(t, err)
+
+			result, err := s.CaptureHold(ctx, hold.ID, tt.request)
+
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				assert.Empty(t, store.transactions)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, dto.HoldCaptured, result.Hold.Status)
+				assert.Equal(t, result.Transaction.ID, result.Hold.TransactionID)
+				assert.Equal(t, tt.wantBalance, result.Balance)
+			}
+			assert.Equal(t, tt.wantBalance, store.balance("acc-1"))
+
+			held, err := s.Held(ctx, "acc-1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantHeld, held)
+		})
+	}
+}
+
+func TestSettledHoldsCannotBeSettledAgain(t *testing.T) {
+	s, store := holdService()
+	ctx := asPrincipal("alice", "customer")
+	expiring := holdNow.Add(time.Hour)
+
+	captured, err := s.CreateHold(ctx, dto.CreateHoldRequest{AccountID: "acc-1", Amount: 10})
+	require.NoError(t, err)
+	_, err = s.CaptureHold(ctx, captured.ID, dto.CaptureHoldRequest{})
+	require.NoError(t, err)
+
+	released, err := s.CreateHold(ctx, dto.CreateHoldRequest{AccountID: "acc-1", Amount: 10})
+	require.NoError(t, err)
+	hold, err := s.ReleaseHold(ctx, released.ID)
+	require.NoError(t, err)
+	assert.Equal(t, dto.HoldReleased, hold.Status)
+
+	expired, err := s.CreateHold(ctx, dto.CreateHoldRequest{AccountID: "acc-1", Amount: 10, ExpiresAt: &expiring})
+	require.NoError(t, err)
+	s.now = func() time.Time { return expiring }
+
+	for _, id := range []string{captured.ID, released.ID, expired.ID} {
+		_, err = s.CaptureHold(ctx, id, dto.CaptureHoldRequest{})
+		assert.True(t, errors.Is(err, ErrHoldNotActive), "capture %s: got %v", id, err)
+		_, err = s.ReleaseHold(ctx, id)
+		assert.True(t, errors.Is(err, ErrHoldNotActive), "release %s: got %v", id, err)
+	}
+	assert.Equal(t, float64(90), store.balance("acc-1"))
+
+	hold, err = s.GetHold(ctx, expired.ID)
+	require.NoError(t, err)
+	assert.Equal(t, dto.HoldExpired, hold.Status, "reported expired before the sweep")
+
+	count, err := s.ExpireHolds(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, dto.HoldExpired, store.holds[expired.ID].Status)
+}
+
+func TestHoldsOfAnotherCustomer(t *testing.T) {
+	s, _ := holdService()
+	hold, err := s.CreateHold(asPrincipal("alice", "customer"), dto.CreateHoldRequest{AccountID: "acc-1", Amount: 10})
+	require.NoError(t, err)
+
+	bob := asPrincipal("bob", "customer")
+	_, err = s.CreateHold(bob, dto.CreateHoldRequest{AccountID: "acc-1", Amount: 10})
+	assert.True(t, errors.Is(err, ErrForbidden), "got %v", err)
+	_, err = s.GetHold(bob, hold.ID)
+	assert.True(t, errors.Is(err, ErrForbidden), "got %v", err)
+	_, err = s.ReleaseHold(bob, hold.ID)
+	assert.True(t, errors.Is(err, ErrForbidden), "got %v", err)
+	_, err = s.ReleaseHold(bob, "missing")
+	assert.True(t, errors.Is(err, ErrHoldNotFound), "got %v", err)
+}
+
+func TestOnlyTheCreatorOrStaffSettleAHold(t *testing.T) {
+	s, store := holdService()
+	teller := asPrincipal("tom", "teller")
+	owner := asPrincipal("alice", "customer")
+	hold, err := s.CreateHold(teller, dto.CreateHoldRequest{AccountID: "acc-1", Amount: 60})
+	require.NoError(t, err)
+
+	_, err = s.ReleaseHold(owner, hold.ID)
+	assert.True(t, errors.Is(err, ErrForbidden), "got %v", err)
+	_, err = s.CaptureHold(owner, hold.ID, dto.CaptureHoldRequest{})
+	assert.True(t, errors.Is(err, ErrForbidden), "got %v", err)
+	assert.Equal(t, dto.HoldActive, store.holds[hold.ID].Status, "the owner can't free the teller's hold")
+
+	released, err := s.ReleaseHold(asPrincipal("ann", "admin"), hold.ID)
+	require.NoError(t, err)
+	assert.Equal(t, dto.HoldReleased, released.Status)
+}
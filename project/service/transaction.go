@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/authz"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/events"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/policy"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+var (
+	// ErrAccountNotFound is returned when the transaction's account does not exist
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrForbidden is returned when the principal may not transact on the account
+	ErrForbidden = errors.New("principal may not transact on account")
+	// ErrInsufficientFunds is returned when a withdrawal exceeds the balance
+	// of an account without overdraft. The other limits of the account are
+	// reported as *policy.Violation errors too.
+	ErrInsufficientFunds = policy.ErrInsufficientFunds
+	// ErrInvalidTransactionType is returned for types other than deposit and withdrawal
+	ErrInvalidTransactionType = errors.New("invalid transaction type")
+)
+
+// TransactionService applies deposits, withdrawals and transfers to account
+// balances. The balance update, the transaction record and its
+// TransactionPosted event are written atomically.
+type TransactionService struct {
+	accounts     repository.AccountRepository
+	transactions repository.TransactionRepository
+	txManager    repository.TxManager
+	// holds is nil when holds are not supported
+	holds repository.HoldRepository
+	// outbox is nil when no events are recorded
+	outbox repository.OutboxRepository
+
+	// now tells the day whose withdrawals count towards the daily limit and
+	// which holds have expired
+	now func() time.Time
+}
+
+// NewTransactionService creates a transaction service. Without a TxManager
+// the writes are not atomic.
+func NewTransactionService(accounts repository.AccountRepository, transactions repository.TransactionRepository, txManager repository.TxManager) *TransactionService {
+	return &TransactionService{accounts: accounts, transactions: transactions, txManager: txManager, now: time.Now}
+}
+
+// SetOutbox makes the service record a TransactionPosted event for every transaction
+func (s *TransactionService) SetOutbox(outbox repository.OutboxRepository) {
+	s.outbox = outbox
+}
+
+// TransactionResult is an applied transaction and the resulting balance
+type TransactionResult struct {
+	Transaction *dto.TransactionDTO
+	Balance     float64
+}
+
+// Create validates and applies a transaction on behalf of the principal in ctx
+func (s *TransactionService) Create(ctx context.Context, req dto.CreateTransactionRequest) (*TransactionResult, error) {
+	if err := Validate(req); err != nil {
+		return nil, err
+	}
+
+	var result *TransactionResult
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = s.apply(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// apply updates the balance and records the transaction, it must run in a transaction
+func (s *TransactionService) apply(ctx context.Context, req dto.CreateTransactionRequest) (*TransactionResult, error) {
+	account, err := s.accounts.GetByID(ctx, req.AccountID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get account for transaction", err)
+		return nil, err
+	}
+
+	if account == nil {
+		logger.WarnContext(ctx, "Account not found for transaction", map[string]interface{}{
+			"account_id": req.AccountID,
+		})
+		return nil, ErrAccountNotFound
+	}
+
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if !authz.CanTransact(principal, account) {
+		return nil, ErrForbidden
+	}
+
+	if req.Type != dto.TransactionDeposit && req.Type != dto.TransactionWithdrawal {
+		logger.WarnContext(ctx, "Invalid transaction type", map[string]interface{}{
+			"account_id": req.AccountID,
+			"type":       req.Type,
+		})
+		return nil, ErrInvalidTransactionType
+	}
+
+	if err := s.evaluate(ctx, account, req.Type, req.Amount); err != nil {
+		return nil, err
+	}
+
+	return s.post(ctx, account, req.Type, req.Amount)
+}
+
+// evaluate checks a deposit or withdrawal against the account's policy, it
+// must run in a transaction
+func (s *TransactionService) evaluate(ctx context.Context, account *dto.AccountDTO, transactionType string, amount float64) error {
+	posting := policy.Posting{Account: account, Type: transactionType, Amount: amount}
+	if transactionType == dto.TransactionWithdrawal {
+		// The account row is locked, so concurrent withdrawals can't both
+		// fit under the limits
+		if account.Limits.DailyWithdrawalLimit != nil {
+			today, err := s.transactions.GetTransactionSummarySince(ctx, account.ID, startOfDay(s.now()))
+			if err != nil {
+				logger.ErrorContext(ctx, "Failed to get today's withdrawals", err)
+				return err
+			}
+			posting.WithdrawnToday = today.TotalWithdrawals
+		}
+
+		held, err := s.Held(ctx, account.ID)
+		if err != nil {
+			return err
+		}
+		posting.Held = held
+	}
+
+	if err := policy.Evaluate(posting); err != nil {
+		logger.WarnContext(ctx, "Transaction rejected by account policy", map[string]interface{}{
+			"account_id":      account.ID,
+			"type":            transactionType,
+			"amount":          amount,
+			"current_balance": account.Balance,
+			"withdrawn_today": posting.WithdrawnToday,
+			"held":            posting.Held,
+			"reason":          err.Error(),
+		})
+		return err
+	}
+	return nil
+}
+
+// PostAccrual posts interest or a fee computed by the bank. Unlike Create it
+// is not made on behalf of a principal and the account's limits don't apply,
+// so a fee may take the balance below zero.
+func (s *TransactionService) PostAccrual(ctx context.Context, accountID, transactionType string, amount float64) (*TransactionResult, error) {
+	if transactionType != dto.TransactionInterest && transactionType != dto.TransactionFee {
+		return nil, ErrInvalidTransactionType
+	}
+
+	var result *TransactionResult
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		account, err := s.accounts.GetByID(ctx, accountID)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to get account for accrual", err)
+			return err
+		}
+		if account == nil {
+			return ErrAccountNotFound
+		}
+
+		result, err = s.post(ctx, account, transactionType, amount)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// post updates the balance and records the transaction, it must run in a transaction
+func (s *TransactionService) post(ctx context.Context, account *dto.AccountDTO, transactionType string, amount float64) (*TransactionResult, error) {
+	newBalance := account.Balance + amount
+	if dto.IsDebit(transactionType) {
+		newBalance = account.Balance - amount
+	}
+	logger.InfoContext(ctx, "Processing "+transactionType, map[string]interface{}{
+		"account_id":      account.ID,
+		"current_balance": account.Balance,
+		"amount":          amount,
+		"new_balance":     newBalance,
+	})
+
+	if err := s.accounts.UpdateBalance(ctx, account.ID, newBalance); err != nil {
+		logger.ErrorContext(ctx, "Failed to update account balance", err)
+		return nil, err
+	}
+
+	transaction := &dto.TransactionDTO{
+		AccountID: account.ID,
+		Amount:    amount,
+		Type:      transactionType,
+	}
+	if err := s.transactions.Create(ctx, transaction); err != nil {
+		logger.ErrorContext(ctx, "Failed to create transaction", err)
+		return nil, err
+	}
+
+	err := record(ctx, s.outbox, dto.EventTransactionPosted, account.ID, dto.TransactionPostedEvent{
+		TransactionID: transaction.ID,
+		AccountID:     account.ID,
+		Type:          transactionType,
+		Amount:        amount,
+		Balance:       newBalance,
+		CreatedAt:     transaction.CreatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransactionResult{Transaction: transaction, Balance: newBalance}, nil
+}
+
+// withinTransaction runs fn atomically when a TxManager is configured
+func (s *TransactionService) withinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withinTransaction(ctx, s.txManager, fn)
+}
+
+func withinTransaction(ctx context.Context, txManager repository.TxManager, fn func(ctx context.Context) error) error {
+	if txManager == nil {
+		return fn(ctx)
+	}
+	return txManager.WithinTransaction(ctx, fn)
+}
+
+// record appends an event to the outbox, in the transaction in ctx, unless
+// outbox is nil
+func record(ctx context.Context, outbox repository.OutboxRepository, eventType, accountID string, payload interface{}) error {
+	if outbox == nil {
+		return nil
+	}
+
+	event, err := events.New(ctx, eventType, accountID, payload)
+	if err != nil {
+		return err
+	}
+	if err := outbox.Append(ctx, event); err != nil {
+		logger.ErrorContext(ctx, "Failed to record "+eventType+" event", err)
+		return err
+	}
+	return nil
+}
+
+// IsRejection reports whether err is a business rule rejection rather than a
+// database failure
+func IsRejection(err error) bool {
+	var validationErr *ValidationError
+	var violation *policy.Violation
+	return errors.As(err, &validationErr) ||
+		errors.As(err, &violation) ||
+		errors.Is(err, ErrAccountNotFound) ||
+		errors.Is(err, ErrForbidden) ||
+		errors.Is(err, ErrInvalidTransactionType) ||
+		errors.Is(err, ErrCurrencyMismatch)
+}
+
+// startOfDay returns midnight UTC of the day of t
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func asPrincipal(id string, roles ...string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{ID: id, Roles: roles})
+}
+
+func TestCreateTransaction(t *testing.T) {
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		request     dto.CreateTransactionRequest
+		wantErr     error
+		wantBalance float64
+	}{
+		{
+			name:        "deposit",
+			ctx:         asPrincipal("alice", "customer"),
+			request:     dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 25.5, Type: "deposit"},
+			wantBalance: 125.5,
+		},
+		{
+			name:        "withdrawal",
+			ctx:         asPrincipal("alice", "customer"),
+			request:     dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 100, Type: "withdrawal"},
+			wantBalance: 0,
+		},
+		{
+			name:        "insufficient funds",
+			ctx:         asPrincipal("alice", "customer"),
+			request:     dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 100.01, Type: "withdrawal"},
+			wantErr:     ErrInsufficientFunds,
+			wantBalance: 100,
+		},
+		{
+			name:        "account not found",
+			ctx:         asPrincipal("alice", "customer"),
+			request:     dto.CreateTransactionRequest{AccountID: "missing", Amount: 1, Type: "deposit"},
+			wantErr:     ErrAccountNotFound,
+			wantBalance: 100,
+		},
+		{
+			name:        "not the owner",
+			ctx:         asPrincipal("bob", "customer"),
+			request:     dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 1, Type: "deposit"},
+			wantErr:     ErrForbidden,
+			wantBalance: 100,
+		},
+		{
+			name:        "teller",
+			ctx:         asPrincipal("bob", "teller"),
+			request:     dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 1, Type: "deposit"},
+			wantBalance: 101,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore(&dto.AccountDTO{ID: "acc-1", OwnerID: "alice", Balance: 100})
+
+			result, err := store.service().Create(tt.ctx, tt.request)
+
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				assert.Empty(t, store.transactions)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantBalance, result.Balance)
+				assert.NotEmpty(t, result.Transaction.ID)
+			}
+			assert.Equal(t, tt.wantBalance, store.balance("acc-1"))
+		})
+	}
+}
+
+func TestCreateTransactionValidates(t *testing.T) {
+	store := newFakeStore(&dto.AccountDTO{ID: "acc-1", OwnerID: "alice", Balance: 100})
+
+	_, err := store.service().Create(asPrincipal("alice"), dto.CreateTransactionRequest{Amount: -5, Type: "transfer"})
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"account_id is required",
+		"amount must be greater than 0",
+		"type must be one of: deposit, withdrawal",
+	}, validationErr.Problems)
+}
+
+func TestCreateTransactionRollsBackOnDatabaseError(t *testing.T) {
+	store := newFakeStore(&dto.AccountDTO{ID: "acc-1", OwnerID: "alice", Balance: 100})
+	store.failOnCreate = 1
+
+	_, err := store.service().Create(asPrincipal("alice"), dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 10, Type: "deposit"})
+
+	assert.True(t, errors.Is(err, errDatabase))
+	assert.Equal(t, 100.0, store.balance("acc-1"), "the balance update is rolled back with the failed insert")
+}
+
+func TestCreateTransactionRecordsTransactionPosted(t *testing.T) {
+	store := newFakeStore(&dto.AccountDTO{ID: "acc-1", OwnerID: "alice", Balance: 100})
+	svc := store.service()
+	svc.SetOutbox(fakeOutbox{store})
+
+	result, err := svc.Create(asPrincipal("alice"), dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 40, Type: "withdrawal"})
+	require.NoError(t, err)
+
+	require.Len(t, store.events, 1)
+	event := store.events[0]
+	assert.Equal(t, dto.EventTransactionPosted, event.Type)
+	assert.Equal(t, "acc-1", event.AccountID)
+	var payload dto.TransactionPostedEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	assert.Equal(t, result.Transaction.ID, payload.TransactionID)
+	assert.Equal(t, 60.0, payload.Balance)
+}
+
+func TestRolledBackTransactionsRecordNoEvents(t *testing.T) {
+	store := newFakeStore(&dto.AccountDTO{ID: "acc-1", OwnerID: "alice", Balance: 0})
+	store.failOnCreate = 2
+	svc := store.service()
+	svc.SetOutbox(fakeOutbox{store})
+
+	_, err := svc.Import(asPrincipal("alice"), rows(
+		dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 100, Type: "deposit"},
+		dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 10, Type: "deposit"},
+	), dto.BatchAllOrNothing)
+
+	assert.True(t, errors.Is(err, errDatabase))
+	assert.Empty(t, store.events, "the first row's event is rolled back with it")
+}
+
+func TestCreateTransactionDailyWithdrawalLimitUsesTheLedger(t *testing.T) {
+	dailyLimit := 300.0
+	now := time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC)
+	store := newFakeStore(&dto.AccountDTO{
+		ID: "acc-1", OwnerID: "alice", Balance: 1000,
+		Limits: dto.AccountLimits{DailyWithdrawalLimit: &dailyLimit},
+	})
+	store.transactions = []*dto.TransactionDTO{
+		{ID: "tx-yesterday", AccountID: "acc-1", Amount: 250, Type: "withdrawal", CreatedAt: now.Add(-24 * time.Hour)},
+		{ID: "tx-deposit", AccountID: "acc-1", Amount: 500, Type: "deposit", CreatedAt: now.Add(-time.Hour)},
+		{ID: "tx-today", AccountID: "acc-1", Amount: 200, Type: "withdrawal", CreatedAt: now.Add(-time.Hour)},
+	}
+	svc := store.service()
+	svc.now = func() time.Time { return now }
+
+	_, err := svc.Create(asPrincipal("alice"), dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 100.01, Type: "withdrawal"})
+	assert.True(t, errors.Is(err, policy.ErrDailyWithdrawalLimitExceeded), "got %v", err)
+	assert.True(t, IsRejection(err))
+
+	_, err = svc.Create(asPrincipal("alice"), dto.CreateTransactionRequest{AccountID: "acc-1", Amount: 100, Type: "withdrawal"})
+	require.NoError(t, err)
+	assert.Equal(t, 900.0, store.balance("acc-1"))
+}
+
+func TestPostAccrualSkipsLimits(t *testing.T) {
+	store := newFakeStore(&dto.AccountDTO{ID: "acc-1", OwnerID: "alice", Balance: 2})
+	svc := store.service()
+
+	result, err := svc.PostAccrual(context.Background(), "acc-1", dto.TransactionFee, 5)
+	require.NoError(t, err)
+	assert.Equal(t, -3.0, result.Balance, "fees may overdraw the account")
+	assert.Equal(t, dto.TransactionFee, store.transactions[0].Type)
+
+	result, err = svc.PostAccrual(context.Background(), "acc-1", dto.TransactionInterest, 0.25)
+	require.NoError(t, err)
+	assert.Equal(t, -2.75, result.Balance)
+
+	_, err = svc.PostAccrual(context.Background(), "acc-1", dto.TransactionDeposit, 10)
+	assert.True(t, errors.Is(err, ErrInvalidTransactionType))
+
+	_, err = svc.PostAccrual(context.Background(), "acc-2", dto.TransactionFee, 5)
+	assert.True(t, errors.Is(err, ErrAccountNotFound))
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gcalvocr/go-testing/auth"
+	"github.com/gcalvocr/go-testing/authz"
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+)
+
+// ErrCurrencyMismatch is returned when transferring between accounts of
+// different currencies
+var ErrCurrencyMismatch = errors.New("accounts have different currencies")
+
+// TransferResult is a completed transfer and the balance of the source account
+type TransferResult struct {
+	Withdrawal *dto.TransactionDTO
+	Deposit    *dto.TransactionDTO
+	Balance    float64
+}
+
+// Transfer moves money between two accounts on behalf of the principal in
+// ctx, who must be allowed to transact on the source account. The withdrawal,
+// the deposit and a TransferCompleted event for each account are written
+// atomically.
+func (s *TransactionService) Transfer(ctx context.Context, req dto.CreateTransferRequest) (*TransferResult, error) {
+	if err := Validate(req); err != nil {
+		return nil, err
+	}
+	if req.FromAccountID == req.ToAccountID {
+		return nil, &ValidationError{Problems: []string{"to_account_id must differ from from_account_id"}}
+	}
+
+	var result *TransferResult
+	err := s.withinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = s.transfer(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// transfer posts both sides of a transfer, it must run in a transaction
+func (s *TransactionService) transfer(ctx context.Context, req dto.CreateTransferRequest) (*TransferResult, error) {
+	from, to, err := s.lockTransferAccounts(ctx, req.FromAccountID, req.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if !authz.CanTransact(principal, from) {
+		return nil, ErrForbidden
+	}
+
+	if from.Currency != to.Currency {
+		logger.WarnContext(ctx, "Transfer between currencies rejected", map[string]interface{}{
+			"from_account_id": from.ID,
+			"to_account_id":   to.ID,
+			"from_currency":   from.Currency,
+			"to_currency":     to.Currency,
+		})
+		return nil, ErrCurrencyMismatch
+	}
+
+	if err := s.evaluate(ctx, from, dto.TransactionWithdrawal, req.Amount); err != nil {
+		return nil, err
+	}
+	if err := s.evaluate(ctx, to, dto.TransactionDeposit, req.Amount); err != nil {
+		return nil, err
+	}
+
+	withdrawal, err := s.post(ctx, from, dto.TransactionWithdrawal, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	deposit, err := s.post(ctx, to, dto.TransactionDeposit, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each account's feed, statement and stream only read its own events, so
+	// both accounts get the TransferCompleted event
+	completed := dto.TransferCompletedEvent{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        req.Amount,
+		Currency:      from.Currency,
+		WithdrawalID:  withdrawal.Transaction.ID,
+		DepositID:     deposit.Transaction.ID,
+		CreatedAt:     withdrawal.Transaction.CreatedAt,
+	}
+	for _, accountID := range []string{from.ID, to.ID} {
+		if err := record(ctx, s.outbox, dto.EventTransferCompleted, accountID, completed); err != nil {
+			return nil, err
+		}
+	}
+
+	return &TransferResult{
+		Withdrawal: withdrawal.Transaction,
+		Deposit:    deposit.Transaction,
+		Balance:    withdrawal.Balance,
+	}, nil
+}
+
+// lockTransferAccounts reads both accounts of a transfer. The rows are
+// locked in ID order, so opposite transfers between the same accounts can't
+// deadlock.
+func (s *TransactionService) lockTransferAccounts(ctx context.Context, fromID, toID string) (*dto.AccountDTO, *dto.AccountDTO, error) {
+	ids := []string{fromID, toID}
+	if toID < fromID {
+		ids = []string{toID, fromID}
+	}
+
+	accounts := make(map[string]*dto.AccountDTO, len(ids))
+	for _, id := range ids {
+		account, err := s.accounts.GetByID(ctx, id)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to get account for transfer", err)
+			return nil, nil, err
+		}
+		if account == nil {
+			logger.WarnContext(ctx, "Account not found for transfer", map[string]interface{}{
+				"account_id": id,
+			})
+			return nil, nil, ErrAccountNotFound
+		}
+		accounts[id] = account
+	}
+	return accounts[fromID], accounts[toID], nil
+}
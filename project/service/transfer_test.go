@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func transferStore() *fakeStore {
+	return newFakeStore(
+		&dto.AccountDTO{ID: "acc-1", OwnerID: "alice", Currency: "USD", Balance: 100},
+		&dto.AccountDTO{ID: "acc-2", OwnerID: "bob", Currency: "USD", Balance: 10},
+		&dto.AccountDTO{ID: "acc-3", OwnerID: "bob", Currency: "EUR", Balance: 10},
+	)
+}
+
+func TestTransfer(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		request  dto.CreateTransferRequest
+		wantErr  error
+		wantFrom float64
+		wantTo   float64
+	}{
+		{
+			name:     "transfer",
+			ctx:      asPrincipal("alice", "customer"),
+			request:  dto.CreateTransferRequest{FromAccountID: "acc-1", ToAccountID: "acc-2", Amount: 40},
+			wantFrom: 60,
+			wantTo:   50,
+		},
+		{
+			name:     "insufficient funds",
+			ctx:      asPrincipal("alice", "customer"),
+			request:  dto.CreateTransferRequest{FromAccountID: "acc-1", ToAccountID: "acc-2", Amount: 100.01},
+			wantErr:  ErrInsufficientFunds,
+			wantFrom: 100,
+			wantTo:   10,
+		},
+		{
+			name:     "not the owner of the source",
+			ctx:      asPrincipal("bob", "customer"),
+			request:  dto.CreateTransferRequest{FromAccountID: "acc-1", ToAccountID: "acc-2", Amount: 1},
+			wantErr:  ErrForbidden,
+			wantFrom: 100,
+			wantTo:   10,
+		},
+		{
+			name:     "destination not found",
+			ctx:      asPrincipal("alice", "customer"),
+			request:  dto.CreateTransferRequest{FromAccountID: "acc-1", ToAccountID: "missing", Amount: 1},
+			wantErr:  ErrAccountNotFound,
+			wantFrom: 100,
+			wantTo:   10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := transferStore()
+
+			result, err := store.service().Transfer(tt.ctx, tt.request)
+
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				assert.True(t, IsRejection(err))
+				assert.Empty(t, store.transactions)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantFrom, result.Balance)
+				assert.Equal(t, dto.TransactionWithdrawal, result.Withdrawal.Type)
+				assert.Equal(t, dto.TransactionDeposit, result.Deposit.Type)
+			}
+			assert.Equal(t, tt.wantFrom, store.balance("acc-1"))
+			assert.Equal(t, tt.wantTo, store.balance("acc-2"))
+		})
+	}
+}
+
+func TestTransferRejectsCurrencyMismatch(t *testing.T) {
+	store := transferStore()
+
+	_, err := store.service().Transfer(asPrincipal("alice"), dto.CreateTransferRequest{FromAccountID: "acc-1", ToAccountID: "acc-3", Amount: 1})
+
+	assert.True(t, errors.Is(err, ErrCurrencyMismatch), "got %v", err)
+	assert.Equal(t, 100.0, store.balance("acc-1"))
+}
+
+func TestTransferValidates(t *testing.T) {
+	store := transferStore()
+
+	_, err := store.service().Transfer(asPrincipal("alice"), dto.CreateTransferRequest{FromAccountID: "acc-1", ToAccountID: "acc-1", Amount: 1})
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{"to_account_id must differ from from_account_id"}, validationErr.Problems)
+}
+
+func TestTransferRecordsTransferCompleted(t *testing.T) {
+	store := transferStore()
+	svc := store.service()
+	svc.SetOutbox(fakeOutbox{store})
+
+	result, err := svc.Transfer(asPrincipal("alice"), dto.CreateTransferRequest{FromAccountID: "acc-1", ToAccountID: "acc-2", Amount: 40})
+	require.NoError(t, err)
+
+	require.Len(t, store.events, 4, "a TransactionPosted event per side and a TransferCompleted event per account")
+	assert.Equal(t, dto.EventTransactionPosted, store.events[0].Type)
+	assert.Equal(t, dto.EventTransactionPosted, store.events[1].Type)
+	for i, accountID := range []string{"acc-1", "acc-2"} {
+		event := store.events[2+i]
+		assert.Equal(t, dto.EventTransferCompleted, event.Type)
+		assert.Equal(t, accountID, event.AccountID)
+		var payload dto.TransferCompletedEvent
+		require.NoError(t, json.Unmarshal(event.Payload, &payload))
+		assert.Equal(t, dto.TransferCompletedEvent{
+			FromAccountID: "acc-1",
+			ToAccountID:   "acc-2",
+			Amount:        40,
+			Currency:      "USD",
+			WithdrawalID:  result.Withdrawal.ID,
+			DepositID:     result.Deposit.ID,
+			CreatedAt:     payload.CreatedAt,
+		}, payload)
+	}
+}
+
+func TestTransferRollsBackWhenTheDepositFails(t *testing.T) {
+	store := transferStore()
+	store.failOnCreate = 2
+	svc := store.service()
+	svc.SetOutbox(fakeOutbox{store})
+
+	_, err := svc.Transfer(asPrincipal("alice"), dto.CreateTransferRequest{FromAccountID: "acc-1", ToAccountID: "acc-2", Amount: 40})
+
+	assert.True(t, errors.Is(err, errDatabase))
+	assert.Equal(t, 100.0, store.balance("acc-1"), "the withdrawal is rolled back with the failed deposit")
+	assert.Equal(t, 10.0, store.balance("acc-2"))
+	assert.Empty(t, store.transactions)
+	assert.Empty(t, store.events, "no event outlives the rolled back transfer")
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate enforces the validate tags of the dto package
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// Report fields by their JSON names, the names clients send
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// ValidationError lists the validation rules a request breaks
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// Validate checks a request against the rules in its validate tags
+func Validate(request interface{}) error {
+	err := validate.Struct(request)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err
+	}
+
+	problems := make([]string, len(fieldErrors))
+	for i, fieldError := range fieldErrors {
+		problems[i] = describe(fieldError)
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// describe turns a failed rule into a message for API clients
+func describe(fieldError validator.FieldError) string {
+	field, param := fieldError.Field(), fieldError.Param()
+	switch fieldError.Tag() {
+	case "required", "required_if", "required_unless":
+		return field + " is required"
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.Join(strings.Fields(param), ", "))
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, param)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s", field, param)
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", field, param)
+	case "url":
+		return field + " must be an absolute URL"
+	case "len":
+		return fmt.Sprintf("%s must be exactly %s characters", field, param)
+	default:
+		return fmt.Sprintf("%s is invalid (%s)", field, fieldError.Tag())
+	}
+}
@@ -0,0 +1,56 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+)
+
+// csvEncoder writes a spreadsheet friendly statement: one row per ledger line
+// between an opening and a closing balance row. The closing row carries the
+// period totals in its debit and credit columns.
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) WriteHeader(header Header) error {
+	e.w.Write([]string{"date", "transaction_id", "type", "debit", "credit", "balance"})
+	e.w.Write([]string{formatTime(header.From), "", "opening_balance", "", "", formatAmount(header.OpeningBalance)})
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) WriteLine(line Line) error {
+	debit, credit := "", formatAmount(line.Amount)
+	if dto.IsDebit(line.Type) {
+		debit, credit = credit, ""
+	}
+	return e.w.Write([]string{formatTime(line.Date), line.TransactionID, line.Type, debit, credit, formatAmount(line.Balance)})
+}
+
+func (e *csvEncoder) WriteTotals(totals Totals) error {
+	e.w.Write([]string{
+		"", "", "closing_balance",
+		formatAmount(totals.TotalWithdrawals), formatAmount(totals.TotalDeposits), formatAmount(totals.ClosingBalance),
+	})
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,86 @@
+package statement
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// JSON Lines records, told apart by their "record" field
+type (
+	jsonlOpening struct {
+		Record      string     `json:"record"`
+		AccountID   string     `json:"account_id"`
+		AccountName string     `json:"account_name"`
+		Currency    string     `json:"currency"`
+		From        *time.Time `json:"from,omitempty"`
+		To          time.Time  `json:"to"`
+		Balance     float64    `json:"balance"`
+		GeneratedAt time.Time  `json:"generated_at"`
+	}
+
+	jsonlLine struct {
+		Record        string    `json:"record"`
+		TransactionID string    `json:"transaction_id"`
+		Date          time.Time `json:"date"`
+		Type          string    `json:"type"`
+		Amount        float64   `json:"amount"`
+		Balance       float64   `json:"balance"`
+	}
+
+	jsonlClosing struct {
+		Record            string  `json:"record"`
+		TotalTransactions int     `json:"total_transactions"`
+		TotalDeposits     float64 `json:"total_deposits"`
+		TotalWithdrawals  float64 `json:"total_withdrawals"`
+		Balance           float64 `json:"balance"`
+	}
+)
+
+// jsonlEncoder writes one JSON object per line: the opening balance, the
+// transactions and the closing balance with the period totals
+type jsonlEncoder struct {
+	enc *json.Encoder
+}
+
+func newJSONLEncoder(w io.Writer) *jsonlEncoder {
+	return &jsonlEncoder{enc: json.NewEncoder(w)}
+}
+
+func (e *jsonlEncoder) WriteHeader(header Header) error {
+	record := jsonlOpening{
+		Record:      "opening_balance",
+		AccountID:   header.AccountID,
+		AccountName: header.AccountName,
+		Currency:    header.Currency,
+		To:          header.To.UTC(),
+		Balance:     header.OpeningBalance,
+		GeneratedAt: header.GeneratedAt.UTC(),
+	}
+	if !header.From.IsZero() {
+		from := header.From.UTC()
+		record.From = &from
+	}
+	return e.enc.Encode(record)
+}
+
+func (e *jsonlEncoder) WriteLine(line Line) error {
+	return e.enc.Encode(jsonlLine{
+		Record:        "transaction",
+		TransactionID: line.TransactionID,
+		Date:          line.Date.UTC(),
+		Type:          line.Type,
+		Amount:        line.Amount,
+		Balance:       line.Balance,
+	})
+}
+
+func (e *jsonlEncoder) WriteTotals(totals Totals) error {
+	return e.enc.Encode(jsonlClosing{
+		Record:            "closing_balance",
+		TotalTransactions: totals.TotalTransactions,
+		TotalDeposits:     totals.TotalDeposits,
+		TotalWithdrawals:  totals.TotalWithdrawals,
+		Balance:           totals.ClosingBalance,
+	})
+}
@@ -0,0 +1,93 @@
+package statement
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+)
+
+// ofxBankID identifies the bank in BANKACCTFROM, the API has no routing number
+const ofxBankID = "000000000"
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+// ofxEncoder writes an OFX 2.2 bank statement response. OFX has no per line
+// balance, so the running balance goes in each transaction's MEMO and the
+// opening balance in BALLIST.
+type ofxEncoder struct {
+	w      *bufio.Writer
+	header Header
+}
+
+func newOFXEncoder(w io.Writer) *ofxEncoder {
+	return &ofxEncoder{w: bufio.NewWriter(w)}
+}
+
+func (e *ofxEncoder) WriteHeader(header Header) error {
+	e.header = header
+	start := header.From
+	if start.IsZero() {
+		start = header.To
+	}
+
+	e.w.WriteString(ofxHeader)
+	e.w.WriteString("<OFX>\n")
+	e.w.WriteString("<SIGNONMSGSRSV1><SONRS>")
+	e.w.WriteString("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>")
+	fmt.Fprintf(e.w, "<DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE>", ofxTime(header.GeneratedAt))
+	e.w.WriteString("</SONRS></SIGNONMSGSRSV1>\n")
+	e.w.WriteString("<BANKMSGSRSV1><STMTTRNRS><TRNUID>0</TRNUID>")
+	e.w.WriteString("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+	fmt.Fprintf(e.w, "<STMTRS><CURDEF>%s</CURDEF>\n", ofxText(header.Currency))
+	fmt.Fprintf(e.w, "<BANKACCTFROM><BANKID>%s</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>\n",
+		ofxBankID, ofxText(header.AccountID))
+	fmt.Fprintf(e.w, "<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>\n", ofxTime(start), ofxTime(header.To))
+	return e.w.Flush()
+}
+
+func (e *ofxEncoder) WriteLine(line Line) error {
+	trnType, name, amount := "CREDIT", "Deposit", line.Amount
+	switch line.Type {
+	case dto.TransactionWithdrawal:
+		trnType, name, amount = "DEBIT", "Withdrawal", -line.Amount
+	case dto.TransactionInterest:
+		trnType, name = "INT", "Interest"
+	case dto.TransactionFee:
+		trnType, name, amount = "FEE", "Fee", -line.Amount
+	}
+	_, err := fmt.Fprintf(e.w,
+		"<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%s</FITID><NAME>%s</NAME><MEMO>Balance %s</MEMO></STMTTRN>\n",
+		trnType, ofxTime(line.Date), formatAmount(amount), ofxText(line.TransactionID),
+		name, formatAmount(line.Balance))
+	return err
+}
+
+func (e *ofxEncoder) WriteTotals(totals Totals) error {
+	e.w.WriteString("</BANKTRANLIST>\n")
+	fmt.Fprintf(e.w, "<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>\n",
+		formatAmount(totals.ClosingBalance), ofxTime(e.header.To))
+	fmt.Fprintf(e.w, "<BALLIST><BAL><NAME>Opening balance</NAME><DESC>Balance at the start of the period</DESC><BALTYPE>DOLLAR</BALTYPE><VALUE>%s</VALUE></BAL></BALLIST>\n",
+		formatAmount(e.header.OpeningBalance))
+	e.w.WriteString("</STMTRS></STMTTRNRS></BANKMSGSRSV1>\n")
+	e.w.WriteString("</OFX>\n")
+	return e.w.Flush()
+}
+
+// ofxTime formats a timestamp as an OFX datetime in GMT
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
+
+// ofxText escapes character data
+func ofxText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
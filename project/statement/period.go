@@ -0,0 +1,47 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// ParsePeriod parses the from and to query parameters of a statement into the
+// half-open interval [from, to). Both accept a date (YYYY-MM-DD, UTC) or an
+// RFC 3339 timestamp. A to date includes that whole day. An empty from means
+// the beginning of the account history and an empty to means now.
+func ParsePeriod(fromValue, toValue string, now time.Time) (from, to time.Time, err error) {
+	if fromValue != "" {
+		if from, err = parseBound(fromValue, false); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+
+	to = now
+	if toValue != "" {
+		if to, err = parseBound(toValue, true); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
+
+func parseBound(value string, endOfDay bool) (time.Time, error) {
+	if date, err := time.Parse(dateLayout, value); err == nil {
+		if endOfDay {
+			date = date.AddDate(0, 0, 1)
+		}
+		return date, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a date (YYYY-MM-DD) nor an RFC 3339 timestamp", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,151 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+)
+
+// Format is a statement export format
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+	FormatOFX   Format = "ofx"
+)
+
+// Formats lists the supported formats
+var Formats = []Format{FormatCSV, FormatJSONL, FormatOFX}
+
+// ErrUnknownFormat is returned for an unsupported format
+var ErrUnknownFormat = errors.New("unknown statement format")
+
+// ParseFormat parses a format name, defaulting to CSV when empty
+func ParseFormat(name string) (Format, error) {
+	if name == "" {
+		return FormatCSV, nil
+	}
+	for _, format := range Formats {
+		if string(format) == name {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownFormat, name)
+}
+
+// ContentType returns the media type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatOFX:
+		return "application/x-ofx"
+	default:
+		return "text/csv"
+	}
+}
+
+// Header describes the account and period of a statement
+type Header struct {
+	AccountID      string
+	AccountName    string
+	Currency       string
+	From           time.Time
+	To             time.Time
+	OpeningBalance float64
+	GeneratedAt    time.Time
+}
+
+// Line is a ledger line with the balance after the transaction
+type Line struct {
+	TransactionID string
+	Date          time.Time
+	Type          string
+	Amount        float64
+	Balance       float64
+}
+
+// Totals closes a statement. They are computed the same way as the
+// repository's GetTransactionSummary over the statement period, with
+// interest counted as deposits and fees as withdrawals.
+type Totals struct {
+	TotalTransactions int
+	TotalDeposits     float64
+	TotalWithdrawals  float64
+	ClosingBalance    float64
+}
+
+// Encoder writes a statement in one format
+type Encoder interface {
+	WriteHeader(header Header) error
+	WriteLine(line Line) error
+	WriteTotals(totals Totals) error
+}
+
+// NewEncoder returns the encoder of the format writing to w
+func NewEncoder(format Format, w io.Writer) Encoder {
+	switch format {
+	case FormatJSONL:
+		return newJSONLEncoder(w)
+	case FormatOFX:
+		return newOFXEncoder(w)
+	default:
+		return newCSVEncoder(w)
+	}
+}
+
+// Writer keeps the running balance and totals while transactions are
+// streamed into a statement
+type Writer struct {
+	encoder Encoder
+	balance float64
+	totals  Totals
+}
+
+// NewWriter writes the statement header and returns a writer for its lines
+func NewWriter(encoder Encoder, header Header) (*Writer, error) {
+	header.OpeningBalance = round(header.OpeningBalance)
+	if err := encoder.WriteHeader(header); err != nil {
+		return nil, err
+	}
+	return &Writer{encoder: encoder, balance: header.OpeningBalance}, nil
+}
+
+// Add writes the ledger line of a transaction
+func (w *Writer) Add(transaction *dto.TransactionDTO) error {
+	switch transaction.Type {
+	case dto.TransactionDeposit, dto.TransactionInterest:
+		w.balance = round(w.balance + transaction.Amount)
+		w.totals.TotalDeposits = round(w.totals.TotalDeposits + transaction.Amount)
+	case dto.TransactionWithdrawal, dto.TransactionFee:
+		w.balance = round(w.balance - transaction.Amount)
+		w.totals.TotalWithdrawals = round(w.totals.TotalWithdrawals + transaction.Amount)
+	default:
+		return fmt.Errorf("transaction %s has unknown type %q", transaction.ID, transaction.Type)
+	}
+	w.totals.TotalTransactions++
+
+	return w.encoder.WriteLine(Line{
+		TransactionID: transaction.ID,
+		Date:          transaction.CreatedAt,
+		Type:          transaction.Type,
+		Amount:        transaction.Amount,
+		Balance:       w.balance,
+	})
+}
+
+// Close writes the totals and returns them
+func (w *Writer) Close() (Totals, error) {
+	w.totals.ClosingBalance = w.balance
+	return w.totals, w.encoder.WriteTotals(w.totals)
+}
+
+// round rounds an amount to cents so running balances don't drift
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
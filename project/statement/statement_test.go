@@ -0,0 +1,224 @@
+package statement
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	periodStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	periodEnd   = time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func testHeader() Header {
+	return Header{
+		AccountID:      "acc-1",
+		AccountName:    "Savings & Co",
+		Currency:       "USD",
+		From:           periodStart,
+		To:             periodEnd,
+		OpeningBalance: 100,
+		GeneratedAt:    periodEnd,
+	}
+}
+
+func testTransactions() []*dto.TransactionDTO {
+	return []*dto.TransactionDTO{
+		{ID: "tx-1", Type: "deposit", Amount: 50.10, CreatedAt: periodStart.Add(time.Hour)},
+		{ID: "tx-2", Type: "withdrawal", Amount: 20.05, CreatedAt: periodStart.Add(2 * time.Hour)},
+		{ID: "tx-3", Type: "deposit", Amount: 0.2, CreatedAt: periodStart.Add(3 * time.Hour)},
+	}
+}
+
+// writeStatement renders the test statement in a format
+func writeStatement(t *testing.T, format Format) (string, Totals) {
+	var buf bytes.Buffer
+	writer, err := NewWriter(NewEncoder(format, &buf), testHeader())
+	require.NoError(t, err)
+	for _, tx := range testTransactions() {
+		require.NoError(t, writer.Add(tx))
+	}
+	totals, err := writer.Close()
+	require.NoError(t, err)
+	return buf.String(), totals
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Format
+		wantErr  bool
+	}{
+		{name: "default", input: "", expected: FormatCSV},
+		{name: "csv", input: "csv", expected: FormatCSV},
+		{name: "jsonl", input: "jsonl", expected: FormatJSONL},
+		{name: "ofx", input: "ofx", expected: FormatOFX},
+		{name: "unknown", input: "pdf", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := ParseFormat(tt.input)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrUnknownFormat))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, format)
+		})
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from, to string
+		wantFrom time.Time
+		wantTo   time.Time
+		wantErr  bool
+	}{
+		{name: "defaults", wantTo: now},
+		{name: "dates include the to day", from: "2025-01-01", to: "2025-01-31", wantFrom: periodStart, wantTo: periodEnd},
+		{
+			name: "timestamps", from: "2025-01-01T10:00:00Z", to: "2025-01-01T12:00:00+01:00",
+			wantFrom: periodStart.Add(10 * time.Hour), wantTo: periodStart.Add(11 * time.Hour),
+		},
+		{name: "empty period", from: "2025-01-01T10:00:00Z", to: "2025-01-01T11:00:00+01:00", wantErr: true},
+		{name: "open start", to: "2025-01-31", wantTo: periodEnd},
+		{name: "invalid from", from: "yesterday", wantErr: true},
+		{name: "invalid to", to: "31/01/2025", wantErr: true},
+		{name: "reversed", from: "2025-02-01", to: "2025-01-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := ParsePeriod(tt.from, tt.to, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.wantFrom.Equal(from), "from = %v", from)
+			assert.True(t, tt.wantTo.Equal(to), "to = %v", to)
+		})
+	}
+}
+
+func TestWriterKeepsRunningBalanceAndTotals(t *testing.T) {
+	_, totals := writeStatement(t, FormatCSV)
+
+	assert.Equal(t, Totals{
+		TotalTransactions: 3,
+		TotalDeposits:     50.3,
+		TotalWithdrawals:  20.05,
+		ClosingBalance:    130.25,
+	}, totals)
+}
+
+func TestWriterCountsInterestAsCreditAndFeesAsDebit(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewWriter(NewEncoder(FormatCSV, &buf), testHeader())
+	require.NoError(t, err)
+	require.NoError(t, writer.Add(&dto.TransactionDTO{ID: "tx-1", Type: "interest", Amount: 0.17, CreatedAt: periodStart}))
+	require.NoError(t, writer.Add(&dto.TransactionDTO{ID: "tx-2", Type: "fee", Amount: 5, CreatedAt: periodStart}))
+
+	totals, err := writer.Close()
+	require.NoError(t, err)
+
+	assert.Equal(t, Totals{
+		TotalTransactions: 2,
+		TotalDeposits:     0.17,
+		TotalWithdrawals:  5,
+		ClosingBalance:    95.17,
+	}, totals)
+	assert.Contains(t, buf.String(), "tx-1,interest,,0.17,100.17\n")
+	assert.Contains(t, buf.String(), "tx-2,fee,5.00,,95.17\n")
+}
+
+func TestWriterRejectsUnknownType(t *testing.T) {
+	writer, err := NewWriter(NewEncoder(FormatCSV, io.Discard), testHeader())
+	require.NoError(t, err)
+
+	assert.Error(t, writer.Add(&dto.TransactionDTO{ID: "tx-1", Type: "transfer", Amount: 1}))
+}
+
+func TestCSVStatement(t *testing.T) {
+	output, _ := writeStatement(t, FormatCSV)
+
+	expected := `date,transaction_id,type,debit,credit,balance
+2025-01-01T00:00:00Z,,opening_balance,,,100.00
+2025-01-01T01:00:00Z,tx-1,deposit,,50.10,150.10
+2025-01-01T02:00:00Z,tx-2,withdrawal,20.05,,130.05
+2025-01-01T03:00:00Z,tx-3,deposit,,0.20,130.25
+,,closing_balance,20.05,50.30,130.25
+`
+	assert.Equal(t, expected, output)
+}
+
+func TestJSONLStatement(t *testing.T) {
+	output, _ := writeStatement(t, FormatJSONL)
+
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 5)
+
+	assert.Equal(t, "opening_balance", records[0]["record"])
+	assert.Equal(t, "Savings & Co", records[0]["account_name"])
+	assert.Equal(t, 100.0, records[0]["balance"])
+
+	assert.Equal(t, "transaction", records[2]["record"])
+	assert.Equal(t, "tx-2", records[2]["transaction_id"])
+	assert.Equal(t, 130.05, records[2]["balance"])
+
+	assert.Equal(t, "closing_balance", records[4]["record"])
+	assert.Equal(t, 3.0, records[4]["total_transactions"])
+	assert.Equal(t, 130.25, records[4]["balance"])
+}
+
+func TestOFXStatement(t *testing.T) {
+	output, _ := writeStatement(t, FormatOFX)
+
+	var ofx struct {
+		Currency     string `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>CURDEF"`
+		AccountID    string `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKACCTFROM>ACCTID"`
+		Start        string `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKTRANLIST>DTSTART"`
+		Transactions []struct {
+			Type   string `xml:"TRNTYPE"`
+			Amount string `xml:"TRNAMT"`
+			ID     string `xml:"FITID"`
+			Memo   string `xml:"MEMO"`
+		} `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKTRANLIST>STMTTRN"`
+		Ledger  string `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>LEDGERBAL>BALAMT"`
+		Opening string `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BALLIST>BAL>VALUE"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(output), &ofx))
+
+	assert.Equal(t, "USD", ofx.Currency)
+	assert.Equal(t, "acc-1", ofx.AccountID)
+	assert.Equal(t, "20250101000000.000[0:GMT]", ofx.Start)
+	require.Len(t, ofx.Transactions, 3)
+	assert.Equal(t, "DEBIT", ofx.Transactions[1].Type)
+	assert.Equal(t, "-20.05", ofx.Transactions[1].Amount)
+	assert.Equal(t, "Balance 130.05", ofx.Transactions[1].Memo)
+	assert.Equal(t, "130.25", ofx.Ledger)
+	assert.Equal(t, "100.00", ofx.Opening)
+}
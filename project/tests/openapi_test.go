@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gcalvocr/go-testing/openapi"
+	"github.com/gcalvocr/go-testing/server"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fetchOpenAPIDocument gets the document the way API clients do
+func fetchOpenAPIDocument(t *testing.T, srv *server.Server) *openapi.Document {
+	req, err := http.NewRequest("GET", "/openapi.json", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	srv.GetRouter().ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var doc openapi.Document
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&doc))
+	return &doc
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	srv := server.NewServer()
+	require.NoError(t, srv.SetupRoutes())
+
+	doc := fetchOpenAPIDocument(t, srv)
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+
+	registered := 0
+	err := srv.GetRouter().Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		require.NoError(t, err, "route %s must restrict its methods", template)
+
+		path := openapi.Path(template)
+		for _, method := range methods {
+			registered++
+			item, ok := doc.Paths[path]
+			if assert.True(t, ok, "path %s is not documented", path) {
+				assert.Contains(t, item, strings.ToLower(method), "%s %s is not documented", method, path)
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// And nothing is documented that isn't served
+	assert.Len(t, doc.Endpoints(), registered)
+}
+
+func TestOpenAPIDocumentDescribesDTOs(t *testing.T) {
+	srv := server.NewServer()
+	require.NoError(t, srv.SetupRoutes())
+
+	doc := fetchOpenAPIDocument(t, srv)
+
+	account := doc.Components.Schemas["AccountResponse"]
+	require.NotNil(t, account)
+	assert.Contains(t, account.Properties, "created_at")
+	assert.Equal(t, "date-time", account.Properties["created_at"].Format)
+
+	request := doc.Components.Schemas["CreateTransactionRequest"]
+	require.NotNil(t, request)
+	assert.ElementsMatch(t, []string{"account_id", "amount", "type"}, request.Required)
+	assert.ElementsMatch(t, []string{"deposit", "withdrawal"}, request.Properties["type"].Enum)
+
+	// Public routes opt out of the global security requirement
+	health := doc.Paths["/health"]["get"]
+	require.NotNil(t, health.Security)
+	assert.Empty(t, *health.Security)
+	assert.Nil(t, doc.Paths["/accounts"]["get"].Security)
+}
+
+func TestDocsPageIsPublic(t *testing.T) {
+	srv := server.NewServer()
+	require.NoError(t, srv.SetupRoutes())
+
+	req, err := http.NewRequest("GET", "/docs", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	srv.GetRouter().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "/openapi.json")
+}
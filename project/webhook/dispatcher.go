@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+// Dispatcher is the events.Sink of the webhook consumer. It records a
+// pending delivery of each event for every subscription that wants it; a
+// subscription gets a single delivery per event however often the relay
+// publishes it.
+type Dispatcher struct {
+	webhooks repository.WebhookRepository
+	now      func() time.Time
+}
+
+// NewDispatcher creates a dispatcher
+func NewDispatcher(webhooks repository.WebhookRepository) *Dispatcher {
+	return &Dispatcher{webhooks: webhooks, now: time.Now}
+}
+
+func (d *Dispatcher) Publish(ctx context.Context, event *dto.EventDTO) error {
+	subscriptions, err := d.webhooks.GetSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Matches(event) {
+			continue
+		}
+
+		now := d.now()
+		delivery := &dto.WebhookDeliveryDTO{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Body:           string(body),
+			Status:         dto.DeliveryPending,
+			NextAttemptAt:  &now,
+		}
+		if err := d.webhooks.CreateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+
+		logger.InfoContext(ctx, "Webhook delivery queued", map[string]interface{}{
+			"delivery_id":     delivery.ID,
+			"subscription_id": subscription.ID,
+			"event_type":      event.Type,
+		})
+	}
+	return nil
+}
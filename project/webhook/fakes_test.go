@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+)
+
+// fakeWebhooks is an in-memory WebhookRepository
+type fakeWebhooks struct {
+	subscriptions map[string]*dto.WebhookSubscriptionDTO
+	deliveries    map[string]*dto.WebhookDeliveryDTO
+}
+
+func newFakeWebhooks(subscriptions ...*dto.WebhookSubscriptionDTO) *fakeWebhooks {
+	f := &fakeWebhooks{
+		subscriptions: make(map[string]*dto.WebhookSubscriptionDTO),
+		deliveries:    make(map[string]*dto.WebhookDeliveryDTO),
+	}
+	for _, subscription := range subscriptions {
+		f.subscriptions[subscription.ID] = subscription
+	}
+	return f
+}
+
+func (f *fakeWebhooks) CreateSubscription(ctx context.Context, subscription *dto.WebhookSubscriptionDTO) error {
+	f.subscriptions[subscription.ID] = subscription
+	return nil
+}
+
+func (f *fakeWebhooks) GetSubscription(ctx context.Context, id string) (*dto.WebhookSubscriptionDTO, error) {
+	return f.subscriptions[id], nil
+}
+
+func (f *fakeWebhooks) GetSubscriptions(ctx context.Context) ([]*dto.WebhookSubscriptionDTO, error) {
+	var subscriptions []*dto.WebhookSubscriptionDTO
+	for _, subscription := range f.subscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool { return subscriptions[i].ID < subscriptions[j].ID })
+	return subscriptions, nil
+}
+
+func (f *fakeWebhooks) DeleteSubscription(ctx context.Context, id string) error {
+	delete(f.subscriptions, id)
+	return nil
+}
+
+func (f *fakeWebhooks) CreateDelivery(ctx context.Context, delivery *dto.WebhookDeliveryDTO) error {
+	delivery.ID = delivery.SubscriptionID + ":" + delivery.EventID
+	if _, ok := f.deliveries[delivery.ID]; !ok {
+		stored := *delivery
+		f.deliveries[delivery.ID] = &stored
+	}
+	return nil
+}
+
+func (f *fakeWebhooks) GetDelivery(ctx context.Context, id string) (*dto.WebhookDeliveryDTO, error) {
+	if delivery, ok := f.deliveries[id]; ok {
+		copied := *delivery
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeWebhooks) GetDeliveries(ctx context.Context, subscriptionID, status string) ([]*dto.WebhookDeliveryDTO, error) {
+	var deliveries []*dto.WebhookDeliveryDTO
+	for _, delivery := range f.deliveries {
+		if delivery.SubscriptionID == subscriptionID && (status == "" || delivery.Status == status) {
+			copied := *delivery
+			deliveries = append(deliveries, &copied)
+		}
+	}
+	return deliveries, nil
+}
+
+func (f *fakeWebhooks) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*dto.WebhookDeliveryDTO, error) {
+	var due []*dto.WebhookDeliveryDTO
+	for _, delivery := range f.deliveries {
+		if delivery.Status == dto.DeliveryPending && !delivery.NextAttemptAt.After(now) && len(due) < limit {
+			copied := *delivery
+			due = append(due, &copied)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeWebhooks) ClaimDelivery(ctx context.Context, id string, due, until time.Time) (bool, error) {
+	delivery := f.deliveries[id]
+	if delivery == nil || delivery.Status != dto.DeliveryPending || !delivery.NextAttemptAt.Equal(due) {
+		return false, nil
+	}
+	delivery.NextAttemptAt = &until
+	return true, nil
+}
+
+func (f *fakeWebhooks) UpdateDelivery(ctx context.Context, delivery *dto.WebhookDeliveryDTO) error {
+	stored := *delivery
+	f.deliveries[delivery.ID] = &stored
+	return nil
+}
@@ -0,0 +1,73 @@
+// Package webhook delivers domain events to the URLs partners subscribe.
+// A Dispatcher, fed by an outbox relay, records a delivery per matching
+// subscription and a Worker sends them, signed with the subscription's
+// secret, retrying with exponential backoff until they are delivered or
+// dead.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Headers of a delivery
+const (
+	HeaderDeliveryID = "X-Webhook-ID"
+	HeaderEventID    = "X-Event-ID"
+	HeaderEventType  = "X-Event-Type"
+	// HeaderTimestamp is the Unix time the attempt was signed at
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature is "sha256=" and the hex HMAC-SHA256 of the timestamp,
+	// a dot and the body, keyed with the subscription's secret
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+var (
+	// ErrInvalidSignature is returned when a signature doesn't match the body
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrStaleTimestamp is returned when a delivery was signed too long ago,
+	// receivers reject those to prevent replays
+	ErrStaleTimestamp = errors.New("webhook timestamp outside the tolerance")
+)
+
+// Sign returns the signature header of a body signed at timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the timestamp and signature headers of a delivery the way a
+// receiver should: the signature must match and the timestamp must be
+// within tolerance of now.
+func Verify(secret, timestamp, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	signedAt := time.Unix(seconds, 0)
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, signedAt, body))) {
+		return ErrInvalidSignature
+	}
+	if now.Sub(signedAt).Abs() > tolerance {
+		return ErrStaleTimestamp
+	}
+	return nil
+}
+
+// NewSecret returns a random secret for a subscription created without one
+func NewSecret() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic("failed to generate webhook secret: " + err.Error())
+	}
+	return "whsec_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,46 @@
+package webhook
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	signedAt := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"type":"AccountCreated"}`)
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	signature := Sign("secret-of-sixteen", signedAt, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		now       time.Time
+		wantErr   error
+	}{
+		{name: "valid", secret: "secret-of-sixteen", timestamp: timestamp, body: body, now: signedAt.Add(time.Minute)},
+		{name: "other secret", secret: "another-secret!!", timestamp: timestamp, body: body, now: signedAt, wantErr: ErrInvalidSignature},
+		{name: "tampered body", secret: "secret-of-sixteen", timestamp: timestamp, body: []byte(`{}`), now: signedAt, wantErr: ErrInvalidSignature},
+		{name: "other timestamp", secret: "secret-of-sixteen", timestamp: "1741608001", body: body, now: signedAt, wantErr: ErrInvalidSignature},
+		{name: "malformed timestamp", secret: "secret-of-sixteen", timestamp: "noon", body: body, now: signedAt, wantErr: ErrInvalidSignature},
+		{name: "replayed", secret: "secret-of-sixteen", timestamp: timestamp, body: body, now: signedAt.Add(6 * time.Minute), wantErr: ErrStaleTimestamp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.timestamp, signature, tt.body, tt.now, 5*time.Minute)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	secret := NewSecret()
+	assert.True(t, strings.HasPrefix(secret, "whsec_"))
+	assert.NotEqual(t, secret, NewSecret())
+}
@@ -0,0 +1,225 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/gcalvocr/go-testing/logger"
+	"github.com/gcalvocr/go-testing/repository"
+)
+
+const (
+	// DefaultInterval is how often Run polls for due deliveries
+	DefaultInterval = 5 * time.Second
+	// DefaultMaxAttempts is how many attempts a delivery gets before it is dead
+	DefaultMaxAttempts = 8
+	// DefaultBackoff is the delay before the first retry, it doubles with
+	// every further attempt up to maxBackoff
+	DefaultBackoff = 30 * time.Second
+	maxBackoff     = time.Hour
+
+	// timeout bounds an attempt, and claimFor how long a claimed delivery is
+	// left to its worker before another one may retry it
+	timeout  = 10 * time.Second
+	claimFor = time.Minute
+
+	// batchSize caps the deliveries loaded per query
+	batchSize = 100
+	// maxErrorLength caps the response body kept as the error of an attempt
+	maxErrorLength = 512
+)
+
+// Worker sends due deliveries. Each delivery is claimed before it is sent,
+// so several workers may poll the same database; a delivery whose worker
+// died is retried once its claim lapses.
+type Worker struct {
+	webhooks    repository.WebhookRepository
+	client      *http.Client
+	now         func() time.Time
+	interval    time.Duration
+	maxAttempts int
+	backoff     time.Duration
+}
+
+// NewWorker creates a worker sending with client, or with a client with a
+// 10 second timeout when client is nil
+func NewWorker(webhooks repository.WebhookRepository, client *http.Client) *Worker {
+	if client == nil {
+		client = &http.Client{Timeout: timeout}
+	}
+	return &Worker{
+		webhooks:    webhooks,
+		client:      client,
+		now:         time.Now,
+		interval:    DefaultInterval,
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     DefaultBackoff,
+	}
+}
+
+// SetInterval sets how often Run polls for due deliveries
+func (w *Worker) SetInterval(interval time.Duration) {
+	if interval > 0 {
+		w.interval = interval
+	}
+}
+
+// SetRetryPolicy sets how many attempts a delivery gets and the delay before its first retry
+func (w *Worker) SetRetryPolicy(maxAttempts int, backoff time.Duration) {
+	if maxAttempts > 0 {
+		w.maxAttempts = maxAttempts
+	}
+	if backoff > 0 {
+		w.backoff = backoff
+	}
+}
+
+// Run sends due deliveries until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	logger.Info("Webhook worker started", map[string]interface{}{
+		"interval":     w.interval.String(),
+		"max_attempts": w.maxAttempts,
+	})
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("Failed to send webhook deliveries", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Webhook worker stopped", nil)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue attempts the deliveries that are due and returns how many it attempted
+func (w *Worker) DeliverDue(ctx context.Context) (int, error) {
+	due, err := w.webhooks.GetDueDeliveries(ctx, w.now(), batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	attempted := 0
+	for _, delivery := range due {
+		claimed, err := w.webhooks.ClaimDelivery(ctx, delivery.ID, *delivery.NextAttemptAt, w.now().Add(claimFor))
+		if err != nil {
+			return attempted, err
+		}
+		if !claimed {
+			continue // Another worker has it
+		}
+
+		if err := w.attempt(ctx, delivery); err != nil {
+			return attempted, err
+		}
+		attempted++
+	}
+	return attempted, nil
+}
+
+// attempt sends a delivery once and records the outcome
+func (w *Worker) attempt(ctx context.Context, delivery *dto.WebhookDeliveryDTO) error {
+	subscription, err := w.webhooks.GetSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return err
+	}
+
+	now := w.now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.LastStatusCode, delivery.LastError = 0, ""
+
+	if subscription == nil {
+		delivery.LastError = "subscription deleted"
+		delivery.Status, delivery.NextAttemptAt = dto.DeliveryDead, nil
+		return w.webhooks.UpdateDelivery(ctx, delivery)
+	}
+
+	statusCode, sendErr := w.send(ctx, subscription, delivery, now)
+	delivery.LastStatusCode = statusCode
+
+	fields := map[string]interface{}{
+		"delivery_id":     delivery.ID,
+		"subscription_id": subscription.ID,
+		"attempt":         delivery.Attempts,
+		"status_code":     statusCode,
+	}
+	switch {
+	case sendErr == nil:
+		delivery.Status, delivery.NextAttemptAt, delivery.DeliveredAt = dto.DeliveryDelivered, nil, &now
+		logger.InfoContext(ctx, "Webhook delivered", fields)
+	case delivery.Attempts >= w.maxAttempts:
+		delivery.LastError = sendErr.Error()
+		delivery.Status, delivery.NextAttemptAt = dto.DeliveryDead, nil
+		fields["error"] = delivery.LastError
+		logger.WarnContext(ctx, "Webhook delivery dead after its last attempt", fields)
+	default:
+		next := now.Add(w.retryDelay(delivery.Attempts))
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = &next
+		fields["error"], fields["next_attempt_at"] = delivery.LastError, next
+		logger.WarnContext(ctx, "Webhook delivery failed, it will be retried", fields)
+	}
+	return w.webhooks.UpdateDelivery(ctx, delivery)
+}
+
+// send posts the delivery signed at now and returns the response status,
+// anything but a 2xx is an error
+func (w *Worker) send(ctx context.Context, subscription *dto.WebhookSubscriptionDTO, delivery *dto.WebhookDeliveryDTO, now time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body := []byte(delivery.Body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDeliveryID, delivery.ID)
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, fmt.Sprint(now.Unix()))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, now, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+		return resp.StatusCode, fmt.Errorf("receiver answered %d: %s", resp.StatusCode, bytes.TrimSpace(excerpt))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// retryDelay is the delay after the given failed attempt
+func (w *Worker) retryDelay(attempts int) time.Duration {
+	delay := w.backoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// Redeliver queues a delivery to be sent again at now with a fresh set of
+// attempts, whatever its status
+func Redeliver(delivery *dto.WebhookDeliveryDTO, now time.Time) {
+	delivery.Status = dto.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = &now
+	delivery.DeliveredAt = nil
+}
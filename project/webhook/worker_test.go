@@ -0,0 +1,208 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gcalvocr/go-testing/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var webhookNow = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+const testSecret = "secret-of-sixteen"
+
+// receiver is a local endpoint that verifies the signature of what it
+// receives against the clock of the worker and answers with status
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	now      time.Time
+	status   int
+	received []*dto.EventDTO
+	errors   []error
+}
+
+func newReceiver(t *testing.T, status int) *receiver {
+	r := &receiver{now: webhookNow, status: status}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if err := Verify(testSecret, req.Header.Get(HeaderTimestamp), req.Header.Get(HeaderSignature), body, r.now, 5*time.Minute); err != nil {
+			r.errors = append(r.errors, err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		var event dto.EventDTO
+		if err := json.Unmarshal(body, &event); err == nil && req.Header.Get(HeaderEventID) == event.ID {
+			r.received = append(r.received, &event)
+		}
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *receiver) setNow(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.now = now
+}
+
+// setup subscribes the receiver to every event and dispatches one
+// TransactionPosted event to it
+func setup(t *testing.T, r *receiver) (*fakeWebhooks, *Worker, *time.Time) {
+	webhooks := newFakeWebhooks(&dto.WebhookSubscriptionDTO{
+		ID:         "sub-1",
+		URL:        r.URL,
+		EventTypes: []string{dto.EventAccountCreated, dto.EventTransactionPosted},
+		Secret:     testSecret,
+		CreatedAt:  webhookNow.Add(-time.Hour),
+	})
+	now := webhookNow
+	clock := func() time.Time { return now }
+
+	dispatcher := NewDispatcher(webhooks)
+	dispatcher.now = clock
+	event := &dto.EventDTO{ID: "evt-1", Type: dto.EventTransactionPosted, AccountID: "acc-1", OccurredAt: webhookNow}
+	require.NoError(t, dispatcher.Publish(context.Background(), event))
+
+	worker := NewWorker(webhooks, r.Client())
+	worker.now = clock
+	return webhooks, worker, &now
+}
+
+func TestDispatcherQueuesADeliveryPerMatchingSubscription(t *testing.T) {
+	webhooks := newFakeWebhooks(
+		&dto.WebhookSubscriptionDTO{ID: "all", EventTypes: []string{dto.EventTransactionPosted}},
+		&dto.WebhookSubscriptionDTO{ID: "acc-1", EventTypes: []string{dto.EventTransactionPosted}, AccountID: "acc-1"},
+		&dto.WebhookSubscriptionDTO{ID: "acc-2", EventTypes: []string{dto.EventTransactionPosted}, AccountID: "acc-2"},
+		&dto.WebhookSubscriptionDTO{ID: "created", EventTypes: []string{dto.EventAccountCreated}},
+		&dto.WebhookSubscriptionDTO{ID: "later", EventTypes: []string{dto.EventTransactionPosted}, CreatedAt: webhookNow.Add(time.Second)},
+	)
+	event := &dto.EventDTO{ID: "evt-1", Type: dto.EventTransactionPosted, AccountID: "acc-1", OccurredAt: webhookNow}
+	dispatcher := NewDispatcher(webhooks)
+
+	require.NoError(t, dispatcher.Publish(context.Background(), event))
+	require.NoError(t, dispatcher.Publish(context.Background(), event), "the relay may publish an event again")
+
+	assert.Len(t, webhooks.deliveries, 2)
+	for _, id := range []string{"all:evt-1", "acc-1:evt-1"} {
+		require.Contains(t, webhooks.deliveries, id)
+		assert.Equal(t, dto.DeliveryPending, webhooks.deliveries[id].Status)
+	}
+}
+
+func TestWorkerDeliversSignedEvents(t *testing.T) {
+	r := newReceiver(t, http.StatusNoContent)
+	webhooks, worker, _ := setup(t, r)
+
+	attempted, err := worker.DeliverDue(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+	assert.Empty(t, r.errors)
+	require.Len(t, r.received, 1)
+	assert.Equal(t, "acc-1", r.received[0].AccountID)
+
+	delivery := webhooks.deliveries["sub-1:evt-1"]
+	assert.Equal(t, dto.DeliveryDelivered, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusNoContent, delivery.LastStatusCode)
+	assert.Nil(t, delivery.NextAttemptAt)
+	assert.Equal(t, webhookNow, *delivery.DeliveredAt)
+
+	attempted, err = worker.DeliverDue(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, attempted, "delivered events are not sent again")
+}
+
+func TestWorkerRetriesWithBackoffUntilDead(t *testing.T) {
+	r := newReceiver(t, http.StatusServiceUnavailable)
+	webhooks, worker, now := setup(t, r)
+	worker.SetRetryPolicy(4, time.Minute)
+
+	for attempt, wantDelay := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		attempted, err := worker.DeliverDue(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, attempted)
+
+		delivery := webhooks.deliveries["sub-1:evt-1"]
+		assert.Equal(t, dto.DeliveryPending, delivery.Status)
+		assert.Equal(t, attempt+1, delivery.Attempts)
+		assert.Equal(t, http.StatusServiceUnavailable, delivery.LastStatusCode)
+		assert.Contains(t, delivery.LastError, "503")
+		assert.Equal(t, now.Add(wantDelay), *delivery.NextAttemptAt)
+
+		attempted, err = worker.DeliverDue(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, attempted, "not due before the backoff")
+		*now = *delivery.NextAttemptAt
+		r.setNow(*now)
+	}
+
+	_, err := worker.DeliverDue(context.Background())
+	require.NoError(t, err)
+	delivery := webhooks.deliveries["sub-1:evt-1"]
+	assert.Equal(t, dto.DeliveryDead, delivery.Status)
+	assert.Equal(t, 4, delivery.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, delivery.LastStatusCode)
+	assert.Nil(t, delivery.NextAttemptAt)
+	assert.Empty(t, r.errors)
+}
+
+func TestRedeliver(t *testing.T) {
+	r := newReceiver(t, http.StatusInternalServerError)
+	webhooks, worker, _ := setup(t, r)
+	worker.SetRetryPolicy(1, time.Minute)
+
+	_, err := worker.DeliverDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, dto.DeliveryDead, webhooks.deliveries["sub-1:evt-1"].Status)
+
+	r.setStatus(http.StatusOK)
+	delivery, err := webhooks.GetDelivery(context.Background(), "sub-1:evt-1")
+	require.NoError(t, err)
+	Redeliver(delivery, webhookNow)
+	require.NoError(t, webhooks.UpdateDelivery(context.Background(), delivery))
+
+	attempted, err := worker.DeliverDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+	assert.Equal(t, dto.DeliveryDelivered, webhooks.deliveries["sub-1:evt-1"].Status)
+	assert.Len(t, r.received, 2, "the failed attempt and the redelivery")
+}
+
+func TestDeliveriesOfDeletedSubscriptionsAreDead(t *testing.T) {
+	r := newReceiver(t, http.StatusOK)
+	webhooks, worker, _ := setup(t, r)
+	require.NoError(t, webhooks.DeleteSubscription(context.Background(), "sub-1"))
+
+	_, err := worker.DeliverDue(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, dto.DeliveryDead, webhooks.deliveries["sub-1:evt-1"].Status)
+	assert.Empty(t, r.received)
+}
+
+func TestRetryDelayIsCapped(t *testing.T) {
+	worker := NewWorker(newFakeWebhooks(), nil)
+	assert.Equal(t, DefaultBackoff, worker.retryDelay(1))
+	assert.Equal(t, 4*DefaultBackoff, worker.retryDelay(3))
+	assert.Equal(t, maxBackoff, worker.retryDelay(50))
+}